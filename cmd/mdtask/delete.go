@@ -0,0 +1,269 @@
+package mdtask
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tkancf/mdtask/internal/cli"
+	"github.com/tkancf/mdtask/internal/constants"
+	"github.com/tkancf/mdtask/internal/output"
+	"github.com/tkancf/mdtask/internal/repository"
+	"github.com/tkancf/mdtask/internal/service"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete <task-id>...",
+	Short: "Move tasks to the trash",
+	Long: `Move tasks to the trash in .mdtask/trash of their directory. Subtasks are
+deleted together with their parent unless --keep-subtasks is given, which
+detaches them instead. Use 'mdtask trash restore' to bring a task back.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runDelete,
+}
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "List, restore or remove deleted tasks",
+	Long:  `Work with the tasks moved to the trash by 'mdtask delete' and 'mdtask purge'.`,
+	Args:  cobra.NoArgs,
+	RunE:  runTrashList,
+}
+
+var trashListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List deleted tasks",
+	Args:  cobra.NoArgs,
+	RunE:  runTrashList,
+}
+
+var trashRestoreCmd = &cobra.Command{
+	Use:   "restore <task-id>...",
+	Short: "Restore deleted tasks",
+	Long: `Move tasks back from the trash to the file they were deleted from. Subtasks
+in the trash are restored with their parent.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runTrashRestore,
+}
+
+var trashEmptyCmd = &cobra.Command{
+	Use:   "empty",
+	Short: "Permanently remove deleted tasks",
+	Args:  cobra.NoArgs,
+	RunE:  runTrashEmpty,
+}
+
+var purgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Move old archived tasks to the trash",
+	Long: `Move archived tasks that have not been updated for a while to the trash.
+Subtasks that do not qualify themselves are detached and kept. The age is
+given as days, weeks, months or years (90d, 12w, 6m, 1y) or as a date
+(YYYY-MM-DD).`,
+	Args: cobra.NoArgs,
+	RunE: runPurge,
+}
+
+var (
+	deleteKeepSubtasks  bool
+	purgeArchivedBefore string
+	purgeDryRun         bool
+)
+
+func init() {
+	rootCmd.AddCommand(deleteCmd)
+	rootCmd.AddCommand(trashCmd)
+	rootCmd.AddCommand(purgeCmd)
+	trashCmd.AddCommand(trashListCmd)
+	trashCmd.AddCommand(trashRestoreCmd)
+	trashCmd.AddCommand(trashEmptyCmd)
+
+	deleteCmd.Flags().BoolVar(&deleteKeepSubtasks, "keep-subtasks", false, "Keep subtasks and detach them from the deleted task")
+	purgeCmd.Flags().StringVar(&purgeArchivedBefore, "archived-before", "", "Purge archived tasks last updated before this age or date (e.g. 90d)")
+	purgeCmd.Flags().BoolVar(&purgeDryRun, "dry-run", false, "List the tasks that would be purged")
+	purgeCmd.MarkFlagRequired("archived-before")
+}
+
+func runDelete(cmd *cobra.Command, args []string) error {
+	ctx, err := cli.LoadContext(cmd)
+	if err != nil {
+		return err
+	}
+
+	taskService := service.NewTaskService(ctx.Repo, ctx.Config)
+	var deleted []*task.Task
+	for _, arg := range args {
+		taskID, err := cli.NormalizeTaskID(arg)
+		if err != nil {
+			return err
+		}
+		tasks, err := taskService.DeleteTask(taskID, deleteKeepSubtasks)
+		deleted = append(deleted, tasks...)
+		if err != nil {
+			printDeleted(deleted)
+			return err
+		}
+	}
+
+	if outputFormat == "json" {
+		return output.NewJSONPrinter(os.Stdout).PrintTasks(deleted)
+	}
+	printDeleted(deleted)
+	return nil
+}
+
+func printDeleted(tasks []*task.Task) {
+	if outputFormat == "json" {
+		return
+	}
+	for _, t := range tasks {
+		fmt.Printf("Moved %s to the trash: %s\n", t.ID, t.Title)
+	}
+}
+
+func runTrashList(cmd *cobra.Command, args []string) error {
+	ctx, err := cli.LoadContext(cmd)
+	if err != nil {
+		return err
+	}
+
+	entries, err := ctx.Repo.Trash()
+	if err != nil {
+		return err
+	}
+
+	if outputFormat == "json" {
+		if entries == nil {
+			entries = []repository.TrashEntry{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("The trash is empty.")
+		return nil
+	}
+	for _, e := range entries {
+		fmt.Printf("%s  %s  %s\n", e.DeletedAt.Format(constants.DateTimeFormat), e.ID, e.Title)
+	}
+	return nil
+}
+
+func runTrashRestore(cmd *cobra.Command, args []string) error {
+	ctx, err := cli.LoadContext(cmd)
+	if err != nil {
+		return err
+	}
+
+	taskService := service.NewTaskService(ctx.Repo, ctx.Config)
+	var restored []*task.Task
+	for _, arg := range args {
+		taskID, err := cli.NormalizeTaskID(arg)
+		if err != nil {
+			return err
+		}
+		tasks, err := taskService.RestoreTask(taskID)
+		restored = append(restored, tasks...)
+		if err != nil {
+			printRestored(restored)
+			return err
+		}
+	}
+
+	if outputFormat == "json" {
+		return output.NewJSONPrinter(os.Stdout).PrintTasks(restored)
+	}
+	printRestored(restored)
+	return nil
+}
+
+func printRestored(tasks []*task.Task) {
+	if outputFormat == "json" {
+		return
+	}
+	for _, t := range tasks {
+		fmt.Printf("Restored %s: %s\n", t.ID, t.Title)
+	}
+}
+
+func runTrashEmpty(cmd *cobra.Command, args []string) error {
+	ctx, err := cli.LoadContext(cmd)
+	if err != nil {
+		return err
+	}
+
+	n, err := ctx.Repo.EmptyTrash()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Removed %d task(s) from the trash.\n", n)
+	return nil
+}
+
+func runPurge(cmd *cobra.Command, args []string) error {
+	ctx, err := cli.LoadContext(cmd)
+	if err != nil {
+		return err
+	}
+
+	before, err := parseAge(purgeArchivedBefore, time.Now())
+	if err != nil {
+		return err
+	}
+
+	purged, err := service.NewTaskService(ctx.Repo, ctx.Config).PurgeArchived(before, purgeDryRun)
+	if outputFormat == "json" {
+		if printErr := output.NewJSONPrinter(os.Stdout).PrintTasks(purged); err == nil {
+			err = printErr
+		}
+		return err
+	}
+
+	verb := "Moved"
+	if purgeDryRun {
+		verb = "Would move"
+	}
+	for _, t := range purged {
+		fmt.Printf("%s %s to the trash: %s\n", verb, t.ID, t.Title)
+	}
+	if err != nil {
+		return err
+	}
+	if len(purged) == 0 {
+		fmt.Printf("No archived tasks last updated before %s.\n", before.Format(constants.DateFormat))
+	} else if !purgeDryRun {
+		fmt.Println("Run 'mdtask trash empty' to remove them permanently.")
+	}
+	return nil
+}
+
+var agePattern = regexp.MustCompile(`^(\d+)([dwmy])$`)
+
+// parseAge returns the time an age such as 90d, 12w, 6m or 1y before now,
+// or the start of a YYYY-MM-DD date
+func parseAge(value string, now time.Time) (time.Time, error) {
+	if m := agePattern.FindStringSubmatch(value); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		switch m[2] {
+		case "d":
+			return now.AddDate(0, 0, -n), nil
+		case "w":
+			return now.AddDate(0, 0, -7*n), nil
+		case "m":
+			return now.AddDate(0, -n, 0), nil
+		default:
+			return now.AddDate(-n, 0, 0), nil
+		}
+	}
+	if date, err := time.ParseInLocation(constants.DateFormat, value, now.Location()); err == nil {
+		return date, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid age %q (use e.g. 90d, 12w, 6m, 1y or YYYY-MM-DD)", value)
+}
@@ -0,0 +1,35 @@
+package mdtask
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	now := time.Date(2025, 6, 20, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"90d", time.Date(2025, 3, 22, 9, 0, 0, 0, time.UTC), false},
+		{"2w", time.Date(2025, 6, 6, 9, 0, 0, 0, time.UTC), false},
+		{"6m", time.Date(2024, 12, 20, 9, 0, 0, 0, time.UTC), false},
+		{"1y", time.Date(2024, 6, 20, 9, 0, 0, 0, time.UTC), false},
+		{"2025-01-31", time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), false},
+		{"90", time.Time{}, true},
+		{"-3d", time.Time{}, true},
+		{"soon", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseAge(tt.value, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAge() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseAge() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
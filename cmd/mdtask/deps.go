@@ -0,0 +1,167 @@
+package mdtask
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tkancf/mdtask/internal/cli"
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/output"
+	"github.com/tkancf/mdtask/internal/service"
+)
+
+var depsCmd = &cobra.Command{
+	Use:   "deps <task-id>",
+	Short: "Show the dependency graph of a task",
+	Long: `Show the tasks a task is blocked by and the tasks it blocks, following
+the dependencies in both directions.
+
+Use --format dot to print the graph in Graphviz DOT format, e.g.
+  mdtask deps <task-id> --format dot | dot -Tsvg > deps.svg`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDeps,
+}
+
+func init() {
+	rootCmd.AddCommand(depsCmd)
+}
+
+// depsJSON is the JSON output of the deps command
+type depsJSON struct {
+	Root    string               `json:"root"`
+	Tasks   []output.TaskJSON    `json:"tasks"`
+	Edges   []service.Dependency `json:"edges"`
+	Missing []string             `json:"missing,omitempty"`
+}
+
+func runDeps(cmd *cobra.Command, args []string) error {
+	ctx, err := cli.LoadContext(cmd)
+	if err != nil {
+		return err
+	}
+
+	taskID, err := cli.NormalizeTaskID(args[0])
+	if err != nil {
+		return err
+	}
+
+	graph, err := service.NewTaskService(ctx.Repo, ctx.Config).DependencyGraph(taskID)
+	if err != nil {
+		return err
+	}
+
+	switch outputFormat {
+	case "json":
+		result := depsJSON{Root: graph.Root, Edges: graph.Edges, Missing: graph.Missing}
+		if result.Edges == nil {
+			result.Edges = []service.Dependency{}
+		}
+		for _, id := range graphTaskIDs(graph) {
+			result.Tasks = append(result.Tasks, output.NewTaskJSON(graph.Tasks[id]))
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	case "dot":
+		writeDependencyDOT(os.Stdout, graph, ctx.Config.GetWorkflow())
+		return nil
+	}
+
+	printDependencyTree(graph)
+	return nil
+}
+
+// printDependencyTree prints the blockers and dependents of the root task
+// as indented trees
+func printDependencyTree(g *service.DependencyGraph) {
+	root := g.Tasks[g.Root]
+	fmt.Printf("%s (%s) [%s]\n", root.Title, root.ID, root.GetStatus())
+
+	if len(g.Blockers(g.Root)) == 0 && len(g.Dependents(g.Root)) == 0 {
+		fmt.Println("\nNo dependencies.")
+		return
+	}
+
+	if blockers := g.Blockers(g.Root); len(blockers) > 0 {
+		fmt.Println("\nBlocked by:")
+		printDependencyBranch(g, blockers, g.Blockers, 1, map[string]bool{g.Root: true})
+	}
+	if dependents := g.Dependents(g.Root); len(dependents) > 0 {
+		fmt.Println("\nBlocks:")
+		printDependencyBranch(g, dependents, g.Dependents, 1, map[string]bool{g.Root: true})
+	}
+}
+
+func printDependencyBranch(g *service.DependencyGraph, ids []string, next func(string) []string, depth int, path map[string]bool) {
+	indent := strings.Repeat("  ", depth)
+	for _, id := range ids {
+		t, ok := g.Tasks[id]
+		if !ok {
+			fmt.Printf("%s%s (missing)\n", indent, id)
+			continue
+		}
+		fmt.Printf("%s%s  %s [%s]\n", indent, t.ID, t.Title, t.GetStatus())
+
+		// Task files can be edited by hand, so guard against cycles
+		if path[id] {
+			continue
+		}
+		path[id] = true
+		printDependencyBranch(g, next(id), next, depth+1, path)
+		delete(path, id)
+	}
+}
+
+// writeDependencyDOT writes the graph in Graphviz DOT format. Edges point
+// from a blocker to the task it blocks, i.e. in the order of work.
+func writeDependencyDOT(w io.Writer, g *service.DependencyGraph, workflow *config.Workflow) {
+	fmt.Fprintln(w, "digraph dependencies {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	fmt.Fprintln(w, "  node [shape=box, style=rounded];")
+
+	for _, id := range graphTaskIDs(g) {
+		t := g.Tasks[id]
+		attrs := []string{fmt.Sprintf("label=%s", dotQuote(t.Title+"\n"+t.ID+"\n"+string(t.GetStatus())))}
+		if color := workflow.Color(t.GetStatus()); color != "" {
+			attrs = append(attrs, fmt.Sprintf("color=%s", dotQuote(color)))
+		}
+		if workflow.IsDone(t.GetStatus()) {
+			attrs = append(attrs, `fontcolor="gray50"`)
+		}
+		if id == g.Root {
+			attrs = append(attrs, "penwidth=2")
+		}
+		fmt.Fprintf(w, "  %s [%s];\n", dotQuote(id), strings.Join(attrs, ", "))
+	}
+	for _, id := range g.Missing {
+		fmt.Fprintf(w, "  %s [label=%s, style=dashed];\n", dotQuote(id), dotQuote(id+"\n(missing)"))
+	}
+
+	for _, e := range g.Edges {
+		fmt.Fprintf(w, "  %s -> %s;\n", dotQuote(e.BlockedBy), dotQuote(e.Task))
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// dotQuote returns s as a quoted DOT string
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+// graphTaskIDs returns the IDs of the tasks in the graph in a stable order
+func graphTaskIDs(g *service.DependencyGraph) []string {
+	ids := make([]string, 0, len(g.Tasks))
+	for id := range g.Tasks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
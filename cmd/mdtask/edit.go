@@ -10,6 +10,7 @@ import (
 	"github.com/tkancf/mdtask/internal/cli"
 	"github.com/tkancf/mdtask/internal/errors"
 	"github.com/tkancf/mdtask/internal/output"
+	"github.com/tkancf/mdtask/internal/service"
 	"github.com/tkancf/mdtask/internal/task"
 )
 
@@ -20,6 +21,11 @@ var (
 	editTags        string
 	editContent     string
 	editDeadline    string
+	editSetFields   []string
+	editUnsetFields []string
+	editRecur       string
+	editBlockedBy   []string
+	editUnblock     []string
 )
 
 var editCmd = &cobra.Command{
@@ -36,10 +42,15 @@ func init() {
 	// Add flags for programmatic editing
 	editCmd.Flags().StringVar(&editTitle, "title", "", "Update task title")
 	editCmd.Flags().StringVar(&editDescription, "description", "", "Update task description")
-	editCmd.Flags().StringVar(&editStatus, "status", "", "Update task status (TODO, WIP, WAIT, SCHE, DONE)")
+	editCmd.Flags().StringVar(&editStatus, "status", "", "Update task status (one of the workflow statuses, by default TODO, WIP, WAIT, SCHE, DONE)")
 	editCmd.Flags().StringVar(&editTags, "tags", "", "Update task tags (comma-separated)")
 	editCmd.Flags().StringVar(&editContent, "content", "", "Update task content")
 	editCmd.Flags().StringVar(&editDeadline, "deadline", "", "Update task deadline (YYYY-MM-DD)")
+	editCmd.Flags().StringArrayVar(&editSetFields, "set", nil, "Set a custom field (name=value, can be repeated)")
+	editCmd.Flags().StringArrayVar(&editUnsetFields, "unset", nil, "Remove a custom field (can be repeated)")
+	editCmd.Flags().StringVar(&editRecur, "recur", "", "Update the repeat rule (e.g. weekly:mon), or 'none' to stop repeating")
+	editCmd.Flags().StringSliceVar(&editBlockedBy, "blocked-by", nil, "Add tasks that must be done first (comma-separated IDs)")
+	editCmd.Flags().StringSliceVar(&editUnblock, "unblock", nil, "Remove dependencies on the given task IDs (comma-separated)")
 }
 
 func runEdit(cmd *cobra.Command, args []string) error {
@@ -55,85 +66,120 @@ func runEdit(cmd *cobra.Command, args []string) error {
 	
 	// Check if any flags are provided for programmatic editing
 	hasFlags := editTitle != "" || editDescription != "" || editStatus != "" || 
-		editTags != "" || editContent != "" || editDeadline != ""
+		editTags != "" || editContent != "" || editDeadline != "" ||
+		len(editSetFields) > 0 || len(editUnsetFields) > 0 || editRecur != "" ||
+		len(editBlockedBy) > 0 || len(editUnblock) > 0
 	
 	if hasFlags {
 		// Programmatic editing mode
-		t, err := ctx.Repo.FindByID(taskID)
-		if err != nil {
-			return err
-		}
+		var params service.UpdateTaskParams
 		
-		// Update fields based on flags
 		if editTitle != "" {
 			if err := task.ValidateTitle(editTitle); err != nil {
 				return fmt.Errorf("invalid title: %w", err)
 			}
-			t.Title = editTitle
+			params.Title = &editTitle
 		}
 		
 		if cmd.Flags().Changed("description") {
 			if err := task.ValidateDescription(editDescription); err != nil {
 				return fmt.Errorf("invalid description: %w", err)
 			}
-			t.Description = editDescription
+			params.Description = &editDescription
 		}
 		
 		if editStatus != "" {
-			status, err := cli.ValidateStatus(strings.ToUpper(editStatus))
+			status, err := cli.ValidateWorkflowStatus(ctx.Config.GetWorkflow(), strings.ToUpper(editStatus))
 			if err != nil {
 				return err
 			}
-			t.SetStatus(status)
+			params.Status = &status
 		}
 		
 		if editTags != "" {
-			// Preserve system tags
-			var systemTags []string
-			for _, tag := range t.Tags {
-				if tag == "mdtask" || strings.HasPrefix(tag, "mdtask/") {
-					systemTags = append(systemTags, tag)
-				}
-			}
-			
-			// Parse new user tags
+			// Parse new user tags; system tags are preserved by the service
 			userTags := strings.Split(editTags, ",")
 			for i, tag := range userTags {
 				userTags[i] = strings.TrimSpace(tag)
 			}
-			
-			// Combine system and user tags
-			t.Tags = append(systemTags, userTags...)
+			params.Tags = &userTags
 		}
 		
 		if cmd.Flags().Changed("content") {
-			t.Content = editContent
+			params.Content = &editContent
 		}
 		
 		if editDeadline != "" {
 			if editDeadline == "none" || editDeadline == "clear" {
-				// Clear deadline
-				var newTags []string
-				for _, tag := range t.Tags {
-					if !strings.HasPrefix(tag, "mdtask/deadline/") {
-						newTags = append(newTags, tag)
-					}
-				}
-				t.Tags = newTags
+				params.ClearDeadline = true
 			} else {
-				// Parse and set deadline
 				deadline, err := cli.ParseDeadline(editDeadline)
 				if err != nil {
 					return err
 				}
-				if deadline != nil {
-					t.SetDeadline(*deadline)
+				params.Deadline = deadline
+			}
+		}
+		
+		if len(editSetFields) > 0 || len(editUnsetFields) > 0 {
+			params.Fields = make(map[string]interface{})
+		}
+		for _, assignment := range editSetFields {
+			name, value, err := cli.ParseFieldAssignment(ctx.Config, assignment)
+			if err != nil {
+				return err
+			}
+			params.Fields[name] = value
+		}
+		for _, name := range editUnsetFields {
+			if err := task.ValidateFieldName(name); err != nil {
+				return err
+			}
+			params.Fields[name] = nil
+		}
+		
+		if editRecur != "" {
+			if editRecur == "none" || editRecur == "clear" {
+				params.ClearRecurrence = true
+			} else {
+				rule, err := task.ParseRecurrence(editRecur)
+				if err != nil {
+					return err
 				}
+				params.Recurrence = rule
 			}
 		}
 		
-		// Update the task
-		if err := ctx.Repo.Update(t); err != nil {
+		for _, id := range editBlockedBy {
+			blockerID, err := cli.NormalizeTaskID(id)
+			if err != nil {
+				return err
+			}
+			params.AddBlockedBy = append(params.AddBlockedBy, blockerID)
+		}
+		for _, id := range editUnblock {
+			blockerID, err := cli.NormalizeTaskID(id)
+			if err != nil {
+				return err
+			}
+			params.RemoveBlockedBy = append(params.RemoveBlockedBy, blockerID)
+		}
+		
+		// Update the task; the service enforces the workflow transitions,
+		// validates dependencies and schedules the next occurrence of
+		// recurring tasks
+		taskService := service.NewTaskService(ctx.Repo, ctx.Config)
+
+		// A next occurrence created by an earlier completion is not reported
+		// again
+		reportNext := params.Status != nil
+		if reportNext {
+			existing, err := taskService.FindNextOccurrence(taskID)
+			reportNext = err == nil && existing == nil
+		}
+
+		t, err := taskService.UpdateTask(taskID, params)
+		if err != nil {
 			return err
 		}
 		
@@ -143,6 +189,15 @@ func runEdit(cmd *cobra.Command, args []string) error {
 		}
 		
 		fmt.Printf("Task %s updated successfully.\n", taskID)
+		if reportNext {
+			if next, err := taskService.FindNextOccurrence(taskID); err == nil && next != nil {
+				fmt.Printf("Next occurrence: %s", next.ID)
+				if deadline := next.GetDeadline(); deadline != nil {
+					fmt.Printf(" (due %s)", deadline.Format("2006-01-02"))
+				}
+				fmt.Println()
+			}
+		}
 		return nil
 	}
 	
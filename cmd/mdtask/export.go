@@ -0,0 +1,99 @@
+package mdtask
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tkancf/mdtask/internal/cli"
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/ical"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+var (
+	exportOutput   string
+	exportTags     []string
+	exportStatuses []string
+	exportArchived bool
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export tasks to other formats",
+}
+
+var exportICSCmd = &cobra.Command{
+	Use:   "ics",
+	Short: "Export tasks as an iCalendar file",
+	Long: `Export tasks as an iCalendar (.ics) file for calendar apps.
+
+Each task becomes a to-do (VTODO). Tasks that are not done also get an
+all-day event on their deadline and an event at their reminder. UIDs are
+derived from task IDs, so importing the file again updates the entries
+instead of duplicating them.
+
+Use 'mdtask web' to subscribe to a live feed at /calendar.ics instead.`,
+	Example: `  mdtask export ics -o tasks.ics
+  mdtask export ics --tag work --status TODO --status WIP`,
+	Args: cobra.NoArgs,
+	RunE: runExportICS,
+}
+
+func init() {
+	exportICSCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Write to a file instead of standard output")
+	exportICSCmd.Flags().StringArrayVar(&exportTags, "tag", nil, "Only export tasks with this tag (can be repeated; type/* matches a prefix)")
+	exportICSCmd.Flags().StringArrayVarP(&exportStatuses, "status", "s", nil, "Only export tasks in this status (can be repeated)")
+	exportICSCmd.Flags().BoolVarP(&exportArchived, "archived", "a", false, "Include archived tasks")
+	exportCmd.AddCommand(exportICSCmd)
+	rootCmd.AddCommand(exportCmd)
+}
+
+func runExportICS(cmd *cobra.Command, args []string) error {
+	ctx, err := cli.LoadContext(cmd)
+	if err != nil {
+		return err
+	}
+	workflow := ctx.Config.GetWorkflow()
+
+	selection := ical.Selection{Tags: exportTags, Archived: exportArchived}
+	for _, name := range exportStatuses {
+		status, err := workflow.Parse(name)
+		if err != nil {
+			return err
+		}
+		selection.Statuses = append(selection.Statuses, status)
+	}
+
+	tasks, err := ctx.Repo.FindAll()
+	if err != nil {
+		return fmt.Errorf("failed to list tasks: %w", err)
+	}
+	tasks = selection.Select(tasks, workflow)
+
+	if exportOutput == "" {
+		return writeCalendar(os.Stdout, tasks, workflow)
+	}
+
+	f, err := os.Create(exportOutput)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", exportOutput, err)
+	}
+	if err := writeCalendar(f, tasks, workflow); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", exportOutput, err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d tasks to %s\n", len(tasks), exportOutput)
+	return nil
+}
+
+func writeCalendar(w io.Writer, tasks []*task.Task, workflow *config.Workflow) error {
+	if err := ical.NewEncoder(workflow).Encode(w, tasks); err != nil {
+		return fmt.Errorf("failed to write calendar: %w", err)
+	}
+	return nil
+}
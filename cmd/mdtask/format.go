@@ -0,0 +1,41 @@
+package mdtask
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tkancf/mdtask/internal/cli"
+	"github.com/tkancf/mdtask/internal/output"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// outputColumns selects the columns of table formats
+var outputColumns []string
+
+func addColumnsFlag(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&outputColumns, "columns", nil,
+		"Columns of the csv, tsv, markdown-table and html formats (comma-separated, e.g. id,title,estimate)")
+}
+
+// printFormatted writes tasks in one of the formats of the output registry.
+// It reports false for text and json, which commands print themselves.
+func printFormatted(ctx *cli.Context, tasks []*task.Task, title string) (bool, error) {
+	if outputFormat == "text" || outputFormat == "json" {
+		return false, nil
+	}
+
+	workflow := ctx.Config.GetWorkflow()
+	names := outputColumns
+	if len(names) == 0 {
+		names = ctx.Config.Output.Columns
+	}
+	columns, err := output.ParseColumns(names, workflow)
+	if err != nil {
+		return true, err
+	}
+	return true, output.Format(outputFormat, os.Stdout, tasks, output.Options{
+		Columns:  columns,
+		Workflow: workflow,
+		Title:    title,
+	})
+}
@@ -3,12 +3,13 @@ package mdtask
 import (
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/tkancf/mdtask/internal/cli"
 	"github.com/tkancf/mdtask/internal/output"
-	"github.com/tkancf/mdtask/internal/task"
+	taskpkg "github.com/tkancf/mdtask/internal/task"
 )
 
 var getCmd = &cobra.Command{
@@ -21,6 +22,7 @@ var getCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(getCmd)
+	addColumnsFlag(getCmd)
 }
 
 func runGet(cmd *cobra.Command, args []string) error {
@@ -43,6 +45,9 @@ func runGet(cmd *cobra.Command, args []string) error {
 	}
 
 	// Output based on format
+	if ok, err := printFormatted(ctx, []*taskpkg.Task{foundTask}, foundTask.Title); ok {
+		return err
+	}
 	if outputFormat == "json" {
 		printer := output.NewJSONPrinter(os.Stdout)
 		return printer.PrintTask(foundTask)
@@ -55,7 +60,7 @@ func runGet(cmd *cobra.Command, args []string) error {
 
 func printTaskDetails(t interface{}) {
 	// Type assert to access task fields
-	task := t.(*task.Task)
+	task := t.(*taskpkg.Task)
 	fmt.Printf("ID: %s\n", task.ID)
 	fmt.Printf("Title: %s\n", task.Title)
 	fmt.Printf("Status: %s\n", task.GetStatus())
@@ -78,6 +83,27 @@ func printTaskDetails(t interface{}) {
 	if r := task.GetReminder(); r != nil {
 		fmt.Printf("Reminder: %s\n", r.Format("2006-01-02 15:04"))
 	}
+
+	if rule, err := task.GetRecurrence(); err != nil {
+		fmt.Printf("Repeats: invalid rule (%v)\n", err)
+	} else if rule != nil {
+		fmt.Printf("Repeats: %s\n", rule)
+	}
+	if prev := task.GetPreviousID(); prev != "" {
+		fmt.Printf("Previous: %s\n", prev)
+	}
+
+	if blockers := task.GetBlockedBy(); len(blockers) > 0 {
+		fmt.Printf("Blocked by: %s\n", strings.Join(blockers, ", "))
+	}
+
+	if spent := task.TimeSpent(time.Now()); spent > 0 || task.RunningClock() != nil {
+		fmt.Printf("Time spent: %s", taskpkg.FormatDuration(spent))
+		if running := task.RunningClock(); running != nil {
+			fmt.Printf(" (running since %s)", running.Start.Format("2006-01-02 15:04"))
+		}
+		fmt.Println()
+	}
 	
 	if task.IsArchived() {
 		fmt.Printf("Archived: Yes\n")
@@ -87,6 +113,13 @@ func printTaskDetails(t interface{}) {
 		fmt.Printf("Tags: %v\n", task.Tags)
 	}
 	
+	if len(task.Fields) > 0 {
+		fmt.Printf("Fields:\n")
+		for _, name := range task.FieldNames() {
+			fmt.Printf("  %s: %s\n", name, taskpkg.FieldString(task.Fields[name]))
+		}
+	}
+	
 	if task.Content != "" {
 		fmt.Printf("\n--- Content ---\n%s\n", task.Content)
 	}
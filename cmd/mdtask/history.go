@@ -0,0 +1,264 @@
+package mdtask
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tkancf/mdtask/internal/cli"
+	"github.com/tkancf/mdtask/internal/constants"
+	"github.com/tkancf/mdtask/internal/repository"
+	"github.com/tkancf/mdtask/internal/service"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history <task-id>",
+	Short: "Show the git history of a task",
+	Long: `Show the commits that changed a task, newest first, with the fields each
+commit changed. The task directory must be inside a git repository; set
+auto_commit in the [git] section of the config to commit every change made
+through mdtask.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runHistory,
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <task-id> [rev]",
+	Short: "Show field changes of a task since a revision",
+	Long: `Show the fields of a task that changed between a git revision and the
+current file. The revision can be a commit hash from 'mdtask history' or any
+other git revision such as HEAD~3. Without a revision, the changes of the
+latest commit of the task and any uncommitted changes are shown.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runDiff,
+}
+
+var revertCmd = &cobra.Command{
+	Use:   "revert <task-id> <rev>",
+	Short: "Restore a task to an earlier revision",
+	Long: `Restore the file of a task to its content at a git revision. With
+auto_commit enabled the restored version is committed.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runRevert,
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(revertCmd)
+}
+
+// historyEntry is a revision in the JSON output of history
+type historyEntry struct {
+	Hash    string        `json:"hash"`
+	Author  string        `json:"author"`
+	Time    time.Time     `json:"time"`
+	Subject string        `json:"subject"`
+	Changes []task.Change `json:"changes"`
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	ctx, err := cli.LoadContext(cmd)
+	if err != nil {
+		return err
+	}
+
+	taskID, err := cli.NormalizeTaskID(args[0])
+	if err != nil {
+		return err
+	}
+
+	revisions, err := ctx.Repo.History(taskID)
+	if err != nil {
+		return err
+	}
+
+	// Load every version once, oldest first, to diff neighbours
+	versions := make([]*task.Task, len(revisions))
+	for i := len(revisions) - 1; i >= 0; i-- {
+		if t, _, err := ctx.Repo.TaskAt(taskID, revisions[i].Hash); err == nil {
+			versions[i] = t
+		}
+	}
+
+	entries := make([]historyEntry, len(revisions))
+	for i, rev := range revisions {
+		entries[i] = historyEntry{Hash: rev.Hash, Author: rev.Author, Time: rev.Time, Subject: rev.Subject, Changes: []task.Change{}}
+		if versions[i] == nil {
+			continue
+		}
+		var previous *task.Task
+		if i+1 < len(versions) {
+			previous = versions[i+1]
+		}
+		if changes := task.Diff(previous, versions[i]); changes != nil {
+			entries[i].Changes = changes
+		}
+	}
+
+	if outputFormat == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Printf("No commits for %s\n", taskID)
+		return nil
+	}
+	for _, e := range entries {
+		fmt.Printf("%s  %s  %s  %s\n", e.Hash[:7], e.Time.Local().Format(constants.DateTimeFormat), e.Author, e.Subject)
+		for _, c := range e.Changes {
+			fmt.Printf("    %s\n", changeSummary(c))
+		}
+	}
+	return nil
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	ctx, err := cli.LoadContext(cmd)
+	if err != nil {
+		return err
+	}
+
+	taskID, err := cli.NormalizeTaskID(args[0])
+	if err != nil {
+		return err
+	}
+
+	current, err := ctx.Repo.FindByID(taskID)
+	if err != nil {
+		return err
+	}
+
+	var old *task.Task
+	from := "it was created"
+	if len(args) == 2 {
+		var rev *repository.Revision
+		old, rev, err = ctx.Repo.TaskAt(taskID, args[1])
+		if err != nil {
+			return err
+		}
+		from = rev.Short()
+	} else {
+		revisions, err := ctx.Repo.History(taskID)
+		if err != nil {
+			return err
+		}
+		if len(revisions) >= 2 {
+			if old, _, err = ctx.Repo.TaskAt(taskID, revisions[1].Hash); err != nil {
+				return err
+			}
+			from = revisions[1].Short()
+		}
+	}
+
+	changes := task.Diff(old, current)
+	if outputFormat == "json" {
+		if changes == nil {
+			changes = []task.Change{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(changes)
+	}
+
+	if len(changes) == 0 {
+		fmt.Printf("No changes to %s since %s\n", taskID, from)
+		return nil
+	}
+	fmt.Printf("Changes to %s since %s:\n", taskID, from)
+	for _, c := range changes {
+		if c.Field == "content" {
+			fmt.Println("content:")
+			for _, line := range lineDiff(c.Old, c.New) {
+				fmt.Printf("  %s\n", line)
+			}
+			continue
+		}
+		fmt.Printf("%s: %s -> %s\n", c.Field, quoteValue(c.Old), quoteValue(c.New))
+	}
+	return nil
+}
+
+func runRevert(cmd *cobra.Command, args []string) error {
+	ctx, err := cli.LoadContext(cmd)
+	if err != nil {
+		return err
+	}
+
+	taskID, err := cli.NormalizeTaskID(args[0])
+	if err != nil {
+		return err
+	}
+
+	current, reverted, rev, err := service.NewTaskService(ctx.Repo, ctx.Config).RevertTask(taskID, args[1])
+	if err != nil {
+		return fmt.Errorf("failed to revert task: %w", err)
+	}
+
+	fmt.Printf("Reverted %s to %s (%s)\n", taskID, rev.Short(), rev.Subject)
+	for _, c := range task.Diff(current, reverted) {
+		fmt.Printf("    %s\n", changeSummary(c))
+	}
+	return nil
+}
+
+// changeSummary formats a change on one line; text bodies are only named
+func changeSummary(c task.Change) string {
+	if c.Field == "content" || c.Field == "description" {
+		return c.Field + " changed"
+	}
+	return fmt.Sprintf("%s: %s -> %s", c.Field, quoteValue(c.Old), quoteValue(c.New))
+}
+
+func quoteValue(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return fmt.Sprintf("%q", s)
+}
+
+// lineDiff compares two texts line by line and returns the lines of both,
+// prefixed with "- " when removed, "+ " when added and "  " when kept
+func lineDiff(a, b string) []string {
+	x := strings.Split(a, "\n")
+	y := strings.Split(b, "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of x[i:] and y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []string
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			lines = append(lines, "  "+x[i])
+			i++
+			j++
+		case i < len(x) && (j == len(y) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, "- "+x[i])
+			i++
+		default:
+			lines = append(lines, "+ "+y[j])
+			j++
+		}
+	}
+	return lines
+}
@@ -0,0 +1,26 @@
+package mdtask
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLineDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []string
+	}{
+		{"equal", "a\nb", "a\nb", []string{"  a", "  b"}},
+		{"added", "a", "a\nb", []string{"  a", "+ b"}},
+		{"removed", "a\nb\nc", "a\nc", []string{"  a", "- b", "  c"}},
+		{"replaced", "a\nb\nc", "a\nx\nc", []string{"  a", "- b", "+ x", "  c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lineDiff(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lineDiff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package mdtask
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/tkancf/mdtask/internal/cli"
+	"github.com/tkancf/mdtask/internal/constants"
+	"github.com/tkancf/mdtask/internal/importer"
+	"github.com/tkancf/mdtask/internal/service"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import --from <format> <file>",
+	Short: "Import tasks from other tools",
+	Long: `Import tasks exported from Taskwarrior, Todoist or GitHub issues, or from a
+CSV file. Use - as the file to read standard input.
+
+Each task remembers its ID in the source tool in the 'source' front matter
+field, so importing the same export again skips the tasks already imported.
+Subtasks are linked to their parents when both are imported.
+
+Formats:
+  taskwarrior  the output of 'task export'
+  todoist      tasks from the Todoist REST or Sync API
+  github       'gh issue list --state all --json number,title,body,state,labels,url,milestone'
+  csv          a header row with title and optionally id, description,
+               notes, due, status, tags and parent columns
+
+Examples:
+  # Preview what would be created
+  task export | mdtask import --from taskwarrior --dry-run -
+
+  mdtask import --from csv backlog.csv`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImport,
+}
+
+var (
+	importFrom   string
+	importDryRun bool
+)
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.Flags().StringVar(&importFrom, "from", "", "Format of the file ("+strings.Join(importer.Formats(), ", ")+")")
+	importCmd.Flags().BoolVarP(&importDryRun, "dry-run", "n", false, "Show the tasks that would be created without creating them")
+	importCmd.MarkFlagRequired("from")
+}
+
+type importResultJSON struct {
+	Action   string   `json:"action"`
+	Source   string   `json:"source"`
+	ID       string   `json:"id,omitempty"`
+	Title    string   `json:"title"`
+	Status   string   `json:"status,omitempty"`
+	Deadline string   `json:"deadline,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Parent   string   `json:"parent,omitempty"`
+	FilePath string   `json:"file_path,omitempty"`
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	var in io.Reader = os.Stdin
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", args[0], err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	records, err := importer.Parse(importFrom, in)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", args[0], err)
+	}
+
+	ctx, err := cli.LoadContext(cmd)
+	if err != nil {
+		return err
+	}
+	results, err := service.NewTaskService(ctx.Repo, ctx.Config).ImportTasks(records, importDryRun)
+	// Report the tasks created before a failure, so the import can be resumed
+	if len(results) > 0 {
+		if printErr := printImportResults(results); printErr != nil && err == nil {
+			err = printErr
+		}
+	}
+	return err
+}
+
+func printImportResults(results []service.ImportResult) error {
+	var out []importResultJSON
+	created, skipped := 0, 0
+	for _, result := range results {
+		item := importResultJSON{
+			Action: "create",
+			Source: result.Record.Source,
+			Title:  result.Params.Title,
+			Status: result.Params.Status,
+			Tags:   result.Params.Tags,
+			Parent: result.Record.Parent,
+		}
+		if result.Params.Deadline != nil {
+			item.Deadline = result.Params.Deadline.Format(constants.DateFormat)
+		}
+		switch {
+		case result.Duplicate:
+			item = importResultJSON{Action: "skip", Source: result.Record.Source, ID: result.Task.ID, Title: result.Task.Title}
+			skipped++
+		case result.Task != nil:
+			item.ID, item.FilePath = result.Task.ID, result.Path
+			item.Parent = result.Task.GetParentID()
+			created++
+		default:
+			created++
+		}
+		out = append(out, item)
+	}
+
+	if outputFormat == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(out)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACTION\tSOURCE\tSTATUS\tDEADLINE\tTITLE")
+	for _, item := range out {
+		action := item.Action
+		if action == "create" && importDryRun {
+			action = "would create"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", action, item.Source, item.Status, item.Deadline, item.Title)
+	}
+	w.Flush()
+
+	verb := "Created"
+	if importDryRun {
+		verb = "Would create"
+	}
+	fmt.Printf("\n%s %d tasks, skipped %d already imported\n", verb, created, skipped)
+	return nil
+}
@@ -59,6 +59,13 @@ func runInit(cmd *cobra.Command, args []string) error {
 # Default: ["."]
 paths = ["."]
 
+# Files and directories to leave out, in the .gitignore syntax. Patterns
+# from .mdtaskignore and .gitignore files are applied as well; .git,
+# .obsidian and node_modules are always skipped.
+# Example: ["templates/", "drafts/**/*.md"]
+# Default: []
+# ignore = []
+
 [task]
 # Prefix to add to all new task titles
 # This is useful for adding project codes, ticket numbers, etc.
@@ -67,7 +74,7 @@ paths = ["."]
 title_prefix = ""
 
 # Default status for new tasks
-# Options: TODO, WIP, WAIT, SCHE, DONE
+# Options: any status of the [workflow] section (TODO, WIP, WAIT, SCHE, DONE by default)
 # Default: "TODO"
 default_status = "TODO"
 
@@ -108,6 +115,95 @@ port = 7000
 # Whether to open browser automatically when starting web server
 # Default: true
 open_browser = true
+
+[notify]
+# How 'mdtask remind' shows reminders:
+# auto        osascript on macOS, else the D-Bus notification service (via
+#             gdbus) or notify-send if available, else the terminal
+# dbus        the freedesktop.org notification service, called with gdbus
+# notify-send libnotify's notify-send
+# osascript   macOS notifications
+# terminal    ring the bell and print the reminder
+# Default: "auto"
+backend = "auto"
+
+# How late a missed reminder is still shown, e.g. after the computer was off.
+# Older ones are dropped; "0" shows all missed reminders.
+# Default: "24h"
+catch_up = "24h"
+
+[git]
+# Commit every task created, updated or archived through mdtask when the
+# task directory is inside a git repository (see 'mdtask history')
+# Default: false
+auto_commit = false
+
+[storage]
+# Dated subfolders for new task files, built from YYYY, MM and DD
+# Example: "YYYY/MM" writes tasks created in June 2025 to 2025/06/
+# Default: "" (no subfolders)
+subfolders = ""
+
+# How new tasks and their files are named (see 'mdtask migrate-ids'):
+# timestamp  20250629103000.md, with _1, _2 for more tasks in the same second
+# ulid       01JYXKS620ABCDEFGHJKMNPQRS.md, sortable and unique across machines
+# slug       20250629-fix-login.md, the creation date and the title
+# Default: "timestamp"
+id_format = "timestamp"
+
+# Routes send new tasks with a tag to another directory, which must be one
+# of the paths above or inside one. The first matching route is used; a
+# tag also matches its children (work matches work/api).
+# [[routes]]
+# tag = "work"
+# dir = "work"
+
+[output]
+# Columns of the csv, tsv, markdown-table and html formats
+# Built-in: id, title, description, status, tags, deadline, reminder, created,
+# updated, completed, parent, blocked_by, archived, time_spent, content;
+# other names show custom fields
+# Default: ["id", "status", "title", "deadline", "tags"]
+# columns = ["id", "status", "title", "deadline", "tags"]
+
+# Status workflow. Uncomment to replace the default TODO, WIP, WAIT, SCHE, DONE.
+# Statuses are listed in display order; color is used by the web UI and TUI.
+# [workflow]
+# done = ["DONE"]
+#
+# [[workflow.statuses]]
+# name = "TODO"
+# label = "To Do"
+# color = "#3B82F6"
+#
+# [[workflow.statuses]]
+# name = "REVIEW"
+# label = "In Review"
+# color = "#F59E0B"
+#
+# [[workflow.statuses]]
+# name = "DONE"
+# color = "#10B981"
+#
+# Optional: allowed transitions per status. Statuses without an entry can
+# move to any status.
+# [workflow.transitions]
+# TODO = ["REVIEW"]
+# REVIEW = ["TODO", "DONE"]
+
+# Hooks run when tasks change, from the CLI, web UI, TUI and MCP server.
+# on: create, update, archive, status (any change) or status:<STATUS>
+# A command runs with sh -c and gets the event JSON on stdin; a url gets it
+# in a POST request, retried on errors (retries, default 3).
+# [[hooks]]
+# on = "status:DONE"
+# command = "jq -c '{text: (\"Done: \" + .task.title)}' | curl -sS -d @- \"$SLACK_WEBHOOK_URL\""
+#
+# [[hooks]]
+# on = "create"
+# url = "https://ci.example.com/trigger"
+# headers = { Authorization = "Bearer <token>" }
+# timeout = "10s"
 `
 	
 	// Create directory if it doesn't exist
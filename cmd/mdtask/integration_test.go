@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -58,7 +59,7 @@ It provides a CLI interface for managing tasks with YAML frontmatter metadata.`,
 	
 	// Add all subcommands
 	cmd.AddCommand(newCmd, listCmd, editCmd, getCmd, archiveCmd, searchCmd, versionCmd, 
-		initCmd, mcpCmd, remindCmd, statsCmd, tuiCmd, webCmd)
+		initCmd, mcpCmd, remindCmd, statsCmd, tuiCmd, webCmd, recurCmd, readyCmd, depsCmd, startCmd, stopCmd, timesheetCmd, exportCmd, importCmd)
 	
 	cmd.SetOut(stdout)
 	cmd.SetErr(stderr)
@@ -222,4 +223,216 @@ func TestIntegration_TaskValidation(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to create valid task: %v", err)
 	}
-}
\ No newline at end of file
+}
+func TestIntegration_RecurringTask(t *testing.T) {
+	tc := NewTestContext(t)
+	defer tc.Cleanup()
+	// Flag values are package globals and would leak into other tests
+	defer func() { newRecur, recurRule, recurFrom, recurCount = "", "", "", 5 }()
+
+	if err := tc.Execute("new", "--title", "Weekly review", "--recur", "weekly:fri", "--deadline", "2024-01-05", "--content", "Review"); err != nil {
+		t.Fatalf("failed to create recurring task: %v", err)
+	}
+	if err := tc.Execute("new", "--title", "Invalid", "--recur", "sometimes", "--content", "x"); err == nil {
+		t.Error("expected an invalid rule to be rejected")
+	}
+
+	if err := tc.Execute("recur"); err != nil {
+		t.Fatalf("failed to preview recurring tasks: %v", err)
+	}
+	if err := tc.Execute("recur", "--rule", "monthly:last", "--from", "2024-01-01", "-n", "3"); err != nil {
+		t.Fatalf("failed to preview rule: %v", err)
+	}
+}
+
+func TestIntegration_Dependencies(t *testing.T) {
+	tc := NewTestContext(t)
+	defer tc.Cleanup()
+	defer func() { newBlockedBy = nil }()
+
+	if err := tc.Execute("new", "--title", "Design", "--content", "x"); err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+	if err := tc.Execute("new", "--title", "Build", "--blocked-by", "19990101000000", "--content", "x"); err == nil {
+		t.Error("expected a missing blocker to be rejected")
+	}
+
+	if err := tc.Execute("ready"); err != nil {
+		t.Fatalf("failed to list ready tasks: %v", err)
+	}
+	if err := tc.Execute("deps", "19990101000000"); err == nil {
+		t.Error("expected deps of an unknown task to fail")
+	}
+}
+
+func TestIntegration_Query(t *testing.T) {
+	tc := NewTestContext(t)
+	defer tc.Cleanup()
+	defer func() { listQuery, newTags = "", nil }()
+
+	if err := tc.Execute("new", "--title", "Fix login", "--tags", "type/bug", "--content", "x"); err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+
+	if err := tc.Execute("list", "-q", `tag:type/bug and not done and title~"login"`); err != nil {
+		t.Fatalf("failed to list with query: %v", err)
+	}
+	if err := tc.Execute("list", "-q", "deadline<soon"); err == nil {
+		t.Error("expected an invalid query to be rejected")
+	}
+}
+
+func TestIntegration_TimeTracking(t *testing.T) {
+	tc := NewTestContext(t)
+	defer tc.Cleanup()
+	defer func() {
+		timesheetWeek, timesheetMonth, timesheetToday, timesheetLast = false, false, false, false
+		timesheetFrom, timesheetTo = "", ""
+	}()
+
+	if err := tc.Execute("stop"); err == nil {
+		t.Error("expected stop without a running timer to fail")
+	}
+	if err := tc.Execute("start", "19990101000000"); err == nil {
+		t.Error("expected start of an unknown task to fail")
+	}
+
+	if err := tc.Execute("timesheet", "--week"); err != nil {
+		t.Fatalf("failed to show timesheet: %v", err)
+	}
+	if err := tc.Execute("timesheet", "--from", "2024-01-01", "--to", "2024-02-01"); err != nil {
+		t.Fatalf("failed to show timesheet for a range: %v", err)
+	}
+	if err := tc.Execute("timesheet", "--from", "January"); err == nil {
+		t.Error("expected an invalid date to be rejected")
+	}
+}
+
+func TestIntegration_StatsReport(t *testing.T) {
+	tc := NewTestContext(t)
+	defer tc.Cleanup()
+	defer func() { statsReport, statsFrom, statsTo, statsTag = "", "", "", "" }()
+
+	if err := tc.Execute("new", "--title", "Sprint task", "--content", "x"); err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+
+	for _, kind := range []string{"burndown", "cfd", "throughput"} {
+		if err := tc.Execute("stats", "--report", kind, "--tag", "type/*"); err != nil {
+			t.Errorf("failed to show %s report: %v", kind, err)
+		}
+	}
+	if err := tc.Execute("stats", "--report", "gantt"); err == nil {
+		t.Error("expected an unknown report to be rejected")
+	}
+	if err := tc.Execute("stats", "--report", "cfd", "--from", "2025-06-10", "--to", "2025-06-01"); err == nil {
+		t.Error("expected an empty range to be rejected")
+	}
+}
+
+func TestIntegration_ExportICS(t *testing.T) {
+	tc := NewTestContext(t)
+	defer tc.Cleanup()
+	defer func() { exportOutput, exportTags, exportStatuses = "", nil, nil }()
+
+	// Subcommands keep the --paths flag of the first root they were added
+	// to, so 'new' may write elsewhere; write the tasks directly instead
+	files := map[string]string{
+		"20250620090000.md": `---
+id: task/20250620090000
+title: Ship release
+tags:
+    - mdtask
+    - mdtask/status/TODO
+    - mdtask/deadline/2025-06-30
+    - mdtask/reminder/2025-06-29T10:00
+    - work
+created: 2025-06-20 09:00
+updated: 2025-06-20 09:00
+---
+`,
+		"20250620100000.md": `---
+id: task/20250620100000
+title: Water plants
+tags:
+    - mdtask
+    - mdtask/status/TODO
+    - home
+created: 2025-06-20 10:00
+updated: 2025-06-20 10:00
+---
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tc.tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write task: %v", err)
+		}
+	}
+
+	file := filepath.Join(tc.tempDir, "tasks.ics")
+	if err := tc.Execute("export", "ics", "-o", file, "--tag", "work", "--status", "todo"); err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("failed to read export: %v", err)
+	}
+	ics := string(data)
+	for _, want := range []string{"BEGIN:VCALENDAR", "UID:task-20250620090000@mdtask", "SUMMARY:Ship release", "DUE;VALUE=DATE:20250630", "-deadline@mdtask", "-reminder@mdtask"} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("export does not contain %q:\n%s", want, ics)
+		}
+	}
+	if strings.Contains(ics, "Water plants") {
+		t.Errorf("export contains a task without the tag:\n%s", ics)
+	}
+
+	if err := tc.Execute("export", "ics", "--status", "BOGUS"); err == nil {
+		t.Error("expected an unknown status to be rejected")
+	}
+}
+
+func TestIntegration_Import(t *testing.T) {
+	tc := NewTestContext(t)
+	defer tc.Cleanup()
+	defer func() { importFrom, importDryRun = "", false }()
+
+	csvDir := t.TempDir()
+	file := filepath.Join(csvDir, "backlog.csv")
+	csv := "id,title,status,tags,due,parent\n1,Epic,WIP,team,2025-07-01,\n2,Story,,team,,1\n"
+	if err := os.WriteFile(file, []byte(csv), 0644); err != nil {
+		t.Fatalf("failed to write CSV: %v", err)
+	}
+
+	countTasks := func() int {
+		matches, _ := filepath.Glob(filepath.Join(tc.tempDir, "*.md"))
+		return len(matches)
+	}
+
+	if err := tc.Execute("import", "--from", "csv", "--dry-run", file); err != nil {
+		t.Fatalf("failed to preview import: %v", err)
+	}
+	if n := countTasks(); n != 0 {
+		t.Fatalf("dry run created %d tasks", n)
+	}
+
+	importDryRun = false
+	if err := tc.Execute("import", "--from", "csv", file); err != nil {
+		t.Fatalf("failed to import: %v", err)
+	}
+	if n := countTasks(); n != 2 {
+		t.Fatalf("import created %d tasks, want 2", n)
+	}
+
+	// Importing again skips the tasks
+	if err := tc.Execute("import", "--from", "csv", file); err != nil {
+		t.Fatalf("failed to import again: %v", err)
+	}
+	if n := countTasks(); n != 2 {
+		t.Errorf("second import left %d tasks, want 2", n)
+	}
+
+	if err := tc.Execute("import", "--from", "jira", file); err == nil {
+		t.Error("expected an unknown format to be rejected")
+	}
+}
@@ -10,13 +10,25 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/tkancf/mdtask/internal/cli"
 	"github.com/tkancf/mdtask/internal/output"
+	"github.com/tkancf/mdtask/internal/query"
 	"github.com/tkancf/mdtask/internal/task"
 )
 
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List tasks",
-	Long:  `List all tasks in the configured directories.`,
+	Long: `List all tasks in the configured directories.
+
+Use --query to filter with the query language, e.g.
+  mdtask list --query 'status:WIP and (tag:type/bug or deadline<2025-07-01)'
+  mdtask list -q 'not done and deadline<=+7d'
+  mdtask list -q 'title~"login" or estimate>2h'
+
+Fields: status, tag, title, description, content, text, id, parent,
+blockedby, deadline, reminder, created, updated, has:<field> and any custom
+field. Operators: : = != < <= > >= ~. Flags: archived, done, overdue, blocked,
+recurring, subtask. Combine with and, or, not and parentheses. Archived tasks
+are only matched when the query mentions archived or --all is given.`,
 	RunE:  runList,
 }
 
@@ -25,14 +37,19 @@ var (
 	listArchived bool
 	listAll      bool
 	listParent   string
+	listFields   []string
+	listQuery    string
 )
 
 func init() {
 	rootCmd.AddCommand(listCmd)
-	listCmd.Flags().StringVarP(&listStatus, "status", "s", "", "Filter by status (TODO, WIP, WAIT, SCHE, DONE)")
+	addColumnsFlag(listCmd)
+	listCmd.Flags().StringVarP(&listStatus, "status", "s", "", "Filter by status (one of the workflow statuses, by default TODO, WIP, WAIT, SCHE, DONE)")
 	listCmd.Flags().BoolVarP(&listArchived, "archived", "a", false, "Show only archived tasks")
 	listCmd.Flags().BoolVar(&listAll, "all", false, "Show all tasks including archived")
 	listCmd.Flags().StringVar(&listParent, "parent", "", "Show only subtasks of the specified parent task ID")
+	listCmd.Flags().StringArrayVar(&listFields, "field", nil, "Filter by custom field (name=value, name!=value or name; can be repeated)")
+	listCmd.Flags().StringVarP(&listQuery, "query", "q", "", "Filter with a query, e.g. 'status:WIP and tag:type/bug'")
 }
 
 func runList(cmd *cobra.Command, args []string) error {
@@ -43,6 +60,22 @@ func runList(cmd *cobra.Command, args []string) error {
 
 	var tasks []*task.Task
 
+	var q query.Expr
+	if listQuery != "" {
+		q, err = query.Parse(listQuery)
+		if err != nil {
+			return err
+		}
+	}
+
+	if listStatus != "" {
+		status, err := ctx.Config.GetWorkflow().Parse(listStatus)
+		if err != nil {
+			return err
+		}
+		listStatus = string(status)
+	}
+
 	// Handle parent filter
 	if listParent != "" {
 		// Validate parent task ID format
@@ -89,7 +122,7 @@ func runList(cmd *cobra.Command, args []string) error {
 				tasks = append(tasks, t)
 			}
 		}
-	} else if listAll {
+	} else if listAll || (q != nil && query.UsesArchived(q)) {
 		tasks, err = ctx.Repo.FindAll()
 	} else {
 		tasks, err = ctx.Repo.FindActive()
@@ -99,6 +132,21 @@ func runList(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to list tasks: %w", err)
 	}
 
+	tasks = filterByFields(tasks, listFields)
+
+	if q != nil {
+		all, err := ctx.Repo.FindAll()
+		if err != nil {
+			return fmt.Errorf("failed to list tasks: %w", err)
+		}
+		env := query.NewEnv(all, ctx.Config.GetWorkflow().IsDone)
+		tasks = query.Filter(tasks, q, env, true)
+	}
+
+	if ok, err := printFormatted(ctx, tasks, "mdtask tasks"); ok {
+		return err
+	}
+
 	if outputFormat == "json" {
 		printer := output.NewJSONPrinter(os.Stdout)
 		if len(tasks) == 0 {
@@ -116,6 +164,20 @@ func runList(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// filterByFields keeps the tasks matching all custom field filters
+func filterByFields(tasks []*task.Task, filters []string) []*task.Task {
+	if len(filters) == 0 {
+		return tasks
+	}
+	var filtered []*task.Task
+	for _, t := range tasks {
+		if t.MatchFields(filters) {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
 func printTasks(tasks []*task.Task) {
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 	defer w.Flush()
@@ -47,7 +47,7 @@ func runMCP(cmd *cobra.Command, args []string) {
 	}
 
 	// Create repository
-	repo := repository.NewTaskRepository(cfg.Paths)
+	repo := repository.NewTaskRepositoryWithConfig(cfg.Paths, cfg)
 
 	// Create and start MCP server
 	server := mcpserver.NewServer(repo, cfg)
@@ -0,0 +1,126 @@
+package mdtask
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tkancf/mdtask/internal/cli"
+	"github.com/tkancf/mdtask/internal/reminder"
+	"github.com/tkancf/mdtask/internal/repository"
+	"github.com/tkancf/mdtask/internal/taskid"
+)
+
+var migrateIDsCmd = &cobra.Command{
+	Use:   "migrate-ids",
+	Short: "Rename tasks to the configured ID format",
+	Long: `Give every task whose ID is not in the ID format a new ID in that format and
+rename its file to match. The format is id_format in the [storage] section
+of the config (timestamp, ulid or slug) unless --to is given. New IDs are
+made from the title and creation date of each task. Parent, blocked-by and
+previous-occurrence references to the old IDs are rewritten, and delivered
+reminders are kept.`,
+	Args: cobra.NoArgs,
+	RunE: runMigrateIDs,
+}
+
+var (
+	migrateIDsTo     string
+	migrateIDsDryRun bool
+)
+
+func init() {
+	rootCmd.AddCommand(migrateIDsCmd)
+
+	migrateIDsCmd.Flags().StringVar(&migrateIDsTo, "to", "", "ID format to migrate to (timestamp, ulid, slug)")
+	migrateIDsCmd.Flags().BoolVar(&migrateIDsDryRun, "dry-run", false, "List the new IDs without renaming anything")
+}
+
+func runMigrateIDs(cmd *cobra.Command, args []string) error {
+	ctx, err := cli.LoadContext(cmd)
+	if err != nil {
+		return err
+	}
+
+	format := migrateIDsTo
+	if format == "" {
+		format = ctx.Config.Storage.IDFormat
+	}
+	if format == "" {
+		format = taskid.FormatTimestamp
+	}
+	if err := taskid.Validate(format); err != nil {
+		return err
+	}
+
+	changes, err := ctx.Repo.MigrateIDs(format, migrateIDsDryRun)
+	if err != nil {
+		// Some tasks may have been renamed before the failure
+		if len(changes) > 0 {
+			renameReminders(ctx, changes)
+			for _, c := range changes {
+				fmt.Printf("Renamed %s to %s\n", c.OldID, c.NewID)
+			}
+			fmt.Printf("Migration stopped after %d task(s); the other tasks keep their IDs.\n", len(changes))
+		}
+		return err
+	}
+
+	if !migrateIDsDryRun && len(changes) > 0 {
+		if err := renameReminders(ctx, changes); err != nil {
+			return err
+		}
+	}
+
+	if outputFormat == "json" {
+		if changes == nil {
+			changes = []repository.IDChange{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(changes)
+	}
+
+	if len(changes) == 0 {
+		fmt.Printf("All task IDs are in the %s format.\n", format)
+		return nil
+	}
+	verb := "Renamed"
+	if migrateIDsDryRun {
+		verb = "Would rename"
+	}
+	for _, c := range changes {
+		fmt.Printf("%s %s to %s\n", verb, c.OldID, c.NewID)
+	}
+	if configured := taskid.New(ctx.Config.Storage.IDFormat).Format(); configured != format {
+		fmt.Printf("New tasks still get %s IDs; set id_format = %q in the [storage] section of the config.\n", configured, format)
+	}
+	return nil
+}
+
+// renameReminders moves the delivered reminders of renamed tasks to their
+// new IDs
+func renameReminders(ctx *cli.Context, changes []repository.IDChange) error {
+	path := newScheduler(ctx, nil).StatePath
+	release, err := reminder.LockState(path)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	state, err := reminder.LoadState(path)
+	if err != nil {
+		return err
+	}
+	renamed := false
+	for _, c := range changes {
+		if state.RenameTask(c.OldID, c.NewID) {
+			renamed = true
+		}
+	}
+	if renamed {
+		return state.Save()
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package mdtask
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tkancf/mdtask/internal/cli"
+	"github.com/tkancf/mdtask/internal/service"
+)
+
+var moveCmd = &cobra.Command{
+	Use:   "move <task-id>",
+	Short: "Move a task file to another directory",
+	Long: `Move the file of a task to another directory, keeping its ID and file name.
+The directory must be one of the task directories or inside one, so tasks
+can be moved between the configured roots. With --with-subtasks the
+subtasks of the task are moved along with it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMove,
+}
+
+var (
+	moveTo           string
+	moveWithSubtasks bool
+)
+
+func init() {
+	rootCmd.AddCommand(moveCmd)
+
+	moveCmd.Flags().StringVar(&moveTo, "to", "", "Directory to move the task to")
+	moveCmd.Flags().BoolVar(&moveWithSubtasks, "with-subtasks", false, "Move the subtasks of the task too")
+	moveCmd.MarkFlagRequired("to")
+}
+
+func runMove(cmd *cobra.Command, args []string) error {
+	ctx, err := cli.LoadContext(cmd)
+	if err != nil {
+		return err
+	}
+
+	taskID, err := cli.NormalizeTaskID(args[0])
+	if err != nil {
+		return err
+	}
+
+	moved, err := service.NewTaskService(ctx.Repo, ctx.Config).MoveTask(taskID, moveTo, moveWithSubtasks)
+	if outputFormat == "json" {
+		if moved == nil {
+			moved = []service.MovedTask{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if encodeErr := encoder.Encode(moved); err == nil {
+			err = encodeErr
+		}
+		return err
+	}
+
+	for _, m := range moved {
+		fmt.Printf("Moved %s to %s\n", m.Task.ID, m.Path)
+	}
+	return err
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/tkancf/mdtask/internal/cli"
 	"github.com/tkancf/mdtask/internal/output"
+	"github.com/tkancf/mdtask/internal/service"
 	"github.com/tkancf/mdtask/internal/task"
 )
 
@@ -29,6 +30,8 @@ var (
 	newDeadline    string
 	newReminder    string
 	newParent      string
+	newRecur       string
+	newBlockedBy   []string
 )
 
 func init() {
@@ -37,10 +40,12 @@ func init() {
 	newCmd.Flags().StringVarP(&newDescription, "description", "d", "", "Task description")
 	newCmd.Flags().StringVarP(&newContent, "content", "c", "", "Task content")
 	newCmd.Flags().StringSliceVar(&newTags, "tags", []string{}, "Additional tags (comma-separated)")
-	newCmd.Flags().StringVarP(&newStatus, "status", "s", "", "Initial status (TODO, WIP, WAIT, SCHE, DONE)")
+	newCmd.Flags().StringVarP(&newStatus, "status", "s", "", "Initial status (one of the workflow statuses, by default TODO, WIP, WAIT, SCHE, DONE)")
 	newCmd.Flags().StringVar(&newDeadline, "deadline", "", "Deadline (YYYY-MM-DD)")
 	newCmd.Flags().StringVar(&newReminder, "reminder", "", "Reminder (YYYY-MM-DD HH:MM or YYYY-MM-DD)")
 	newCmd.Flags().StringVar(&newParent, "parent", "", "Parent task ID for creating subtask")
+	newCmd.Flags().StringVar(&newRecur, "recur", "", "Repeat the task (daily, weekly:mon, monthly:1, every:3d; see 'mdtask recur --help')")
+	newCmd.Flags().StringSliceVar(&newBlockedBy, "blocked-by", nil, "IDs of tasks that must be done first (comma-separated)")
 }
 
 func runNew(cmd *cobra.Command, args []string) error {
@@ -149,7 +154,7 @@ func runNew(cmd *cobra.Command, args []string) error {
 	if statusStr == "" {
 		statusStr = "TODO"
 	}
-	statusToSet, err := cli.ValidateStatus(statusStr)
+	statusToSet, err := cli.ValidateWorkflowStatus(ctx.Config.GetWorkflow(), statusStr)
 	if err != nil {
 		return err
 	}
@@ -167,6 +172,14 @@ func runNew(cmd *cobra.Command, args []string) error {
 		t.SetReminder(*reminder)
 	}
 
+	if newRecur != "" {
+		rule, err := task.ParseRecurrence(newRecur)
+		if err != nil {
+			return err
+		}
+		t.SetRecurrence(rule)
+	}
+
 	// Handle parent task relationship
 	if newParent != "" {
 		// Normalize parent task ID
@@ -194,7 +207,20 @@ func runNew(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Creating subtask of: %s\n", parentTask.Title)
 	}
 
-	filePath, err := ctx.Repo.Create(t)
+	// Handle dependencies
+	for _, id := range newBlockedBy {
+		blockerID, err := cli.NormalizeTaskID(id)
+		if err != nil {
+			return err
+		}
+		t.AddBlocker(blockerID)
+	}
+	taskService := service.NewTaskService(ctx.Repo, ctx.Config)
+	if err := taskService.ValidateDependencies(t); err != nil {
+		return err
+	}
+
+	filePath, err := taskService.AddTask(t)
 	if err != nil {
 		return fmt.Errorf("failed to create task: %w", err)
 	}
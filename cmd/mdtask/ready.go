@@ -0,0 +1,67 @@
+package mdtask
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/tkancf/mdtask/internal/cli"
+	"github.com/tkancf/mdtask/internal/output"
+	"github.com/tkancf/mdtask/internal/service"
+)
+
+var readyCmd = &cobra.Command{
+	Use:   "ready",
+	Short: "List tasks that can be worked on now",
+	Long: `List the active tasks that are not done and whose blockers are all done.
+
+Dependencies are set with 'mdtask new --blocked-by <id>' or
+'mdtask edit <id> --blocked-by <id>'. Tasks are sorted by deadline.`,
+	Args: cobra.NoArgs,
+	RunE: runReady,
+}
+
+func init() {
+	rootCmd.AddCommand(readyCmd)
+}
+
+func runReady(cmd *cobra.Command, args []string) error {
+	ctx, err := cli.LoadContext(cmd)
+	if err != nil {
+		return err
+	}
+
+	tasks, err := service.NewTaskService(ctx.Repo, ctx.Config).ReadyTasks()
+	if err != nil {
+		return fmt.Errorf("failed to list ready tasks: %w", err)
+	}
+
+	// Earliest deadline first, tasks without a deadline last
+	sort.SliceStable(tasks, func(i, j int) bool {
+		di, dj := tasks[i].GetDeadline(), tasks[j].GetDeadline()
+		switch {
+		case di != nil && dj != nil && !di.Equal(*dj):
+			return di.Before(*dj)
+		case (di == nil) != (dj == nil):
+			return di != nil
+		}
+		return tasks[i].Created.Before(tasks[j].Created)
+	})
+
+	if outputFormat == "json" {
+		printer := output.NewJSONPrinter(os.Stdout)
+		if len(tasks) == 0 {
+			return printer.PrintEmpty()
+		}
+		return printer.PrintTasks(tasks)
+	}
+
+	if len(tasks) == 0 {
+		fmt.Println("No tasks are ready.")
+		return nil
+	}
+
+	printTasks(tasks)
+	return nil
+}
@@ -0,0 +1,194 @@
+package mdtask
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tkancf/mdtask/internal/cli"
+	"github.com/tkancf/mdtask/internal/constants"
+	"github.com/tkancf/mdtask/internal/service"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+var recurCmd = &cobra.Command{
+	Use:   "recur [task-id]",
+	Short: "Preview upcoming occurrences of recurring tasks",
+	Long: `Show the upcoming occurrences of a recurring task, of all recurring tasks,
+or of a rule given with --rule.
+
+A task repeats when it has a mdtask/recur/<rule> tag. Supported rules:
+  daily
+  weekly:mon     several days: weekly:mon,thu
+  monthly:1      last day of the month: monthly:last
+  every:3d       units: d (days), w (weeks), m (months), y (years)
+
+When a recurring task is marked as done, the next instance is created with
+its deadline and reminder moved to the next occurrence.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRecur,
+}
+
+var (
+	recurRule  string
+	recurFrom  string
+	recurCount int
+)
+
+func init() {
+	rootCmd.AddCommand(recurCmd)
+	recurCmd.Flags().StringVar(&recurRule, "rule", "", "Preview a rule instead of a task (e.g. weekly:mon)")
+	recurCmd.Flags().StringVar(&recurFrom, "from", "", "Start date for --rule (YYYY-MM-DD, default today)")
+	recurCmd.Flags().IntVarP(&recurCount, "count", "n", 5, "Number of occurrences to show")
+}
+
+// recurPreview is the JSON output of the recur command
+type recurPreview struct {
+	ID          string   `json:"id,omitempty"`
+	Title       string   `json:"title,omitempty"`
+	Rule        string   `json:"rule"`
+	Deadline    string   `json:"deadline,omitempty"`
+	Occurrences []string `json:"occurrences"`
+}
+
+func runRecur(cmd *cobra.Command, args []string) error {
+	if recurCount < 1 {
+		return fmt.Errorf("--count must be at least 1")
+	}
+
+	if recurRule != "" {
+		if len(args) > 0 {
+			return fmt.Errorf("use either a task ID or --rule, not both")
+		}
+		return previewRule()
+	}
+
+	ctx, err := cli.LoadContext(cmd)
+	if err != nil {
+		return err
+	}
+
+	var tasks []*task.Task
+	if len(args) == 1 {
+		taskID, err := cli.NormalizeTaskID(args[0])
+		if err != nil {
+			return err
+		}
+		t, err := ctx.Repo.FindByID(taskID)
+		if err != nil {
+			return err
+		}
+		tasks = append(tasks, t)
+	} else {
+		all, err := ctx.Repo.FindActive()
+		if err != nil {
+			return err
+		}
+		tasks = all
+	}
+
+	var previews []recurPreview
+	for _, t := range tasks {
+		preview, err := previewTask(t, time.Now())
+		if err != nil {
+			return fmt.Errorf("task %s: %w", t.ID, err)
+		}
+		if preview == nil {
+			if len(args) == 1 {
+				return fmt.Errorf("task %s does not repeat (add a %s<rule> tag)", t.ID, constants.RecurTagPrefix)
+			}
+			continue
+		}
+		previews = append(previews, *preview)
+	}
+
+	if outputFormat == "json" {
+		if previews == nil {
+			previews = []recurPreview{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(previews)
+	}
+
+	if len(previews) == 0 {
+		fmt.Println("No recurring tasks found.")
+		return nil
+	}
+	for i, p := range previews {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s (%s)\n", p.Title, p.ID)
+		fmt.Printf("  Rule:     %s\n", p.Rule)
+		if p.Deadline != "" {
+			fmt.Printf("  Deadline: %s\n", p.Deadline)
+		}
+		fmt.Println("  Next:")
+		for _, date := range p.Occurrences {
+			fmt.Printf("    %s\n", date)
+		}
+	}
+	return nil
+}
+
+// previewRule prints the occurrences of --rule after --from
+func previewRule() error {
+	rule, err := task.ParseRecurrence(recurRule)
+	if err != nil {
+		return err
+	}
+
+	from := time.Now()
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	if recurFrom != "" {
+		from, err = time.Parse(constants.DateFormat, recurFrom)
+		if err != nil {
+			return fmt.Errorf("invalid --from date (use YYYY-MM-DD): %w", err)
+		}
+	}
+
+	preview := recurPreview{Rule: rule.String()}
+	for _, date := range rule.Occurrences(from, recurCount) {
+		preview.Occurrences = append(preview.Occurrences, formatOccurrence(date))
+	}
+
+	if outputFormat == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(preview)
+	}
+
+	fmt.Printf("Rule: %s\n", preview.Rule)
+	for _, date := range preview.Occurrences {
+		fmt.Printf("  %s\n", date)
+	}
+	return nil
+}
+
+// previewTask returns the occurrences that completing t would create, or
+// nil if t does not repeat
+func previewTask(t *task.Task, now time.Time) (*recurPreview, error) {
+	rule, err := t.GetRecurrence()
+	if err != nil || rule == nil {
+		return nil, err
+	}
+
+	preview := &recurPreview{ID: t.ID, Title: t.Title, Rule: rule.String()}
+	if deadline := t.GetDeadline(); deadline != nil {
+		preview.Deadline = deadline.Format(constants.DateFormat)
+	}
+
+	next, _ := service.NextOccurrenceDates(rule, t.GetDeadline(), t.GetReminder(), now)
+	preview.Occurrences = append(preview.Occurrences, formatOccurrence(next))
+	for _, date := range rule.Occurrences(next, recurCount-1) {
+		preview.Occurrences = append(preview.Occurrences, formatOccurrence(date))
+	}
+	return preview, nil
+}
+
+func formatOccurrence(date time.Time) string {
+	return date.Format("2006-01-02 Mon")
+}
@@ -2,30 +2,56 @@ package mdtask
 
 import (
 	"fmt"
-	"os/exec"
-	"runtime"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/tkancf/mdtask/internal/cli"
+	"github.com/tkancf/mdtask/internal/constants"
+	"github.com/tkancf/mdtask/internal/notify"
+	"github.com/tkancf/mdtask/internal/reminder"
+	"github.com/tkancf/mdtask/internal/watcher"
 )
 
 var remindCmd = &cobra.Command{
 	Use:   "remind",
 	Short: "Check and show reminders for tasks",
-	Long:  `Check all tasks for reminders and show notifications for tasks that are due.`,
-	RunE:  runRemind,
+	Long: `Show notifications for the tasks whose reminder is due.
+
+Each reminder is shown once: delivered reminders are recorded in
+.mdtask/reminders.json of the first task directory. Reminders missed while
+mdtask was not running are shown late, unless they are older than the
+catch-up window (notify.catch_up, 24h by default). Changing the reminder of
+a task arms it again.
+
+With --daemon, mdtask sleeps until the next reminder is due and wakes up
+early when task files change.`,
+	RunE: runRemind,
+}
+
+var remindSnoozeCmd = &cobra.Command{
+	Use:   "snooze <task-id> <duration>",
+	Short: "Show a reminder again later",
+	Long: `Postpone the reminder of a task by a duration such as 30m or 2h, whether or
+not it was shown already. The task file is not changed.`,
+	Example: `  mdtask remind snooze 20250620093000 30m`,
+	Args:    cobra.ExactArgs(2),
+	RunE:    runRemindSnooze,
 }
 
 var (
-	remindCheck bool
-	remindLoop  bool
+	remindCheck    bool
+	remindLoop     bool
+	remindNotifier string
 )
 
 func init() {
 	rootCmd.AddCommand(remindCmd)
+	remindCmd.AddCommand(remindSnoozeCmd)
 	remindCmd.Flags().BoolVarP(&remindCheck, "check", "c", false, "Check and list all tasks with reminders")
-	remindCmd.Flags().BoolVarP(&remindLoop, "daemon", "d", false, "Run as daemon, checking reminders every minute")
+	remindCmd.Flags().BoolVarP(&remindLoop, "daemon", "d", false, "Run as daemon, showing each reminder when it is due")
+	remindCmd.Flags().StringVar(&remindNotifier, "notifier", "", "Notification backend ("+strings.Join(notify.Backends(), ", ")+"), overriding the config")
 }
 
 func runRemind(cmd *cobra.Command, args []string) error {
@@ -38,30 +64,122 @@ func runRemind(cmd *cobra.Command, args []string) error {
 		return checkReminders(ctx)
 	}
 
+	notifier, err := newNotifier(ctx)
+	if err != nil {
+		return err
+	}
+	scheduler := newScheduler(ctx, notifier)
+
 	if remindLoop {
 		fmt.Println("Starting reminder daemon...")
+		fmt.Printf("Notifications via %s\n", notifier.Name())
 		fmt.Println("Press Ctrl+C to stop")
-		
-		// Run once immediately
-		if err := processReminders(ctx); err != nil {
+		return runReminderDaemon(ctx, scheduler)
+	}
+
+	_, err = processReminders(ctx, scheduler, time.Now())
+	return err
+}
+
+// runReminderDaemon delivers reminders until the process is stopped. It
+// sleeps until the next reminder is due, but wakes up when task files or
+// the reminder state change and at least every
+// constants.ReminderCheckInterval, since timers do not follow changes of
+// the wall clock or a suspended computer.
+func runReminderDaemon(ctx *cli.Context, scheduler *reminder.Scheduler) error {
+	w := watcher.New(ctx.Repo, constants.WatchPollInterval)
+	w.Start()
+	defer w.Stop()
+	events, cancel := w.Subscribe()
+	defer cancel()
+
+	reminderLoop(ctx, scheduler, events, constants.WatchPollInterval, nil)
+	return nil
+}
+
+// reminderLoop processes reminders whenever one is due or events receives a
+// change, until stop is closed. The state file is checked every poll, so a
+// snooze written by another process moves the next wake-up.
+func reminderLoop(ctx *cli.Context, scheduler *reminder.Scheduler, events <-chan watcher.Event, poll time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	for {
+		next, err := processReminders(ctx, scheduler, time.Now())
+		if err != nil {
 			fmt.Printf("Error processing reminders: %v\n", err)
 		}
-		
-		// Then run every minute
-		ticker := time.NewTicker(1 * time.Minute)
-		defer ticker.Stop()
-
-		for range ticker.C {
-			if err := processReminders(ctx); err != nil {
-				fmt.Printf("Error processing reminders: %v\n", err)
+		state := statFile(scheduler.StatePath)
+
+		wait := constants.ReminderCheckInterval
+		if !next.IsZero() {
+			if d := time.Until(next); d < wait {
+				wait = d
+			}
+		}
+
+		timer := time.NewTimer(wait)
+	sleep:
+		for {
+			select {
+			case <-stop:
+				timer.Stop()
+				return
+			case <-timer.C:
+				break sleep
+			case <-events:
+				break sleep
+			case <-ticker.C:
+				if !statFile(scheduler.StatePath).equal(state) {
+					break sleep
+				}
 			}
 		}
-	} else {
-		// Run once
-		return processReminders(ctx)
+		timer.Stop()
 	}
+}
 
-	return nil
+// fileStamp identifies a version of a file; the zero value stands for a
+// missing file
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func (f fileStamp) equal(o fileStamp) bool {
+	return f.size == o.size && f.modTime.Equal(o.modTime)
+}
+
+func statFile(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}
+}
+
+// processReminders delivers the due reminders and returns when the next one
+// is due, zero if none is pending
+func processReminders(ctx *cli.Context, scheduler *reminder.Scheduler, now time.Time) (time.Time, error) {
+	tasks, err := ctx.Repo.FindActive()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to load tasks: %w", err)
+	}
+
+	result, err := scheduler.Run(tasks, now)
+	if result == nil {
+		return time.Time{}, err
+	}
+	for _, r := range result.Delivered {
+		fmt.Printf("Reminder shown for task: %s\n", r.Task.Title)
+	}
+	for _, r := range result.Skipped {
+		fmt.Printf("Skipped reminder of %s for task: %s\n", r.At.Format(constants.DateTimeFormat), r.Task.Title)
+	}
+	for _, f := range result.Failed {
+		fmt.Printf("Failed to show notification for task %s: %v\n", f.Task.ID, f.Err)
+	}
+	return result.Next, err
 }
 
 func checkReminders(ctx *cli.Context) error {
@@ -70,6 +188,16 @@ func checkReminders(ctx *cli.Context) error {
 		return fmt.Errorf("failed to load tasks: %w", err)
 	}
 
+	scheduler := newScheduler(ctx, nil)
+	state, err := reminder.LoadState(scheduler.StatePath)
+	if err != nil {
+		return err
+	}
+	pending := make(map[string]reminder.Reminder)
+	for _, r := range scheduler.Pending(tasks, state) {
+		pending[r.Task.ID] = r
+	}
+
 	now := time.Now()
 	hasReminders := false
 
@@ -77,19 +205,26 @@ func checkReminders(ctx *cli.Context) error {
 	fmt.Println()
 
 	for _, task := range tasks {
-		if reminder := task.GetReminder(); reminder != nil {
+		if at := task.GetReminder(); at != nil {
 			hasReminders = true
-			
-			status := "Upcoming"
-			if reminder.Before(now) {
-				status = "Overdue"
-			} else if reminder.Sub(now) < 24*time.Hour {
-				status = "Due soon"
+
+			status := "Delivered"
+			if r, ok := pending[task.ID]; ok {
+				switch {
+				case r.Snoozed:
+					status = "Snoozed until " + r.At.Format(constants.DateTimeFormat)
+				case r.At.Before(now):
+					status = "Overdue"
+				case r.At.Sub(now) < 24*time.Hour:
+					status = "Due soon"
+				default:
+					status = "Upcoming"
+				}
 			}
 
 			fmt.Printf("- [%s] %s\n", status, task.Title)
 			fmt.Printf("  ID: %s\n", task.ID)
-			fmt.Printf("  Reminder: %s\n", reminder.Format("2006-01-02 15:04"))
+			fmt.Printf("  Reminder: %s\n", at.Format("2006-01-02 15:04"))
 			fmt.Println()
 		}
 	}
@@ -101,61 +236,46 @@ func checkReminders(ctx *cli.Context) error {
 	return nil
 }
 
-func processReminders(ctx *cli.Context) error {
-	tasks, err := ctx.Repo.FindActive()
+func runRemindSnooze(cmd *cobra.Command, args []string) error {
+	ctx, err := cli.LoadContext(cmd)
 	if err != nil {
-		return fmt.Errorf("failed to load tasks: %w", err)
+		return err
 	}
 
-	now := time.Now()
-	
-	for _, task := range tasks {
-		if reminder := task.GetReminder(); reminder != nil {
-			// Check if reminder is due (within the current minute)
-			if reminder.Year() == now.Year() &&
-				reminder.Month() == now.Month() &&
-				reminder.Day() == now.Day() &&
-				reminder.Hour() == now.Hour() &&
-				reminder.Minute() == now.Minute() {
-				
-				if err := showNotification(task.Title, task.Description); err != nil {
-					fmt.Printf("Failed to show notification for task %s: %v\n", task.ID, err)
-				} else {
-					fmt.Printf("Reminder shown for task: %s\n", task.Title)
-				}
-			}
-		}
+	taskID, err := cli.NormalizeTaskID(args[0])
+	if err != nil {
+		return err
+	}
+	d, err := time.ParseDuration(args[1])
+	if err != nil || d <= 0 {
+		return fmt.Errorf("invalid duration %q (use e.g. 30m or 2h)", args[1])
 	}
 
-	return nil
-}
+	t, err := ctx.Repo.FindByID(taskID)
+	if err != nil {
+		return fmt.Errorf("failed to find task: %w", err)
+	}
 
-func showNotification(title, message string) error {
-	if runtime.GOOS != "darwin" {
-		return fmt.Errorf("notifications are only supported on macOS")
+	until := time.Now().Add(d).Truncate(time.Second)
+	if err := newScheduler(ctx, nil).Snooze(t, until); err != nil {
+		return err
 	}
+	fmt.Printf("Snoozed %s: %s until %s\n", t.ID, t.Title, until.Format(constants.DateTimeFormat))
+	return nil
+}
 
-	// Use osascript to show macOS notification
-	script := fmt.Sprintf(`display notification "%s" with title "mdtask reminder" subtitle "%s" sound name "default"`, 
-		escapeForAppleScript(message), 
-		escapeForAppleScript(title))
-	
-	cmd := exec.Command("osascript", "-e", script)
-	return cmd.Run()
+// newScheduler returns the reminder scheduler of the task directories
+func newScheduler(ctx *cli.Context, notifier notify.Notifier) *reminder.Scheduler {
+	scheduler := reminder.NewScheduler(notifier, ctx.Config.GetWorkflow(), ctx.Paths[0])
+	scheduler.CatchUp = ctx.Config.Notify.CatchUpWindow()
+	return scheduler
 }
 
-func escapeForAppleScript(s string) string {
-	// Escape quotes and backslashes for AppleScript
-	result := ""
-	for _, r := range s {
-		switch r {
-		case '"':
-			result += "\\\""
-		case '\\':
-			result += "\\\\"
-		default:
-			result += string(r)
-		}
+// newNotifier returns the notifier selected with --notifier or in the config
+func newNotifier(ctx *cli.Context) (notify.Notifier, error) {
+	backend := remindNotifier
+	if backend == "" {
+		backend = ctx.Config.Notify.Backend
 	}
-	return result
-}
\ No newline at end of file
+	return notify.New(backend)
+}
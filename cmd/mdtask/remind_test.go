@@ -0,0 +1,113 @@
+package mdtask
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/cli"
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/notify"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// fakeNotifier records notifications instead of showing them
+type fakeNotifier struct {
+	mu   sync.Mutex
+	sent []notify.Notification
+}
+
+func (f *fakeNotifier) Name() string { return "fake" }
+
+func (f *fakeNotifier) Notify(n notify.Notification) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, n)
+	return nil
+}
+
+func (f *fakeNotifier) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.sent)
+}
+
+func TestProcessReminders(t *testing.T) {
+	ctx := cli.LoadContextWithPaths(config.DefaultConfig(), []string{t.TempDir()})
+
+	now := time.Date(2025, 6, 20, 9, 30, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		title    string
+		reminder time.Time
+	}{
+		{"Due now", now},
+		{"Due later", now.Add(time.Hour)},
+	} {
+		tk := &task.Task{Title: tc.title, Description: "details", Tags: []string{"mdtask"}, Created: now, Updated: now}
+		tk.SetStatus(task.StatusTODO)
+		tk.SetReminder(tc.reminder)
+		if _, err := ctx.Repo.Create(tk); err != nil {
+			t.Fatalf("failed to create task: %v", err)
+		}
+	}
+
+	notifier := &fakeNotifier{}
+	scheduler := newScheduler(ctx, notifier)
+	scheduler.Location = time.UTC
+	next, err := processReminders(ctx, scheduler, now.Add(20*time.Second))
+	if err != nil {
+		t.Fatalf("processReminders() error = %v", err)
+	}
+	if len(notifier.sent) != 1 || notifier.sent[0].Title != "Due now" || notifier.sent[0].Body != "details" {
+		t.Errorf("sent %+v, want the reminder of 'Due now'", notifier.sent)
+	}
+	if !next.Equal(now.Add(time.Hour)) {
+		t.Errorf("next wake-up = %v, want %v", next, now.Add(time.Hour))
+	}
+
+	// Delivered reminders are not shown again
+	if _, err := processReminders(ctx, scheduler, now.Add(time.Minute)); err != nil {
+		t.Fatalf("processReminders() error = %v", err)
+	}
+	if len(notifier.sent) != 1 {
+		t.Errorf("sent %+v, want no repeated reminders", notifier.sent)
+	}
+}
+
+func TestReminderLoop_Snooze(t *testing.T) {
+	ctx := cli.LoadContextWithPaths(config.DefaultConfig(), []string{t.TempDir()})
+
+	tk := &task.Task{Title: "Call back", Tags: []string{"mdtask"}}
+	tk.SetStatus(task.StatusTODO)
+	tk.SetReminder(time.Now().Add(2 * time.Hour))
+	if _, err := ctx.Repo.Create(tk); err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+
+	notifier := &fakeNotifier{}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		reminderLoop(ctx, newScheduler(ctx, notifier), nil, 10*time.Millisecond, stop)
+	}()
+	defer func() {
+		close(stop)
+		<-done
+	}()
+
+	// Snoozing from another process moves the next wake-up of the
+	// sleeping daemon
+	time.Sleep(50 * time.Millisecond)
+	if err := newScheduler(ctx, nil).Snooze(tk, time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("Snooze() error = %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for notifier.count() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the snoozed reminder was not shown")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/tkancf/mdtask/internal/constants"
+	"github.com/tkancf/mdtask/internal/hooks"
 )
 
 var (
@@ -31,7 +33,15 @@ It provides a CLI interface for managing tasks with YAML frontmatter metadata.`,
 )
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+
+	// Hooks are delivered in the background; give them a bounded time to
+	// finish before the process exits
+	if !hooks.Wait(constants.HookFlushTimeout) {
+		fmt.Fprintf(os.Stderr, "Some hooks did not finish within %s and were abandoned.\n", constants.HookFlushTimeout)
+	}
+
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
@@ -39,7 +49,7 @@ func Execute() {
 
 func init() {
 	rootCmd.PersistentFlags().StringSlice("paths", []string{"."}, "Paths to search for task files")
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "text", "Output format (text, json)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "text", "Output format (text, json; list, search and get also csv, tsv, markdown-table, html, todo.txt; deps also dot)")
 }
 
 // SetVersionInfo sets the version information for the CLI
@@ -9,6 +9,8 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/tkancf/mdtask/internal/cli"
 	"github.com/tkancf/mdtask/internal/output"
+	"github.com/tkancf/mdtask/internal/query"
+	"github.com/tkancf/mdtask/internal/search"
 	"github.com/tkancf/mdtask/internal/task"
 )
 
@@ -17,10 +19,20 @@ var searchCmd = &cobra.Command{
 	Short: "Search tasks by content or tags",
 	Long: `Search for tasks by content, title, description, or tag combinations.
 
+Text is matched as whole words, ignoring case and English word endings, and
+results are ranked by relevance with the matches highlighted. Matches in the
+title count more than in the description, and those more than in the content.
+Use "quotes" for a phrase and a trailing * for words starting with a prefix.
+Japanese, Chinese and Korean text can be searched without spaces.
+
 Examples:
   # Search by text
   mdtask search "bug fix"
   
+  # Search for a phrase, or words starting with a prefix
+  mdtask search '"login page"'
+  mdtask search 'deploy*'
+  
   # Search by tags (AND mode - must have all tags)
   mdtask search --tags "type/bug,priority/high"
   
@@ -31,7 +43,13 @@ Examples:
   mdtask search --tags "type/bug" --exclude "status/done"
   
   # Complex search: text + tags
-  mdtask search "login" --tags "type/bug" --exclude "archived"`,
+  mdtask search "login" --tags "type/bug" --exclude "archived"
+  
+  # Filter by custom front matter fields
+  mdtask search "login" --field owner=alice --field estimate
+  
+  # Narrow down with a query (see 'mdtask list --help' for the syntax)
+  mdtask search "login" --query 'status:WIP and deadline<+7d'`,
 	RunE: runSearch,
 }
 
@@ -40,14 +58,19 @@ var (
 	excludeTags    []string
 	searchOrMode   bool
 	searchArchived bool
+	searchFields   []string
+	searchQuery    string
 )
 
 func init() {
 	rootCmd.AddCommand(searchCmd)
+	addColumnsFlag(searchCmd)
 	searchCmd.Flags().StringSliceVarP(&searchTags, "tags", "t", []string{}, "Tags to include (comma-separated)")
 	searchCmd.Flags().StringSliceVarP(&excludeTags, "exclude", "e", []string{}, "Tags to exclude (comma-separated)")
 	searchCmd.Flags().BoolVarP(&searchOrMode, "or", "o", false, "Use OR logic for tags (default is AND)")
 	searchCmd.Flags().BoolVarP(&searchArchived, "archived", "a", false, "Include archived tasks")
+	searchCmd.Flags().StringArrayVar(&searchFields, "field", nil, "Filter by custom field (name=value, name!=value or name; can be repeated)")
+	searchCmd.Flags().StringVarP(&searchQuery, "query", "q", "", "Filter with a query, e.g. 'status:WIP and tag:type/bug'")
 }
 
 func runSearch(cmd *cobra.Command, args []string) error {
@@ -57,6 +80,15 @@ func runSearch(cmd *cobra.Command, args []string) error {
 	}
 
 	var tasks []*task.Task
+	text := strings.Join(args, " ")
+
+	var q query.Expr
+	if searchQuery != "" {
+		q, err = query.Parse(searchQuery)
+		if err != nil {
+			return err
+		}
+	}
 
 	// If we have tag filters, use tag search
 	if len(searchTags) > 0 || len(excludeTags) > 0 {
@@ -69,50 +101,64 @@ func runSearch(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return fmt.Errorf("failed to search by tags: %w", err)
 		}
-		
-		// If we also have a text query, filter results
-		if len(args) > 0 {
-			query := strings.ToLower(strings.Join(args, " "))
-			var filtered []*task.Task
-			for _, t := range tasks {
-				if strings.Contains(strings.ToLower(t.Title), query) ||
-					strings.Contains(strings.ToLower(t.Description), query) ||
-					strings.Contains(strings.ToLower(t.Content), query) {
-					filtered = append(filtered, t)
-				}
-			}
-			tasks = filtered
+	} else if text != "" || len(searchFields) > 0 || q != nil {
+		// Text, field filters or query only
+		if searchArchived || (q != nil && query.UsesArchived(q)) {
+			tasks, err = ctx.Repo.FindAll()
+		} else {
+			tasks, err = ctx.Repo.FindActive()
 		}
-	} else if len(args) > 0 {
-		// Text search only
-		query := strings.Join(args, " ")
-		tasks, err = ctx.Repo.Search(query)
 		if err != nil {
 			return fmt.Errorf("failed to search: %w", err)
 		}
-		
-		// Filter out archived unless requested
-		if !searchArchived {
-			var filtered []*task.Task
-			for _, t := range tasks {
-				if !t.IsArchived() {
-					filtered = append(filtered, t)
-				}
-			}
-			tasks = filtered
-		}
 	} else {
 		// No search criteria
-		fmt.Println("Please provide search text or tag filters")
+		fmt.Println("Please provide search text, tag or field filters, or a query")
 		return nil
 	}
 
+	tasks = filterByFields(tasks, searchFields)
+
+	var all []*task.Task
+	if q != nil || text != "" {
+		all, err = ctx.Repo.FindAll()
+		if err != nil {
+			return fmt.Errorf("failed to search: %w", err)
+		}
+	}
+
+	if q != nil {
+		tasks = query.Filter(tasks, q, query.NewEnv(all, ctx.Config.GetWorkflow().IsDone), true)
+	}
+
+	// Text matches are ranked over all tasks, so that scores do not depend
+	// on the other filters, and then narrowed down to the filtered tasks
+	var results []search.Result
+	if text != "" {
+		idx, err := ctx.Repo.SearchIndex()
+		if err != nil {
+			return fmt.Errorf("failed to search: %w", err)
+		}
+		results = filterResults(idx.Search(text), tasks)
+		tasks = make([]*task.Task, len(results))
+		for i, r := range results {
+			tasks[i] = r.Task
+		}
+	}
+
+	if ok, err := printFormatted(ctx, tasks, "mdtask search"); ok {
+		return err
+	}
+
 	// JSON output
 	if outputFormat == "json" {
 		printer := output.NewJSONPrinter(os.Stdout)
 		if len(tasks) == 0 {
 			return printer.PrintEmpty()
 		}
+		if text != "" {
+			return printer.PrintSearchResults(results)
+		}
 		return printer.PrintTasks(tasks)
 	}
 	
@@ -123,11 +169,15 @@ func runSearch(cmd *cobra.Command, args []string) error {
 	}
 
 	// Text output
-	fmt.Printf("Found %d task(s):\n\n", len(tasks))
+	if text != "" {
+		fmt.Printf("Found %d task(s), best matches first:\n\n", len(tasks))
+	} else {
+		fmt.Printf("Found %d task(s):\n\n", len(tasks))
+	}
 	
 	// Display search criteria
-	if len(args) > 0 {
-		fmt.Printf("Text: \"%s\"\n", strings.Join(args, " "))
+	if text != "" {
+		fmt.Printf("Text: \"%s\"\n", text)
 	}
 	if len(searchTags) > 0 {
 		mode := "AND"
@@ -136,6 +186,12 @@ func runSearch(cmd *cobra.Command, args []string) error {
 		}
 		fmt.Printf("Tags (%s): %s\n", mode, strings.Join(searchTags, ", "))
 	}
+	if len(searchFields) > 0 {
+		fmt.Printf("Fields: %s\n", strings.Join(searchFields, ", "))
+	}
+	if q != nil {
+		fmt.Printf("Query: %s\n", q)
+	}
 	if len(excludeTags) > 0 {
 		// Remove auto-added archived tag from display
 		displayExclude := []string{}
@@ -150,6 +206,12 @@ func runSearch(cmd *cobra.Command, args []string) error {
 	}
 	fmt.Println(strings.Repeat("-", 80))
 
+	snippets := make(map[string]search.Snippet, len(results))
+	for _, r := range results {
+		snippets[r.Task.ID] = r.Snippet
+	}
+	markStart, markEnd := highlightMarkers()
+
 	// Display tasks
 	for _, t := range tasks {
 		status := string(t.GetStatus())
@@ -161,21 +223,29 @@ func runSearch(cmd *cobra.Command, args []string) error {
 			}
 		}
 		
-		fmt.Printf("[%s] %s\n", status, t.Title)
-		if t.Description != "" {
-			fmt.Printf("     %s\n", t.Description)
+		title, description := t.Title, t.Description
+		snippet, hasSnippet := snippets[t.ID]
+		switch {
+		case !hasSnippet:
+		case snippet.Field == search.FieldTitle:
+			title = snippet.Highlight(markStart, markEnd)
+		case snippet.Field == search.FieldDescription:
+			description = snippet.Highlight(markStart, markEnd)
+		}
+
+		fmt.Printf("[%s] %s\n", status, title)
+		if description != "" {
+			fmt.Printf("     %s\n", description)
+		}
+		if hasSnippet && snippet.Field == search.FieldContent {
+			fmt.Printf("     %s\n", snippet.Highlight(markStart, markEnd))
 		}
 		if deadline != "" {
 			fmt.Printf("     Due: %s\n", deadline)
 		}
 		if len(t.Tags) > 0 {
 			// Filter out mdtask system tags for display
-			var displayTags []string
-			for _, tag := range t.Tags {
-				if tag != "mdtask" && !strings.HasPrefix(tag, "mdtask/") {
-					displayTags = append(displayTags, tag)
-				}
-			}
+			displayTags := t.UserTags()
 			if len(displayTags) > 0 {
 				fmt.Printf("     Tags: %s\n", strings.Join(displayTags, ", "))
 			}
@@ -186,3 +256,26 @@ func runSearch(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// filterResults keeps the search results whose task is one of tasks
+func filterResults(results []search.Result, tasks []*task.Task) []search.Result {
+	keep := make(map[string]bool, len(tasks))
+	for _, t := range tasks {
+		keep[t.ID] = true
+	}
+	var filtered []search.Result
+	for _, r := range results {
+		if keep[r.Task.ID] {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// highlightMarkers returns the markers around search matches: bold on a
+// terminal, ** when the output is piped or NO_COLOR is set
+func highlightMarkers() (string, string) {
+	if info, err := os.Stdout.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 && os.Getenv("NO_COLOR") == "" {
+		return "\033[1m", "\033[0m"
+	}
+	return "**", "**"
+}
@@ -7,16 +7,35 @@ import (
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
 	"github.com/tkancf/mdtask/internal/cli"
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/errors"
+	"github.com/tkancf/mdtask/internal/query"
+	"github.com/tkancf/mdtask/internal/report"
+	"github.com/tkancf/mdtask/internal/service"
 	"github.com/tkancf/mdtask/internal/task"
 )
 
 var statsCmd = &cobra.Command{
 	Use:   "stats",
 	Short: "Show task statistics",
-	Long:  `Display statistics about your tasks including daily progress, completion rates, and status breakdown.`,
-	RunE:  runStats,
+	Long: `Display statistics about your tasks including daily progress, completion rates, and status breakdown.
+
+With --report, draw a daily chart instead, by default over the last 14 days:
+
+  burndown    open tasks left each day against an ideal line
+  cfd         cumulative flow: the tasks in each status per day
+  throughput  tasks completed per day
+
+Examples:
+  # Burndown of a sprint
+  mdtask stats --report burndown --from 2025-06-02 --to 2025-06-13 --tag sprint/24
+
+  # Cumulative flow of this month as JSON
+  mdtask stats --report cfd --month -f json`,
+	RunE: runStats,
 }
 
 var (
@@ -24,25 +43,31 @@ var (
 	statsWeek   bool
 	statsMonth  bool
 	statsSimple bool
+	statsReport string
+	statsFrom   string
+	statsTo     string
+	statsTag    string
 )
 
+// defaultReportDays is the length of a report without a date range
+const defaultReportDays = 14
+
 func init() {
 	rootCmd.AddCommand(statsCmd)
 	statsCmd.Flags().StringVarP(&statsDate, "date", "d", "", "Show stats for specific date (YYYY-MM-DD)")
 	statsCmd.Flags().BoolVarP(&statsWeek, "week", "w", false, "Show stats for current week")
 	statsCmd.Flags().BoolVarP(&statsMonth, "month", "m", false, "Show stats for current month")
 	statsCmd.Flags().BoolVarP(&statsSimple, "simple", "s", false, "Show simple output without graphics")
+	statsCmd.Flags().StringVar(&statsReport, "report", "", "Draw a daily chart: burndown, cfd or throughput")
+	statsCmd.Flags().StringVar(&statsFrom, "from", "", "First day of the range (YYYY-MM-DD)")
+	statsCmd.Flags().StringVar(&statsTo, "to", "", "Last day of the range (YYYY-MM-DD), defaults to today")
+	statsCmd.Flags().StringVar(&statsTag, "tag", "", "Only count tasks with this tag (type/* matches a prefix)")
 }
 
 type TaskStats struct {
 	Total       int `json:"total"`
-	ByStatus    struct {
-		TODO int `json:"todo"`
-		WIP  int `json:"wip"`
-		WAIT int `json:"wait"`
-		SCHE int `json:"sche"`
-		DONE int `json:"done"`
-	} `json:"by_status"`
+	// ByStatus is keyed by the lower-cased status name
+	ByStatus    map[string]int `json:"by_status"`
 	Activity struct {
 		Created   int `json:"created"`
 		Updated   int `json:"updated"`
@@ -56,6 +81,31 @@ type TaskStats struct {
 		Start string `json:"start"`
 		End   string `json:"end"`
 	} `json:"date_range"`
+	Flow FlowStats `json:"flow"`
+}
+
+// FlowStats are the flow metrics of the period, computed from the status
+// history of the tasks
+type FlowStats struct {
+	// Throughput is the number of tasks completed per day
+	Throughput float64           `json:"throughput_per_day"`
+	CycleTime  DurationStatsJSON `json:"cycle_time"`
+	LeadTime   DurationStatsJSON `json:"lead_time"`
+}
+
+// DurationStatsJSON summarizes durations in hours
+type DurationStatsJSON struct {
+	Count        int     `json:"count"`
+	AverageHours float64 `json:"average_hours"`
+	MedianHours  float64 `json:"median_hours"`
+}
+
+func newDurationStatsJSON(d service.DurationStats) DurationStatsJSON {
+	return DurationStatsJSON{
+		Count:        d.Count,
+		AverageHours: d.Average.Hours(),
+		MedianHours:  d.Median.Hours(),
+	}
 }
 
 func runStats(cmd *cobra.Command, args []string) error {
@@ -64,16 +114,48 @@ func runStats(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	var kind report.Kind
+	if statsReport != "" {
+		if kind, err = report.ParseKind(statsReport); err != nil {
+			return errors.ValidationError("report", err.Error())
+		}
+	}
+
 	tasks, err := ctx.Repo.FindAll()
 	if err != nil {
 		return err
 	}
+	if statsTag != "" {
+		tasks = query.Filter(tasks, query.Tags([]string{statsTag}, nil, false), query.NewEnv(tasks, ctx.Config.GetWorkflow().IsDone), true)
+	}
 
 	// Determine the date range
 	var startDate, endDate time.Time
 	now := time.Now()
 	
-	if statsDate != "" {
+	if statsFrom != "" || statsTo != "" {
+		// Explicit range; the end day is included
+		endDate = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		if statsTo != "" {
+			if endDate, err = time.ParseInLocation("2006-01-02", statsTo, now.Location()); err != nil {
+				return fmt.Errorf("invalid date format: %w", err)
+			}
+		}
+		endDate = endDate.AddDate(0, 0, 1)
+		startDate = endDate.AddDate(0, 0, -defaultReportDays)
+		if statsFrom != "" {
+			if startDate, err = time.ParseInLocation("2006-01-02", statsFrom, now.Location()); err != nil {
+				return fmt.Errorf("invalid date format: %w", err)
+			}
+		}
+		if !startDate.Before(endDate) {
+			return errors.ValidationError("from", "the range must start before it ends")
+		}
+	} else if kind != "" && statsDate == "" && !statsWeek && !statsMonth {
+		// Reports default to the last two weeks
+		endDate = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, 1)
+		startDate = endDate.AddDate(0, 0, -defaultReportDays)
+	} else if statsDate != "" {
 		// Specific date
 		startDate, err = time.Parse("2006-01-02", statsDate)
 		if err != nil {
@@ -98,7 +180,11 @@ func runStats(cmd *cobra.Command, args []string) error {
 		endDate = startDate.AddDate(0, 0, 1)
 	}
 
-	stats := calculateStats(tasks, startDate, endDate, now)
+	workflow := ctx.Config.GetWorkflow()
+	if kind != "" {
+		return runReport(kind, tasks, workflow, startDate, endDate, now)
+	}
+	stats := calculateStats(tasks, workflow, startDate, endDate, now)
 	
 	if outputFormat == "json" {
 		encoder := json.NewEncoder(os.Stdout)
@@ -107,16 +193,45 @@ func runStats(cmd *cobra.Command, args []string) error {
 	}
 	
 	if statsSimple {
-		displaySimpleStats(stats, startDate, endDate)
+		displaySimpleStats(stats, workflow, startDate, endDate)
 	} else {
-		displayDetailedStats(stats, startDate, endDate, tasks)
+		displayDetailedStats(stats, workflow, startDate, endDate, tasks)
 	}
 
 	return nil
 }
 
-func calculateStats(tasks []*task.Task, startDate, endDate, now time.Time) TaskStats {
-	stats := TaskStats{}
+// runReport prints a daily chart of the days in [startDate, endDate)
+func runReport(kind report.Kind, tasks []*task.Task, workflow *config.Workflow, startDate, endDate, now time.Time) error {
+	// Task times are read as UTC wall clock, so the days are too
+	day := func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	r, err := report.Build(kind, tasks, workflow, day(startDate), day(endDate.AddDate(0, 0, -1)), day(now))
+	if err != nil {
+		return errors.ValidationError("range", err.Error())
+	}
+
+	if outputFormat == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	}
+	return r.WriteText(os.Stdout, report.DefaultTextWidth)
+}
+
+func calculateStats(tasks []*task.Task, workflow *config.Workflow, startDate, endDate, now time.Time) TaskStats {
+	stats := TaskStats{ByStatus: make(map[string]int)}
+	flow := service.CalculateFlowMetrics(tasks, workflow, startDate, endDate)
+	stats.Activity.Completed = len(flow.Completed)
+	stats.Flow = FlowStats{
+		Throughput: flow.Throughput(),
+		CycleTime:  newDurationStatsJSON(flow.CycleTime),
+		LeadTime:   newDurationStatsJSON(flow.LeadTime),
+	}
+	for _, status := range workflow.Names() {
+		stats.ByStatus[statusKey(status)] = 0
+	}
 	stats.DateRange.Start = startDate.Format("2006-01-02")
 	stats.DateRange.End = endDate.AddDate(0, 0, -1).Format("2006-01-02")
 	
@@ -128,18 +243,7 @@ func calculateStats(tasks []*task.Task, startDate, endDate, now time.Time) TaskS
 		stats.Total++
 		
 		// Count by status
-		switch t.GetStatus() {
-		case task.StatusTODO:
-			stats.ByStatus.TODO++
-		case task.StatusWIP:
-			stats.ByStatus.WIP++
-		case task.StatusWAIT:
-			stats.ByStatus.WAIT++
-		case task.StatusSCHE:
-			stats.ByStatus.SCHE++
-		case task.StatusDONE:
-			stats.ByStatus.DONE++
-		}
+		stats.ByStatus[statusKey(t.GetStatus())]++
 		
 		// Check if created in date range
 		if t.Created.After(startDate) && t.Created.Before(endDate) {
@@ -151,11 +255,6 @@ func calculateStats(tasks []*task.Task, startDate, endDate, now time.Time) TaskS
 			stats.Activity.Updated++
 		}
 		
-		// Check if completed in date range (assuming DONE tasks were completed when last updated)
-		if t.GetStatus() == task.StatusDONE && t.Updated.After(startDate) && t.Updated.Before(endDate) {
-			stats.Activity.Completed++
-		}
-		
 		// Check deadlines
 		if deadline := t.GetDeadline(); deadline != nil {
 			if deadline.Before(now) {
@@ -169,26 +268,34 @@ func calculateStats(tasks []*task.Task, startDate, endDate, now time.Time) TaskS
 	return stats
 }
 
-func displaySimpleStats(stats TaskStats, startDate, endDate time.Time) {
+// statusKey is the key of a status in TaskStats.ByStatus
+func statusKey(status task.Status) string {
+	return strings.ToLower(string(status))
+}
+
+func displaySimpleStats(stats TaskStats, workflow *config.Workflow, startDate, endDate time.Time) {
 	dateRange := formatDateRange(startDate, endDate)
 	fmt.Printf("Task Statistics for %s\n", dateRange)
 	fmt.Println(strings.Repeat("-", 40))
 	fmt.Printf("Total Active Tasks: %d\n", stats.Total)
-	fmt.Printf("  TODO: %d\n", stats.ByStatus.TODO)
-	fmt.Printf("  WIP:  %d\n", stats.ByStatus.WIP)
-	fmt.Printf("  WAIT: %d\n", stats.ByStatus.WAIT)
-	fmt.Printf("  SCHE: %d\n", stats.ByStatus.SCHE)
-	fmt.Printf("  DONE: %d\n", stats.ByStatus.DONE)
+	width := statusNameWidth(workflow)
+	for _, status := range workflow.Names() {
+		fmt.Printf("  %-*s %d\n", width+1, string(status)+":", stats.ByStatus[statusKey(status)])
+	}
 	fmt.Println()
 	fmt.Printf("Created:   %d\n", stats.Activity.Created)
 	fmt.Printf("Updated:   %d\n", stats.Activity.Updated)
 	fmt.Printf("Completed: %d\n", stats.Activity.Completed)
 	fmt.Println()
+	fmt.Printf("Throughput: %.1f tasks/day\n", stats.Flow.Throughput)
+	fmt.Printf("Cycle Time: %s\n", formatDurationStats(stats.Flow.CycleTime))
+	fmt.Printf("Lead Time:  %s\n", formatDurationStats(stats.Flow.LeadTime))
+	fmt.Println()
 	fmt.Printf("Overdue Tasks:      %d\n", stats.Deadlines.Overdue)
 	fmt.Printf("Upcoming Deadlines: %d\n", stats.Deadlines.Upcoming)
 }
 
-func displayDetailedStats(stats TaskStats, startDate, endDate time.Time, tasks []*task.Task) {
+func displayDetailedStats(stats TaskStats, workflow *config.Workflow, startDate, endDate time.Time, tasks []*task.Task) {
 	dateRange := formatDateRange(startDate, endDate)
 	
 	// Header
@@ -207,16 +314,23 @@ func displayDetailedStats(stats TaskStats, startDate, endDate time.Time, tasks [
 		fmt.Println("No activity in this period")
 	}
 	
+	// Flow metrics from the status history
+	fmt.Println("\n⏱  Flow")
+	fmt.Println(strings.Repeat("─", 40))
+	fmt.Printf("Throughput: %.1f task(s)/day\n", stats.Flow.Throughput)
+	fmt.Printf("Cycle Time: %s\n", formatDurationStats(stats.Flow.CycleTime))
+	fmt.Printf("Lead Time:  %s\n", formatDurationStats(stats.Flow.LeadTime))
+	
 	// Status Breakdown with progress bars
 	fmt.Println("\n📋 Status Breakdown")
 	fmt.Println(strings.Repeat("─", 40))
 	
 	if stats.Total > 0 {
-		displayStatusBar("TODO", stats.ByStatus.TODO, stats.Total, "🔵")
-		displayStatusBar("WIP ", stats.ByStatus.WIP, stats.Total, "🟡")
-		displayStatusBar("WAIT", stats.ByStatus.WAIT, stats.Total, "⚪")
-		displayStatusBar("SCHE", stats.ByStatus.SCHE, stats.Total, "🟣")
-		displayStatusBar("DONE", stats.ByStatus.DONE, stats.Total, "🟢")
+		width := statusNameWidth(workflow)
+		for _, status := range workflow.Names() {
+			label := fmt.Sprintf("%-*s", width, status)
+			displayStatusBar(label, stats.ByStatus[statusKey(status)], stats.Total, workflow.Color(status))
+		}
 		
 		fmt.Printf("\nTotal Active Tasks: %d\n", stats.Total)
 		
@@ -251,7 +365,7 @@ func displayDetailedStats(stats TaskStats, startDate, endDate time.Time, tasks [
 	}
 	
 	// Tasks in progress
-	if stats.ByStatus.WIP > 0 {
+	if stats.ByStatus[statusKey(task.StatusWIP)] > 0 {
 		fmt.Println("\n🚧 Tasks in Progress")
 		fmt.Println(strings.Repeat("─", 40))
 		for _, t := range tasks {
@@ -264,7 +378,30 @@ func displayDetailedStats(stats TaskStats, startDate, endDate time.Time, tasks [
 	fmt.Println()
 }
 
-func displayStatusBar(label string, count, total int, emoji string) {
+// formatDurationStats formats the average and median of durations, or "-"
+// if there are none
+func formatDurationStats(d DurationStatsJSON) string {
+	if d.Count == 0 {
+		return "-"
+	}
+	hours := func(h float64) string {
+		return task.FormatSpan(time.Duration(h * float64(time.Hour)))
+	}
+	return fmt.Sprintf("avg %s, median %s (%d task(s))", hours(d.AverageHours), hours(d.MedianHours), d.Count)
+}
+
+// statusNameWidth returns the length of the longest status name
+func statusNameWidth(workflow *config.Workflow) int {
+	width := 0
+	for _, status := range workflow.Names() {
+		if len(status) > width {
+			width = len(status)
+		}
+	}
+	return width
+}
+
+func displayStatusBar(label string, count, total int, color string) {
 	if total == 0 {
 		return
 	}
@@ -275,7 +412,8 @@ func displayStatusBar(label string, count, total int, emoji string) {
 	
 	bar := strings.Repeat("█", filled) + strings.Repeat("░", barLength-filled)
 	
-	fmt.Printf("%s %s [%s] %3d (%.1f%%)\n", emoji, label, bar, count, percentage)
+	dot := lipgloss.NewStyle().Foreground(lipgloss.Color(color)).Render("●")
+	fmt.Printf("%s %s [%s] %3d (%.1f%%)\n", dot, label, bar, count, percentage)
 }
 
 func formatDateRange(startDate, endDate time.Time) string {
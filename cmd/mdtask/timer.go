@@ -0,0 +1,96 @@
+package mdtask
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tkancf/mdtask/internal/cli"
+	"github.com/tkancf/mdtask/internal/service"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+var startCmd = &cobra.Command{
+	Use:   "start <task-id>",
+	Short: "Start the timer on a task",
+	Long: `Start tracking time on a task. The task moves to the active status of the
+workflow (WIP by default) and a clock entry is added to the "## Log" section
+of the task file:
+
+  ## Log
+
+  - 2025-06-20 09:00 - 10:30 (1h30m)
+  - 2025-06-20 14:00 - (running)
+
+Only one timer runs at a time; a timer running on another task is stopped.
+Use 'mdtask timesheet' to report the logged time.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runStart,
+}
+
+var stopCmd = &cobra.Command{
+	Use:   "stop [task-id]",
+	Short: "Stop the running timer",
+	Long: `Stop the running timer, or the timer of the given task, and record the
+end time in the task's "## Log" section.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runStop,
+}
+
+func init() {
+	rootCmd.AddCommand(startCmd)
+	rootCmd.AddCommand(stopCmd)
+}
+
+func runStart(cmd *cobra.Command, args []string) error {
+	ctx, err := cli.LoadContext(cmd)
+	if err != nil {
+		return err
+	}
+
+	taskID, err := cli.NormalizeTaskID(args[0])
+	if err != nil {
+		return err
+	}
+
+	started, stopped, err := service.NewTaskService(ctx.Repo, ctx.Config).StartTimer(taskID, time.Now())
+	for _, s := range stopped {
+		printStopped(s)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to start timer: %w", err)
+	}
+
+	fmt.Printf("Started %s: %s at %s\n", started.Task.ID, started.Task.Title, started.Entry.Start.Format("15:04"))
+	fmt.Printf("Status: %s\n", started.Task.GetStatus())
+	return nil
+}
+
+func runStop(cmd *cobra.Command, args []string) error {
+	ctx, err := cli.LoadContext(cmd)
+	if err != nil {
+		return err
+	}
+
+	taskID := ""
+	if len(args) > 0 {
+		taskID, err = cli.NormalizeTaskID(args[0])
+		if err != nil {
+			return err
+		}
+	}
+
+	stopped, err := service.NewTaskService(ctx.Repo, ctx.Config).StopTimer(taskID, time.Now())
+	for _, s := range stopped {
+		printStopped(s)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stop timer: %w", err)
+	}
+	return nil
+}
+
+func printStopped(s service.TimerEntry) {
+	fmt.Printf("Stopped %s: %s after %s (total %s)\n", s.Task.ID, s.Task.Title,
+		task.FormatDuration(s.Entry.Duration(time.Now())), task.FormatDuration(s.Task.TimeSpent(time.Now())))
+}
@@ -0,0 +1,189 @@
+package mdtask
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tkancf/mdtask/internal/cli"
+	"github.com/tkancf/mdtask/internal/constants"
+	"github.com/tkancf/mdtask/internal/service"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+var timesheetCmd = &cobra.Command{
+	Use:   "timesheet",
+	Short: "Report the time logged per task and per tag",
+	Long: `Report the time logged with 'mdtask start' and 'mdtask stop', per task and
+per tag. Archived tasks are included and running timers count up to now.
+
+Examples:
+  # This week, Monday to Sunday (default)
+  mdtask timesheet --week
+
+  # Last week
+  mdtask timesheet --week --last
+
+  # This month, or any range of days
+  mdtask timesheet --month
+  mdtask timesheet --from 2025-06-01 --to 2025-06-15`,
+	Args: cobra.NoArgs,
+	RunE: runTimesheet,
+}
+
+var (
+	timesheetWeek  bool
+	timesheetMonth bool
+	timesheetToday bool
+	timesheetLast  bool
+	timesheetFrom  string
+	timesheetTo    string
+)
+
+func init() {
+	rootCmd.AddCommand(timesheetCmd)
+	timesheetCmd.Flags().BoolVarP(&timesheetWeek, "week", "w", false, "Report the current week (default)")
+	timesheetCmd.Flags().BoolVarP(&timesheetMonth, "month", "m", false, "Report the current month")
+	timesheetCmd.Flags().BoolVar(&timesheetToday, "today", false, "Report today")
+	timesheetCmd.Flags().BoolVar(&timesheetLast, "last", false, "Report the previous week, month or day instead")
+	timesheetCmd.Flags().StringVar(&timesheetFrom, "from", "", "First day to report (YYYY-MM-DD)")
+	timesheetCmd.Flags().StringVar(&timesheetTo, "to", "", "Last day to report (YYYY-MM-DD), defaults to today")
+}
+
+type timesheetJSON struct {
+	From  string              `json:"from"`
+	To    string              `json:"to"`
+	Total float64             `json:"total_hours"`
+	Tasks []timesheetTaskJSON `json:"tasks"`
+	ByTag map[string]float64  `json:"by_tag"`
+}
+
+type timesheetTaskJSON struct {
+	ID    string   `json:"id"`
+	Title string   `json:"title"`
+	Tags  []string `json:"tags,omitempty"`
+	Hours float64  `json:"hours"`
+}
+
+func runTimesheet(cmd *cobra.Command, args []string) error {
+	ctx, err := cli.LoadContext(cmd)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	from, to, err := timesheetRange(now)
+	if err != nil {
+		return err
+	}
+
+	sheet, err := service.NewTaskService(ctx.Repo, ctx.Config).Timesheet(from, to, now)
+	if err != nil {
+		return fmt.Errorf("failed to build timesheet: %w", err)
+	}
+
+	// The range is shown with its last day rather than the exclusive end
+	lastDay := to.AddDate(0, 0, -1).Format(constants.DateFormat)
+
+	if outputFormat == "json" {
+		result := timesheetJSON{
+			From:  from.Format(constants.DateFormat),
+			To:    lastDay,
+			Total: sheet.Total.Hours(),
+			Tasks: []timesheetTaskJSON{},
+			ByTag: make(map[string]float64),
+		}
+		for _, row := range sheet.Tasks {
+			result.Tasks = append(result.Tasks, timesheetTaskJSON{
+				ID:    row.Task.ID,
+				Title: row.Task.Title,
+				Tags:  row.Task.UserTags(),
+				Hours: row.Duration.Hours(),
+			})
+		}
+		for _, tag := range sheet.Tags {
+			result.ByTag[tag.Tag] = tag.Duration.Hours()
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	fmt.Printf("Timesheet %s to %s\n\n", from.Format(constants.DateFormat), lastDay)
+	if len(sheet.Tasks) == 0 {
+		fmt.Println("No time logged.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTITLE\tTIME")
+	fmt.Fprintln(w, strings.Repeat("-", 70))
+	for _, row := range sheet.Tasks {
+		title := row.Task.Title
+		if row.Task.RunningClock() != nil {
+			title += " (running)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", row.Task.ID, title, task.FormatDuration(row.Duration))
+	}
+	fmt.Fprintf(w, "\tTotal\t%s\n", task.FormatDuration(sheet.Total))
+	w.Flush()
+
+	if len(sheet.Tags) > 0 {
+		fmt.Println()
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TAG\tTIME")
+		fmt.Fprintln(w, strings.Repeat("-", 30))
+		for _, tag := range sheet.Tags {
+			fmt.Fprintf(w, "%s\t%s\n", tag.Tag, task.FormatDuration(tag.Duration))
+		}
+		w.Flush()
+	}
+	return nil
+}
+
+// timesheetRange returns the reported range as [from, to)
+func timesheetRange(now time.Time) (time.Time, time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	offset := 0
+	if timesheetLast {
+		offset = -1
+	}
+
+	if timesheetFrom != "" || timesheetTo != "" {
+		from, to := today, today
+		if timesheetFrom != "" {
+			d, err := time.ParseInLocation(constants.DateFormat, timesheetFrom, time.Local)
+			if err != nil {
+				return from, to, fmt.Errorf("invalid --from date (use YYYY-MM-DD): %s", timesheetFrom)
+			}
+			from = d
+		}
+		if timesheetTo != "" {
+			d, err := time.ParseInLocation(constants.DateFormat, timesheetTo, time.Local)
+			if err != nil {
+				return from, to, fmt.Errorf("invalid --to date (use YYYY-MM-DD): %s", timesheetTo)
+			}
+			to = d
+		}
+		if to.Before(from) {
+			return from, to, fmt.Errorf("--to %s is before --from %s", timesheetTo, timesheetFrom)
+		}
+		return from, to.AddDate(0, 0, 1), nil
+	}
+
+	switch {
+	case timesheetMonth:
+		from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local).AddDate(0, offset, 0)
+		return from, from.AddDate(0, 1, 0), nil
+	case timesheetToday:
+		from := today.AddDate(0, 0, offset)
+		return from, from.AddDate(0, 0, 1), nil
+	}
+	from, to := service.WeekRange(today, offset)
+	return from, to, nil
+}
+
@@ -3,7 +3,9 @@ package mdtask
 import (
 	"github.com/spf13/cobra"
 	"github.com/tkancf/mdtask/internal/cli"
+	"github.com/tkancf/mdtask/internal/constants"
 	"github.com/tkancf/mdtask/internal/tui"
+	"github.com/tkancf/mdtask/internal/watcher"
 )
 
 var tuiCmd = &cobra.Command{
@@ -23,6 +25,14 @@ func runTUI(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	w := watcher.New(ctx.Repo, constants.WatchPollInterval)
+	w.Start()
+	defer w.Stop()
+
+	events, cancel := w.Subscribe()
+	defer cancel()
+
 	app := tui.NewApp(ctx.Repo, ctx.Config)
+	app.SetEvents(events)
 	return app.Run()
 }
\ No newline at end of file
@@ -11,6 +11,7 @@ import (
 	"github.com/tkancf/mdtask/internal/config"
 	"github.com/tkancf/mdtask/internal/constants"
 	"github.com/tkancf/mdtask/internal/repository"
+	"github.com/tkancf/mdtask/internal/watcher"
 	"github.com/tkancf/mdtask/internal/web"
 )
 
@@ -55,13 +56,19 @@ func runWeb(cmd *cobra.Command, args []string) error {
 		paths = cfg.Paths
 	}
 	
-	repo := repository.NewTaskRepository(paths)
+	repo := repository.NewTaskRepositoryWithConfig(paths, cfg)
 
 	server, err := web.NewServer(repo, cfg, port)
 	if err != nil {
 		return fmt.Errorf("failed to create web server: %w", err)
 	}
 
+	// Push changes made outside the browser (editor, CLI, sync) to open pages
+	w := watcher.New(repo, constants.WatchPollInterval)
+	w.Start()
+	defer w.Stop()
+	server.EnableLiveUpdates(w)
+
 	// Start server in a goroutine to handle browser opening after port is determined
 	errCh := make(chan error, 1)
 	go func() {
@@ -30,7 +30,7 @@ func LoadContext(cmd *cobra.Command) (*Context, error) {
 	}
 
 	// Create repository
-	repo := repository.NewTaskRepository(paths)
+	repo := repository.NewTaskRepositoryWithConfig(paths, cfg)
 
 	return &Context{
 		Config: cfg,
@@ -45,7 +45,7 @@ func LoadContextWithPaths(cfg *config.Config, paths []string) *Context {
 		paths = cfg.Paths
 	}
 
-	repo := repository.NewTaskRepository(paths)
+	repo := repository.NewTaskRepositoryWithConfig(paths, cfg)
 
 	return &Context{
 		Config: cfg,
@@ -5,8 +5,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/tkancf/mdtask/internal/config"
 	"github.com/tkancf/mdtask/internal/constants"
 	"github.com/tkancf/mdtask/internal/task"
+	"github.com/tkancf/mdtask/internal/taskid"
 )
 
 // NormalizeTaskID ensures task ID has the proper prefix
@@ -28,17 +30,27 @@ func NormalizeTaskID(id string) (string, error) {
 		}
 	}
 	
+	// IDs of the other formats of storage.id_format (ULID, slug)
+	if taskid.Valid(id) {
+		return constants.TaskIDPrefix + id, nil
+	}
+	
 	return "", fmt.Errorf("invalid task ID format: %s", id)
 }
 
-// ValidateStatus checks if the given status string is valid
+// ValidateStatus checks if the given status string is valid in the default
+// workflow
 func ValidateStatus(status string) (task.Status, error) {
-	switch task.Status(status) {
-	case task.StatusTODO, task.StatusWIP, task.StatusWAIT, task.StatusSCHE, task.StatusDONE:
-		return task.Status(status), nil
-	default:
-		return "", fmt.Errorf("invalid status: %s (valid: TODO, WIP, WAIT, SCHE, DONE)", status)
+	return ValidateWorkflowStatus(config.DefaultWorkflow(), status)
+}
+
+// ValidateWorkflowStatus checks if the given status string is declared in
+// the workflow
+func ValidateWorkflowStatus(workflow *config.Workflow, status string) (task.Status, error) {
+	if !workflow.IsValid(task.Status(status)) {
+		return "", fmt.Errorf("invalid status: %s (valid: %s)", status, workflow)
 	}
+	return task.Status(status), nil
 }
 
 // ParseDeadline parses deadline string in YYYY-MM-DD format
@@ -67,4 +79,24 @@ func ParseReminder(reminder string) (*time.Time, error) {
 	}
 	
 	return &t, nil
-}
\ No newline at end of file
+}
+
+// ParseFieldAssignment parses a "name=value" custom field assignment and
+// converts the value according to the field schema in the config
+func ParseFieldAssignment(cfg *config.Config, assignment string) (string, interface{}, error) {
+	parts := strings.SplitN(assignment, "=", 2)
+	if len(parts) != 2 {
+		return "", nil, fmt.Errorf("invalid field assignment (use name=value): %s", assignment)
+	}
+
+	name := strings.TrimSpace(parts[0])
+	if err := task.ValidateFieldName(name); err != nil {
+		return "", nil, err
+	}
+
+	value, err := cfg.ParseFieldValue(name, parts[1])
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid value for field %s: %w", name, err)
+	}
+	return name, value, nil
+}
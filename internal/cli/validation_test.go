@@ -1,9 +1,11 @@
 package cli
 
 import (
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/tkancf/mdtask/internal/config"
 	"github.com/tkancf/mdtask/internal/task"
 )
 
@@ -32,6 +34,18 @@ func TestNormalizeTaskID(t *testing.T) {
 			want:    "task/20240101120000_1",
 			wantErr: false,
 		},
+		{
+			name:    "ULID",
+			input:   "01JYXKS620ABCDEFGHJKMNPQRS",
+			want:    "task/01JYXKS620ABCDEFGHJKMNPQRS",
+			wantErr: false,
+		},
+		{
+			name:    "slug",
+			input:   "20250629-fix-login",
+			want:    "task/20250629-fix-login",
+			wantErr: false,
+		},
 		{
 			name:    "invalid format",
 			input:   "invalid-id",
@@ -243,6 +257,56 @@ func TestParseReminder(t *testing.T) {
 	}
 }
 
+func TestValidateWorkflowStatus(t *testing.T) {
+	workflow := config.NewWorkflow(config.WorkflowConfig{
+		Statuses: []config.StatusConfig{{Name: "TODO"}, {Name: "REVIEW"}, {Name: "DONE"}},
+	})
+
+	if got, err := ValidateWorkflowStatus(workflow, "REVIEW"); err != nil || got != "REVIEW" {
+		t.Errorf("ValidateWorkflowStatus(REVIEW) = %q, %v", got, err)
+	}
+	_, err := ValidateWorkflowStatus(workflow, "WIP")
+	if err == nil || !strings.Contains(err.Error(), "TODO, REVIEW, DONE") {
+		t.Errorf("expected error listing the workflow statuses, got %v", err)
+	}
+}
+
+func TestParseFieldAssignment(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Fields = map[string]config.FieldConfig{
+		"estimate": {Type: config.FieldTypeDuration},
+	}
+
+	tests := []struct {
+		name      string
+		input     string
+		wantName  string
+		wantValue interface{}
+		wantErr   bool
+	}{
+		{"string", "owner=alice", "owner", "alice", false},
+		{"inferred number", "points = 3", "points", 3, false},
+		{"value with equals", "query=a=b", "query", "a=b", false},
+		{"typed", "estimate=3h", "estimate", "3h", false},
+		{"invalid typed", "estimate=later", "", nil, true},
+		{"missing value", "owner", "", nil, true},
+		{"reserved", "title=x", "", nil, true},
+		{"empty name", "=x", "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, value, err := ParseFieldAssignment(cfg, tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFieldAssignment() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if name != tt.wantName || value != tt.wantValue {
+				t.Errorf("ParseFieldAssignment() = %q, %#v, want %q, %#v", name, value, tt.wantName, tt.wantValue)
+			}
+		})
+	}
+}
+
 // Helper functions
 func timePtr(t time.Time) *time.Time {
 	return &t
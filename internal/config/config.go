@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/BurntSushi/toml"
+	"github.com/tkancf/mdtask/internal/constants"
+	"github.com/tkancf/mdtask/internal/taskid"
 )
 
 // Config represents the mdtask configuration
@@ -24,6 +27,34 @@ type Config struct {
 	
 	// Editor settings
 	Editor EditorConfig `toml:"editor"`
+	
+	// Storage settings
+	Storage StorageConfig `toml:"storage"`
+	
+	// Git integration settings
+	Git GitConfig `toml:"git"`
+	
+	// Output format settings
+	Output OutputConfig `toml:"output"`
+	
+	// Desktop notification settings
+	Notify NotifyConfig `toml:"notify"`
+	
+	// Task statuses and allowed transitions
+	Workflow WorkflowConfig `toml:"workflow"`
+	
+	// Optional schema for custom front matter fields, keyed by field name
+	Fields map[string]FieldConfig `toml:"fields"`
+	
+	// Commands and webhooks run when tasks change
+	Hooks []HookConfig `toml:"hooks"`
+	
+	// Directories for new tasks by tag
+	Routes []RouteConfig `toml:"routes"`
+	
+	// Ignore lists patterns of files and directories under the task
+	// directories that are not searched for tasks, as in .gitignore
+	Ignore []string `toml:"ignore"`
 }
 
 // TaskConfig contains task-related configuration
@@ -72,6 +103,67 @@ type EditorConfig struct {
 	Args []string `toml:"args"`
 }
 
+// StorageConfig contains settings for how task files are written
+type StorageConfig struct {
+	// Take an advisory lock file (.mdtask/write.lock) around every write so
+	// that several mdtask processes never interleave writes
+	LockFile bool `toml:"lock_file"`
+
+	// Subfolders puts new tasks in dated subfolders of their directory,
+	// e.g. "YYYY/MM"; empty writes them directly into it
+	Subfolders string `toml:"subfolders"`
+
+	// IDFormat names new tasks and their files: timestamp (the default),
+	// ulid or slug
+	IDFormat string `toml:"id_format"`
+}
+
+// GitConfig contains settings for task directories inside git work trees
+type GitConfig struct {
+	// Commit every create, update and archive of a task file. Task
+	// directories outside a git work tree are written without commits.
+	AutoCommit bool `toml:"auto_commit"`
+}
+
+// OutputConfig contains settings for the output formats
+type OutputConfig struct {
+	// Columns of the table formats (csv, tsv, markdown-table, html)
+	Columns []string `toml:"columns"`
+}
+
+// NotifyConfig contains settings for reminder notifications
+type NotifyConfig struct {
+	// Backend shows the notifications: auto, dbus, notify-send, osascript
+	// or terminal. Empty means auto.
+	Backend string `toml:"backend"`
+
+	// CatchUp is how late a missed reminder is still shown, e.g. "24h".
+	// Older ones are dropped; "0" shows them all. Empty means 24h.
+	CatchUp string `toml:"catch_up"`
+}
+
+// CatchUpWindow returns the catch-up setting as a duration
+func (n NotifyConfig) CatchUpWindow() time.Duration {
+	if n.CatchUp == "" {
+		return constants.ReminderCatchUp
+	}
+	d, err := time.ParseDuration(n.CatchUp)
+	if err != nil {
+		return constants.ReminderCatchUp
+	}
+	return d
+}
+
+func (c *Config) validateNotify() error {
+	if c.Notify.CatchUp == "" {
+		return nil
+	}
+	if d, err := time.ParseDuration(c.Notify.CatchUp); err != nil || d < 0 {
+		return fmt.Errorf("notify.catch_up must be a duration such as \"24h\": %q", c.Notify.CatchUp)
+	}
+	return nil
+}
+
 // DefaultConfig returns the default configuration
 func DefaultConfig() *Config {
 	return &Config{
@@ -110,10 +202,40 @@ func Load(path string) (*Config, error) {
 	}
 	
 	// Parse TOML
-	if err := toml.Unmarshal(data, config); err != nil {
+	meta, err := toml.Decode(string(data), config)
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 	
+	// A custom workflow may not have the built-in default status
+	if !meta.IsDefined("task", "default_status") && len(config.Workflow.Statuses) > 0 {
+		config.Task.DefaultStatus = config.Workflow.Statuses[0].Name
+	}
+	
+	if err := config.validateWorkflow(); err != nil {
+		return nil, fmt.Errorf("invalid config file: %w", err)
+	}
+	
+	if err := config.validateFields(); err != nil {
+		return nil, fmt.Errorf("invalid config file: %w", err)
+	}
+	
+	if err := config.validateNotify(); err != nil {
+		return nil, fmt.Errorf("invalid config file: %w", err)
+	}
+	
+	if err := config.validateHooks(); err != nil {
+		return nil, fmt.Errorf("invalid config file: %w", err)
+	}
+	
+	if err := config.validateRoutes(); err != nil {
+		return nil, fmt.Errorf("invalid config file: %w", err)
+	}
+	
+	if err := taskid.Validate(config.Storage.IDFormat); err != nil {
+		return nil, fmt.Errorf("invalid config file: storage.id_format: %w", err)
+	}
+	
 	return config, nil
 }
 
@@ -51,7 +51,16 @@ default_tags = ["project/test", "priority/medium"]
 
 [web]
 port = 8080
-open_browser = false`,
+open_browser = false
+
+[storage]
+lock_file = true
+
+[output]
+columns = ["id", "title", "estimate"]
+
+[notify]
+catch_up = "2h"`,
 			wantConfig: &Config{
 				Paths: []string{".", "tasks/"},
 				Task: TaskConfig{
@@ -73,6 +82,15 @@ open_browser = false`,
 					Command: "",
 					Args:    []string{},
 				},
+				Storage: StorageConfig{
+					LockFile: true,
+				},
+				Output: OutputConfig{
+					Columns: []string{"id", "title", "estimate"},
+				},
+				Notify: NotifyConfig{
+					CatchUp: "2h",
+				},
 			},
 			wantErr: false,
 		},
@@ -104,6 +122,12 @@ port = 9000`,
 			},
 			wantErr: false,
 		},
+		{
+			name: "invalid catch-up window",
+			content: `[notify]
+catch_up = "yesterday"`,
+			wantErr: true,
+		},
 		{
 			name:    "invalid TOML",
 			content: `invalid toml content [[[`,
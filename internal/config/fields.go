@@ -0,0 +1,189 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/constants"
+)
+
+// Field types that can be declared in the [fields] schema
+const (
+	FieldTypeString   = "string"
+	FieldTypeInt      = "int"
+	FieldTypeFloat    = "float"
+	FieldTypeBool     = "bool"
+	FieldTypeDate     = "date"
+	FieldTypeDuration = "duration"
+	FieldTypeURL      = "url"
+	FieldTypeEnum     = "enum"
+)
+
+// FieldConfig declares the type of a custom front matter field
+type FieldConfig struct {
+	// Type of the value: string, int, float, bool, date, duration, url or enum
+	Type string `toml:"type"`
+
+	// Allowed values for enum fields
+	Values []string `toml:"values"`
+
+	// Human readable description shown in help output
+	Description string `toml:"description"`
+}
+
+// validateFields checks the [fields] schema for unknown types
+func (c *Config) validateFields() error {
+	for name, field := range c.Fields {
+		switch field.Type {
+		case "", FieldTypeString, FieldTypeInt, FieldTypeFloat, FieldTypeBool,
+			FieldTypeDate, FieldTypeDuration, FieldTypeURL:
+		case FieldTypeEnum:
+			if len(field.Values) == 0 {
+				return fmt.Errorf("field %q: enum requires values", name)
+			}
+		default:
+			return fmt.Errorf("field %q: unknown type %q", name, field.Type)
+		}
+	}
+	return nil
+}
+
+// ParseFieldValue converts a value given on the command line into the type
+// declared for the field. Fields without a schema get the natural YAML type
+// of the text: integers, floats and booleans are recognised, anything else
+// is kept as a string.
+func (c *Config) ParseFieldValue(name, raw string) (interface{}, error) {
+	field, ok := c.fieldConfig(name)
+	if !ok {
+		return inferFieldValue(raw), nil
+	}
+	return field.parse(raw)
+}
+
+// ValidateFieldValue checks a value that already has a type, e.g. from a
+// JSON request, against the schema. Strings are parsed like command line
+// values; numbers from JSON are converted to int for int fields.
+func (c *Config) ValidateFieldValue(name string, value interface{}) (interface{}, error) {
+	field, ok := c.fieldConfig(name)
+	if !ok || value == nil {
+		return value, nil
+	}
+	if s, isString := value.(string); isString {
+		return field.parse(s)
+	}
+
+	switch field.Type {
+	case FieldTypeInt:
+		if f, isFloat := value.(float64); isFloat && f == math.Trunc(f) {
+			return int(f), nil
+		}
+		if i, isInt := value.(int); isInt {
+			return i, nil
+		}
+	case FieldTypeFloat:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case int:
+			return float64(v), nil
+		}
+	case FieldTypeBool:
+		if b, isBool := value.(bool); isBool {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("field %q expects a %s value", name, field.typeName())
+}
+
+func (c *Config) fieldConfig(name string) (FieldConfig, bool) {
+	if c == nil {
+		return FieldConfig{}, false
+	}
+	field, ok := c.Fields[name]
+	return field, ok
+}
+
+func (f FieldConfig) typeName() string {
+	if f.Type == "" {
+		return FieldTypeString
+	}
+	return f.Type
+}
+
+func (f FieldConfig) parse(raw string) (interface{}, error) {
+	raw = strings.TrimSpace(raw)
+	invalid := fmt.Errorf("%q is not a valid %s", raw, f.typeName())
+
+	switch f.Type {
+	case "", FieldTypeString:
+		return raw, nil
+	case FieldTypeInt:
+		i, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, invalid
+		}
+		return i, nil
+	case FieldTypeFloat:
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, invalid
+		}
+		return v, nil
+	case FieldTypeBool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, invalid
+		}
+		return b, nil
+	case FieldTypeDate:
+		d, err := time.Parse(constants.DateFormat, raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid date (use YYYY-MM-DD)", raw)
+		}
+		return d.Format(constants.DateFormat), nil
+	case FieldTypeDuration:
+		if _, err := time.ParseDuration(raw); err != nil {
+			return nil, fmt.Errorf("%q is not a valid duration (e.g. 90m, 3h)", raw)
+		}
+		return raw, nil
+	case FieldTypeURL:
+		u, err := url.Parse(raw)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, invalid
+		}
+		return raw, nil
+	case FieldTypeEnum:
+		for _, v := range f.Values {
+			if v == raw {
+				return raw, nil
+			}
+		}
+		return nil, fmt.Errorf("%q is not one of %s", raw, strings.Join(f.Values, ", "))
+	}
+	return nil, fmt.Errorf("unknown field type %q", f.Type)
+}
+
+// inferFieldValue returns raw as a number only if the number is written
+// back as exactly the same text, so values such as "01234" or "1.10" are
+// kept as strings rather than losing digits
+func inferFieldValue(raw string) interface{} {
+	raw = strings.TrimSpace(raw)
+	if i, err := strconv.Atoi(raw); err == nil && strconv.Itoa(i) == raw {
+		return i
+	}
+	if v, err := strconv.ParseFloat(raw, 64); err == nil && !math.IsInf(v, 0) && !math.IsNaN(v) &&
+		strconv.FormatFloat(v, 'f', -1, 64) == raw {
+		return v
+	}
+	switch raw {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return raw
+}
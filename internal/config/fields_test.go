@@ -0,0 +1,123 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadFields(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mdtask.toml")
+	content := `
+[fields.estimate]
+type = "duration"
+
+[fields.priority]
+type = "enum"
+values = ["low", "high"]
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := map[string]FieldConfig{
+		"estimate": {Type: FieldTypeDuration},
+		"priority": {Type: FieldTypeEnum, Values: []string{"low", "high"}},
+	}
+	if !reflect.DeepEqual(cfg.Fields, want) {
+		t.Errorf("Fields = %#v, want %#v", cfg.Fields, want)
+	}
+
+	if err := os.WriteFile(path, []byte("[fields.x]\ntype = \"color\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("expected error for unknown field type")
+	}
+}
+
+func TestParseFieldValue(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Fields = map[string]FieldConfig{
+		"points":   {Type: FieldTypeInt},
+		"ratio":    {Type: FieldTypeFloat},
+		"billable": {Type: FieldTypeBool},
+		"due":      {Type: FieldTypeDate},
+		"estimate": {Type: FieldTypeDuration},
+		"url":      {Type: FieldTypeURL},
+		"priority": {Type: FieldTypeEnum, Values: []string{"low", "high"}},
+		"code":     {Type: FieldTypeString},
+	}
+
+	tests := []struct {
+		name    string
+		field   string
+		raw     string
+		want    interface{}
+		wantErr bool
+	}{
+		{"int", "points", "3", 3, false},
+		{"invalid int", "points", "three", nil, true},
+		{"float", "ratio", "0.5", 0.5, false},
+		{"bool", "billable", "true", true, false},
+		{"date", "due", "2024-03-01", "2024-03-01", false},
+		{"invalid date", "due", "tomorrow", nil, true},
+		{"duration", "estimate", "3h", "3h", false},
+		{"invalid duration", "estimate", "soon", nil, true},
+		{"url", "url", "https://example.com/x", "https://example.com/x", false},
+		{"invalid url", "url", "example", nil, true},
+		{"enum", "priority", "high", "high", false},
+		{"invalid enum", "priority", "urgent", nil, true},
+		{"string keeps digits", "code", "007", "007", false},
+		{"inferred int", "other", "42", 42, false},
+		{"inferred float", "other", "1.5", 1.5, false},
+		{"inferred bool", "other", "false", false, false},
+		{"inferred string", "other", "alice", "alice", false},
+		{"inferred trailing zero kept", "other", "1.10", "1.10", false},
+		{"inferred leading zero kept", "other", "01234", "01234", false},
+		{"inferred exponent kept", "other", "1e3", "1e3", false},
+		{"inferred negative int", "other", "-7", -7, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cfg.ParseFieldValue(tt.field, tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFieldValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseFieldValue() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateFieldValue(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Fields = map[string]FieldConfig{
+		"points":   {Type: FieldTypeInt},
+		"billable": {Type: FieldTypeBool},
+	}
+
+	if got, err := cfg.ValidateFieldValue("points", float64(3)); err != nil || got != 3 {
+		t.Errorf("ValidateFieldValue(points, 3.0) = %#v, %v", got, err)
+	}
+	if got, err := cfg.ValidateFieldValue("points", "4"); err != nil || got != 4 {
+		t.Errorf("ValidateFieldValue(points, \"4\") = %#v, %v", got, err)
+	}
+	if _, err := cfg.ValidateFieldValue("points", 2.5); err == nil {
+		t.Error("expected error for fractional int")
+	}
+	if _, err := cfg.ValidateFieldValue("billable", float64(1)); err == nil {
+		t.Error("expected error for number in bool field")
+	}
+	if got, err := cfg.ValidateFieldValue("owner", "alice"); err != nil || got != "alice" {
+		t.Errorf("ValidateFieldValue(owner) = %#v, %v", got, err)
+	}
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Hook events that can be given in the on setting of a hook
+const (
+	HookCreate  = "create"
+	HookUpdate  = "update"
+	HookArchive = "archive"
+	// HookStatus fires on every status change; HookStatus + ":" + name
+	// only when a task moves to that status
+	HookStatus = "status"
+)
+
+// HookConfig runs a shell command or calls a webhook when tasks change.
+// Hooks are declared as [[hooks]] tables.
+type HookConfig struct {
+	// On is the event: create, update, archive, status or status:<STATUS>
+	On string `toml:"on"`
+
+	// Command is run with sh -c and gets the event JSON on stdin
+	Command string `toml:"command"`
+
+	// URL receives the event JSON in a POST request
+	URL string `toml:"url"`
+
+	// Headers are added to webhook requests, e.g. Authorization
+	Headers map[string]string `toml:"headers"`
+
+	// Retries is how often a failed webhook request is repeated; 0 means 3
+	Retries int `toml:"retries"`
+
+	// Timeout limits a command or request, e.g. "10s"; empty means 30s
+	Timeout string `toml:"timeout"`
+}
+
+// TimeoutDuration returns the timeout of the hook
+func (h HookConfig) TimeoutDuration() time.Duration {
+	if d, err := time.ParseDuration(h.Timeout); err == nil && d > 0 {
+		return d
+	}
+	return 30 * time.Second
+}
+
+// validateHooks checks the [[hooks]] tables and puts the status of
+// status:<STATUS> events in its declared spelling
+func (c *Config) validateHooks() error {
+	workflow := c.GetWorkflow()
+	for i := range c.Hooks {
+		h := &c.Hooks[i]
+		name := fmt.Sprintf("hooks[%d]", i)
+
+		event, status, hasStatus := strings.Cut(strings.TrimSpace(h.On), ":")
+		switch {
+		case event == HookStatus && hasStatus:
+			parsed, err := workflow.Parse(status)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			h.On = HookStatus + ":" + string(parsed)
+		case hasStatus:
+			return fmt.Errorf("%s: unknown event %q", name, h.On)
+		case event == HookCreate || event == HookUpdate || event == HookArchive || event == HookStatus:
+			h.On = event
+		case event == "":
+			return fmt.Errorf("%s: on is required (create, update, archive, status or status:<STATUS>)", name)
+		default:
+			return fmt.Errorf("%s: unknown event %q", name, h.On)
+		}
+
+		if (h.Command == "") == (h.URL == "") {
+			return fmt.Errorf("%s: set either command or url", name)
+		}
+		if h.URL != "" {
+			u, err := url.Parse(h.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("%s: url must be an http or https URL, got %q", name, h.URL)
+			}
+		}
+		if h.Retries < 0 {
+			return fmt.Errorf("%s: retries must not be negative", name)
+		}
+		if h.Timeout != "" {
+			if d, err := time.ParseDuration(h.Timeout); err != nil || d <= 0 {
+				return fmt.Errorf("%s: timeout must be a duration such as \"10s\", got %q", name, h.Timeout)
+			}
+		}
+	}
+	return nil
+}
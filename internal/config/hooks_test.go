@@ -0,0 +1,75 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoadHooks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mdtask.toml")
+	content := `
+[[hooks]]
+on = "status:done"
+command = "notify-team"
+
+[[hooks]]
+on = "create"
+url = "https://example.com/hook"
+headers = { Authorization = "Bearer token" }
+retries = 5
+timeout = "5s"
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := []HookConfig{
+		{On: "status:DONE", Command: "notify-team"},
+		{On: "create", URL: "https://example.com/hook", Headers: map[string]string{"Authorization": "Bearer token"}, Retries: 5, Timeout: "5s"},
+	}
+	if !reflect.DeepEqual(cfg.Hooks, want) {
+		t.Errorf("Hooks = %#v, want %#v", cfg.Hooks, want)
+	}
+	if got := cfg.Hooks[0].TimeoutDuration(); got != 30*time.Second {
+		t.Errorf("default timeout = %v", got)
+	}
+	if got := cfg.Hooks[1].TimeoutDuration(); got != 5*time.Second {
+		t.Errorf("timeout = %v", got)
+	}
+}
+
+func TestLoadHooksInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"missing event", "[[hooks]]\ncommand = \"true\"\n"},
+		{"unknown event", "[[hooks]]\non = \"delete\"\ncommand = \"true\"\n"},
+		{"unknown status", "[[hooks]]\non = \"status:FINISHED\"\ncommand = \"true\"\n"},
+		{"event with argument", "[[hooks]]\non = \"create:TODO\"\ncommand = \"true\"\n"},
+		{"no action", "[[hooks]]\non = \"create\"\n"},
+		{"both actions", "[[hooks]]\non = \"create\"\ncommand = \"true\"\nurl = \"https://example.com\"\n"},
+		{"bad url", "[[hooks]]\non = \"create\"\nurl = \"example.com/hook\"\n"},
+		{"negative retries", "[[hooks]]\non = \"create\"\nurl = \"https://example.com\"\nretries = -1\n"},
+		{"bad timeout", "[[hooks]]\non = \"create\"\ncommand = \"true\"\ntimeout = \"soon\"\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "mdtask.toml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Load(path); err == nil {
+				t.Error("expected Load() to fail")
+			}
+		})
+	}
+}
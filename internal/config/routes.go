@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// RouteConfig sends new tasks carrying a tag to a directory. Routes are
+// declared as [[routes]] tables and the first matching one is used.
+type RouteConfig struct {
+	// Tag matches the tag itself and its children, e.g. "project/foo"
+	// also matches "project/foo/api"
+	Tag string `toml:"tag"`
+
+	// Dir is where matching tasks are written. It must be one of the task
+	// directories or inside one; "~/" is the home directory.
+	Dir string `toml:"dir"`
+}
+
+// Matches reports whether any of tags selects the route
+func (r RouteConfig) Matches(tags []string) bool {
+	for _, tag := range tags {
+		if tag == r.Tag || strings.HasPrefix(tag, r.Tag+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Directory returns Dir with a leading "~/" expanded
+func (r RouteConfig) Directory() string {
+	if rest, ok := strings.CutPrefix(r.Dir, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return r.Dir
+}
+
+// subfolderTokens are the placeholders of storage.subfolders
+var subfolderTokens = strings.NewReplacer("YYYY", "2006", "MM", "01", "DD", "02")
+
+// Subfolder returns the dated subfolder of storage.subfolders for a task
+// created at t, e.g. "2025/06" for "YYYY/MM", or "" when it is not set
+func (s StorageConfig) Subfolder(t time.Time) string {
+	if s.Subfolders == "" {
+		return ""
+	}
+	return filepath.FromSlash(t.Format(subfolderTokens.Replace(s.Subfolders)))
+}
+
+func (c *Config) validateRoutes() error {
+	for i, r := range c.Routes {
+		name := fmt.Sprintf("routes[%d]", i)
+		if strings.TrimSpace(r.Tag) == "" {
+			return fmt.Errorf("%s: tag is required", name)
+		}
+		if strings.TrimSpace(r.Dir) == "" {
+			return fmt.Errorf("%s: dir is required", name)
+		}
+	}
+
+	layout := c.Storage.Subfolders
+	rest := strings.NewReplacer("YYYY", "", "MM", "", "DD", "").Replace(layout)
+	if strings.Trim(rest, "/-_") != "" || strings.HasPrefix(layout, "/") || strings.Contains(layout, "..") {
+		return fmt.Errorf("storage.subfolders must be built from YYYY, MM and DD, e.g. \"YYYY/MM\", got %q", layout)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRouteConfig_Matches(t *testing.T) {
+	route := RouteConfig{Tag: "project/foo", Dir: "tasks/foo"}
+	tests := []struct {
+		name string
+		tags []string
+		want bool
+	}{
+		{"exact tag", []string{"mdtask", "project/foo"}, true},
+		{"child tag", []string{"project/foo/api"}, true},
+		{"tag with same prefix", []string{"project/foobar"}, false},
+		{"parent tag", []string{"project"}, false},
+		{"no tags", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := route.Matches(tt.tags); got != tt.want {
+				t.Errorf("Matches(%v) = %v, want %v", tt.tags, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouteConfig_Directory(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	if got := (RouteConfig{Dir: "~/tasks/work"}).Directory(); got != filepath.Join(home, "tasks", "work") {
+		t.Errorf("Directory() = %q", got)
+	}
+	if got := (RouteConfig{Dir: "tasks/work"}).Directory(); got != "tasks/work" {
+		t.Errorf("Directory() = %q", got)
+	}
+}
+
+func TestStorageConfig_Subfolder(t *testing.T) {
+	created := time.Date(2025, 6, 29, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		layout string
+		want   string
+	}{
+		{"", ""},
+		{"YYYY/MM", filepath.Join("2025", "06")},
+		{"YYYY/MM/DD", filepath.Join("2025", "06", "29")},
+		{"YYYY-MM", "2025-06"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.layout, func(t *testing.T) {
+			s := StorageConfig{Subfolders: tt.layout}
+			if got := s.Subfolder(created); got != tt.want {
+				t.Errorf("Subfolder() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRoutes(t *testing.T) {
+	tests := []struct {
+		name       string
+		routes     []RouteConfig
+		subfolders string
+		wantErr    bool
+	}{
+		{name: "none"},
+		{name: "valid", routes: []RouteConfig{{Tag: "work", Dir: "tasks/work"}}, subfolders: "YYYY/MM"},
+		{name: "missing tag", routes: []RouteConfig{{Dir: "tasks/work"}}, wantErr: true},
+		{name: "missing dir", routes: []RouteConfig{{Tag: "work"}}, wantErr: true},
+		{name: "unknown token", subfolders: "YYYY/week", wantErr: true},
+		{name: "absolute subfolders", subfolders: "/YYYY", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultConfig()
+			c.Routes = tt.routes
+			c.Storage.Subfolders = tt.subfolders
+			if err := c.validateRoutes(); (err != nil) != tt.wantErr {
+				t.Errorf("validateRoutes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// WorkflowConfig declares the task statuses and how tasks move between them
+type WorkflowConfig struct {
+	// Statuses in display order. Defaults to TODO, WIP, WAIT, SCHE, DONE.
+	Statuses []StatusConfig `toml:"statuses"`
+
+	// Statuses that count as finished, e.g. for statistics
+	// Defaults to DONE if declared, otherwise the last status.
+	Done []string `toml:"done"`
+
+	// Allowed transitions, keyed by the current status. Statuses without an
+	// entry may move to any status.
+	Transitions map[string][]string `toml:"transitions"`
+
+	// Status a task moves to when its timer is started
+	// Defaults to WIP if declared; otherwise the status is left alone.
+	Active string `toml:"active"`
+}
+
+// StatusConfig describes a single status
+type StatusConfig struct {
+	// Name as stored in the mdtask/status/<name> tag
+	Name string `toml:"name"`
+
+	// Human readable label, defaults to the name
+	Label string `toml:"label"`
+
+	// Colour as #rrggbb, used by the web UI and the TUI
+	Color string `toml:"color"`
+}
+
+// defaultStatuses matches the colours of the web UI theme
+var defaultStatuses = []StatusConfig{
+	{Name: string(task.StatusTODO), Label: "To Do", Color: "#3B82F6"},
+	{Name: string(task.StatusWIP), Label: "In Progress", Color: "#F59E0B"},
+	{Name: string(task.StatusWAIT), Label: "Waiting", Color: "#6B7280"},
+	{Name: string(task.StatusSCHE), Label: "Scheduled", Color: "#8B5CF6"},
+	{Name: string(task.StatusDONE), Label: "Done", Color: "#10B981"},
+}
+
+// defaultStatusColor is used for statuses without a colour
+const defaultStatusColor = "#6B7280"
+
+var (
+	statusNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	colorPattern      = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+)
+
+// Workflow is the resolved status workflow with defaults applied
+type Workflow struct {
+	statuses    []StatusConfig
+	index       map[task.Status]int
+	done        map[task.Status]bool
+	transitions map[task.Status][]task.Status
+	active      task.Status
+}
+
+// DefaultWorkflow returns the built-in TODO/WIP/WAIT/SCHE/DONE workflow
+func DefaultWorkflow() *Workflow {
+	return NewWorkflow(WorkflowConfig{})
+}
+
+// NewWorkflow resolves a workflow configuration. Missing parts fall back to
+// the defaults; use Validate to report configuration errors.
+func NewWorkflow(wc WorkflowConfig) *Workflow {
+	statuses := wc.Statuses
+	if len(statuses) == 0 {
+		statuses = defaultStatuses
+	}
+
+	w := &Workflow{
+		statuses:    make([]StatusConfig, 0, len(statuses)),
+		index:       make(map[task.Status]int),
+		done:        make(map[task.Status]bool),
+		transitions: make(map[task.Status][]task.Status),
+	}
+	for _, s := range statuses {
+		if s.Label == "" {
+			s.Label = s.Name
+		}
+		if s.Color == "" {
+			s.Color = defaultStatusColor
+		}
+		if _, dup := w.index[task.Status(s.Name)]; dup {
+			continue
+		}
+		w.index[task.Status(s.Name)] = len(w.statuses)
+		w.statuses = append(w.statuses, s)
+	}
+
+	done := wc.Done
+	if len(done) == 0 {
+		if _, ok := w.index[task.StatusDONE]; ok {
+			done = []string{string(task.StatusDONE)}
+		} else if len(w.statuses) > 0 {
+			done = []string{w.statuses[len(w.statuses)-1].Name}
+		}
+	}
+	for _, name := range done {
+		w.done[task.Status(name)] = true
+	}
+
+	switch {
+	case wc.Active != "":
+		w.active = task.Status(wc.Active)
+	case w.IsValid(task.StatusWIP):
+		w.active = task.StatusWIP
+	}
+
+	for from, targets := range wc.Transitions {
+		allowed := make([]task.Status, len(targets))
+		for i, to := range targets {
+			allowed[i] = task.Status(to)
+		}
+		w.transitions[task.Status(from)] = allowed
+	}
+
+	return w
+}
+
+// GetWorkflow returns the configured workflow, or the default one
+func (c *Config) GetWorkflow() *Workflow {
+	if c == nil {
+		return DefaultWorkflow()
+	}
+	return NewWorkflow(c.Workflow)
+}
+
+// validateWorkflow checks the [workflow] section and the default status
+func (c *Config) validateWorkflow() error {
+	for _, s := range c.Workflow.Statuses {
+		if !statusNamePattern.MatchString(s.Name) {
+			return fmt.Errorf("workflow: invalid status name %q", s.Name)
+		}
+		if s.Color != "" && !colorPattern.MatchString(s.Color) {
+			return fmt.Errorf("workflow: status %s: color must be #rrggbb, got %q", s.Name, s.Color)
+		}
+	}
+
+	w := c.GetWorkflow()
+	if len(w.statuses) != len(c.Workflow.Statuses) && len(c.Workflow.Statuses) > 0 {
+		return fmt.Errorf("workflow: duplicate status names")
+	}
+	for _, name := range c.Workflow.Done {
+		if !w.IsValid(task.Status(name)) {
+			return fmt.Errorf("workflow: done status %q is not declared", name)
+		}
+	}
+	for from, targets := range c.Workflow.Transitions {
+		if !w.IsValid(task.Status(from)) {
+			return fmt.Errorf("workflow: transition from undeclared status %q", from)
+		}
+		for _, to := range targets {
+			if !w.IsValid(task.Status(to)) {
+				return fmt.Errorf("workflow: transition from %s to undeclared status %q", from, to)
+			}
+		}
+	}
+	if c.Workflow.Active != "" && !w.IsValid(task.Status(c.Workflow.Active)) {
+		return fmt.Errorf("workflow: active status %q is not declared", c.Workflow.Active)
+	}
+	if c.Task.DefaultStatus != "" && !w.IsValid(task.Status(c.Task.DefaultStatus)) {
+		return fmt.Errorf("task.default_status %q is not a workflow status", c.Task.DefaultStatus)
+	}
+	return nil
+}
+
+// Statuses returns the statuses in display order
+func (w *Workflow) Statuses() []StatusConfig {
+	return w.statuses
+}
+
+// Names returns the status names in display order
+func (w *Workflow) Names() []task.Status {
+	names := make([]task.Status, len(w.statuses))
+	for i, s := range w.statuses {
+		names[i] = task.Status(s.Name)
+	}
+	return names
+}
+
+// String lists the status names, e.g. for help texts and error messages
+func (w *Workflow) String() string {
+	names := make([]string, len(w.statuses))
+	for i, s := range w.statuses {
+		names[i] = s.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// IsValid reports whether status is declared in the workflow
+func (w *Workflow) IsValid(status task.Status) bool {
+	_, ok := w.index[status]
+	return ok
+}
+
+// Parse resolves a status name case-insensitively
+func (w *Workflow) Parse(name string) (task.Status, error) {
+	for _, s := range w.statuses {
+		if strings.EqualFold(s.Name, strings.TrimSpace(name)) {
+			return task.Status(s.Name), nil
+		}
+	}
+	return "", fmt.Errorf("invalid status: %s (valid: %s)", name, w.String())
+}
+
+// Lookup returns the configuration of a status
+func (w *Workflow) Lookup(status task.Status) (StatusConfig, bool) {
+	i, ok := w.index[status]
+	if !ok {
+		return StatusConfig{Name: string(status), Label: string(status), Color: defaultStatusColor}, false
+	}
+	return w.statuses[i], true
+}
+
+// Label returns the display label of a status
+func (w *Workflow) Label(status task.Status) string {
+	s, _ := w.Lookup(status)
+	return s.Label
+}
+
+// Color returns the #rrggbb colour of a status
+func (w *Workflow) Color(status task.Status) string {
+	s, _ := w.Lookup(status)
+	return s.Color
+}
+
+// Order returns the display position of a status; unknown statuses sort last
+func (w *Workflow) Order(status task.Status) int {
+	if i, ok := w.index[status]; ok {
+		return i
+	}
+	return len(w.statuses)
+}
+
+// IsDone reports whether status counts as finished
+func (w *Workflow) IsDone(status task.Status) bool {
+	return w.done[status]
+}
+
+// ActiveStatus returns the status of tasks being worked on, which a task
+// moves to when its timer is started. ok is false if there is none.
+func (w *Workflow) ActiveStatus() (status task.Status, ok bool) {
+	return w.active, w.active != ""
+}
+
+// DoneStatuses returns the finished statuses in display order
+func (w *Workflow) DoneStatuses() []task.Status {
+	var result []task.Status
+	for _, name := range w.Names() {
+		if w.done[name] {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// CanTransition reports whether a task may move from one status to another.
+// Staying in the same status and leaving a status without rules is always
+// allowed.
+func (w *Workflow) CanTransition(from, to task.Status) bool {
+	if from == to {
+		return true
+	}
+	allowed, ok := w.transitions[from]
+	if !ok {
+		return true
+	}
+	for _, s := range allowed {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckTransition returns an error if to is not a declared status or the
+// workflow does not allow moving there from the current status
+func (w *Workflow) CheckTransition(from, to task.Status) error {
+	if !w.IsValid(to) {
+		return fmt.Errorf("unknown status %s (valid: %s)", to, w.String())
+	}
+	if !w.CanTransition(from, to) {
+		return fmt.Errorf("transition from %s to %s is not allowed", from, to)
+	}
+	return nil
+}
+
+// NextStatuses returns the statuses a task in from may move to, including
+// from itself, in display order
+func (w *Workflow) NextStatuses(from task.Status) []task.Status {
+	var result []task.Status
+	for _, name := range w.Names() {
+		if w.CanTransition(from, name) {
+			result = append(result, name)
+		}
+	}
+	return result
+}
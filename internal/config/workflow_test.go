@@ -0,0 +1,172 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+func TestDefaultWorkflow(t *testing.T) {
+	w := DefaultConfig().GetWorkflow()
+
+	want := []task.Status{task.StatusTODO, task.StatusWIP, task.StatusWAIT, task.StatusSCHE, task.StatusDONE}
+	if got := w.Names(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() = %v, want %v", got, want)
+	}
+	if !w.IsDone(task.StatusDONE) || w.IsDone(task.StatusWIP) {
+		t.Error("only DONE should count as done by default")
+	}
+	if !w.CanTransition(task.StatusDONE, task.StatusTODO) {
+		t.Error("default workflow should allow any transition")
+	}
+	if w.Label(task.StatusWIP) != "In Progress" {
+		t.Errorf("Label(WIP) = %q", w.Label(task.StatusWIP))
+	}
+	if status, ok := w.ActiveStatus(); !ok || status != task.StatusWIP {
+		t.Errorf("ActiveStatus() = %q, %v, want WIP", status, ok)
+	}
+}
+
+func TestLoadWorkflow(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mdtask.toml")
+	content := `
+[task]
+default_status = "TODO"
+
+[workflow]
+done = ["DONE", "CANCELLED"]
+
+[[workflow.statuses]]
+name = "TODO"
+color = "#3B82F6"
+
+[[workflow.statuses]]
+name = "REVIEW"
+label = "In Review"
+
+[[workflow.statuses]]
+name = "DONE"
+
+[[workflow.statuses]]
+name = "CANCELLED"
+
+[workflow.transitions]
+TODO = ["REVIEW", "CANCELLED"]
+REVIEW = ["TODO", "DONE"]
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	w := cfg.GetWorkflow()
+
+	if got := w.String(); got != "TODO, REVIEW, DONE, CANCELLED" {
+		t.Errorf("String() = %q", got)
+	}
+	if w.IsValid(task.StatusWIP) {
+		t.Error("WIP should not be part of the custom workflow")
+	}
+	if w.Label("REVIEW") != "In Review" || w.Label("DONE") != "DONE" {
+		t.Errorf("unexpected labels %q, %q", w.Label("REVIEW"), w.Label("DONE"))
+	}
+	if w.Color("DONE") != defaultStatusColor {
+		t.Errorf("Color(DONE) = %q, want default", w.Color("DONE"))
+	}
+	if !w.IsDone("CANCELLED") || !reflect.DeepEqual(w.DoneStatuses(), []task.Status{"DONE", "CANCELLED"}) {
+		t.Errorf("DoneStatuses() = %v", w.DoneStatuses())
+	}
+
+	tests := []struct {
+		from, to task.Status
+		want     bool
+	}{
+		{"TODO", "REVIEW", true},
+		{"TODO", "DONE", false},
+		{"REVIEW", "DONE", true},
+		{"DONE", "TODO", true}, // no rules for DONE
+		{"TODO", "TODO", true},
+	}
+	for _, tt := range tests {
+		if got := w.CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+	if err := w.CheckTransition("TODO", "DONE"); err == nil {
+		t.Error("CheckTransition(TODO, DONE) should fail")
+	}
+	if err := w.CheckTransition("REVIEW", "WIP"); err == nil {
+		t.Error("CheckTransition to an undeclared status should fail")
+	}
+	if got := w.NextStatuses("TODO"); !reflect.DeepEqual(got, []task.Status{"TODO", "REVIEW", "CANCELLED"}) {
+		t.Errorf("NextStatuses(TODO) = %v", got)
+	}
+
+	if status, err := w.Parse("review"); err != nil || status != "REVIEW" {
+		t.Errorf("Parse(review) = %q, %v", status, err)
+	}
+	if _, err := w.Parse("WIP"); err == nil {
+		t.Error("Parse(WIP) should fail for the custom workflow")
+	}
+	if status, ok := w.ActiveStatus(); ok {
+		t.Errorf("ActiveStatus() = %q without WIP or an active status", status)
+	}
+
+	active := NewWorkflow(WorkflowConfig{Statuses: cfg.Workflow.Statuses, Active: "REVIEW"})
+	if status, ok := active.ActiveStatus(); !ok || status != "REVIEW" {
+		t.Errorf("ActiveStatus() = %q, %v, want REVIEW", status, ok)
+	}
+}
+
+func TestLoadWorkflowDefaultStatus(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mdtask.toml")
+	content := "[[workflow.statuses]]\nname = \"BACKLOG\"\n[[workflow.statuses]]\nname = \"CLOSED\"\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Task.DefaultStatus != "BACKLOG" {
+		t.Errorf("DefaultStatus = %q, want the first workflow status", cfg.Task.DefaultStatus)
+	}
+	if !cfg.GetWorkflow().IsDone("CLOSED") {
+		t.Error("the last status should count as done when DONE is not declared")
+	}
+}
+
+func TestLoadWorkflowInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"bad name", "[[workflow.statuses]]\nname = \"IN REVIEW\"\n"},
+		{"bad color", "[[workflow.statuses]]\nname = \"TODO\"\ncolor = \"blue\"\n"},
+		{"duplicate", "[[workflow.statuses]]\nname = \"TODO\"\n[[workflow.statuses]]\nname = \"TODO\"\n"},
+		{"unknown done", "[workflow]\ndone = [\"FINISHED\"]\n"},
+		{"unknown transition", "[workflow.transitions]\nTODO = [\"REVIEW\"]\n"},
+		{"default status", "[task]\ndefault_status = \"NEW\"\n"},
+		{"unknown active", "[workflow]\nactive = \"DOING\"\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "mdtask.toml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Load(path); err == nil {
+				t.Error("expected Load() to fail")
+			}
+		})
+	}
+}
@@ -4,13 +4,16 @@ import "time"
 
 // Tag prefixes
 const (
-	TagPrefix         = "mdtask"
-	StatusTagPrefix   = "mdtask/status/"
-	ArchivedTag       = "mdtask/archived"
-	DeadlineTagPrefix = "mdtask/deadline/"
-	WaitForTagPrefix  = "mdtask/waitfor/"
-	ReminderTagPrefix = "mdtask/reminder/"
-	ParentTagPrefix   = "mdtask/parent/"
+	TagPrefix          = "mdtask"
+	StatusTagPrefix    = "mdtask/status/"
+	ArchivedTag        = "mdtask/archived"
+	DeadlineTagPrefix  = "mdtask/deadline/"
+	WaitForTagPrefix   = "mdtask/waitfor/"
+	ReminderTagPrefix  = "mdtask/reminder/"
+	ParentTagPrefix    = "mdtask/parent/"
+	RecurTagPrefix     = "mdtask/recur/"
+	PreviousTagPrefix  = "mdtask/previous/"
+	BlockedByTagPrefix = "mdtask/blockedby/"
 )
 
 // Status values
@@ -43,6 +46,13 @@ const (
 	DefaultSearchPath   = "."
 	ConfigFilename      = ".mdtask.toml"
 	AltConfigFilename   = "mdtask.toml"
+	StateDirName        = ".mdtask"
+	IndexFilename       = "index.json"
+	LockFilename        = "write.lock"
+	ReminderStateFilename = "reminders.json"
+	ReminderLockFilename = "reminders.lock"
+	TrashDirName        = "trash"
+	IgnoreFilename      = ".mdtaskignore"
 )
 
 // Web server constants
@@ -54,7 +64,12 @@ const (
 
 // Time constants
 const (
-	GenerateIDSleepDuration = time.Second
 	ReminderCheckInterval   = 5 * time.Minute
+	ReminderRetryInterval   = time.Minute
+	ReminderCatchUp         = 24 * time.Hour
+	WatchPollInterval       = 2 * time.Second
+	LockTimeout             = 10 * time.Second
+	LockRetryInterval       = 50 * time.Millisecond
+	HookFlushTimeout        = 10 * time.Second
 	WeekDuration           = 7 * 24 * time.Hour
 )
\ No newline at end of file
@@ -0,0 +1,54 @@
+// Package filelock provides advisory locks on files, which keep separate
+// mdtask processes (e.g. `web`, `mcp` and `remind --daemon`) from
+// interleaving changes to the same files.
+package filelock
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/constants"
+)
+
+// Lock is an exclusive advisory lock held on a file
+type Lock struct {
+	f *os.File
+}
+
+// Acquire blocks until the lock on path is held or timeout expires. The
+// file and its directory are created if missing.
+func Acquire(path string, timeout time.Duration) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), constants.DirPermission); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, constants.FilePermission)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		ok, err := tryLockFile(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if ok {
+			return &Lock{f: f}, nil
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("timed out after %s waiting for lock %s", timeout, f.Name())
+		}
+		time.Sleep(constants.LockRetryInterval)
+	}
+}
+
+// Release unlocks and closes the lock file
+func (l *Lock) Release() {
+	unlockFile(l.f)
+	l.f.Close()
+}
@@ -0,0 +1,318 @@
+// Package hooks runs the shell commands and webhooks configured as
+// [[hooks]] when tasks are created, updated or archived.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// nestedEnv is set for hook commands. Changes made while it is set fire no
+// hooks, so a hook calling mdtask cannot trigger itself again.
+const nestedEnv = "MDTASK_HOOK"
+
+// defaultRetries is how often a failed webhook request is repeated
+const defaultRetries = 3
+
+// queueSize bounds the deliveries waiting to run. Hooks fired while the
+// queue is full are dropped and reported.
+const queueSize = 256
+
+// Payload is the JSON a hook receives
+type Payload struct {
+	// Event is create, update, archive or status:<STATUS>
+	Event string    `json:"event"`
+	Time  time.Time `json:"time"`
+	Task  TaskData  `json:"task"`
+	// PreviousStatus is set when the status changed
+	PreviousStatus string `json:"previous_status,omitempty"`
+}
+
+// TaskData is a task in a hook payload
+type TaskData struct {
+	ID          string                 `json:"id"`
+	Title       string                 `json:"title"`
+	Description string                 `json:"description"`
+	Status      string                 `json:"status"`
+	Tags        []string               `json:"tags"`
+	Created     time.Time              `json:"created"`
+	Updated     time.Time              `json:"updated"`
+	Deadline    *time.Time             `json:"deadline,omitempty"`
+	Reminder    *time.Time             `json:"reminder,omitempty"`
+	Archived    bool                   `json:"archived"`
+	ParentID    string                 `json:"parent_id,omitempty"`
+	BlockedBy   []string               `json:"blocked_by,omitempty"`
+	Fields      map[string]interface{} `json:"fields,omitempty"`
+	Content     string                 `json:"content,omitempty"`
+}
+
+func newTaskData(t *task.Task) TaskData {
+	tags := t.UserTags()
+	if tags == nil {
+		tags = []string{}
+	}
+	return TaskData{
+		ID:          t.ID,
+		Title:       t.Title,
+		Description: t.Description,
+		Status:      string(t.GetStatus()),
+		Tags:        tags,
+		Created:     t.Created,
+		Updated:     t.Updated,
+		Deadline:    t.GetDeadline(),
+		Reminder:    t.GetReminder(),
+		Archived:    t.IsArchived(),
+		ParentID:    t.GetParentID(),
+		BlockedBy:   t.GetBlockedBy(),
+		Fields:      t.Fields,
+		Content:     t.Content,
+	}
+}
+
+// Events returns the events of a change from before to after. Before is
+// nil for a new task. Archiving is not also an update.
+func Events(before, after *task.Task) []string {
+	if before == nil {
+		return []string{config.HookCreate}
+	}
+
+	var events []string
+	if !before.IsArchived() && after.IsArchived() {
+		events = append(events, config.HookArchive)
+	} else {
+		events = append(events, config.HookUpdate)
+	}
+	if status := after.GetStatus(); status != before.GetStatus() {
+		events = append(events, config.HookStatus+":"+string(status))
+	}
+	return events
+}
+
+// matches reports whether a hook declared for on handles event
+func matches(on, event string) bool {
+	return on == event || (on == config.HookStatus && strings.HasPrefix(event, config.HookStatus+":"))
+}
+
+// Dispatcher runs the hooks matching the events of task changes. Fire only
+// queues the deliveries; they run one after another in the background, so
+// a slow hook never delays the change. A failing hook is reported on Log
+// but never undoes or fails the change.
+type Dispatcher struct {
+	hooks []config.HookConfig
+
+	// Log receives the output of commands and hook failures
+	Log io.Writer
+
+	// Tests replace the clock, the HTTP client and the wait between retries
+	now    func() time.Time
+	client *http.Client
+	sleep  func(time.Duration)
+
+	// pending counts the deliveries of this dispatcher not done yet
+	pending sync.WaitGroup
+}
+
+// delivery is a hook to run for one event
+type delivery struct {
+	d       *Dispatcher
+	hook    config.HookConfig
+	payload Payload
+	body    []byte
+}
+
+// The deliveries of every dispatcher share one queue and one worker, so
+// hooks run in the order of the changes, also when each request of a
+// server uses its own dispatcher
+var (
+	queue     = make(chan delivery, queueSize)
+	startOnce sync.Once
+	pending   sync.WaitGroup
+)
+
+func worker() {
+	for job := range queue {
+		if err := job.d.run(job.hook, job.payload, job.body); err != nil {
+			fmt.Fprintf(job.d.Log, "hook %s for %s failed: %v\n", job.hook.On, job.payload.Task.ID, err)
+		}
+		job.d.pending.Done()
+		pending.Done()
+	}
+}
+
+// Wait blocks until every queued delivery has run, or timeout has passed,
+// and reports whether the queue was drained. Short-lived processes call it
+// before they exit.
+func Wait(timeout time.Duration) bool {
+	return waitGroup(&pending, timeout)
+}
+
+// Wait blocks until the deliveries fired by d have run, or timeout has
+// passed, and reports whether they have
+func (d *Dispatcher) Wait(timeout time.Duration) bool {
+	return waitGroup(&d.pending, timeout)
+}
+
+func waitGroup(wg *sync.WaitGroup, timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// New returns a dispatcher for the configured hooks
+func New(hooks []config.HookConfig) *Dispatcher {
+	return &Dispatcher{
+		hooks:  hooks,
+		Log:    os.Stderr,
+		now:    time.Now,
+		client: http.DefaultClient,
+		sleep:  time.Sleep,
+	}
+}
+
+// Fire queues the hooks for the change of a task from before to after. The
+// payload is taken when Fire is called, so the caller may change the task
+// afterwards.
+func (d *Dispatcher) Fire(before, after *task.Task) {
+	if d == nil || len(d.hooks) == 0 || os.Getenv(nestedEnv) != "" {
+		return
+	}
+
+	previous := ""
+	if before != nil && before.GetStatus() != after.GetStatus() {
+		previous = string(before.GetStatus())
+	}
+	for _, event := range Events(before, after) {
+		payload := Payload{Event: event, Time: d.now(), Task: newTaskData(after), PreviousStatus: previous}
+		for _, h := range d.hooks {
+			if !matches(h.On, event) {
+				continue
+			}
+			body, err := json.Marshal(payload)
+			if err != nil {
+				fmt.Fprintf(d.Log, "hook %s for %s failed: %v\n", h.On, after.ID, err)
+				continue
+			}
+			d.enqueue(delivery{d: d, hook: h, payload: payload, body: body})
+		}
+	}
+}
+
+// enqueue hands a delivery to the worker, or drops it if the queue is full
+func (d *Dispatcher) enqueue(job delivery) {
+	startOnce.Do(func() { go worker() })
+
+	d.pending.Add(1)
+	pending.Add(1)
+	select {
+	case queue <- job:
+	default:
+		d.pending.Done()
+		pending.Done()
+		fmt.Fprintf(d.Log, "hook %s for %s dropped: too many hooks pending\n", job.hook.On, job.payload.Task.ID)
+	}
+}
+
+func (d *Dispatcher) run(h config.HookConfig, payload Payload, body []byte) error {
+	if h.Command != "" {
+		return d.runCommand(h, payload, body)
+	}
+	return d.post(h, body)
+}
+
+// runCommand runs the command of a hook with the payload on stdin and the
+// event and task ID in MDTASK_EVENT and MDTASK_TASK_ID
+func (d *Dispatcher) runCommand(h config.HookConfig, payload Payload, body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.TimeoutDuration())
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", h.Command)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Stdout = d.Log
+	cmd.Stderr = d.Log
+	// Do not wait for background processes holding on to the output
+	cmd.WaitDelay = time.Second
+	cmd.Env = append(os.Environ(),
+		nestedEnv+"=1",
+		"MDTASK_EVENT="+payload.Event,
+		"MDTASK_TASK_ID="+payload.Task.ID,
+	)
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%q timed out after %s", h.Command, h.TimeoutDuration())
+		}
+		return fmt.Errorf("%q: %w", h.Command, err)
+	}
+	return nil
+}
+
+// post sends the payload to the URL of a hook. Network errors, 429 and 5xx
+// responses are retried with a doubling delay starting at one second.
+func (d *Dispatcher) post(h config.HookConfig, body []byte) error {
+	retries := h.Retries
+	if retries == 0 {
+		retries = defaultRetries
+	}
+
+	delay := time.Second
+	var err error
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		retry, err = d.postOnce(h, body)
+		if err == nil || !retry || attempt == retries {
+			break
+		}
+		d.sleep(delay)
+		delay *= 2
+	}
+	return err
+}
+
+// postOnce makes one webhook request and reports whether a failure is
+// worth retrying
+func (d *Dispatcher) postOnce(h config.HookConfig, body []byte) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.TimeoutDuration())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "mdtask")
+	for name, value := range h.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("POST %s: %s", h.URL, resp.Status)
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
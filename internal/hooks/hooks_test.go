@@ -0,0 +1,210 @@
+package hooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+func newTask(status task.Status) *task.Task {
+	tk := &task.Task{ID: "task/20250620090000", Title: "Ship it", Tags: []string{"mdtask", "release"}}
+	tk.SetStatus(status)
+	return tk
+}
+
+func newDispatcher(hooks ...config.HookConfig) (*Dispatcher, *bytes.Buffer) {
+	var log bytes.Buffer
+	d := New(hooks)
+	d.Log = &log
+	d.now = func() time.Time { return time.Date(2025, 6, 20, 9, 0, 0, 0, time.UTC) }
+	d.sleep = func(time.Duration) {}
+	return d, &log
+}
+
+func TestEvents(t *testing.T) {
+	todo := newTask(task.StatusTODO)
+	done := newTask(task.StatusDONE)
+	archived := newTask(task.StatusTODO)
+	archived.Archive()
+	archivedDone := newTask(task.StatusDONE)
+	archivedDone.Archive()
+
+	tests := []struct {
+		name          string
+		before, after *task.Task
+		want          []string
+	}{
+		{"create", nil, todo, []string{"create"}},
+		{"update", todo, todo, []string{"update"}},
+		{"status", todo, done, []string{"update", "status:DONE"}},
+		{"archive", todo, archived, []string{"archive"}},
+		{"archive and status", todo, archivedDone, []string{"archive", "status:DONE"}},
+		{"unarchive", archived, todo, []string{"update"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Events(tt.before, tt.after)
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("Events() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFire_Command(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	d, log := newDispatcher(
+		config.HookConfig{On: "status:DONE", Command: `cat > "` + out + `"; echo "$MDTASK_EVENT $MDTASK_TASK_ID $MDTASK_HOOK"`},
+		config.HookConfig{On: "status:WIP", Command: "echo wrong status"},
+		config.HookConfig{On: "create", Command: "echo wrong event"},
+	)
+
+	d.Fire(newTask(task.StatusTODO), newTask(task.StatusDONE))
+	d.Wait(10 * time.Second)
+
+	if got := log.String(); got != "status:DONE task/20250620090000 1\n" {
+		t.Errorf("log = %q", got)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("hook did not run: %v", err)
+	}
+	var payload Payload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatalf("stdin is not JSON: %v\n%s", err, data)
+	}
+	if payload.Event != "status:DONE" || payload.PreviousStatus != "TODO" || payload.Task.Status != "DONE" {
+		t.Errorf("payload = %+v", payload)
+	}
+	if strings.Join(payload.Task.Tags, ",") != "release" {
+		t.Errorf("payload tags = %v, want only user tags", payload.Task.Tags)
+	}
+}
+
+func TestFire_CommandFailure(t *testing.T) {
+	d, log := newDispatcher(
+		config.HookConfig{On: "status", Command: "exit 3"},
+		config.HookConfig{On: "status", Command: "sleep 5", Timeout: "50ms"},
+	)
+
+	d.Fire(newTask(task.StatusTODO), newTask(task.StatusWIP))
+	d.Wait(10 * time.Second)
+
+	got := log.String()
+	if !strings.Contains(got, `hook status for task/20250620090000 failed: "exit 3": exit status 3`) {
+		t.Errorf("log does not report the failing command:\n%s", got)
+	}
+	if !strings.Contains(got, "timed out after 50ms") {
+		t.Errorf("log does not report the timeout:\n%s", got)
+	}
+}
+
+func TestFire_NestedHook(t *testing.T) {
+	t.Setenv(nestedEnv, "1")
+	out := filepath.Join(t.TempDir(), "out")
+	d, _ := newDispatcher(config.HookConfig{On: "create", Command: "touch " + out})
+
+	d.Fire(nil, newTask(task.StatusTODO))
+	d.Wait(10 * time.Second)
+
+	if _, err := os.Stat(out); err == nil {
+		t.Error("hook ran inside another hook")
+	}
+}
+
+func TestFire_Webhook(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		retries   int
+		wantCalls int
+		wantErr   bool
+	}{
+		{"success", []int{http.StatusNoContent}, 0, 1, false},
+		{"retried server error", []int{500, 502, 200}, 0, 3, false},
+		{"retried rate limit", []int{429, 200}, 0, 2, false},
+		{"client error is not retried", []int{400}, 0, 1, true},
+		{"gives up after retries", []int{500, 500, 500}, 2, 3, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			var body []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" || r.Header.Get("X-Token") != "secret" {
+					t.Errorf("unexpected request %s %v", r.Method, r.Header)
+				}
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.statuses[calls])
+				calls++
+			}))
+			defer server.Close()
+
+			var waits []time.Duration
+			d, log := newDispatcher(config.HookConfig{
+				On:      "create",
+				URL:     server.URL,
+				Headers: map[string]string{"X-Token": "secret"},
+				Retries: tt.retries,
+			})
+			d.sleep = func(wait time.Duration) { waits = append(waits, wait) }
+
+			d.Fire(nil, newTask(task.StatusTODO))
+			d.Wait(10 * time.Second)
+
+			if calls != tt.wantCalls {
+				t.Errorf("made %d requests, want %d", calls, tt.wantCalls)
+			}
+			for i, wait := range waits {
+				if want := time.Second << i; wait != want {
+					t.Errorf("wait %d = %v, want %v", i, wait, want)
+				}
+			}
+			if failed := log.Len() > 0; failed != tt.wantErr {
+				t.Errorf("log = %q, want failure %v", log.String(), tt.wantErr)
+			}
+			var payload Payload
+			if err := json.Unmarshal(body, &payload); err != nil || payload.Event != "create" || payload.Task.ID != "task/20250620090000" {
+				t.Errorf("body = %s", body)
+			}
+		})
+	}
+}
+
+func TestFire_DoesNotWaitForDelivery(t *testing.T) {
+	release := make(chan struct{})
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		calls++
+	}))
+	defer server.Close()
+
+	d, log := newDispatcher(config.HookConfig{On: "create", URL: server.URL})
+	start := time.Now()
+	d.Fire(nil, newTask(task.StatusTODO))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Fire() took %v while the endpoint was still responding", elapsed)
+	}
+	if d.Wait(50 * time.Millisecond) {
+		t.Fatal("Wait() reported the delivery done before the endpoint answered")
+	}
+
+	close(release)
+	if !d.Wait(10*time.Second) || !Wait(10*time.Second) {
+		t.Fatal("delivery did not finish")
+	}
+	if calls != 1 || log.Len() != 0 {
+		t.Errorf("made %d requests, log %q", calls, log.String())
+	}
+}
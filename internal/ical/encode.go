@@ -0,0 +1,167 @@
+package ical
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/service"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// Formats of DATE and UTC DATE-TIME values
+const (
+	dateFormat     = "20060102"
+	dateTimeFormat = "20060102T150405Z"
+)
+
+// reminderDuration is the length of the events created for reminders
+const reminderDuration = "PT15M"
+
+// Encoder writes tasks as a calendar: a VTODO per task, plus an all-day
+// VEVENT on the deadline and a short VEVENT at the reminder of tasks that
+// are not done yet
+type Encoder struct {
+	// Name is the display name of the calendar
+	Name string
+	// Workflow decides which statuses are done or in progress
+	Workflow *config.Workflow
+	// Location is the time zone of the times in task files; nil means the
+	// local time zone
+	Location *time.Location
+}
+
+// NewEncoder returns an encoder for the workflow in the local time zone
+func NewEncoder(workflow *config.Workflow) *Encoder {
+	return &Encoder{Name: "mdtask", Workflow: workflow}
+}
+
+// Encode writes a VCALENDAR with the tasks, ordered by ID
+func (e *Encoder) Encode(w io.Writer, tasks []*task.Task) error {
+	sorted := make([]*task.Task, len(tasks))
+	copy(sorted, tasks)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	out := newWriter(w)
+	out.line("BEGIN:VCALENDAR")
+	out.line("VERSION:2.0")
+	out.line("PRODID:-//mdtask//mdtask//EN")
+	out.line("CALSCALE:GREGORIAN")
+	if e.Name != "" {
+		out.prop("X-WR-CALNAME", e.Name)
+	}
+	for _, t := range sorted {
+		e.writeTodo(out, t)
+		if !e.Workflow.IsDone(t.GetStatus()) {
+			e.writeEvents(out, t)
+		}
+	}
+	out.line("END:VCALENDAR")
+	return out.flush()
+}
+
+func (e *Encoder) writeTodo(out *writer, t *task.Task) {
+	out.line("BEGIN:VTODO")
+	out.line("UID:" + UID(t.ID, ""))
+	e.writeCommon(out, t)
+	out.line("CREATED:" + e.utc(t.Created))
+	out.line("LAST-MODIFIED:" + e.utc(t.Updated))
+
+	status := t.GetStatus()
+	switch {
+	case e.Workflow.IsDone(status):
+		out.line("STATUS:COMPLETED")
+		if done := service.CompletedAt(t, e.Workflow); done != nil {
+			out.line("COMPLETED:" + e.utc(*done))
+		}
+	case e.isActive(status):
+		out.line("STATUS:IN-PROCESS")
+	default:
+		out.line("STATUS:NEEDS-ACTION")
+	}
+	if deadline := t.GetDeadline(); deadline != nil {
+		out.line("DUE;VALUE=DATE:" + deadline.Format(dateFormat))
+	}
+	if parent := t.GetParentID(); parent != "" {
+		out.line("RELATED-TO;RELTYPE=PARENT:" + UID(parent, ""))
+	}
+	if reminder := t.GetReminder(); reminder != nil && !e.Workflow.IsDone(status) {
+		writeAlarm(out, t, "TRIGGER;VALUE=DATE-TIME:"+e.utc(*reminder))
+	}
+	out.line("END:VTODO")
+}
+
+func (e *Encoder) writeEvents(out *writer, t *task.Task) {
+	if deadline := t.GetDeadline(); deadline != nil {
+		out.line("BEGIN:VEVENT")
+		out.line("UID:" + UID(t.ID, "deadline"))
+		e.writeCommon(out, t)
+		out.line("DTSTART;VALUE=DATE:" + deadline.Format(dateFormat))
+		out.line("DTEND;VALUE=DATE:" + deadline.AddDate(0, 0, 1).Format(dateFormat))
+		out.line("TRANSP:TRANSPARENT")
+		out.line("END:VEVENT")
+	}
+	if reminder := t.GetReminder(); reminder != nil {
+		out.line("BEGIN:VEVENT")
+		out.line("UID:" + UID(t.ID, "reminder"))
+		e.writeCommon(out, t)
+		out.line("DTSTART:" + e.utc(*reminder))
+		out.line("DURATION:" + reminderDuration)
+		out.line("TRANSP:TRANSPARENT")
+		writeAlarm(out, t, "TRIGGER:PT0S")
+		out.line("END:VEVENT")
+	}
+}
+
+// writeCommon writes the properties shared by to-dos and events
+func (e *Encoder) writeCommon(out *writer, t *task.Task) {
+	out.line("DTSTAMP:" + e.utc(t.Updated))
+	out.prop("SUMMARY", t.Title)
+	if t.Description != "" {
+		out.prop("DESCRIPTION", t.Description)
+	}
+	if tags := t.UserTags(); len(tags) > 0 {
+		escaped := make([]string, len(tags))
+		for i, tag := range tags {
+			escaped[i] = escapeText(tag)
+		}
+		out.line("CATEGORIES:" + strings.Join(escaped, ","))
+	}
+}
+
+func writeAlarm(out *writer, t *task.Task, trigger string) {
+	out.line("BEGIN:VALARM")
+	out.line("ACTION:DISPLAY")
+	out.line(trigger)
+	out.prop("DESCRIPTION", t.Title)
+	out.line("END:VALARM")
+}
+
+func (e *Encoder) isActive(status task.Status) bool {
+	active, ok := e.Workflow.ActiveStatus()
+	return ok && status == active
+}
+
+// utc converts a task time, which holds the wall clock of the task file,
+// to a UTC DATE-TIME value
+func (e *Encoder) utc(t time.Time) string {
+	loc := e.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc)
+	return wall.UTC().Format(dateTimeFormat)
+}
+
+// UID returns the stable UID of a task's entry. The to-do of a task has
+// no kind; its deadline and reminder events are told apart by kind.
+func UID(id, kind string) string {
+	uid := strings.ReplaceAll(id, "/", "-")
+	if kind != "" {
+		uid += "-" + kind
+	}
+	return uid + "@mdtask"
+}
+
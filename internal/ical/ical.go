@@ -0,0 +1,74 @@
+// Package ical writes tasks as an iCalendar (RFC 5545) calendar so that
+// deadlines and reminders show up in calendar apps.
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxLineLength is the longest content line in octets; longer lines are
+// folded onto continuation lines starting with a space
+const maxLineLength = 75
+
+// writer writes content lines with CRLF line endings, folding long lines
+type writer struct {
+	w   *bufio.Writer
+	err error
+}
+
+func newWriter(w io.Writer) *writer {
+	return &writer{w: bufio.NewWriter(w)}
+}
+
+// line writes a property, folding it between characters
+func (w *writer) line(s string) {
+	if w.err != nil {
+		return
+	}
+	limit := maxLineLength
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.write(s[:cut] + "\r\n ")
+		s = s[cut:]
+		// The leading space of continuation lines counts towards the limit
+		limit = maxLineLength - 1
+	}
+	w.write(s + "\r\n")
+}
+
+// prop writes name:value with the value escaped as text
+func (w *writer) prop(name, value string) {
+	w.line(name + ":" + escapeText(value))
+}
+
+func (w *writer) write(s string) {
+	if w.err == nil {
+		_, w.err = w.w.WriteString(s)
+	}
+}
+
+func (w *writer) flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	return w.w.Flush()
+}
+
+// escapeText escapes a TEXT value: backslashes, semicolons, commas and
+// newlines
+func escapeText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(s)
+}
@@ -0,0 +1,167 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+func encode(t *testing.T, tasks ...*task.Task) string {
+	t.Helper()
+	e := NewEncoder(config.DefaultWorkflow())
+	e.Location = time.FixedZone("JST", 9*60*60)
+	var buf bytes.Buffer
+	if err := e.Encode(&buf, tasks); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	return buf.String()
+}
+
+func newTask(id string, status task.Status) *task.Task {
+	created := time.Date(2025, 6, 20, 9, 0, 0, 0, time.UTC)
+	tk := &task.Task{
+		ID:      id,
+		Title:   "Write report",
+		Tags:    []string{"mdtask", "work", "client, acme"},
+		Created: created,
+		Updated: created.Add(2 * time.Hour),
+	}
+	tk.SetStatus(status)
+	return tk
+}
+
+func TestEncode(t *testing.T) {
+	open := newTask("task/20250620090000", task.StatusTODO)
+	open.Description = "Quarterly; see notes\nsecond line"
+	open.SetDeadline(time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC))
+	open.SetReminder(time.Date(2025, 6, 29, 10, 30, 0, 0, time.UTC))
+	open.SetParentID("task/20250601000000")
+
+	done := newTask("task/20250610000000", task.StatusTODO)
+	done.ChangeStatus(task.StatusDONE, time.Date(2025, 6, 21, 17, 0, 0, 0, time.UTC))
+	done.SetDeadline(time.Date(2025, 6, 22, 0, 0, 0, 0, time.UTC))
+
+	got := encode(t, open, done)
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-CALNAME:mdtask\r\n",
+		// Times in JST are written in UTC
+		"UID:task-20250620090000@mdtask\r\nDTSTAMP:20250620T020000Z\r\n",
+		"DESCRIPTION:Quarterly\\; see notes\\nsecond line\r\n",
+		"CATEGORIES:work,client\\, acme\r\n",
+		"CREATED:20250620T000000Z\r\n",
+		"STATUS:NEEDS-ACTION\r\nDUE;VALUE=DATE:20250630\r\n",
+		"RELATED-TO;RELTYPE=PARENT:task-20250601000000@mdtask\r\n",
+		"TRIGGER;VALUE=DATE-TIME:20250629T013000Z\r\n",
+		"UID:task-20250620090000-deadline@mdtask\r\n",
+		"DTSTART;VALUE=DATE:20250630\r\nDTEND;VALUE=DATE:20250701\r\n",
+		"UID:task-20250620090000-reminder@mdtask\r\n",
+		"DTSTART:20250629T013000Z\r\nDURATION:PT15M\r\n",
+		"STATUS:COMPLETED\r\nCOMPLETED:20250621T080000Z\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output does not contain %q:\n%s", want, got)
+		}
+	}
+
+	// Tasks are ordered by ID, and done tasks get no events
+	if strings.Index(got, "UID:task-20250610000000@") > strings.Index(got, "UID:task-20250620090000@") {
+		t.Error("tasks are not ordered by ID")
+	}
+	if strings.Contains(got, "task-20250610000000-deadline") {
+		t.Error("done task has a deadline event")
+	}
+	if strings.Contains(got, "mdtask/status") {
+		t.Errorf("output contains system tags:\n%s", got)
+	}
+}
+
+func TestEncode_StableOutput(t *testing.T) {
+	tk := newTask("task/20250620090000", task.StatusWIP)
+	tk.SetDeadline(time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC))
+
+	first, second := encode(t, tk), encode(t, tk)
+	if first != second {
+		t.Errorf("output changed between runs:\n%s\n%s", first, second)
+	}
+	if !strings.Contains(first, "STATUS:IN-PROCESS\r\n") {
+		t.Errorf("active task is not in process:\n%s", first)
+	}
+}
+
+func TestLineFolding(t *testing.T) {
+	tk := newTask("task/1", task.StatusTODO)
+	tk.Title = strings.Repeat("長いタイトル", 20)
+
+	got := encode(t, tk)
+	for _, line := range strings.Split(strings.TrimSuffix(got, "\r\n"), "\r\n") {
+		if len(line) > maxLineLength {
+			t.Errorf("line is %d octets long: %q", len(line), line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("line splits a character: %q", line)
+		}
+	}
+
+	unfolded := strings.ReplaceAll(got, "\r\n ", "")
+	if !strings.Contains(unfolded, "SUMMARY:"+tk.Title+"\r\n") {
+		t.Errorf("unfolded output does not contain the title:\n%s", unfolded)
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{`a\b`, `a\\b`},
+		{"a;b,c", `a\;b\,c`},
+		{"line\r\nbreak\n", `line\nbreak\n`},
+	}
+	for _, tt := range tests {
+		if got := escapeText(tt.in); got != tt.want {
+			t.Errorf("escapeText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSelection(t *testing.T) {
+	workflow := config.DefaultWorkflow()
+	work := newTask("task/1", task.StatusTODO)
+	home := newTask("task/2", task.StatusWIP)
+	home.Tags = []string{"mdtask", "home"}
+	home.SetStatus(task.StatusWIP)
+	archived := newTask("task/3", task.StatusDONE)
+	archived.Archive()
+	tasks := []*task.Task{work, home, archived}
+
+	tests := []struct {
+		name      string
+		selection Selection
+		want      []string
+	}{
+		{"all active", Selection{}, []string{"task/1", "task/2"}},
+		{"with archived", Selection{Archived: true}, []string{"task/1", "task/2", "task/3"}},
+		{"any tag", Selection{Tags: []string{"home", "other"}}, []string{"task/2"}},
+		{"status", Selection{Statuses: []task.Status{task.StatusTODO, task.StatusDONE}}, []string{"task/1"}},
+		{"tag and status", Selection{Tags: []string{"work"}, Statuses: []task.Status{task.StatusDONE}, Archived: true}, []string{"task/3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, tk := range tt.selection.Select(tasks, workflow) {
+				got = append(got, tk.ID)
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("Select() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package ical
+
+import (
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/query"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// Selection picks the tasks of a calendar. Empty lists match all tasks.
+type Selection struct {
+	// Tags matches tasks with any of the tags; type/* matches a prefix
+	Tags []string
+	// Statuses matches tasks in any of the statuses
+	Statuses []task.Status
+	// Archived includes archived tasks
+	Archived bool
+}
+
+// Select returns the tasks matching the selection
+func (s Selection) Select(tasks []*task.Task, workflow *config.Workflow) []*task.Task {
+	if len(s.Tags) > 0 {
+		tasks = query.Filter(tasks, query.Tags(s.Tags, nil, true), query.NewEnv(tasks, workflow.IsDone), s.Archived)
+	}
+
+	var selected []*task.Task
+	for _, t := range tasks {
+		if t.IsArchived() && !s.Archived {
+			continue
+		}
+		if len(s.Statuses) > 0 && !hasStatus(s.Statuses, t.GetStatus()) {
+			continue
+		}
+		selected = append(selected, t)
+	}
+	return selected
+}
+
+func hasStatus(statuses []task.Status, status task.Status) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+// Package ignore decides which files and directories are left out when
+// task directories are searched for task files.
+//
+// Patterns use the syntax of .gitignore files: a pattern without a slash
+// matches a name at any depth, a leading or inner slash anchors it to the
+// directory of the file it came from, a trailing slash matches directories
+// only, "*", "?" and "[...]" match within a name, "**" matches across
+// directories and "!" re-includes a path excluded by an earlier pattern.
+// The last matching pattern decides.
+package ignore
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/tkancf/mdtask/internal/constants"
+)
+
+// Filenames are the files read in every directory for patterns
+var Filenames = []string{constants.IgnoreFilename, ".gitignore"}
+
+// skipped are directories that never hold task files
+var skipped = map[string]bool{
+	constants.StateDirName: true,
+	".git":                 true,
+	".obsidian":            true,
+	"node_modules":         true,
+}
+
+// Skipped reports whether a directory with the given name is always left
+// out, whatever the patterns say
+func Skipped(name string) bool {
+	return skipped[name]
+}
+
+// rule is a parsed pattern
+type rule struct {
+	// base is the directory of the pattern, relative to the root with
+	// forward slashes, or "" for the root
+	base    string
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// Matcher holds the patterns of a task directory. Paths are given relative
+// to that directory.
+type Matcher struct {
+	rules []rule
+}
+
+// New returns a matcher with patterns that apply to the whole directory,
+// such as the ignore list of the config
+func New(patterns []string) *Matcher {
+	m := &Matcher{}
+	for _, p := range patterns {
+		m.add("", p)
+	}
+	return m
+}
+
+// AddFiles reads the ignore files in dir, whose path relative to the root
+// is rel, and adds their patterns for the paths below dir. Missing files
+// are skipped.
+func (m *Matcher) AddFiles(dir, rel string) {
+	base := filepath.ToSlash(rel)
+	if base == "." {
+		base = ""
+	}
+	for _, name := range Filenames {
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			m.add(base, scanner.Text())
+		}
+		f.Close()
+	}
+}
+
+func (m *Matcher) add(base, pattern string) {
+	pattern = strings.TrimRight(pattern, " \t\r")
+	if pattern == "" || strings.HasPrefix(pattern, "#") {
+		return
+	}
+
+	r := rule{base: base}
+	if rest, ok := strings.CutPrefix(pattern, "!"); ok {
+		r.negate = true
+		pattern = rest
+	}
+	pattern = strings.TrimPrefix(pattern, `\`)
+	if rest, ok := strings.CutSuffix(pattern, "/"); ok {
+		r.dirOnly = true
+		pattern = rest
+	}
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if pattern == "" {
+		return
+	}
+
+	expr := globToRegexp(pattern)
+	if !anchored {
+		expr = "(?:.*/)?" + expr
+	}
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return
+	}
+	r.re = re
+	m.rules = append(m.rules, r)
+}
+
+// globToRegexp translates a pattern to a regular expression
+func globToRegexp(pattern string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if rest, ok := strings.CutPrefix(class, "!"); ok {
+				class = "^" + rest
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// Ignored reports whether the file or directory at rel, relative to the
+// root, is excluded by the patterns
+func (m *Matcher) Ignored(rel string, isDir bool) bool {
+	rel = filepath.ToSlash(rel)
+	ignored := false
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		path := rel
+		if r.base != "" {
+			rest, ok := strings.CutPrefix(rel, r.base+"/")
+			if !ok {
+				continue
+			}
+			path = rest
+		}
+		if r.re.MatchString(path) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
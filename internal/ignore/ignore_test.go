@@ -0,0 +1,96 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatcher_Ignored(t *testing.T) {
+	m := New([]string{
+		"# comment",
+		"",
+		"*.draft.md",
+		"/archive",
+		"build/",
+		"notes/**/scratch.md",
+		"docs/*.md",
+		"!docs/keep.md",
+		"tmp[0-9].md",
+	})
+
+	tests := []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"plan.md", false, false},
+		{"plan.draft.md", false, true},
+		{"sub/plan.draft.md", false, true},
+		{"archive", true, true},
+		{"sub/archive", true, false},
+		{"build", true, true},
+		{"build", false, false},
+		{"sub/build", true, true},
+		{"notes/scratch.md", false, true},
+		{"notes/a/b/scratch.md", false, true},
+		{"docs/readme.md", false, true},
+		{"docs/keep.md", false, false},
+		{"docs/sub/readme.md", false, false},
+		{"tmp1.md", false, true},
+		{"tmpx.md", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rel, func(t *testing.T) {
+			if got := m.Ignored(tt.rel, tt.isDir); got != tt.want {
+				t.Errorf("Ignored(%s, %v) = %v, want %v", tt.rel, tt.isDir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatcher_AddFiles(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "projects")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*.log\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sub, ".mdtaskignore"), []byte("/old.md\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := New(nil)
+	m.AddFiles(dir, ".")
+	m.AddFiles(sub, "projects")
+
+	tests := []struct {
+		rel  string
+		want bool
+	}{
+		{"debug.log", true},
+		{"projects/debug.log", true},
+		{"projects/old.md", true},
+		{"old.md", false},
+		{"projects/sub/old.md", false},
+	}
+	for _, tt := range tests {
+		if got := m.Ignored(tt.rel, false); got != tt.want {
+			t.Errorf("Ignored(%s) = %v, want %v", tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestSkipped(t *testing.T) {
+	for _, name := range []string{".git", "node_modules", ".obsidian", ".mdtask"} {
+		if !Skipped(name) {
+			t.Errorf("Skipped(%s) = false", name)
+		}
+	}
+	if Skipped("tasks") {
+		t.Error("Skipped(tasks) = true")
+	}
+}
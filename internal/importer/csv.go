@@ -0,0 +1,126 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tkancf/mdtask/internal/service"
+)
+
+// csvColumns maps the accepted header names to the fields they fill
+var csvColumns = map[string]string{
+	"id":          "id",
+	"source":      "id",
+	"title":       "title",
+	"name":        "title",
+	"summary":     "title",
+	"description": "description",
+	"notes":       "notes",
+	"content":     "notes",
+	"body":        "notes",
+	"due":         "due",
+	"deadline":    "due",
+	"due date":    "due",
+	"status":      "status",
+	"state":       "status",
+	"tags":        "tags",
+	"labels":      "tags",
+	"parent":      "parent",
+}
+
+// csvStates maps status words of other tools onto import states; other
+// values must be workflow statuses
+var csvStates = map[string]service.ImportState{
+	"open":        service.ImportOpen,
+	"pending":     service.ImportOpen,
+	"active":      service.ImportActive,
+	"started":     service.ImportActive,
+	"in progress": service.ImportActive,
+	"waiting":     service.ImportWaiting,
+	"blocked":     service.ImportWaiting,
+	"done":        service.ImportDone,
+	"completed":   service.ImportDone,
+	"closed":      service.ImportDone,
+}
+
+// ParseCSV reads a CSV file with a header row. A title column is required;
+// id, description, notes, due, status, tags and parent are optional, and
+// other columns are ignored. Tags are separated by commas, semicolons or
+// spaces. Without an id column, rows are told apart by their title.
+func ParseCSV(r io.Reader) ([]service.ImportRecord, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if field, ok := csvColumns[name]; ok {
+			if _, seen := columns[field]; !seen {
+				columns[field] = i
+			}
+		}
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, fmt.Errorf("invalid CSV: no title column in header %q", strings.Join(header, ","))
+	}
+
+	var records []service.ImportRecord
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		get := func(field string) string {
+			if i, ok := columns[field]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+		if strings.Join(row, "") == "" {
+			continue
+		}
+
+		rec := service.ImportRecord{
+			Title:       get("title"),
+			Description: get("description"),
+			Notes:       get("notes"),
+			Tags: cleanTags(strings.FieldsFunc(get("tags"), func(r rune) bool {
+				return r == ',' || r == ';' || r == ' '
+			})...),
+		}
+		if id := get("id"); id != "" {
+			rec.Source = "csv:" + id
+		} else {
+			rec.Source = "csv:" + rec.Title
+		}
+		if parent := get("parent"); parent != "" {
+			rec.Parent = "csv:" + parent
+		}
+		status := get("status")
+		if state, ok := csvStates[strings.ToLower(status)]; ok {
+			rec.State = state
+		} else {
+			rec.Status = status
+		}
+		if rec.Due, err = parseDate(get("due")); err != nil {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		records = append(records, rec)
+	}
+	return records, nil
+}
@@ -0,0 +1,88 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tkancf/mdtask/internal/service"
+)
+
+// githubIssue is an issue as listed by 'gh issue list --json' or the REST
+// API, which differ in the case of the state and the names of some fields
+type githubIssue struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	State   string `json:"state"`
+	URL     string `json:"url"`
+	HTMLURL string `json:"html_url"`
+	Labels  []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	Milestone *struct {
+		Title     string `json:"title"`
+		DueOn     string `json:"dueOn"`
+		DueOnREST string `json:"due_on"`
+	} `json:"milestone"`
+	PullRequest *struct{} `json:"pull_request"`
+}
+
+// ParseGitHub reads issues exported with
+// 'gh issue list --state all --json number,title,body,state,labels,url,milestone'
+// or from the REST API. The body becomes the content, the due date of the
+// milestone the deadline, and pull requests are left out.
+func ParseGitHub(r io.Reader) ([]service.ImportRecord, error) {
+	issues, err := decodeList[githubIssue](r)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []service.ImportRecord
+	for _, issue := range issues {
+		if issue.PullRequest != nil {
+			continue
+		}
+		rec := service.ImportRecord{
+			Source: githubSource(issue),
+			Title:  issue.Title,
+			Notes:  strings.ReplaceAll(issue.Body, "\r\n", "\n"),
+		}
+		if strings.EqualFold(issue.State, "closed") {
+			rec.State = service.ImportDone
+		}
+
+		var labels []string
+		for _, label := range issue.Labels {
+			labels = append(labels, label.Name)
+		}
+		if m := issue.Milestone; m != nil {
+			labels = append(labels, "milestone/"+m.Title)
+			due := m.DueOn
+			if due == "" {
+				due = m.DueOnREST
+			}
+			if rec.Due, err = parseDate(due); err != nil {
+				return nil, fmt.Errorf("issue #%d: %w", issue.Number, err)
+			}
+		}
+		rec.Tags = cleanTags(labels...)
+
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// githubSource identifies an issue by its URL, which includes the
+// repository, or by its number if the export has no URLs
+func githubSource(issue githubIssue) string {
+	switch {
+	case issue.URL != "" && !strings.Contains(issue.URL, "api.github.com"):
+		return "github:" + issue.URL
+	case issue.HTMLURL != "":
+		return "github:" + issue.HTMLURL
+	case issue.Number > 0:
+		return fmt.Sprintf("github:#%d", issue.Number)
+	}
+	return ""
+}
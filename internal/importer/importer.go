@@ -0,0 +1,156 @@
+// Package importer reads tasks exported from other tools as import records
+// for the task service.
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/constants"
+	"github.com/tkancf/mdtask/internal/service"
+)
+
+// Parser reads the export of a tool
+type Parser func(r io.Reader) ([]service.ImportRecord, error)
+
+// Parsers maps the names of the supported formats to their parsers
+var Parsers = map[string]Parser{
+	"taskwarrior": ParseTaskwarrior,
+	"todoist":     ParseTodoist,
+	"github":      ParseGitHub,
+	"csv":         ParseCSV,
+}
+
+// Formats returns the names of the supported formats, sorted
+func Formats() []string {
+	names := make([]string, 0, len(Parsers))
+	for name := range Parsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parse reads an export in the named format
+func Parse(format string, r io.Reader) ([]service.ImportRecord, error) {
+	parse, ok := Parsers[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("unknown import format %q (valid: %s)", format, strings.Join(Formats(), ", "))
+	}
+	return parse(r)
+}
+
+// decodeList decodes a JSON array of items. It also accepts an object with
+// the array under one of keys, and objects one after another as written by
+// older tools.
+func decodeList[T any](r io.Reader, keys ...string) ([]T, error) {
+	br := bufio.NewReader(r)
+	first, err := firstByte(br)
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var items []T
+	switch first {
+	case '[':
+		if err := json.NewDecoder(br).Decode(&items); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+	case '{':
+		data, err := io.ReadAll(br)
+		if err != nil {
+			return nil, err
+		}
+		var wrapper map[string]json.RawMessage
+		if json.Unmarshal(data, &wrapper) == nil {
+			for _, key := range keys {
+				if list, ok := wrapper[key]; ok {
+					if err := json.Unmarshal(list, &items); err != nil {
+						return nil, fmt.Errorf("invalid JSON in %q: %w", key, err)
+					}
+					return items, nil
+				}
+			}
+		}
+		// Objects separated by commas or new lines
+		if json.Unmarshal(append(append([]byte("["), data...), ']'), &items) == nil {
+			return items, nil
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		for {
+			var item T
+			if err := dec.Decode(&item); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("invalid JSON: %w", err)
+			}
+			items = append(items, item)
+		}
+	default:
+		return nil, fmt.Errorf("invalid JSON: expected an array or object, found %q", first)
+	}
+	return items, nil
+}
+
+// firstByte returns the first byte that is not white space, leaving it unread
+func firstByte(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if b != ' ' && b != '\t' && b != '\n' && b != '\r' {
+			return b, br.UnreadByte()
+		}
+	}
+}
+
+// parseDate reads the date of a time stamp, converting times with a zone to
+// the local date. It accepts dates, RFC 3339 times and Taskwarrior's
+// 20060102T150405Z.
+func parseDate(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, "20060102T150405Z", "2006-01-02T15:04:05", "2006-01-02T15:04", constants.DateTimeFormat} {
+		if t, err := time.Parse(layout, value); err == nil {
+			if strings.HasSuffix(layout, "Z") || layout == time.RFC3339 {
+				t = t.Local()
+			}
+			date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+			return &date, nil
+		}
+	}
+	for _, layout := range []string{constants.DateFormat, "2006/01/02", "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid date %q", value)
+}
+
+// cleanTags turns labels into tags: without a leading #, with dashes for
+// spaces, and leaving out mdtask's own tags
+func cleanTags(labels ...string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, label := range labels {
+		tag := strings.Join(strings.Fields(strings.TrimPrefix(strings.TrimSpace(label), "#")), "-")
+		if tag == "" || tag == constants.TagPrefix || strings.HasPrefix(tag, constants.TagPrefix+"/") || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
@@ -0,0 +1,131 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/service"
+)
+
+func date(y int, m time.Month, d int) *time.Time {
+	t := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		input  string
+		want   []service.ImportRecord
+	}{
+		{
+			name:   "taskwarrior",
+			format: "taskwarrior",
+			input: `[
+{"uuid":"a1","description":"Buy milk","status":"pending","due":"20250630","tags":["home"],"project":"house.kitchen","annotations":[{"entry":"20250620T090000Z","description":"2 litres"}]},
+{"uuid":"a2","description":"Gone","status":"deleted"},
+{"uuid":"a3","description":"Fix bike","status":"completed"},
+{"uuid":"a4","description":"Write","status":"pending","start":"20250620T090000Z"},
+{"uuid":"a5","description":"Later","status":"waiting"}
+]`,
+			want: []service.ImportRecord{
+				{Source: "taskwarrior:a1", Title: "Buy milk", Notes: "- 2 litres", Due: date(2025, 6, 30), Tags: []string{"home", "project/house/kitchen"}},
+				{Source: "taskwarrior:a3", Title: "Fix bike", State: service.ImportDone},
+				{Source: "taskwarrior:a4", Title: "Write", State: service.ImportActive},
+				{Source: "taskwarrior:a5", Title: "Later", State: service.ImportWaiting},
+			},
+		},
+		{
+			name:   "taskwarrior objects per line",
+			format: "taskwarrior",
+			input: `{"uuid":"a1","description":"One","status":"pending"},
+{"uuid":"a2","description":"Two","status":"pending"}`,
+			want: []service.ImportRecord{
+				{Source: "taskwarrior:a1", Title: "One"},
+				{Source: "taskwarrior:a2", Title: "Two"},
+			},
+		},
+		{
+			name:   "todoist sync items",
+			format: "todoist",
+			input: `{"items":[
+{"id":"2","content":"Child","parent_id":"1","labels":["work stuff"],"checked":true},
+{"id":1,"content":"Parent","description":"Notes","due":{"date":"2025-07-01T10:00:00"}}
+]}`,
+			want: []service.ImportRecord{
+				{Source: "todoist:2", Title: "Child", Tags: []string{"work-stuff"}, Parent: "todoist:1", State: service.ImportDone},
+				{Source: "todoist:1", Title: "Parent", Notes: "Notes", Due: date(2025, 7, 1)},
+			},
+		},
+		{
+			name:   "github",
+			format: "github",
+			input: `[
+{"number":7,"title":"Crash","body":"Steps\r\n1. run","state":"OPEN","url":"https://github.com/o/r/issues/7","labels":[{"name":"bug"}],"milestone":{"title":"v1","dueOn":"2025-08-01T00:00:00Z"}},
+{"number":8,"title":"Done","state":"closed","html_url":"https://github.com/o/r/issues/8","url":"https://api.github.com/repos/o/r/issues/8"},
+{"number":9,"title":"PR","state":"open","pull_request":{}}
+]`,
+			want: []service.ImportRecord{
+				{Source: "github:https://github.com/o/r/issues/7", Title: "Crash", Notes: "Steps\n1. run", Tags: []string{"bug", "milestone/v1"}, Due: date(2025, 8, 1)},
+				{Source: "github:https://github.com/o/r/issues/8", Title: "Done", State: service.ImportDone},
+			},
+		},
+		{
+			name:   "csv",
+			format: "CSV",
+			input: "\ufeffID,Title,Status,Labels,Due Date,Parent,Owner\n" +
+				"1,Epic,in progress,\"a, b;#c\",2025/07/02,,me\n" +
+				"2,Story,WIP,,,1,\n" +
+				",,,,,,\n",
+			want: []service.ImportRecord{
+				{Source: "csv:1", Title: "Epic", Tags: []string{"a", "b", "c"}, Due: date(2025, 7, 2), State: service.ImportActive},
+				{Source: "csv:2", Title: "Story", Status: "WIP", Parent: "csv:1"},
+			},
+		},
+		{
+			name:   "csv without ids",
+			format: "csv",
+			input:  "title,notes\nWrite docs,see wiki\n",
+			want: []service.ImportRecord{
+				{Source: "csv:Write docs", Title: "Write docs", Notes: "see wiki"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.format, strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		input  string
+	}{
+		{"unknown format", "jira", "[]"},
+		{"invalid json", "todoist", "[{"},
+		{"not json", "github", "number,title"},
+		{"invalid date", "taskwarrior", `[{"uuid":"a","description":"x","due":"soon"}]`},
+		{"csv without title", "csv", "id,name2\n1,x\n"},
+		{"csv invalid date", "csv", "title,due\nx,tomorrow\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.format, strings.NewReader(tt.input)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tkancf/mdtask/internal/service"
+)
+
+// taskwarriorTask is a task as written by 'task export'
+type taskwarriorTask struct {
+	UUID        string   `json:"uuid"`
+	Description string   `json:"description"`
+	Status      string   `json:"status"`
+	Due         string   `json:"due"`
+	Start       string   `json:"start"`
+	Project     string   `json:"project"`
+	Tags        []string `json:"tags"`
+	Annotations []struct {
+		Description string `json:"description"`
+	} `json:"annotations"`
+}
+
+// ParseTaskwarrior reads the output of 'task export'. Deleted tasks and
+// recurring templates are left out; projects become project/... tags and
+// annotations the content.
+func ParseTaskwarrior(r io.Reader) ([]service.ImportRecord, error) {
+	tasks, err := decodeList[taskwarriorTask](r)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []service.ImportRecord
+	for _, t := range tasks {
+		if t.Status == "deleted" || t.Status == "recurring" {
+			continue
+		}
+		rec := service.ImportRecord{
+			Source: "taskwarrior:" + t.UUID,
+			Title:  t.Description,
+		}
+		if t.UUID == "" {
+			rec.Source = ""
+		}
+
+		switch {
+		case t.Status == "completed":
+			rec.State = service.ImportDone
+		case t.Status == "waiting":
+			rec.State = service.ImportWaiting
+		case t.Start != "":
+			rec.State = service.ImportActive
+		}
+
+		if rec.Due, err = parseDate(t.Due); err != nil {
+			return nil, fmt.Errorf("task %s: %w", t.UUID, err)
+		}
+
+		labels := t.Tags
+		if t.Project != "" {
+			labels = append(labels, "project/"+strings.ReplaceAll(t.Project, ".", "/"))
+		}
+		rec.Tags = cleanTags(labels...)
+
+		var notes []string
+		for _, a := range t.Annotations {
+			notes = append(notes, "- "+a.Description)
+		}
+		rec.Notes = strings.Join(notes, "\n")
+
+		records = append(records, rec)
+	}
+	return records, nil
+}
@@ -0,0 +1,61 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/tkancf/mdtask/internal/service"
+)
+
+// todoistTask is a task of the Todoist REST or Sync API
+type todoistTask struct {
+	ID          json.Number `json:"id"`
+	Content     string      `json:"content"`
+	Description string      `json:"description"`
+	// IsCompleted is set by the REST API, Checked by the Sync API
+	IsCompleted bool        `json:"is_completed"`
+	Checked     bool        `json:"checked"`
+	Labels      []string    `json:"labels"`
+	ParentID    json.Number `json:"parent_id"`
+	Due         *struct {
+		Date string `json:"date"`
+	} `json:"due"`
+}
+
+// ParseTodoist reads tasks from the Todoist API, either as an array or as
+// the items of a sync response. The description becomes the content.
+func ParseTodoist(r io.Reader) ([]service.ImportRecord, error) {
+	tasks, err := decodeList[todoistTask](r, "items", "tasks", "results")
+	if err != nil {
+		return nil, err
+	}
+
+	var records []service.ImportRecord
+	for _, t := range tasks {
+		rec := service.ImportRecord{
+			Source: todoistSource(t.ID),
+			Title:  t.Content,
+			Notes:  t.Description,
+			Tags:   cleanTags(t.Labels...),
+			Parent: todoistSource(t.ParentID),
+		}
+		if t.IsCompleted || t.Checked {
+			rec.State = service.ImportDone
+		}
+		if t.Due != nil {
+			if rec.Due, err = parseDate(t.Due.Date); err != nil {
+				return nil, fmt.Errorf("task %s: %w", t.ID, err)
+			}
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+func todoistSource(id json.Number) string {
+	if id == "" {
+		return ""
+	}
+	return "todoist:" + id.String()
+}
@@ -3,13 +3,17 @@ package mcp
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/errors"
+	"github.com/tkancf/mdtask/internal/query"
 	"github.com/tkancf/mdtask/internal/repository"
+	"github.com/tkancf/mdtask/internal/service"
 	"github.com/tkancf/mdtask/internal/task"
 )
 
@@ -43,11 +47,13 @@ func NewServer(repo repository.Repository, cfg *config.Config) *Server {
 }
 
 func (s *Server) registerTools() {
+	statuses := s.config.GetWorkflow().String()
+
 	// List tasks tool
 	listTool := mcp.NewTool("list_tasks",
 		mcp.WithDescription("List all tasks or filter by status"),
 		mcp.WithString("status",
-			mcp.Description("Filter by status (TODO, WIP, WAIT, SCHE, DONE)"),
+			mcp.Description(fmt.Sprintf("Filter by status (%s)", statuses)),
 		),
 		mcp.WithBoolean("archived",
 			mcp.Description("Include archived tasks"),
@@ -66,11 +72,14 @@ func (s *Server) registerTools() {
 			mcp.Description("Task description"),
 		),
 		mcp.WithString("status",
-			mcp.Description("Initial status (TODO, WIP, WAIT, SCHE, DONE)"),
+			mcp.Description(fmt.Sprintf("Initial status (%s)", statuses)),
 		),
 		mcp.WithArray("tags",
 			mcp.Description("Additional tags for the task"),
 		),
+		mcp.WithArray("blocked_by",
+			mcp.Description("IDs of tasks that must be done before this one"),
+		),
 	)
 	s.mcp.AddTool(createTool, s.createTaskHandler)
 
@@ -88,7 +97,7 @@ func (s *Server) registerTools() {
 			mcp.Description("New description"),
 		),
 		mcp.WithString("status",
-			mcp.Description("New status (TODO, WIP, WAIT, SCHE, DONE)"),
+			mcp.Description(fmt.Sprintf("New status (%s); only transitions allowed by the workflow are accepted", statuses)),
 		),
 		mcp.WithArray("add_tags",
 			mcp.Description("Tags to add"),
@@ -96,15 +105,30 @@ func (s *Server) registerTools() {
 		mcp.WithArray("remove_tags",
 			mcp.Description("Tags to remove"),
 		),
+		mcp.WithObject("fields",
+			mcp.Description("Custom front matter fields to set, e.g. {\"estimate\": \"3h\"}; a null value removes the field"),
+		),
+		mcp.WithArray("remove_fields",
+			mcp.Description("Custom fields to remove"),
+		),
+		mcp.WithArray("add_blocked_by",
+			mcp.Description("IDs of tasks that must be done before this one; cycles are rejected"),
+		),
+		mcp.WithArray("remove_blocked_by",
+			mcp.Description("IDs of tasks this one should no longer wait for"),
+		),
+		mcp.WithString("version",
+			mcp.Description("Version returned by get_task; the update fails if the task has changed since"),
+		),
 	)
 	s.mcp.AddTool(updateTool, s.updateTaskHandler)
 
 	// Search tasks tool
 	searchTool := mcp.NewTool("search_tasks",
-		mcp.WithDescription("Search tasks by query"),
+		mcp.WithDescription("Full-text search over tasks, best matches first"),
 		mcp.WithString("query",
 			mcp.Required(),
-			mcp.Description("Search query"),
+			mcp.Description("Search words; use \"quotes\" for a phrase and a trailing * for a prefix"),
 		),
 		mcp.WithBoolean("archived",
 			mcp.Description("Include archived tasks"),
@@ -112,6 +136,22 @@ func (s *Server) registerTools() {
 	)
 	s.mcp.AddTool(searchTool, s.searchTasksHandler)
 
+	// Query tasks tool
+	queryTool := mcp.NewTool("query_tasks",
+		mcp.WithDescription(`Find tasks with the query language, e.g. status:WIP and (tag:type/bug or deadline<2025-07-01) and not archived and title~"login". `+
+			"Fields: status, tag (type/* for a prefix), title, description, content, text, id, parent, blockedby, deadline, reminder, created, updated, "+
+			"has:<field> and custom fields. Operators: : = != < <= > >= ~ (contains). Dates: YYYY-MM-DD, YYYY-MM-DDTHH:MM, today, tomorrow or offsets like +7d. "+
+			"Flags: archived, done, overdue, blocked, recurring, subtask. Combine with and, or, not and parentheses."),
+		mcp.WithString("query",
+			mcp.Required(),
+			mcp.Description("Query expression"),
+		),
+		mcp.WithBoolean("archived",
+			mcp.Description("Include archived tasks even if the query does not mention archived"),
+		),
+	)
+	s.mcp.AddTool(queryTool, s.queryTasksHandler)
+
 	// Archive task tool
 	archiveTool := mcp.NewTool("archive_task",
 		mcp.WithDescription("Archive a task"),
@@ -122,6 +162,34 @@ func (s *Server) registerTools() {
 	)
 	s.mcp.AddTool(archiveTool, s.archiveTaskHandler)
 
+	// Delete task tool
+	deleteTool := mcp.NewTool("delete_task",
+		mcp.WithDescription("Move a task and its subtasks to the trash"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Task ID to delete"),
+		),
+		mcp.WithBoolean("keep_subtasks",
+			mcp.Description("Keep the subtasks and detach them from the task instead of deleting them"),
+		),
+	)
+	s.mcp.AddTool(deleteTool, s.deleteTaskHandler)
+
+	// Trash tools
+	listTrashTool := mcp.NewTool("list_trash",
+		mcp.WithDescription("List the deleted tasks in the trash"),
+	)
+	s.mcp.AddTool(listTrashTool, s.listTrashHandler)
+
+	restoreTool := mcp.NewTool("restore_task",
+		mcp.WithDescription("Restore a deleted task and its deleted subtasks from the trash"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Task ID to restore"),
+		),
+	)
+	s.mcp.AddTool(restoreTool, s.restoreTaskHandler)
+
 	// Get task tool
 	getTool := mcp.NewTool("get_task",
 		mcp.WithDescription("Get details of a specific task"),
@@ -132,6 +200,12 @@ func (s *Server) registerTools() {
 	)
 	s.mcp.AddTool(getTool, s.getTaskHandler)
 
+	// Ready tasks tool
+	readyTool := mcp.NewTool("list_ready_tasks",
+		mcp.WithDescription("List the active tasks that are not done and whose blockers are all done"),
+	)
+	s.mcp.AddTool(readyTool, s.listReadyTasksHandler)
+
 	// Statistics tool
 	statsTool := mcp.NewTool("get_statistics",
 		mcp.WithDescription("Get task statistics"),
@@ -161,7 +235,11 @@ func (s *Server) listTasksHandler(ctx context.Context, request mcp.CallToolReque
 	var err error
 
 	if status != "" {
-		tasks, err = s.repo.FindByStatus(task.Status(strings.ToUpper(status)))
+		parsed, parseErr := s.config.GetWorkflow().Parse(status)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		tasks, err = s.repo.FindByStatus(parsed)
 	} else {
 		tasks, err = s.repo.FindAll()
 	}
@@ -181,7 +259,27 @@ func (s *Server) listTasksHandler(ctx context.Context, request mcp.CallToolReque
 		tasks = filtered
 	}
 
-	// Format response
+	return mcp.NewToolResultText(formatTaskList(tasks)), nil
+}
+
+func (s *Server) queryTasksHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	q, err := query.Parse(request.GetString("query", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := s.repo.FindAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tasks: %w", err)
+	}
+	env := query.NewEnv(tasks, s.config.GetWorkflow().IsDone)
+	tasks = query.Filter(tasks, q, env, request.GetBool("archived", false))
+
+	return mcp.NewToolResultText(formatTaskList(tasks)), nil
+}
+
+// formatTaskList formats the result of list_tasks and query_tasks
+func formatTaskList(tasks []*task.Task) string {
 	var result strings.Builder
 	result.WriteString(fmt.Sprintf("Found %d tasks\n\n", len(tasks)))
 	
@@ -194,11 +292,17 @@ func (s *Server) listTasksHandler(ctx context.Context, request mcp.CallToolReque
 		if t.Description != "" {
 			result.WriteString(fmt.Sprintf("Description: %s\n", t.Description))
 		}
+		if deadline := t.GetDeadline(); deadline != nil {
+			result.WriteString(fmt.Sprintf("Deadline: %s\n", deadline.Format("2006-01-02")))
+		}
+		if blockers := t.GetBlockedBy(); len(blockers) > 0 {
+			result.WriteString(fmt.Sprintf("Blocked by: %s\n", strings.Join(blockers, ", ")))
+		}
 		result.WriteString(fmt.Sprintf("Created: %s\n", t.Created.Format("2006-01-02 15:04:05")))
 		result.WriteString("\n")
 	}
 
-	return mcp.NewToolResultText(result.String()), nil
+	return result.String()
 }
 
 func (s *Server) createTaskHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -230,9 +334,13 @@ func (s *Server) createTaskHandler(ctx context.Context, request mcp.CallToolRequ
 
 	// Set status
 	if status == "" {
-		status = "TODO"
+		status = string(s.service().DefaultStatus())
+	}
+	parsed, err := s.config.GetWorkflow().Parse(status)
+	if err != nil {
+		return nil, err
 	}
-	t.SetStatus(task.Status(strings.ToUpper(status)))
+	t.SetStatus(parsed)
 
 	// Handle tags - need to manually extract from interface{}
 	if argsMap, ok := request.Params.Arguments.(map[string]interface{}); ok {
@@ -247,13 +355,20 @@ func (s *Server) createTaskHandler(ctx context.Context, request mcp.CallToolRequ
 		}
 	}
 
+	// Set dependencies
+	for _, id := range request.GetStringSlice("blocked_by", []string{}) {
+		t.AddBlocker(id)
+	}
+	if err := s.service().ValidateDependencies(t); err != nil {
+		return nil, err
+	}
+
 	// Create in repository
-	_, err := s.repo.Create(t)
-	if err != nil {
+	if _, err := s.service().AddTask(t); err != nil {
 		return nil, fmt.Errorf("failed to create task: %w", err)
 	}
 
-	result := fmt.Sprintf("Task created successfully\nID: %s\nTitle: %s", t.ID, t.Title)
+	result := fmt.Sprintf("Task created successfully\nID: %s\nTitle: %s\nVersion: %s", t.ID, t.Title, t.Version)
 	return mcp.NewToolResultText(result), nil
 }
 
@@ -279,10 +394,17 @@ func (s *Server) updateTaskHandler(ctx context.Context, request mcp.CallToolRequ
 		t.Description = description
 	}
 
-	// Update status
-	status := request.GetString("status", "")
-	if status != "" {
-		t.SetStatus(task.Status(strings.ToUpper(status)))
+	// Update status if the workflow allows the transition
+	workflow := s.config.GetWorkflow()
+	previousStatus := t.GetStatus()
+	if status := request.GetString("status", ""); status != "" {
+		parsed, err := workflow.Parse(status)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.service().ChangeStatus(t, parsed, time.Now()); err != nil {
+			return nil, err
+		}
 	}
 
 	// Add tags
@@ -308,14 +430,66 @@ func (s *Server) updateTaskHandler(ctx context.Context, request mcp.CallToolRequ
 		t.Tags = newTags
 	}
 
+	// Update custom fields
+	fields, _ := request.GetArguments()["fields"].(map[string]interface{})
+	if remove := request.GetStringSlice("remove_fields", []string{}); len(remove) > 0 {
+		if fields == nil {
+			fields = make(map[string]interface{})
+		}
+		for _, name := range remove {
+			fields[name] = nil
+		}
+	}
+	if err := s.service().ApplyFields(t, fields); err != nil {
+		return nil, err
+	}
+
+	// Update dependencies
+	for _, id := range request.GetStringSlice("remove_blocked_by", []string{}) {
+		t.RemoveBlocker(id)
+	}
+	if addBlockers := request.GetStringSlice("add_blocked_by", []string{}); len(addBlockers) > 0 {
+		for _, id := range addBlockers {
+			t.AddBlocker(id)
+		}
+		if err := s.service().ValidateDependencies(t); err != nil {
+			return nil, err
+		}
+	}
+
+	// Only apply the update if the task is unchanged since the caller read it
+	if version := request.GetString("version", ""); version != "" {
+		t.Version = version
+	}
+
 	t.Updated = time.Now()
 
+	// Completing a recurring task creates its next instance, unless an
+	// earlier completion did so already
+	reportNext := !workflow.IsDone(previousStatus) && workflow.IsDone(t.GetStatus())
+	if reportNext {
+		existing, err := s.service().FindNextOccurrence(t.ID)
+		reportNext = err == nil && existing == nil
+	}
+
 	// Update in repository
-	if err := s.repo.Update(t); err != nil {
+	if err := s.service().SaveTask(t); err != nil {
+		if errors.IsConflict(err) {
+			return nil, fmt.Errorf("failed to update task: %w (call get_task again for the latest version)", err)
+		}
 		return nil, fmt.Errorf("failed to update task: %w", err)
 	}
 
-	result := fmt.Sprintf("Task updated successfully\nID: %s\nTitle: %s", t.ID, t.Title)
+	result := fmt.Sprintf("Task updated successfully\nID: %s\nTitle: %s\nVersion: %s", t.ID, t.Title, t.Version)
+
+	if reportNext {
+		if next, err := s.service().FindNextOccurrence(t.ID); err == nil && next != nil {
+			result += fmt.Sprintf("\nNext occurrence: %s", next.ID)
+			if deadline := next.GetDeadline(); deadline != nil {
+				result += fmt.Sprintf(" (due %s)", deadline.Format("2006-01-02"))
+			}
+		}
+	}
 	return mcp.NewToolResultText(result), nil
 }
 
@@ -374,12 +548,9 @@ func (s *Server) archiveTaskHandler(ctx context.Context, request mcp.CallToolReq
 		return nil, fmt.Errorf("failed to get task: %w", err)
 	}
 
-	// Add archive tag
+	// Archive the task and its subtasks; archiving again is not an error
 	if !t.IsArchived() {
-		t.Archive()
-		t.Updated = time.Now()
-		
-		if err := s.repo.Update(t); err != nil {
+		if t, err = s.service().ArchiveTask(id); err != nil {
 			return nil, fmt.Errorf("failed to archive task: %w", err)
 		}
 	}
@@ -388,6 +559,63 @@ func (s *Server) archiveTaskHandler(ctx context.Context, request mcp.CallToolReq
 	return mcp.NewToolResultText(result), nil
 }
 
+func (s *Server) deleteTaskHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id := request.GetString("id", "")
+	if id == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+
+	deleted, err := s.service().DeleteTask(id, request.GetBool("keep_subtasks", false))
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete task: %w", err)
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Moved %d task(s) to the trash\n", len(deleted)))
+	for _, t := range deleted {
+		result.WriteString(fmt.Sprintf("\nID: %s\nTitle: %s\n", t.ID, t.Title))
+	}
+	return mcp.NewToolResultText(result.String()), nil
+}
+
+func (s *Server) listTrashHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	entries, err := s.repo.Trash()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trash: %w", err)
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Found %d deleted tasks\n\n", len(entries)))
+	for _, e := range entries {
+		result.WriteString(fmt.Sprintf("ID: %s\n", e.ID))
+		result.WriteString(fmt.Sprintf("Title: %s\n", e.Title))
+		if e.ParentID != "" {
+			result.WriteString(fmt.Sprintf("Parent: %s\n", e.ParentID))
+		}
+		result.WriteString(fmt.Sprintf("Deleted: %s\n\n", e.DeletedAt.Format("2006-01-02 15:04")))
+	}
+	return mcp.NewToolResultText(result.String()), nil
+}
+
+func (s *Server) restoreTaskHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id := request.GetString("id", "")
+	if id == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+
+	restored, err := s.service().RestoreTask(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore task: %w", err)
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Restored %d task(s)\n", len(restored)))
+	for _, t := range restored {
+		result.WriteString(fmt.Sprintf("\nID: %s\nTitle: %s\n", t.ID, t.Title))
+	}
+	return mcp.NewToolResultText(result.String()), nil
+}
+
 func (s *Server) getTaskHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	id := request.GetString("id", "")
 	if id == "" {
@@ -413,6 +641,23 @@ func (s *Server) getTaskHandler(ctx context.Context, request mcp.CallToolRequest
 	result.WriteString(fmt.Sprintf("Created: %s\n", t.Created.Format("2006-01-02 15:04:05")))
 	result.WriteString(fmt.Sprintf("Updated: %s\n", t.Updated.Format("2006-01-02 15:04:05")))
 	result.WriteString(fmt.Sprintf("Tags: %s\n", strings.Join(t.Tags, ", ")))
+	if blockers := t.GetBlockedBy(); len(blockers) > 0 {
+		result.WriteString(fmt.Sprintf("Blocked by: %s\n", strings.Join(blockers, ", ")))
+	}
+	if graph, err := s.service().DependencyGraph(t.ID); err == nil {
+		if dependents := graph.Dependents(t.ID); len(dependents) > 0 {
+			result.WriteString(fmt.Sprintf("Blocks: %s\n", strings.Join(dependents, ", ")))
+		}
+	}
+	if len(t.Fields) > 0 {
+		result.WriteString("Fields:\n")
+		for _, name := range t.FieldNames() {
+			result.WriteString(fmt.Sprintf("  %s: %s\n", name, task.FieldString(t.Fields[name])))
+		}
+	}
+	if t.Version != "" {
+		result.WriteString(fmt.Sprintf("Version: %s\n", t.Version))
+	}
 	
 	if t.Content != "" {
 		result.WriteString("\nContent:\n")
@@ -422,6 +667,27 @@ func (s *Server) getTaskHandler(ctx context.Context, request mcp.CallToolRequest
 	return mcp.NewToolResultText(result.String()), nil
 }
 
+func (s *Server) listReadyTasksHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	tasks, err := s.service().ReadyTasks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ready tasks: %w", err)
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Found %d ready tasks\n\n", len(tasks)))
+	for _, t := range tasks {
+		result.WriteString(fmt.Sprintf("ID: %s\n", t.ID))
+		result.WriteString(fmt.Sprintf("Title: %s\n", t.Title))
+		result.WriteString(fmt.Sprintf("Status: %s\n", t.GetStatus()))
+		if deadline := t.GetDeadline(); deadline != nil {
+			result.WriteString(fmt.Sprintf("Deadline: %s\n", deadline.Format("2006-01-02")))
+		}
+		result.WriteString("\n")
+	}
+
+	return mcp.NewToolResultText(result.String()), nil
+}
+
 func (s *Server) getStatisticsHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	tasks, err := s.repo.FindAll()
 	if err != nil {
@@ -453,8 +719,8 @@ func (s *Server) getStatisticsHandler(ctx context.Context, request mcp.CallToolR
 	result.WriteString(fmt.Sprintf("Archived: %d\n\n", totalArchived))
 	
 	result.WriteString("By Status:\n")
-	for status, count := range stats {
-		result.WriteString(fmt.Sprintf("  %s: %d\n", status, count))
+	for _, status := range s.statusOrder(stats) {
+		result.WriteString(fmt.Sprintf("  %s: %d\n", status, stats[status]))
 	}
 
 	return mcp.NewToolResultText(result.String()), nil
@@ -486,8 +752,8 @@ func (s *Server) tasksResourceHandler(ctx context.Context, request mcp.ReadResou
 	}
 
 	// Output by status
-	for _, status := range []string{"TODO", "WIP", "WAIT", "SCHE", "DONE"} {
-		if tasks, ok := byStatus[status]; ok && len(tasks) > 0 {
+	for _, status := range s.config.GetWorkflow().Names() {
+		if tasks, ok := byStatus[string(status)]; ok && len(tasks) > 0 {
 			content.WriteString(fmt.Sprintf("## %s (%d)\n\n", status, len(tasks)))
 			for _, t := range tasks {
 				content.WriteString(fmt.Sprintf("- **%s** (`%s`)\n", t.Title, t.ID))
@@ -548,6 +814,33 @@ func (s *Server) statisticsResourceHandler(ctx context.Context, request mcp.Read
 	}, nil
 }
 
+// service returns the task service, which runs the configured hooks on
+// every change
+func (s *Server) service() *service.TaskService {
+	return service.NewTaskService(s.repo, s.config)
+}
+
+// statusOrder returns the statuses counted in stats, workflow statuses first
+// in display order followed by any others
+func (s *Server) statusOrder(stats map[string]int) []string {
+	var order []string
+	seen := make(map[string]bool)
+	for _, status := range s.config.GetWorkflow().Names() {
+		seen[string(status)] = true
+		if stats[string(status)] > 0 {
+			order = append(order, string(status))
+		}
+	}
+	var others []string
+	for status := range stats {
+		if !seen[status] {
+			others = append(others, status)
+		}
+	}
+	sort.Strings(others)
+	return append(order, others...)
+}
+
 func (s *Server) Serve() error {
 	return server.ServeStdio(s.mcp)
 }
\ No newline at end of file
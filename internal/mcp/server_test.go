@@ -3,6 +3,7 @@ package mcp
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -10,23 +11,41 @@ import (
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/tkancf/mdtask/internal/config"
 	"github.com/tkancf/mdtask/internal/errors"
+	"github.com/tkancf/mdtask/internal/repository"
+	"github.com/tkancf/mdtask/internal/search"
 	"github.com/tkancf/mdtask/internal/task"
 )
 
 type mockRepository struct {
-	tasks map[string]*task.Task
+	tasks    map[string]*task.Task
+	trash    []repository.TrashEntry
+	trashed  map[string]*task.Task
+	order    []string
+	versions map[string]string
+	writes   int
 }
 
 func newMockRepository() *mockRepository {
 	return &mockRepository{
-		tasks: make(map[string]*task.Task),
+		tasks:    make(map[string]*task.Task),
+		versions: make(map[string]string),
+		trashed:  make(map[string]*task.Task),
 	}
 }
 
+// bumpVersion simulates the repository assigning a new version on every write
+func (m *mockRepository) bumpVersion(t *task.Task) {
+	m.writes++
+	t.Version = fmt.Sprintf("v%d", m.writes)
+	m.versions[t.ID] = t.Version
+}
+
 func (m *mockRepository) Create(t *task.Task) (string, error) {
 	// Generate unique ID with microseconds to avoid collisions in tests
 	t.ID = fmt.Sprintf("task/%s%d", time.Now().Format("20060102150405"), time.Now().Nanosecond())
 	m.tasks[t.ID] = t
+	m.order = append(m.order, t.ID)
+	m.bumpVersion(t)
 	return t.ID, nil
 }
 
@@ -35,7 +54,8 @@ func (m *mockRepository) FindByID(id string) (*task.Task, error) {
 	if !ok {
 		return nil, errors.NotFound("task", id)
 	}
-	return t, nil
+	// Like the file repository, hand out copies
+	return t.Clone(), nil
 }
 
 func (m *mockRepository) FindByIDWithPath(id string) (*task.Task, string, error) {
@@ -47,7 +67,11 @@ func (m *mockRepository) FindByIDWithPath(id string) (*task.Task, string, error)
 }
 
 func (m *mockRepository) Update(t *task.Task) error {
+	if current, ok := m.versions[t.ID]; ok && t.Version != "" && t.Version != current {
+		return errors.ConflictError("task "+t.ID, "file was modified since it was read")
+	}
 	m.tasks[t.ID] = t
+	m.bumpVersion(t)
 	return nil
 }
 
@@ -57,9 +81,12 @@ func (m *mockRepository) Save(t *task.Task, filePath string) error {
 }
 
 func (m *mockRepository) FindAll() ([]*task.Task, error) {
+	// Return tasks in creation order so tests can rely on the last created task
 	tasks := make([]*task.Task, 0, len(m.tasks))
-	for _, t := range m.tasks {
-		tasks = append(tasks, t)
+	for _, id := range m.order {
+		if t, ok := m.tasks[id]; ok {
+			tasks = append(tasks, t)
+		}
 	}
 	return tasks, nil
 }
@@ -97,6 +124,11 @@ func (m *mockRepository) Search(query string) ([]*task.Task, error) {
 	return tasks, nil
 }
 
+func (m *mockRepository) SearchIndex() (*search.Index, error) {
+	tasks, _ := m.FindAll()
+	return search.NewIndex(tasks), nil
+}
+
 func (m *mockRepository) SearchByTags(includeTags, excludeTags []string, orMode bool) ([]*task.Task, error) {
 	tasks := make([]*task.Task, 0)
 	for _, t := range m.tasks {
@@ -105,6 +137,59 @@ func (m *mockRepository) SearchByTags(includeTags, excludeTags []string, orMode
 	return tasks, nil
 }
 
+func (m *mockRepository) Delete(id string) (*repository.TrashEntry, error) {
+	t, ok := m.tasks[id]
+	if !ok {
+		return nil, errors.NotFound("task", id)
+	}
+	delete(m.tasks, id)
+	m.trashed[id] = t
+	entry := repository.TrashEntry{ID: id, Title: t.Title, ParentID: t.GetParentID(), DeletedAt: time.Now()}
+	m.trash = append([]repository.TrashEntry{entry}, m.trash...)
+	return &entry, nil
+}
+
+func (m *mockRepository) Restore(id string) (*task.Task, error) {
+	t, ok := m.trashed[id]
+	if !ok {
+		return nil, errors.NotFound("task in trash", id)
+	}
+	delete(m.trashed, id)
+	for i, entry := range m.trash {
+		if entry.ID == id {
+			m.trash = append(m.trash[:i], m.trash[i+1:]...)
+			break
+		}
+	}
+	m.tasks[id] = t
+	return t, nil
+}
+
+func (m *mockRepository) Trash() ([]repository.TrashEntry, error) {
+	return m.trash, nil
+}
+
+func (m *mockRepository) Move(id, dir string) (string, error) {
+	if _, ok := m.tasks[id]; !ok {
+		return "", errors.NotFound("task", id)
+	}
+	return dir, nil
+}
+
+func (m *mockRepository) TaskAt(id, rev string) (*task.Task, *repository.Revision, error) {
+	return nil, nil, errors.NotFound("revision", rev)
+}
+
+func (m *mockRepository) Revert(t *task.Task, revision *repository.Revision) error {
+	return m.Update(t)
+}
+
+func (m *mockRepository) EmptyTrash() (int, error) {
+	n := len(m.trash)
+	m.trash = nil
+	m.trashed = make(map[string]*task.Task)
+	return n, nil
+}
 
 func TestListTasksHandler(t *testing.T) {
 	repo := newMockRepository()
@@ -469,4 +554,376 @@ func TestUpdateTaskHandler(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestUpdateTaskHandler_Version(t *testing.T) {
+	repo := newMockRepository()
+	cfg := config.DefaultConfig()
+	server := NewServer(repo, cfg)
+
+	initialTask := &task.Task{
+		Title:   "Original Title",
+		Tags:    []string{"mdtask", "mdtask/status/TODO"},
+		Created: time.Now(),
+		Updated: time.Now(),
+	}
+	repo.Create(initialTask)
+	version := initialTask.Version
+
+	// get_task exposes the current version
+	result, err := server.getTaskHandler(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Arguments: map[string]interface{}{"id": initialTask.ID}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content := result.Content[0].(mcp.TextContent).Text; !strings.Contains(content, "Version: "+version) {
+		t.Errorf("expected get_task result to contain version %q, got: %s", version, content)
+	}
+
+	update := func(args map[string]interface{}) (*mcp.CallToolResult, error) {
+		return server.updateTaskHandler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Arguments: args},
+		})
+	}
+
+	// Update with the current version succeeds and reports the new one
+	result, err = update(map[string]interface{}{"id": initialTask.ID, "title": "First", "version": version})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	newVersion := repo.versions[initialTask.ID]
+	if newVersion == version {
+		t.Fatal("expected version to change after update")
+	}
+	if content := result.Content[0].(mcp.TextContent).Text; !strings.Contains(content, "Version: "+newVersion) {
+		t.Errorf("expected update result to contain version %q, got: %s", newVersion, content)
+	}
+
+	// Update with the old version is rejected
+	_, err = update(map[string]interface{}{"id": initialTask.ID, "title": "Second", "version": version})
+	if err == nil || !strings.Contains(err.Error(), "conflict") {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+}
+
+func TestUpdateTaskHandler_Fields(t *testing.T) {
+	repo := newMockRepository()
+	cfg := config.DefaultConfig()
+	cfg.Fields = map[string]config.FieldConfig{
+		"points": {Type: config.FieldTypeInt},
+	}
+	server := NewServer(repo, cfg)
+
+	initialTask := &task.Task{
+		Title:   "Task with fields",
+		Tags:    []string{"mdtask", "mdtask/status/TODO"},
+		Created: time.Now(),
+		Updated: time.Now(),
+		Fields:  map[string]interface{}{"owner": "alice", "url": "https://example.com"},
+	}
+	repo.Create(initialTask)
+
+	update := func(args map[string]interface{}) error {
+		_, err := server.updateTaskHandler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Arguments: args},
+		})
+		return err
+	}
+
+	err := update(map[string]interface{}{
+		"id":            initialTask.ID,
+		"fields":        map[string]interface{}{"points": float64(3), "owner": nil, "estimate": "2h"},
+		"remove_fields": []interface{}{"url"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]interface{}{"points": 3, "estimate": "2h"}
+	if got := repo.tasks[initialTask.ID].Fields; !reflect.DeepEqual(got, want) {
+		t.Errorf("Fields = %#v, want %#v", got, want)
+	}
+
+	// Schema and reserved names are enforced
+	if err := update(map[string]interface{}{"id": initialTask.ID, "fields": map[string]interface{}{"points": "many"}}); err == nil {
+		t.Error("expected error for invalid int field")
+	}
+	if err := update(map[string]interface{}{"id": initialTask.ID, "fields": map[string]interface{}{"title": "x"}}); err == nil {
+		t.Error("expected error for reserved field name")
+	}
+
+	result, err := server.getTaskHandler(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Arguments: map[string]interface{}{"id": initialTask.ID}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content := result.Content[0].(mcp.TextContent).Text; !strings.Contains(content, "Fields:\n  estimate: 2h\n  points: 3\n") {
+		t.Errorf("expected get_task to list fields, got: %s", content)
+	}
+}
+
+func TestUpdateTaskHandler_Workflow(t *testing.T) {
+	repo := newMockRepository()
+	cfg := config.DefaultConfig()
+	cfg.Workflow.Transitions = map[string][]string{"TODO": {"WIP"}}
+	server := NewServer(repo, cfg)
+
+	initialTask := &task.Task{
+		Title:   "Workflow Task",
+		Tags:    []string{"mdtask", "mdtask/status/TODO"},
+		Created: time.Now(),
+		Updated: time.Now(),
+	}
+	repo.Create(initialTask)
+
+	update := func(status string) error {
+		_, err := server.updateTaskHandler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Arguments: map[string]interface{}{"id": initialTask.ID, "status": status}},
+		})
+		return err
+	}
+
+	if err := update("DONE"); err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Errorf("expected TODO -> DONE to be rejected, got %v", err)
+	}
+	if err := update("unknown"); err == nil {
+		t.Error("expected an unknown status to be rejected")
+	}
+	if err := update("wip"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := repo.tasks[initialTask.ID].GetStatus(); got != task.StatusWIP {
+		t.Errorf("status = %s, want WIP", got)
+	}
+}
+
+func TestUpdateTaskHandler_Recurring(t *testing.T) {
+	repo := newMockRepository()
+	server := NewServer(repo, config.DefaultConfig())
+
+	chore := &task.Task{
+		Title:   "Weekly review",
+		Tags:    []string{"mdtask", "mdtask/status/WIP", "mdtask/recur/weekly:fri"},
+		Created: time.Now(),
+		Updated: time.Now(),
+	}
+	repo.Create(chore)
+
+	result, err := server.updateTaskHandler(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Arguments: map[string]interface{}{"id": chore.ID, "status": "DONE"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "Next occurrence:") {
+		t.Errorf("result should mention the next occurrence, got %q", text)
+	}
+
+	var next *task.Task
+	for _, candidate := range repo.tasks {
+		if candidate.GetPreviousID() == chore.ID {
+			next = candidate
+		}
+	}
+	if next == nil {
+		t.Fatal("next occurrence was not created")
+	}
+	if next.GetStatus() != task.StatusTODO || next.GetDeadline() == nil || next.GetDeadline().Weekday() != time.Friday {
+		t.Errorf("unexpected next occurrence: status %s, deadline %v", next.GetStatus(), next.GetDeadline())
+	}
+
+	// Completing it again finds the occurrence already there and creates
+	// nothing, so none is reported
+	for _, status := range []string{"WIP", "DONE"} {
+		result, err = server.updateTaskHandler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Arguments: map[string]interface{}{"id": chore.ID, "status": status}},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; strings.Contains(text, "Next occurrence:") {
+		t.Errorf("result should not report an existing occurrence, got %q", text)
+	}
+	if len(repo.tasks) != 2 {
+		t.Errorf("got %d tasks, want the task and one occurrence", len(repo.tasks))
+	}
+}
+
+func TestDependencyHandlers(t *testing.T) {
+	repo := newMockRepository()
+	server := NewServer(repo, config.DefaultConfig())
+
+	call := func(handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]interface{}) (string, error) {
+		result, err := handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Arguments: args},
+		})
+		if err != nil {
+			return "", err
+		}
+		return result.Content[0].(mcp.TextContent).Text, nil
+	}
+
+	design := &task.Task{Title: "Design", Tags: []string{"mdtask", "mdtask/status/TODO"}}
+	repo.Create(design)
+
+	if _, err := call(server.createTaskHandler, map[string]interface{}{"title": "Build", "blocked_by": []interface{}{design.ID}}); err != nil {
+		t.Fatalf("create_task error: %v", err)
+	}
+	var build *task.Task
+	for _, candidate := range repo.tasks {
+		if candidate.Title == "Build" {
+			build = candidate
+		}
+	}
+	if build == nil || !build.IsBlockedBy(design.ID) {
+		t.Fatalf("Build should be blocked by Design, got %v", build)
+	}
+
+	if _, err := call(server.createTaskHandler, map[string]interface{}{"title": "Orphan", "blocked_by": []interface{}{"task/missing"}}); err == nil {
+		t.Error("expected a missing blocker to be rejected")
+	}
+
+	text, err := call(server.getTaskHandler, map[string]interface{}{"id": design.ID})
+	if err != nil {
+		t.Fatalf("get_task error: %v", err)
+	}
+	if !strings.Contains(text, "Blocks: "+build.ID) {
+		t.Errorf("get_task should list the blocked tasks, got %q", text)
+	}
+
+	text, err = call(server.listReadyTasksHandler, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("list_ready_tasks error: %v", err)
+	}
+	if !strings.Contains(text, design.ID) || strings.Contains(text, build.ID) {
+		t.Errorf("only Design should be ready, got %q", text)
+	}
+
+	if _, err := call(server.updateTaskHandler, map[string]interface{}{"id": design.ID, "add_blocked_by": []interface{}{build.ID}}); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("expected a dependency cycle to be rejected, got %v", err)
+	}
+}
+
+func TestQueryTasksHandler(t *testing.T) {
+	repo := newMockRepository()
+	server := NewServer(repo, config.DefaultConfig())
+
+	for _, tags := range [][]string{
+		{"mdtask", "mdtask/status/WIP", "type/bug"},
+		{"mdtask", "mdtask/status/TODO", "type/bug"},
+		{"mdtask", "mdtask/status/WIP", "type/bug", "mdtask/archived"},
+	} {
+		repo.Create(&task.Task{Title: "Login bug", Tags: tags, Created: time.Now(), Updated: time.Now()})
+	}
+
+	query := func(args map[string]interface{}) (string, error) {
+		result, err := server.queryTasksHandler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Arguments: args},
+		})
+		if err != nil {
+			return "", err
+		}
+		return result.Content[0].(mcp.TextContent).Text, nil
+	}
+
+	tests := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"query": "status:WIP and tag:type/bug"}, "Found 1 tasks"},
+		{map[string]interface{}{"query": "status:WIP", "archived": true}, "Found 2 tasks"},
+		{map[string]interface{}{"query": "archived"}, "Found 1 tasks"},
+		{map[string]interface{}{"query": `title~"login" and not status:WIP`}, "Found 1 tasks"},
+	}
+	for _, tt := range tests {
+		text, err := query(tt.args)
+		if err != nil {
+			t.Fatalf("query_tasks(%v) error: %v", tt.args, err)
+		}
+		if !strings.HasPrefix(text, tt.want) {
+			t.Errorf("query_tasks(%v) = %q, want %q", tt.args, text, tt.want)
+		}
+	}
+
+	if _, err := query(map[string]interface{}{"query": "status:(WIP"}); err == nil {
+		t.Error("expected a syntax error")
+	}
+}
+
+func TestTrashHandlers(t *testing.T) {
+	repo := newMockRepository()
+	server := NewServer(repo, config.DefaultConfig())
+
+	call := func(handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]interface{}) (string, error) {
+		result, err := handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Arguments: args},
+		})
+		if err != nil {
+			return "", err
+		}
+		return result.Content[0].(mcp.TextContent).Text, nil
+	}
+
+	parent := &task.Task{Title: "Parent", Tags: []string{"mdtask", "mdtask/status/TODO"}}
+	repo.Create(parent)
+	child := &task.Task{Title: "Child", Tags: []string{"mdtask", "mdtask/status/TODO"}}
+	child.SetParentID(parent.ID)
+	repo.Create(child)
+
+	text, err := call(server.deleteTaskHandler, map[string]interface{}{"id": parent.ID})
+	if err != nil {
+		t.Fatalf("delete_task error: %v", err)
+	}
+	if !strings.Contains(text, "Moved 2 task(s)") || len(repo.tasks) != 0 {
+		t.Errorf("delete_task should delete the parent and its subtask, got %q", text)
+	}
+
+	text, err = call(server.listTrashHandler, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("list_trash error: %v", err)
+	}
+	if !strings.Contains(text, parent.ID) || !strings.Contains(text, "Parent: "+parent.ID) {
+		t.Errorf("list_trash = %q", text)
+	}
+
+	if _, err := call(server.restoreTaskHandler, map[string]interface{}{"id": parent.ID}); err != nil {
+		t.Fatalf("restore_task error: %v", err)
+	}
+	if repo.tasks[parent.ID] == nil || repo.tasks[child.ID] == nil {
+		t.Error("restore_task should restore the parent and its subtask")
+	}
+
+	if _, err := call(server.deleteTaskHandler, map[string]interface{}{"id": parent.ID, "keep_subtasks": true}); err != nil {
+		t.Fatalf("delete_task error: %v", err)
+	}
+	if kept := repo.tasks[child.ID]; kept == nil || kept.HasParent() {
+		t.Errorf("keep_subtasks should detach the subtask, got %v", kept)
+	}
+}
+
+func TestArchiveTaskHandler(t *testing.T) {
+	repo := newMockRepository()
+	server := NewServer(repo, config.DefaultConfig())
+
+	parent := &task.Task{Title: "Parent", Tags: []string{"mdtask", "mdtask/status/TODO"}}
+	repo.Create(parent)
+	child := &task.Task{Title: "Child", Tags: []string{"mdtask", "mdtask/status/TODO"}}
+	child.SetParentID(parent.ID)
+	repo.Create(child)
+
+	// Archiving again is not an error
+	for i := 0; i < 2; i++ {
+		_, err := server.archiveTaskHandler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Arguments: map[string]interface{}{"id": parent.ID}},
+		})
+		if err != nil {
+			t.Fatalf("archive_task error: %v", err)
+		}
+	}
+	if !repo.tasks[parent.ID].IsArchived() || !repo.tasks[child.ID].IsArchived() {
+		t.Error("archive_task should archive the parent and its subtask")
+	}
+}
@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// DBus sends notifications to the freedesktop.org notification service on
+// the session bus, calling it with gdbus
+type DBus struct{}
+
+// Name implements Notifier
+func (DBus) Name() string { return BackendDBus }
+
+// Notify implements Notifier
+func (DBus) Notify(n Notification) error {
+	// Notify(app_name, replaces_id, app_icon, summary, body, actions,
+	// hints, expire_timeout); -1 leaves the timeout to the server
+	return runCommand("gdbus", "call", "--session",
+		"--dest=org.freedesktop.Notifications",
+		"--object-path=/org/freedesktop/Notifications",
+		"--method=org.freedesktop.Notifications.Notify",
+		gvariantString(appName), "0", gvariantString(""),
+		gvariantString(n.Title), gvariantString(n.Body),
+		"[]", "{}", "-1")
+}
+
+// gvariantString quotes s as a string in the GVariant text format that
+// gdbus parses its arguments with
+func gvariantString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`).Replace(s) + `"`
+}
+
+// NotifySend shows notifications with the notify-send program of libnotify
+type NotifySend struct{}
+
+// Name implements Notifier
+func (NotifySend) Name() string { return BackendNotifySend }
+
+// Notify implements Notifier
+func (NotifySend) Notify(n Notification) error {
+	// -- ends the options, so titles starting with - are not taken as one
+	args := []string{"--app-name=" + appName, "--", n.Title}
+	if n.Body != "" {
+		args = append(args, n.Body)
+	}
+	return runCommand("notify-send", args...)
+}
+
+// OSAScript shows macOS notifications through AppleScript
+type OSAScript struct{}
+
+// Name implements Notifier
+func (OSAScript) Name() string { return BackendOSAScript }
+
+// Notify implements Notifier
+func (OSAScript) Notify(n Notification) error {
+	script := fmt.Sprintf(`display notification "%s" with title "mdtask reminder" subtitle "%s" sound name "default"`,
+		escapeAppleScript(n.Body), escapeAppleScript(n.Title))
+	return runCommand("osascript", "-e", script)
+}
+
+func escapeAppleScript(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
+
+// Terminal rings the terminal bell and prints the notification, for systems
+// without a notification service
+type Terminal struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewTerminal returns a notifier writing to w
+func NewTerminal(w io.Writer) *Terminal {
+	return &Terminal{w: w}
+}
+
+// Name implements Notifier
+func (*Terminal) Name() string { return BackendTerminal }
+
+// Notify implements Notifier
+func (t *Terminal) Notify(n Notification) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	msg := "\a🔔 " + n.Title
+	if n.Body != "" {
+		msg += ": " + n.Body
+	}
+	_, err := fmt.Fprintln(t.w, msg)
+	return err
+}
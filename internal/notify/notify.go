@@ -0,0 +1,107 @@
+// Package notify shows desktop notifications through the notification
+// system of the platform, falling back to the terminal.
+package notify
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Notification is a message for the user
+type Notification struct {
+	// Title is the headline, e.g. the task title
+	Title string
+	// Body is the optional text below the title
+	Body string
+}
+
+// Notifier delivers notifications
+type Notifier interface {
+	Notify(n Notification) error
+	// Name identifies the backend, e.g. in log messages
+	Name() string
+}
+
+// Backend names accepted by New and the notify.backend config setting
+const (
+	BackendAuto       = "auto"
+	BackendDBus       = "dbus"
+	BackendNotifySend = "notify-send"
+	BackendOSAScript  = "osascript"
+	BackendTerminal   = "terminal"
+)
+
+// appName is the application name shown by notification daemons
+const appName = "mdtask"
+
+// runCommand runs an external program; tests replace it
+var runCommand = func(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// lookPath and getenv find programs and the session bus; tests replace them
+var (
+	lookPath = exec.LookPath
+	getenv   = os.Getenv
+	goos     = runtime.GOOS
+)
+
+var backends = map[string]func() Notifier{
+	BackendDBus:       func() Notifier { return DBus{} },
+	BackendNotifySend: func() Notifier { return NotifySend{} },
+	BackendOSAScript:  func() Notifier { return OSAScript{} },
+	BackendTerminal:   func() Notifier { return NewTerminal(os.Stdout) },
+}
+
+// Backends returns the names accepted by New
+func Backends() []string {
+	names := []string{BackendAuto}
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// New returns the notifier of a backend. An empty name or "auto" picks the
+// first available of osascript on macOS, the D-Bus notification service
+// and notify-send, and falls back to the terminal.
+func New(name string) (Notifier, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || name == BackendAuto {
+		return detect(), nil
+	}
+	newNotifier, ok := backends[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown notification backend %q (valid: %s)", name, strings.Join(Backends(), ", "))
+	}
+	return newNotifier(), nil
+}
+
+func detect() Notifier {
+	if goos == "darwin" {
+		if _, err := lookPath("osascript"); err == nil {
+			return OSAScript{}
+		}
+	}
+	if getenv("DBUS_SESSION_BUS_ADDRESS") != "" {
+		if _, err := lookPath("gdbus"); err == nil {
+			return DBus{}
+		}
+	}
+	if _, err := lookPath("notify-send"); err == nil {
+		return NotifySend{}
+	}
+	return NewTerminal(os.Stdout)
+}
@@ -0,0 +1,126 @@
+package notify
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// stubCommands records the programs run instead of running them
+func stubCommands(t *testing.T) *[][]string {
+	t.Helper()
+	var calls [][]string
+	orig := runCommand
+	runCommand = func(name string, args ...string) error {
+		calls = append(calls, append([]string{name}, args...))
+		return nil
+	}
+	t.Cleanup(func() { runCommand = orig })
+	return &calls
+}
+
+func TestBackends(t *testing.T) {
+	n := Notification{Title: `Call "Bob"`, Body: "Line one\nback\\slash"}
+
+	tests := []struct {
+		notifier Notifier
+		want     []string
+	}{
+		{DBus{}, []string{"gdbus", "call", "--session",
+			"--dest=org.freedesktop.Notifications",
+			"--object-path=/org/freedesktop/Notifications",
+			"--method=org.freedesktop.Notifications.Notify",
+			`"mdtask"`, "0", `""`, `"Call \"Bob\""`, `"Line one\nback\\slash"`, "[]", "{}", "-1"}},
+		{NotifySend{}, []string{"notify-send", "--app-name=mdtask", "--", `Call "Bob"`, "Line one\nback\\slash"}},
+		{OSAScript{}, []string{"osascript", "-e",
+			`display notification "Line one` + "\n" + `back\\slash" with title "mdtask reminder" subtitle "Call \"Bob\"" sound name "default"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.notifier.Name(), func(t *testing.T) {
+			calls := stubCommands(t)
+			if err := tt.notifier.Notify(n); err != nil {
+				t.Fatalf("Notify() error = %v", err)
+			}
+			if len(*calls) != 1 || !reflect.DeepEqual((*calls)[0], tt.want) {
+				t.Errorf("ran %q, want %q", *calls, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotifySend_NoBody(t *testing.T) {
+	calls := stubCommands(t)
+	NotifySend{}.Notify(Notification{Title: "-x"})
+	want := []string{"notify-send", "--app-name=mdtask", "--", "-x"}
+	if !reflect.DeepEqual((*calls)[0], want) {
+		t.Errorf("ran %q, want %q", (*calls)[0], want)
+	}
+}
+
+func TestBackendError(t *testing.T) {
+	orig := runCommand
+	runCommand = func(string, ...string) error { return errors.New("no bus") }
+	defer func() { runCommand = orig }()
+
+	if err := (DBus{}).Notify(Notification{Title: "x"}); err == nil {
+		t.Error("expected the error of the command")
+	}
+}
+
+func TestTerminal(t *testing.T) {
+	var buf bytes.Buffer
+	n := NewTerminal(&buf)
+	n.Notify(Notification{Title: "Standup", Body: "Room 4"})
+	n.Notify(Notification{Title: "Lunch"})
+	if got, want := buf.String(), "\a🔔 Standup: Room 4\n\a🔔 Lunch\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestNew(t *testing.T) {
+	origLookPath, origGetenv, origGOOS := lookPath, getenv, goos
+	defer func() { lookPath, getenv, goos = origLookPath, origGetenv, origGOOS }()
+
+	tests := []struct {
+		name     string
+		backend  string
+		goos     string
+		programs []string
+		bus      string
+		want     string
+	}{
+		{"macOS", "auto", "darwin", []string{"osascript", "notify-send"}, "", BackendOSAScript},
+		{"session bus", "", "linux", []string{"gdbus", "notify-send"}, "unix:path=/run/user/1000/bus", BackendDBus},
+		{"no session bus", "auto", "linux", []string{"gdbus", "notify-send"}, "", BackendNotifySend},
+		{"nothing installed", "auto", "linux", nil, "unix:path=/run/user/1000/bus", BackendTerminal},
+		{"explicit", "Notify-Send", "darwin", nil, "", BackendNotifySend},
+		{"bell", "terminal", "linux", nil, "", BackendTerminal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goos = tt.goos
+			getenv = func(string) string { return tt.bus }
+			lookPath = func(name string) (string, error) {
+				for _, p := range tt.programs {
+					if p == name {
+						return "/usr/bin/" + name, nil
+					}
+				}
+				return "", errors.New("not found")
+			}
+
+			n, err := New(tt.backend)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if n.Name() != tt.want {
+				t.Errorf("New(%q) = %s, want %s", tt.backend, n.Name(), tt.want)
+			}
+		})
+	}
+
+	if _, err := New("pigeon"); err == nil {
+		t.Error("expected an unknown backend to be rejected")
+	}
+}
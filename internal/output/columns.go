@@ -0,0 +1,121 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/constants"
+	"github.com/tkancf/mdtask/internal/service"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// DefaultColumns are the columns of table formats unless configured
+var DefaultColumns = []string{"id", "status", "title", "deadline", "tags"}
+
+// Column is a value shown for each task in table formats
+type Column struct {
+	// Name is the column name as given in --columns and the header
+	Name  string
+	Value func(t *task.Task) string
+}
+
+// BuiltinColumns lists the built-in column names in display order. Other
+// names show the custom front matter field of that name.
+var BuiltinColumns = []string{
+	"id", "title", "description", "status", "tags", "deadline", "reminder",
+	"created", "updated", "completed", "parent", "blocked_by", "archived",
+	"time_spent", "content",
+}
+
+// ParseColumns returns the columns of the given names, or the default
+// columns if there are none
+func ParseColumns(names []string, workflow *config.Workflow) ([]Column, error) {
+	if len(names) == 0 {
+		names = DefaultColumns
+	}
+
+	columns := make([]Column, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		value := builtinColumn(strings.ToLower(name), workflow)
+		if value != nil {
+			name = strings.ToLower(name)
+		} else {
+			if err := task.ValidateFieldName(name); err != nil {
+				return nil, fmt.Errorf("unknown column %q (built-in: %s; other names are custom fields)", name, strings.Join(BuiltinColumns, ", "))
+			}
+			value = fieldColumn(name)
+		}
+		columns = append(columns, Column{Name: name, Value: value})
+	}
+	return columns, nil
+}
+
+func builtinColumn(name string, workflow *config.Workflow) func(t *task.Task) string {
+	switch name {
+	case "id":
+		return func(t *task.Task) string { return t.ID }
+	case "title":
+		return func(t *task.Task) string { return t.Title }
+	case "description":
+		return func(t *task.Task) string { return t.Description }
+	case "status":
+		return func(t *task.Task) string { return string(t.GetStatus()) }
+	case "tags":
+		return func(t *task.Task) string { return strings.Join(t.UserTags(), " ") }
+	case "deadline":
+		return func(t *task.Task) string { return formatTime(t.GetDeadline(), constants.DateFormat) }
+	case "reminder":
+		return func(t *task.Task) string { return formatTime(t.GetReminder(), constants.DateTimeFormat) }
+	case "created":
+		return func(t *task.Task) string { return t.Created.Format(constants.DateTimeFormat) }
+	case "updated":
+		return func(t *task.Task) string { return t.Updated.Format(constants.DateTimeFormat) }
+	case "completed":
+		return func(t *task.Task) string {
+			return formatTime(service.CompletedAt(t, workflow), constants.DateTimeFormat)
+		}
+	case "parent":
+		return func(t *task.Task) string { return t.GetParentID() }
+	case "blocked_by":
+		return func(t *task.Task) string { return strings.Join(t.GetBlockedBy(), " ") }
+	case "archived":
+		return func(t *task.Task) string {
+			if t.IsArchived() {
+				return "yes"
+			}
+			return ""
+		}
+	case "time_spent":
+		return func(t *task.Task) string {
+			if spent := t.TimeSpent(time.Now()); spent > 0 {
+				return task.FormatDuration(spent)
+			}
+			return ""
+		}
+	case "content":
+		return func(t *task.Task) string { return t.Content }
+	}
+	return nil
+}
+
+func fieldColumn(name string) func(t *task.Task) string {
+	return func(t *task.Task) string {
+		if value, ok := t.GetField(name); ok {
+			return task.FieldString(value)
+		}
+		return ""
+	}
+}
+
+func formatTime(t *time.Time, layout string) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(layout)
+}
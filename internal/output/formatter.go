@@ -0,0 +1,114 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// Options are the settings of a formatter
+type Options struct {
+	// Columns are the values shown by table formats
+	Columns []Column
+	// Workflow tells formats which statuses are done
+	Workflow *config.Workflow
+	// Title heads documents such as the HTML report
+	Title string
+	// Now is the time of the report; zero means the current time
+	Now time.Time
+}
+
+func (o Options) now() time.Time {
+	if o.Now.IsZero() {
+		return time.Now()
+	}
+	return o.Now
+}
+
+// Formatter writes a list of tasks in some format
+type Formatter interface {
+	Format(w io.Writer, tasks []*task.Task, opts Options) error
+}
+
+// FormatterFunc adapts a function to a Formatter
+type FormatterFunc func(w io.Writer, tasks []*task.Task, opts Options) error
+
+// Format calls f
+func (f FormatterFunc) Format(w io.Writer, tasks []*task.Task, opts Options) error {
+	return f(w, tasks, opts)
+}
+
+var (
+	formattersMu sync.RWMutex
+	formatters   = make(map[string]Formatter)
+)
+
+func init() {
+	Register("csv", FormatterFunc(formatCSV))
+	Register("tsv", FormatterFunc(formatTSV))
+	Register("markdown-table", FormatterFunc(formatMarkdown))
+	Register("html", FormatterFunc(formatHTML))
+	Register("todo.txt", FormatterFunc(formatTodoTxt))
+}
+
+// Register makes a formatter available under a name, replacing any
+// formatter registered under that name before
+func Register(name string, f Formatter) {
+	formattersMu.Lock()
+	defer formattersMu.Unlock()
+	formatters[strings.ToLower(name)] = f
+}
+
+// Lookup returns the formatter registered under a name
+func Lookup(name string) (Formatter, bool) {
+	formattersMu.RLock()
+	defer formattersMu.RUnlock()
+	f, ok := formatters[strings.ToLower(name)]
+	return f, ok
+}
+
+// Formats returns the names of the registered formatters, sorted
+func Formats() []string {
+	formattersMu.RLock()
+	defer formattersMu.RUnlock()
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Format writes the tasks with the formatter registered under a name
+func Format(name string, w io.Writer, tasks []*task.Task, opts Options) error {
+	f, ok := Lookup(name)
+	if !ok {
+		return fmt.Errorf("unknown format %q (valid: text, json, %s)", name, strings.Join(Formats(), ", "))
+	}
+	if opts.Workflow == nil {
+		opts.Workflow = config.DefaultWorkflow()
+	}
+	return f.Format(w, tasks, opts)
+}
+
+// rows returns the header and the column values of each task
+func rows(tasks []*task.Task, columns []Column) (header []string, values [][]string) {
+	header = make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = c.Name
+	}
+	values = make([][]string, len(tasks))
+	for i, t := range tasks {
+		values[i] = make([]string, len(columns))
+		for j, c := range columns {
+			values[i][j] = c.Value(t)
+		}
+	}
+	return header, values
+}
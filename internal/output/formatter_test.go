@@ -0,0 +1,149 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+func formatterTasks() []*task.Task {
+	created := time.Date(2025, 6, 20, 9, 0, 0, 0, time.UTC)
+	open := &task.Task{
+		ID:      "task/20250620090000",
+		Title:   "Write report",
+		Tags:    []string{"mdtask", "work", "q2"},
+		Created: created,
+		Updated: created,
+		Fields:  map[string]interface{}{"estimate": "3h", "priority": "a"},
+	}
+	open.SetStatus(task.StatusWIP)
+	open.SetDeadline(time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC))
+
+	done := &task.Task{
+		ID:      "task/20250621090000",
+		Title:   "Fix \"quotes\", | pipes",
+		Tags:    []string{"mdtask"},
+		Created: created.AddDate(0, 0, 1),
+		Updated: created.AddDate(0, 0, 2),
+		Content: "line one\nline two",
+	}
+	done.SetStatus(task.StatusTODO)
+	done.ChangeStatus(task.StatusDONE, created.AddDate(0, 0, 2))
+	done.SetParentID(open.ID)
+	return []*task.Task{open, done}
+}
+
+func TestFormat(t *testing.T) {
+	workflow := config.DefaultWorkflow()
+	columns, err := ParseColumns([]string{"id", "Status", "title", "estimate", "content"}, workflow)
+	if err != nil {
+		t.Fatalf("ParseColumns() error = %v", err)
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"csv", `id,status,title,estimate,content
+task/20250620090000,WIP,Write report,3h,
+task/20250621090000,DONE,"Fix ""quotes"", | pipes",,"line one
+line two"
+`},
+		{"tsv", "id\tstatus\ttitle\testimate\tcontent\n" +
+			"task/20250620090000\tWIP\tWrite report\t3h\t\n" +
+			"task/20250621090000\tDONE\tFix \"quotes\", | pipes\t\tline one line two\n"},
+		{"markdown-table", `| id | status | title | estimate | content |
+| --- | --- | --- | --- | --- |
+| task/20250620090000 | WIP | Write report | 3h |  |
+| task/20250621090000 | DONE | Fix "quotes", \| pipes |  | line one<br>line two |
+`},
+		{"todo.txt", `(A) 2025-06-20 Write report +work +q2 status:WIP due:2025-06-30 id:task/20250620090000
+x 2025-06-22 2025-06-21 Fix "quotes", | pipes id:task/20250621090000 parent:task/20250620090000
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Format(tt.format, &buf, formatterTasks(), Options{Columns: columns, Workflow: workflow}); err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("Format() =\n%s\nwant\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestFormat_HTML(t *testing.T) {
+	workflow := config.DefaultWorkflow()
+	columns, _ := ParseColumns(nil, workflow)
+	tasks := formatterTasks()
+	tasks[0].Title = "<script>alert(1)</script>"
+
+	var buf bytes.Buffer
+	opts := Options{Columns: columns, Workflow: workflow, Title: "Sprint 24", Now: time.Date(2025, 6, 23, 10, 0, 0, 0, time.UTC)}
+	if err := Format("html", &buf, tasks, opts); err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"<title>Sprint 24</title>",
+		"2 tasks, generated 2025-06-23 10:00",
+		`<strong style="color: #F59E0B">1</strong>In Progress`,
+		`<span class="status" style="background: #10B981">DONE</span>`,
+		"&lt;script&gt;",
+		"<td>work q2</td>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report does not contain %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "<script>") {
+		t.Error("report contains an unescaped title")
+	}
+}
+
+func TestFormat_Unknown(t *testing.T) {
+	if err := Format("xml", &bytes.Buffer{}, nil, Options{}); err == nil {
+		t.Error("expected an unknown format to be rejected")
+	}
+}
+
+func TestParseColumns(t *testing.T) {
+	workflow := config.DefaultWorkflow()
+
+	columns, err := ParseColumns(nil, workflow)
+	if err != nil || len(columns) != len(DefaultColumns) {
+		t.Fatalf("ParseColumns(nil) = %d columns, %v", len(columns), err)
+	}
+
+	if _, err := ParseColumns([]string{"id", "status_history"}, workflow); err == nil {
+		t.Error("expected a reserved field to be rejected")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register("count", FormatterFunc(func(w io.Writer, tasks []*task.Task, opts Options) error {
+		_, err := fmt.Fprintf(w, "%d\n", len(tasks))
+		return err
+	}))
+	defer func() {
+		formattersMu.Lock()
+		delete(formatters, "count")
+		formattersMu.Unlock()
+	}()
+
+	var buf bytes.Buffer
+	if err := Format("COUNT", &buf, formatterTasks(), Options{}); err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if buf.String() != "2\n" {
+		t.Errorf("Format() = %q, want %q", buf.String(), "2\n")
+	}
+}
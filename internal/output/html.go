@@ -0,0 +1,105 @@
+package output
+
+import (
+	"html/template"
+	"io"
+
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// htmlReport is a standalone page with a summary per status and a table of
+// the tasks, styled inline so it can be mailed or archived as one file
+var htmlReport = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; margin: 2rem; color: #111827; }
+h1 { font-size: 1.5rem; margin-bottom: 0.25rem; }
+.generated { color: #6B7280; margin-top: 0; }
+.summary { display: flex; gap: 1rem; flex-wrap: wrap; margin: 1.5rem 0; }
+.summary div { border: 1px solid #E5E7EB; border-radius: 6px; padding: 0.5rem 1rem; }
+.summary strong { display: block; font-size: 1.25rem; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #E5E7EB; padding: 0.4rem 0.6rem; text-align: left; vertical-align: top; }
+th { background: #F9FAFB; }
+td { white-space: pre-wrap; }
+.status { color: #fff; border-radius: 4px; padding: 0 0.4rem; white-space: nowrap; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="generated">{{len .Rows}} tasks, generated {{.Generated}}</p>
+<div class="summary">
+{{- range .Summary}}
+<div><strong style="color: {{.Color}}">{{.Count}}</strong>{{.Label}}</div>
+{{- end}}
+</div>
+<table>
+<thead><tr>{{range .Header}}<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{- range .Rows}}
+<tr>{{range .}}<td>{{if .Color}}<span class="status" style="background: {{.Color}}">{{.Text}}</span>{{else}}{{.Text}}{{end}}</td>{{end}}</tr>
+{{- end}}
+</tbody>
+</table>
+</body>
+</html>
+`))
+
+type htmlCell struct {
+	Text  string
+	Color string
+}
+
+type htmlStatus struct {
+	Label string
+	Color string
+	Count int
+}
+
+// formatHTML writes a standalone HTML report
+func formatHTML(w io.Writer, tasks []*task.Task, opts Options) error {
+	workflow := opts.Workflow
+	header, values := rows(tasks, opts.Columns)
+
+	data := struct {
+		Title     string
+		Generated string
+		Summary   []htmlStatus
+		Header    []string
+		Rows      [][]htmlCell
+	}{
+		Title:     opts.Title,
+		Generated: opts.now().Format("2006-01-02 15:04"),
+		Header:    header,
+	}
+	if data.Title == "" {
+		data.Title = "mdtask report"
+	}
+
+	counts := make(map[task.Status]int)
+	for _, t := range tasks {
+		counts[t.GetStatus()]++
+	}
+	for _, status := range workflow.Names() {
+		data.Summary = append(data.Summary, htmlStatus{
+			Label: workflow.Label(status),
+			Color: workflow.Color(status),
+			Count: counts[status],
+		})
+	}
+
+	for i, row := range values {
+		cells := make([]htmlCell, len(row))
+		for j, value := range row {
+			cells[j].Text = value
+			if opts.Columns[j].Name == "status" {
+				cells[j].Color = workflow.Color(tasks[i].GetStatus())
+			}
+		}
+		data.Rows = append(data.Rows, cells)
+	}
+	return htmlReport.Execute(w, data)
+}
@@ -6,29 +6,42 @@ import (
 	"os"
 	"time"
 
+	"github.com/tkancf/mdtask/internal/search"
 	"github.com/tkancf/mdtask/internal/task"
 )
 
 // TaskJSON represents a task in JSON format
 type TaskJSON struct {
-	ID          string     `json:"id"`
-	Title       string     `json:"title"`
-	Description string     `json:"description"`
-	Status      string     `json:"status"`
-	Tags        []string   `json:"tags"`
-	Created     time.Time  `json:"created"`
-	Updated     time.Time  `json:"updated"`
-	Deadline    *time.Time `json:"deadline,omitempty"`
-	Reminder    *time.Time `json:"reminder,omitempty"`
-	IsArchived  bool       `json:"is_archived"`
-	Content     string     `json:"content,omitempty"`
-	ParentID    string     `json:"parent_id,omitempty"`
-	FilePath    string     `json:"file_path,omitempty"`
+	ID          string                 `json:"id"`
+	Title       string                 `json:"title"`
+	Description string                 `json:"description"`
+	Status      string                 `json:"status"`
+	Tags        []string               `json:"tags"`
+	Created     time.Time              `json:"created"`
+	Updated     time.Time              `json:"updated"`
+	Deadline    *time.Time             `json:"deadline,omitempty"`
+	Reminder    *time.Time             `json:"reminder,omitempty"`
+	IsArchived  bool                   `json:"is_archived"`
+	Content     string                 `json:"content,omitempty"`
+	ParentID    string                 `json:"parent_id,omitempty"`
+	BlockedBy   []string               `json:"blocked_by,omitempty"`
+	FilePath    string                 `json:"file_path,omitempty"`
+	Version     string                 `json:"version,omitempty"`
+	Fields      map[string]interface{} `json:"fields,omitempty"`
+
+	StatusHistory []StatusChangeJSON `json:"status_history,omitempty"`
+}
+
+// StatusChangeJSON is a recorded status transition
+type StatusChangeJSON struct {
+	From string    `json:"from"`
+	To   string    `json:"to"`
+	At   time.Time `json:"at"`
 }
 
 // NewTaskJSON creates a TaskJSON from a task.Task
 func NewTaskJSON(t *task.Task) TaskJSON {
-	return TaskJSON{
+	tj := TaskJSON{
 		ID:          t.ID,
 		Title:       t.Title,
 		Description: t.Description,
@@ -41,7 +54,25 @@ func NewTaskJSON(t *task.Task) TaskJSON {
 		IsArchived:  t.IsArchived(),
 		Content:     t.Content,
 		ParentID:    t.GetParentID(),
+		BlockedBy:   t.GetBlockedBy(),
+		Version:     t.Version,
+		Fields:      t.Fields,
 	}
+	for _, change := range t.StatusHistory {
+		tj.StatusHistory = append(tj.StatusHistory, StatusChangeJSON{
+			From: string(change.From),
+			To:   string(change.To),
+			At:   change.At,
+		})
+	}
+	return tj
+}
+
+// SearchResultJSON is a task found by a full-text search
+type SearchResultJSON struct {
+	TaskJSON
+	Score   float64 `json:"score"`
+	Snippet string  `json:"snippet,omitempty"`
 }
 
 // NewTaskJSONWithPath creates a TaskJSON with file path information
@@ -85,6 +116,19 @@ func (p *JSONPrinter) PrintTasks(tasks []*task.Task) error {
 	return p.printJSON(jsonTasks)
 }
 
+// PrintSearchResults outputs search results, best matches first, as JSON array
+func (p *JSONPrinter) PrintSearchResults(results []search.Result) error {
+	jsonResults := make([]SearchResultJSON, len(results))
+	for i, r := range results {
+		jsonResults[i] = SearchResultJSON{
+			TaskJSON: NewTaskJSON(r.Task),
+			Score:    r.Score,
+			Snippet:  r.Snippet.Text,
+		}
+	}
+	return p.printJSON(jsonResults)
+}
+
 // PrintEmpty outputs an empty JSON array
 func (p *JSONPrinter) PrintEmpty() error {
 	return p.printJSON([]TaskJSON{})
@@ -95,4 +139,4 @@ func (p *JSONPrinter) printJSON(v interface{}) error {
 	encoder := json.NewEncoder(p.writer)
 	encoder.SetIndent("", "  ")
 	return encoder.Encode(v)
-}
\ No newline at end of file
+}
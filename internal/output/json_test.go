@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/tkancf/mdtask/internal/search"
 	"github.com/tkancf/mdtask/internal/task"
 )
 
@@ -21,11 +22,14 @@ func TestNewTaskJSON(t *testing.T) {
 		Created:     time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
 		Updated:     time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC),
 		Content:     "Test Content",
+		Version:     "0123456789abcdef",
+		Fields:      map[string]interface{}{"estimate": "3h"},
 	}
 	testTask.SetStatus(task.StatusTODO)
 	testTask.SetDeadline(deadline)
 	testTask.SetReminder(reminder)
 	testTask.SetParentID("task/20240101000000")
+	testTask.AddBlocker("task/20231231000000")
 
 	tj := NewTaskJSON(testTask)
 
@@ -41,12 +45,21 @@ func TestNewTaskJSON(t *testing.T) {
 	if tj.ParentID != "task/20240101000000" {
 		t.Errorf("expected ParentID %q, got %q", "task/20240101000000", tj.ParentID)
 	}
+	if len(tj.BlockedBy) != 1 || tj.BlockedBy[0] != "task/20231231000000" {
+		t.Errorf("expected BlockedBy [task/20231231000000], got %v", tj.BlockedBy)
+	}
 	if tj.Deadline == nil || !tj.Deadline.Equal(deadline) {
 		t.Error("deadline not set correctly")
 	}
 	if tj.Reminder == nil || !tj.Reminder.Equal(reminder) {
 		t.Error("reminder not set correctly")
 	}
+	if tj.Version != testTask.Version {
+		t.Errorf("expected Version %q, got %q", testTask.Version, tj.Version)
+	}
+	if tj.Fields["estimate"] != "3h" {
+		t.Errorf("expected custom fields, got %v", tj.Fields)
+	}
 }
 
 func TestNewTaskJSONWithPath(t *testing.T) {
@@ -134,6 +147,31 @@ func TestJSONPrinter_PrintTasks(t *testing.T) {
 	}
 }
 
+func TestJSONPrinter_PrintSearchResults(t *testing.T) {
+	var buf bytes.Buffer
+	printer := NewJSONPrinter(&buf)
+
+	results := []search.Result{
+		{
+			Task:    &task.Task{ID: "task/20240101120000", Title: "Task 1", Tags: []string{"mdtask"}},
+			Score:   2.5,
+			Snippet: search.Snippet{Text: "the login page"},
+		},
+	}
+
+	if err := printer.PrintSearchResults(results); err != nil {
+		t.Fatalf("PrintSearchResults() error = %v", err)
+	}
+
+	var decoded []SearchResultJSON
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("failed to unmarshal JSON: %v", err)
+	}
+	if len(decoded) != 1 || decoded[0].ID != "task/20240101120000" || decoded[0].Score != 2.5 || decoded[0].Snippet != "the login page" {
+		t.Errorf("unexpected search results: %+v", decoded)
+	}
+}
+
 func TestJSONPrinter_PrintEmpty(t *testing.T) {
 	var buf bytes.Buffer
 	printer := NewJSONPrinter(&buf)
@@ -0,0 +1,59 @@
+package output
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"strings"
+
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// formatCSV writes a header row and a row per task as RFC 4180 CSV
+func formatCSV(w io.Writer, tasks []*task.Task, opts Options) error {
+	header, values := rows(tasks, opts.Columns)
+	cw := csv.NewWriter(w)
+	cw.Write(header)
+	cw.WriteAll(values)
+	return cw.Error()
+}
+
+// formatTSV writes tab-separated values. Tabs and line breaks in values
+// become spaces, since TSV cannot quote them.
+func formatTSV(w io.Writer, tasks []*task.Task, opts Options) error {
+	header, values := rows(tasks, opts.Columns)
+	clean := strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ", "\r", " ")
+
+	bw := bufio.NewWriter(w)
+	for _, row := range append([][]string{header}, values...) {
+		for i, value := range row {
+			row[i] = clean.Replace(value)
+		}
+		bw.WriteString(strings.Join(row, "\t") + "\n")
+	}
+	return bw.Flush()
+}
+
+// formatMarkdown writes a GitHub-flavoured Markdown table
+func formatMarkdown(w io.Writer, tasks []*task.Task, opts Options) error {
+	header, values := rows(tasks, opts.Columns)
+	escape := strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>", "\r", "<br>")
+
+	bw := bufio.NewWriter(w)
+	writeRow := func(row []string) {
+		for i, value := range row {
+			row[i] = escape.Replace(value)
+		}
+		bw.WriteString("| " + strings.Join(row, " | ") + " |\n")
+	}
+	writeRow(header)
+	separator := make([]string, len(header))
+	for i := range separator {
+		separator[i] = "---"
+	}
+	bw.WriteString("| " + strings.Join(separator, " | ") + " |\n")
+	for _, row := range values {
+		writeRow(row)
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,67 @@
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/tkancf/mdtask/internal/constants"
+	"github.com/tkancf/mdtask/internal/service"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// todoTxtPriority matches priority fields that todo.txt can express
+var todoTxtPriority = regexp.MustCompile(`^[A-Z]$`)
+
+// formatTodoTxt writes a line per task in the todo.txt format:
+//
+//	x 2025-06-21 2025-06-20 Write report +work due:2025-06-30 id:task/20250620090000
+//
+// Done tasks are marked with x and their completion date, a priority field
+// of a single capital letter becomes the priority, tags become +projects
+// and the status of tasks that are neither done nor in the first status is
+// kept as status:. Columns are ignored.
+func formatTodoTxt(w io.Writer, tasks []*task.Task, opts Options) error {
+	workflow := opts.Workflow
+	initial := task.Status("")
+	if names := workflow.Names(); len(names) > 0 {
+		initial = names[0]
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, t := range tasks {
+		var parts []string
+		status := t.GetStatus()
+		done := workflow.IsDone(status)
+		if done {
+			parts = append(parts, "x")
+			if at := service.CompletedAt(t, workflow); at != nil {
+				parts = append(parts, at.Format(constants.DateFormat))
+			}
+		} else if value, ok := t.GetField("priority"); ok {
+			if p := strings.ToUpper(task.FieldString(value)); todoTxtPriority.MatchString(p) {
+				parts = append(parts, "("+p+")")
+			}
+		}
+		parts = append(parts, t.Created.Format(constants.DateFormat))
+		parts = append(parts, strings.Join(strings.Fields(t.Title), " "))
+
+		for _, tag := range t.UserTags() {
+			parts = append(parts, "+"+tag)
+		}
+		if !done && status != initial {
+			parts = append(parts, "status:"+string(status))
+		}
+		if d := t.GetDeadline(); d != nil {
+			parts = append(parts, "due:"+d.Format(constants.DateFormat))
+		}
+		parts = append(parts, "id:"+t.ID)
+		if parent := t.GetParentID(); parent != "" {
+			parts = append(parts, "parent:"+parent)
+		}
+		fmt.Fprintln(bw, strings.Join(parts, " "))
+	}
+	return bw.Flush()
+}
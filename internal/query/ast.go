@@ -0,0 +1,200 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Expr is a node of a parsed query
+type Expr interface {
+	// String returns the expression in query syntax
+	String() string
+}
+
+// AndExpr matches tasks matching both sides
+type AndExpr struct {
+	Left, Right Expr
+}
+
+// OrExpr matches tasks matching either side
+type OrExpr struct {
+	Left, Right Expr
+}
+
+// NotExpr matches tasks not matching Expr
+type NotExpr struct {
+	Expr Expr
+}
+
+// FlagExpr is a bare keyword such as archived or overdue
+type FlagExpr struct {
+	Name string
+}
+
+// TextExpr is free text matched against the title, description, content
+// and tags
+type TextExpr struct {
+	Text string
+}
+
+// CompareExpr compares a task field with a value, e.g. deadline<2025-07-01
+type CompareExpr struct {
+	Field string
+	Op    Op
+	Value string
+}
+
+// Op is a comparison operator
+type Op string
+
+// Comparison operators
+const (
+	OpHas   Op = ":"
+	OpEq    Op = "="
+	OpNe    Op = "!="
+	OpLt    Op = "<"
+	OpLe    Op = "<="
+	OpGt    Op = ">"
+	OpGe    Op = ">="
+	OpMatch Op = "~"
+)
+
+func (e *AndExpr) String() string {
+	return group(e.Left, false) + " and " + group(e.Right, false)
+}
+
+func (e *OrExpr) String() string {
+	return e.Left.String() + " or " + e.Right.String()
+}
+
+func (e *NotExpr) String() string {
+	return "not " + group(e.Expr, true)
+}
+
+func (e *FlagExpr) String() string {
+	return e.Name
+}
+
+func (e *TextExpr) String() string {
+	if isFlag(e.Text) || needsQuotes(e.Text) {
+		return quote(e.Text)
+	}
+	return e.Text
+}
+
+func (e *CompareExpr) String() string {
+	value := e.Value
+	if valueNeedsQuotes(value) {
+		value = quote(value)
+	}
+	return e.Field + string(e.Op) + value
+}
+
+// group wraps lower precedence expressions in parentheses
+func group(e Expr, wrapAnd bool) string {
+	switch e.(type) {
+	case *OrExpr:
+		return "(" + e.String() + ")"
+	case *AndExpr:
+		if wrapAnd {
+			return "(" + e.String() + ")"
+		}
+	}
+	return e.String()
+}
+
+func needsQuotes(s string) bool {
+	if s == "" || strings.ContainsAny(s, wordBreaks+" \t\n'") {
+		return true
+	}
+	switch strings.ToLower(s) {
+	case "and", "or", "not":
+		return true
+	}
+	return false
+}
+
+// valueNeedsQuotes is needsQuotes for the value of a comparison, which may
+// contain operator characters
+func valueNeedsQuotes(s string) bool {
+	if s == "" || strings.ContainsAny(s, valueBreaks+" \t\n'") {
+		return true
+	}
+	switch strings.ToLower(s) {
+	case "and", "or", "not":
+		return true
+	}
+	return false
+}
+
+func quote(s string) string {
+	return fmt.Sprintf("%q", s)
+}
+
+// And combines expressions with and, skipping nil ones. It returns nil if
+// there is nothing to combine.
+func And(exprs ...Expr) Expr {
+	var result Expr
+	for _, e := range exprs {
+		if e == nil {
+			continue
+		}
+		if result == nil {
+			result = e
+		} else {
+			result = &AndExpr{Left: result, Right: e}
+		}
+	}
+	return result
+}
+
+// Or combines expressions with or, skipping nil ones
+func Or(exprs ...Expr) Expr {
+	var result Expr
+	for _, e := range exprs {
+		if e == nil {
+			continue
+		}
+		if result == nil {
+			result = e
+		} else {
+			result = &OrExpr{Left: result, Right: e}
+		}
+	}
+	return result
+}
+
+// Tags builds the query for tasks having the include tags (all of them, or
+// any of them if any is set) and none of the exclude tags. It returns nil
+// if both lists are empty.
+func Tags(include, exclude []string, any bool) Expr {
+	var included []Expr
+	for _, tag := range include {
+		included = append(included, &CompareExpr{Field: "tag", Op: OpHas, Value: tag})
+	}
+	var result Expr
+	if any {
+		result = Or(included...)
+	} else {
+		result = And(included...)
+	}
+	for _, tag := range exclude {
+		result = And(result, &NotExpr{Expr: &CompareExpr{Field: "tag", Op: OpHas, Value: tag}})
+	}
+	return result
+}
+
+// UsesArchived reports whether the query filters on the archived flag
+func UsesArchived(e Expr) bool {
+	switch e := e.(type) {
+	case *AndExpr:
+		return UsesArchived(e.Left) || UsesArchived(e.Right)
+	case *OrExpr:
+		return UsesArchived(e.Left) || UsesArchived(e.Right)
+	case *NotExpr:
+		return UsesArchived(e.Expr)
+	case *FlagExpr:
+		return e.Name == FlagArchived
+	}
+	return false
+}
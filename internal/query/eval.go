@@ -0,0 +1,422 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/constants"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// Env is the context a query is evaluated in
+type Env struct {
+	// Now is the reference time for relative dates and overdue tasks
+	Now time.Time
+	// IsDone reports whether a status counts as done; defaults to DONE
+	IsDone func(task.Status) bool
+	// Find looks up other tasks, e.g. to tell whether a blocker is done
+	Find func(id string) *task.Task
+}
+
+// NewEnv returns an environment for evaluating queries over tasks
+func NewEnv(tasks []*task.Task, isDone func(task.Status) bool) *Env {
+	byID := make(map[string]*task.Task, len(tasks))
+	for _, t := range tasks {
+		byID[t.ID] = t
+	}
+	return &Env{
+		Now:    time.Now(),
+		IsDone: isDone,
+		Find:   func(id string) *task.Task { return byID[id] },
+	}
+}
+
+func (env *Env) now() time.Time {
+	if env == nil || env.Now.IsZero() {
+		return time.Now()
+	}
+	return env.Now
+}
+
+func (env *Env) isDone(status task.Status) bool {
+	if env == nil || env.IsDone == nil {
+		return status == task.StatusDONE
+	}
+	return env.IsDone(status)
+}
+
+// Match reports whether t matches the query. env may be nil.
+func Match(e Expr, t *task.Task, env *Env) bool {
+	switch e := e.(type) {
+	case *AndExpr:
+		return Match(e.Left, t, env) && Match(e.Right, t, env)
+	case *OrExpr:
+		return Match(e.Left, t, env) || Match(e.Right, t, env)
+	case *NotExpr:
+		return !Match(e.Expr, t, env)
+	case *FlagExpr:
+		return matchFlag(e.Name, t, env)
+	case *TextExpr:
+		return matchText(e.Text, t)
+	case *CompareExpr:
+		return matchCompare(e, t, env)
+	}
+	return false
+}
+
+// Filter returns the tasks matching e. Archived tasks are only included if
+// includeArchived is set or the query filters on archived itself.
+func Filter(tasks []*task.Task, e Expr, env *Env, includeArchived bool) []*task.Task {
+	includeArchived = includeArchived || UsesArchived(e)
+	var matched []*task.Task
+	for _, t := range tasks {
+		if t.IsArchived() && !includeArchived {
+			continue
+		}
+		if Match(e, t, env) {
+			matched = append(matched, t)
+		}
+	}
+	return matched
+}
+
+func matchFlag(name string, t *task.Task, env *Env) bool {
+	switch name {
+	case FlagArchived:
+		return t.IsArchived()
+	case FlagDone:
+		return env.isDone(t.GetStatus())
+	case FlagOverdue:
+		deadline := t.GetDeadline()
+		return deadline != nil && !env.isDone(t.GetStatus()) &&
+			deadline.Format(constants.DateFormat) < env.now().Format(constants.DateFormat)
+	case FlagBlocked:
+		for _, id := range t.GetBlockedBy() {
+			if env == nil || env.Find == nil {
+				return true
+			}
+			// Blockers that no longer exist do not block
+			if blocker := env.Find(id); blocker != nil && !env.isDone(blocker.GetStatus()) {
+				return true
+			}
+		}
+		return false
+	case FlagRecurring:
+		return hasTagPrefix(t, constants.RecurTagPrefix)
+	case FlagSubtask:
+		return t.HasParent()
+	}
+	return false
+}
+
+func matchText(text string, t *task.Task) bool {
+	if containsFold(t.Title, text) || containsFold(t.Description, text) || containsFold(t.Content, text) {
+		return true
+	}
+	for _, tag := range t.Tags {
+		if containsFold(tag, text) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchCompare(e *CompareExpr, t *task.Task, env *Env) bool {
+	kind, builtin := fields[e.Field]
+	if !builtin {
+		kind = kindCustom
+	}
+
+	switch kind {
+	case kindStatus:
+		return matchString(string(t.GetStatus()), e.Op, e.Value)
+
+	case kindTag:
+		if e.Op == OpNe {
+			return !matchAny(t.Tags, OpEq, e.Value, matchTag)
+		}
+		return matchAny(t.Tags, e.Op, e.Value, matchTag)
+
+	case kindText:
+		values := []string{t.Title, t.Description, t.Content}
+		switch e.Field {
+		case "title":
+			values = values[:1]
+		case "description":
+			values = values[1:2]
+		case "content":
+			values = values[2:]
+		}
+		if e.Op == OpNe {
+			return !matchAny(values, OpEq, e.Value, matchString)
+		}
+		return matchAny(values, e.Op, e.Value, matchString)
+
+	case kindID:
+		var ids []string
+		switch e.Field {
+		case "id":
+			ids = []string{t.ID}
+		case "parent":
+			if parent := t.GetParentID(); parent != "" {
+				ids = []string{parent}
+			}
+		case "blockedby":
+			ids = t.GetBlockedBy()
+		}
+		if e.Op == OpNe {
+			return !matchAny(ids, OpEq, e.Value, matchID)
+		}
+		return matchAny(ids, e.Op, e.Value, matchID)
+
+	case kindDate:
+		want, err := resolveDate(e.Value, env.now())
+		if err != nil {
+			return false
+		}
+		// Values with a time compare to the minute, others by day
+		layout := constants.DateFormat
+		if len(want) > len(constants.DateFormat) {
+			layout = dateTimeLayout
+		}
+		date, ok := taskDate(t, e.Field, layout)
+		if !ok {
+			return e.Op == OpNe
+		}
+		return compare(strings.Compare(date, want), e.Op)
+
+	case kindHas:
+		return hasValue(t, e.Value)
+	}
+
+	// Custom front matter field
+	value, ok := t.GetField(e.Field)
+	if !ok {
+		return e.Op == OpNe
+	}
+	if list, isList := value.([]interface{}); isList {
+		items := make([]string, len(list))
+		for i, item := range list {
+			items[i] = task.FieldString(item)
+		}
+		if e.Op == OpNe {
+			return !matchAny(items, OpEq, e.Value, matchValue)
+		}
+		return matchAny(items, e.Op, e.Value, matchValue)
+	}
+	return matchValue(task.FieldString(value), e.Op, e.Value)
+}
+
+// matchAny reports whether any of the values matches
+func matchAny(values []string, op Op, want string, match func(string, Op, string) bool) bool {
+	for _, v := range values {
+		if match(v, op, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchString compares text case-insensitively; : and ~ test for a substring
+func matchString(value string, op Op, want string) bool {
+	switch op {
+	case OpHas, OpMatch:
+		return containsFold(value, want)
+	case OpEq:
+		return strings.EqualFold(value, want)
+	case OpNe:
+		return !strings.EqualFold(value, want)
+	}
+	return false
+}
+
+// matchTag compares a tag; a trailing * matches any tag with that prefix
+func matchTag(tag string, op Op, want string) bool {
+	if op == OpMatch {
+		return containsFold(tag, want)
+	}
+	if prefix, ok := strings.CutSuffix(want, "*"); ok {
+		return strings.HasPrefix(strings.ToLower(tag), strings.ToLower(prefix))
+	}
+	return strings.EqualFold(tag, want)
+}
+
+// matchID compares task IDs, which may be given without the task/ prefix
+func matchID(id string, op Op, want string) bool {
+	if op == OpMatch {
+		return containsFold(id, want)
+	}
+	return strings.EqualFold(id, want) || strings.EqualFold(id, constants.TaskIDPrefix+want)
+}
+
+// matchValue compares a custom field value. Ordering uses numbers,
+// durations or dates when both sides parse as such.
+func matchValue(value string, op Op, want string) bool {
+	switch op {
+	case OpHas, OpEq, OpNe:
+		equal := strings.EqualFold(value, want)
+		if a, b, ok := parseNumbers(value, want); ok {
+			equal = a == b
+		}
+		if op == OpNe {
+			return !equal
+		}
+		return equal
+	case OpMatch:
+		return containsFold(value, want)
+	}
+	return compare(compareValues(value, want), op)
+}
+
+func compareValues(a, b string) int {
+	if x, y, ok := parseNumbers(a, b); ok {
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	if x, errA := time.ParseDuration(a); errA == nil {
+		if y, errB := time.ParseDuration(b); errB == nil {
+			return compareInt(int64(x), int64(y))
+		}
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+func parseNumbers(a, b string) (float64, float64, bool) {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	return x, y, errA == nil && errB == nil
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compare applies op to the result of a three-way comparison
+func compare(cmp int, op Op) bool {
+	switch op {
+	case OpHas, OpEq:
+		return cmp == 0
+	case OpNe:
+		return cmp != 0
+	case OpLt:
+		return cmp < 0
+	case OpLe:
+		return cmp <= 0
+	case OpGt:
+		return cmp > 0
+	case OpGe:
+		return cmp >= 0
+	}
+	return false
+}
+
+// taskDate returns a date field formatted with layout
+func taskDate(t *task.Task, field, layout string) (string, bool) {
+	var date *time.Time
+	switch field {
+	case "deadline":
+		date = t.GetDeadline()
+	case "reminder":
+		date = t.GetReminder()
+	case "created":
+		date = &t.Created
+	case "updated":
+		date = &t.Updated
+	}
+	if date == nil || date.IsZero() {
+		return "", false
+	}
+	return date.Format(layout), true
+}
+
+var relativeDate = regexp.MustCompile(`^([+-]?\d+)([dwmy])$`)
+
+// dateTimeLayout is the layout of date values with a time
+const dateTimeLayout = "2006-01-02T15:04"
+
+// resolveDate turns a date value into YYYY-MM-DD, or YYYY-MM-DDTHH:MM if
+// it has a time. Besides dates it accepts today, tomorrow, yesterday and
+// offsets from today such as +7d or -2w.
+func resolveDate(value string, now time.Time) (string, error) {
+	switch strings.ToLower(value) {
+	case "today":
+		return now.Format(constants.DateFormat), nil
+	case "tomorrow":
+		return now.AddDate(0, 0, 1).Format(constants.DateFormat), nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1).Format(constants.DateFormat), nil
+	}
+
+	if m := relativeDate.FindStringSubmatch(strings.ToLower(value)); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		switch m[2] {
+		case "d":
+			now = now.AddDate(0, 0, n)
+		case "w":
+			now = now.AddDate(0, 0, 7*n)
+		case "m":
+			now = now.AddDate(0, n, 0)
+		case "y":
+			now = now.AddDate(n, 0, 0)
+		}
+		return now.Format(constants.DateFormat), nil
+	}
+
+	if date, err := time.Parse(dateTimeLayout, value); err == nil {
+		return date.Format(dateTimeLayout), nil
+	}
+	date, err := time.Parse(constants.DateFormat, value)
+	if err != nil {
+		return "", fmt.Errorf("invalid date %q (use YYYY-MM-DD, YYYY-MM-DDTHH:MM, today, tomorrow, yesterday or an offset like +7d)", value)
+	}
+	return date.Format(constants.DateFormat), nil
+}
+
+// hasValue implements has:<name> for built-in and custom fields
+func hasValue(t *task.Task, name string) bool {
+	switch strings.ToLower(name) {
+	case "deadline", "due":
+		return t.GetDeadline() != nil
+	case "reminder":
+		return t.GetReminder() != nil
+	case "parent":
+		return t.HasParent()
+	case "blockedby", "blockers":
+		return t.HasBlockers()
+	case "recur", "recurrence":
+		return hasTagPrefix(t, constants.RecurTagPrefix)
+	case "description":
+		return t.Description != ""
+	case "content":
+		return strings.TrimSpace(t.Content) != ""
+	}
+	_, ok := t.GetField(name)
+	return ok
+}
+
+func hasTagPrefix(t *task.Task, prefix string) bool {
+	for _, tag := range t.Tags {
+		if strings.HasPrefix(tag, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
@@ -0,0 +1,142 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+func newTestTasks() []*task.Task {
+	date := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+
+	login := &task.Task{
+		ID:          "task/20250101000000",
+		Title:       "Fix login redirect",
+		Description: "Users land on a blank page",
+		Tags:        []string{"mdtask", "type/bug", "area/auth"},
+		Created:     date("2025-01-01"),
+		Fields:      map[string]interface{}{"estimate": "3h", "points": float64(5), "owners": []interface{}{"alice", "bob"}},
+	}
+	login.SetStatus(task.StatusWIP)
+	login.SetDeadline(date("2025-06-20"))
+	login.SetReminder(time.Date(2025, 6, 20, 9, 30, 0, 0, time.Local))
+
+	docs := &task.Task{
+		ID:      "task/20250102000000",
+		Title:   "Write docs",
+		Tags:    []string{"mdtask", "type/docs"},
+		Created: date("2025-01-02"),
+		Fields:  map[string]interface{}{"estimate": "30m", "points": float64(2)},
+	}
+	docs.SetStatus(task.StatusTODO)
+	docs.AddBlocker(login.ID)
+
+	old := &task.Task{
+		ID:      "task/20240101000000",
+		Title:   "Old login task",
+		Tags:    []string{"mdtask", "type/bug", "mdtask/recur/weekly:mon"},
+		Created: date("2024-01-01"),
+	}
+	old.SetStatus(task.StatusDONE)
+	old.SetParentID(login.ID)
+	old.Archive()
+
+	return []*task.Task{login, docs, old}
+}
+
+func TestMatch(t *testing.T) {
+	tasks := newTestTasks()
+	env := NewEnv(tasks, nil)
+	env.Now = time.Date(2025, 7, 1, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		query string
+		want  string // first letters of the matching titles
+	}{
+		{"status:wip", "F"},
+		{"status!=WIP", "WO"},
+		{"tag:type/bug", "FO"},
+		{"tag:TYPE/*", "FWO"},
+		{"tag~auth", "F"},
+		{"tag!=type/bug", "W"},
+		{"login", "FO"},
+		{`"blank page"`, "F"},
+		{"title~login", "FO"},
+		{"title=\"write docs\"", "W"},
+		{"deadline<2025-07-01", "F"},
+		{"deadline>=today", ""},
+		{"deadline:2025-06-20", "F"},
+		{"deadline!=2025-06-20", "WO"},
+		{"created<2025-01-02", "FO"},
+		{"reminder<2025-06-20T10:00", "F"},
+		{"reminder<=2025-06-20T09:30", "F"},
+		{"reminder>2025-06-20T09:30", ""},
+		{"reminder:2025-06-20", "F"},
+		{"created>-1y", "FW"},
+		{"has:deadline", "F"},
+		{"has:estimate", "FW"},
+		{"estimate>1h", "F"},
+		{"points>=3", "F"},
+		{"points=5", "F"},
+		{"owners:bob", "F"},
+		{"estimate!=3h", "WO"},
+		{"id:20250102000000", "W"},
+		{"parent:task/20250101000000", "O"},
+		{"blockedby:20250101000000", "W"},
+		{"archived", "O"},
+		{"done", "O"},
+		{"overdue", "F"},
+		{"blocked", "W"},
+		{"recurring", "O"},
+		{"subtask", "O"},
+		{"not done and not archived", "FW"},
+		{`status:WIP and (tag:type/bug or deadline<2025-07-01) and not archived and title~"login"`, "F"},
+		{"tag:type/bug or tag:type/docs and points<3", "FWO"},
+		{"(tag:type/bug or tag:type/docs) and points<3", "W"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			expr, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got := ""
+			for _, task := range tasks {
+				if Match(expr, task, env) {
+					got += task.Title[:1]
+				}
+			}
+			if got != tt.want {
+				t.Errorf("%s matched %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	tasks := newTestTasks()
+
+	bugs, _ := Parse("tag:type/bug")
+	if got := Filter(tasks, bugs, nil, false); len(got) != 1 {
+		t.Errorf("archived tasks should be skipped by default, got %d tasks", len(got))
+	}
+	if got := Filter(tasks, bugs, nil, true); len(got) != 2 {
+		t.Errorf("includeArchived should keep archived tasks, got %d tasks", len(got))
+	}
+
+	archivedBugs, _ := Parse("tag:type/bug and archived")
+	if got := Filter(tasks, archivedBugs, nil, false); len(got) != 1 || !got[0].IsArchived() {
+		t.Errorf("a query on archived should see archived tasks, got %v", got)
+	}
+
+	// Without a task lookup any blocker counts
+	blocked, _ := Parse("blocked")
+	if got := Filter(tasks, blocked, nil, false); len(got) != 1 {
+		t.Errorf("blocked without env matched %d tasks", len(got))
+	}
+}
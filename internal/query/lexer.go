@@ -0,0 +1,130 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+	tokAnd
+	tokOr
+	tokNot
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokString:
+		return fmt.Sprintf("%q", t.text)
+	}
+	return fmt.Sprintf("'%s'", t.text)
+}
+
+// wordBreaks are the characters that end a bare word
+const wordBreaks = "()\":=!<>~&|"
+
+// valueBreaks end the value of a comparison, which may contain operator
+// characters such as the colon of a time
+const valueBreaks = "()\"&|"
+
+// lex splits the input into tokens
+func lex(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+
+		case r == '"' || r == '\'':
+			start := i
+			var sb strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, &Error{Pos: start, Msg: "unterminated string"}
+			}
+			i++
+			tokens = append(tokens, token{tokString, sb.String(), start})
+
+		case r == '!' || r == '<' || r == '>':
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				tokens = append(tokens, token{tokOp, string(runes[i : i+2]), i})
+				i += 2
+			} else if r == '!' {
+				tokens = append(tokens, token{tokNot, "!", i})
+				i++
+			} else {
+				tokens = append(tokens, token{tokOp, string(r), i})
+				i++
+			}
+		case r == ':' || r == '=' || r == '~':
+			tokens = append(tokens, token{tokOp, string(r), i})
+			i++
+
+		case r == '&' || r == '|':
+			if i+1 >= len(runes) || runes[i+1] != r {
+				return nil, &Error{Pos: i, Msg: fmt.Sprintf("unexpected '%c' (use %c%c)", r, r, r)}
+			}
+			kind := tokAnd
+			if r == '|' {
+				kind = tokOr
+			}
+			tokens = append(tokens, token{kind, string(runes[i : i+2]), i})
+			i += 2
+
+		default:
+			start := i
+			breaks := wordBreaks
+			if n := len(tokens); n >= 2 && tokens[n-1].kind == tokOp && tokens[n-2].kind == tokWord {
+				breaks = valueBreaks
+			}
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune(breaks, runes[i]) {
+				i++
+			}
+			word := string(runes[start:i])
+			kind := tokWord
+			switch strings.ToLower(word) {
+			case "and":
+				kind = tokAnd
+			case "or":
+				kind = tokOr
+			case "not":
+				kind = tokNot
+			}
+			tokens = append(tokens, token{kind, word, start})
+		}
+	}
+
+	return append(tokens, token{tokEOF, "", len(runes)}), nil
+}
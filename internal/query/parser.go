@@ -0,0 +1,270 @@
+// Package query implements the task query language shared by the CLI, the
+// web UI and the MCP server, e.g.
+//
+//	status:WIP and (tag:type/bug or deadline<2025-07-01) and not archived and title~"login"
+//
+// Grammar:
+//
+//	expr    = and { ("or" | "||") and }
+//	and     = unary { ["and" | "&&"] unary }      adjacent terms are and-ed
+//	unary   = ("not" | "!") unary | primary
+//	primary = "(" expr ")" | field op value | flag | text
+//	op      = ":" | "=" | "!=" | "<" | "<=" | ">" | ">=" | "~"
+//
+// Values and free text containing spaces or operators must be quoted with
+// double or single quotes.
+package query
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// Error is a syntax error in a query
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid query: %s at position %d", e.Msg, e.Pos+1)
+}
+
+// Flags usable as bare keywords or with is:
+const (
+	FlagArchived  = "archived"
+	FlagDone      = "done"
+	FlagOverdue   = "overdue"
+	FlagBlocked   = "blocked"
+	FlagRecurring = "recurring"
+	FlagSubtask   = "subtask"
+)
+
+var flags = []string{FlagArchived, FlagDone, FlagOverdue, FlagBlocked, FlagRecurring, FlagSubtask}
+
+func isFlag(word string) bool {
+	for _, f := range flags {
+		if strings.EqualFold(word, f) {
+			return true
+		}
+	}
+	return false
+}
+
+type fieldKind int
+
+const (
+	kindCustom fieldKind = iota
+	kindStatus
+	kindTag
+	kindText
+	kindID
+	kindDate
+	kindHas
+	kindIs
+)
+
+// fields maps the built-in field names to their kind
+var fields = map[string]fieldKind{
+	"status":      kindStatus,
+	"tag":         kindTag,
+	"title":       kindText,
+	"description": kindText,
+	"content":     kindText,
+	"text":        kindText,
+	"id":          kindID,
+	"parent":      kindID,
+	"blockedby":   kindID,
+	"deadline":    kindDate,
+	"reminder":    kindDate,
+	"created":     kindDate,
+	"updated":     kindDate,
+	"has":         kindHas,
+	"is":          kindIs,
+}
+
+// fieldAliases are alternative spellings of built-in fields
+var fieldAliases = map[string]string{
+	"tags": "tag",
+	"due":  "deadline",
+}
+
+// allowedOps lists the operators each kind of field supports
+var allowedOps = map[fieldKind][]Op{
+	kindStatus: {OpHas, OpEq, OpNe},
+	kindTag:    {OpHas, OpEq, OpNe, OpMatch},
+	kindText:   {OpHas, OpEq, OpNe, OpMatch},
+	kindID:     {OpHas, OpEq, OpNe, OpMatch},
+	kindDate:   {OpHas, OpEq, OpNe, OpLt, OpLe, OpGt, OpGe},
+	kindHas:    {OpHas},
+	kindIs:     {OpHas},
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// Parse parses a query
+func Parse(input string) (Expr, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 1 {
+		return nil, &Error{Pos: 0, Msg: "empty query"}
+	}
+
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %s", tok)}
+	}
+	return expr, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &OrExpr{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek().kind {
+		case tokAnd:
+			p.next()
+		case tokWord, tokString, tokLParen, tokNot:
+			// Adjacent terms are and-ed
+		default:
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &AndExpr{Left: left, Right: right}
+	}
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	if p.peek().kind == tokNot {
+		p.next()
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &NotExpr{Expr: expr}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokLParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, &Error{Pos: closing.pos, Msg: fmt.Sprintf("expected ')' but found %s", closing)}
+		}
+		return expr, nil
+
+	case tokString:
+		return &TextExpr{Text: tok.text}, nil
+
+	case tokWord:
+		if p.peek().kind != tokOp {
+			if isFlag(tok.text) {
+				return &FlagExpr{Name: strings.ToLower(tok.text)}, nil
+			}
+			return &TextExpr{Text: tok.text}, nil
+		}
+		op := p.next()
+		value := p.next()
+		if value.kind != tokWord && value.kind != tokString {
+			return nil, &Error{Pos: value.pos, Msg: fmt.Sprintf("expected a value after %s%s but found %s", tok.text, op.text, value)}
+		}
+		return newCompare(tok, Op(op.text), value)
+	}
+
+	return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %s", tok)}
+}
+
+// newCompare validates a comparison against the field it refers to
+func newCompare(field token, op Op, value token) (Expr, error) {
+	name := strings.ToLower(field.text)
+	if alias, ok := fieldAliases[name]; ok {
+		name = alias
+	}
+
+	kind, builtin := fields[name]
+	if !builtin {
+		// Anything else is a custom front matter field, which keeps its case
+		name = field.text
+		if err := task.ValidateFieldName(name); err != nil {
+			return nil, &Error{Pos: field.pos, Msg: err.Error()}
+		}
+	}
+
+	if ops, ok := allowedOps[kind]; ok && !containsOp(ops, op) {
+		return nil, &Error{Pos: field.pos, Msg: fmt.Sprintf("operator %s cannot be used with %s", op, name)}
+	}
+
+	switch kind {
+	case kindDate:
+		if _, err := resolveDate(value.text, time.Now()); err != nil {
+			return nil, &Error{Pos: value.pos, Msg: err.Error()}
+		}
+	case kindIs:
+		if !isFlag(value.text) {
+			return nil, &Error{Pos: value.pos, Msg: fmt.Sprintf("unknown flag %q (valid: %s)", value.text, strings.Join(flags, ", "))}
+		}
+		return &FlagExpr{Name: strings.ToLower(value.text)}, nil
+	}
+
+	return &CompareExpr{Field: name, Op: op, Value: value.text}, nil
+}
+
+func containsOp(ops []Op, op Op) bool {
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
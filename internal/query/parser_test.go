@@ -0,0 +1,104 @@
+package query
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "status:WIP", want: "status:WIP"},
+		{input: "STATUS = wip", want: "status=wip"},
+		{input: "login", want: "login"},
+		{input: `"login page"`, want: `"login page"`},
+		{input: `"archived"`, want: `"archived"`},
+		{input: "archived", want: "archived"},
+		{input: "is:Overdue", want: "overdue"},
+		{input: "tags:type/bug", want: "tag:type/bug"},
+		{input: "due<=+7d", want: "deadline<=+7d"},
+		{input: "due<2025-06-20T10:00", want: "deadline<2025-06-20T10:00"},
+		{input: "reminder>=2025-06-20T09:30 and tag:a:b", want: "reminder>=2025-06-20T09:30 and tag:a:b"},
+		{input: "estimate>2", want: "estimate>2"},
+		{input: `title~"log in"`, want: `title~"log in"`},
+		{input: "a b", want: "a and b"},
+		{input: "a && b || c", want: "a and b or c"},
+		{input: "a or b and c", want: "a or b and c"},
+		{input: "(a or b) and c", want: "(a or b) and c"},
+		{input: "not a and b", want: "not a and b"},
+		{input: "!(a and b)", want: "not (a and b)"},
+		{input: "NOT archived", want: "not archived"},
+		{
+			input: `status:WIP and (tag:type/bug or deadline<2025-07-01) and not archived and title~"login"`,
+			want:  "status:WIP and (tag:type/bug or deadline<2025-07-01) and not archived and title~login",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.input, err)
+			}
+			if got := expr.String(); got != tt.want {
+				t.Errorf("Parse(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+
+			// The string form parses back to the same query
+			again, err := Parse(expr.String())
+			if err != nil || again.String() != expr.String() {
+				t.Errorf("round trip of %q gave %v, %v", expr, again, err)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "", want: "empty query"},
+		{input: "   ", want: "empty query"},
+		{input: "(status:WIP", want: "expected ')'"},
+		{input: "status:WIP)", want: "unexpected ')'"},
+		{input: "status:", want: "expected a value"},
+		{input: "status<WIP", want: "operator < cannot be used with status"},
+		{input: "tag>a", want: "operator > cannot be used with tag"},
+		{input: "deadline<soon", want: "invalid date"},
+		{input: "is:forgotten", want: "unknown flag"},
+		{input: `title~"login`, want: "unterminated string"},
+		{input: "a & b", want: "use &&"},
+		{input: "a and", want: "unexpected end of query"},
+		{input: "id:status", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Parse(%q) error = %v", tt.input, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse(%q) error = %v, want %q", tt.input, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTags(t *testing.T) {
+	if Tags(nil, nil, false) != nil {
+		t.Error("Tags() without tags should be nil")
+	}
+	if got := Tags([]string{"a", "b"}, []string{"c"}, true).String(); got != "(tag:a or tag:b) and not tag:c" {
+		t.Errorf("Tags() = %s", got)
+	}
+	if got := Tags([]string{"a", "b"}, nil, false).String(); got != "tag:a and tag:b" {
+		t.Errorf("Tags() = %s", got)
+	}
+}
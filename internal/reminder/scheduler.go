@@ -0,0 +1,219 @@
+// Package reminder schedules the reminders of tasks: it finds the due ones,
+// remembers which were delivered, postpones snoozed ones and works out when
+// the next one is due.
+package reminder
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/constants"
+	"github.com/tkancf/mdtask/internal/errors"
+	"github.com/tkancf/mdtask/internal/notify"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// Reminder is a notification scheduled for a task
+type Reminder struct {
+	Task *task.Task
+	// At is when the reminder fires: the reminder time of the task or the
+	// end of its snooze
+	At      time.Time
+	Snoozed bool
+
+	key string
+}
+
+// Failure is a reminder that could not be delivered
+type Failure struct {
+	Reminder
+	Err error
+}
+
+// Result reports what a run of the scheduler did
+type Result struct {
+	Delivered []Reminder
+	// Skipped are missed reminders older than the catch-up window; they
+	// count as delivered without a notification
+	Skipped []Reminder
+	// Failed reminders are retried after constants.ReminderRetryInterval
+	Failed []Failure
+	// Next is when the next reminder is due, zero if none is pending
+	Next time.Time
+}
+
+func (r *Result) wakeAt(at time.Time) {
+	if r.Next.IsZero() || at.Before(r.Next) {
+		r.Next = at
+	}
+}
+
+// Scheduler delivers the reminders of tasks exactly once
+type Scheduler struct {
+	Notifier notify.Notifier
+	Workflow *config.Workflow
+	// StatePath is the file recording delivered and snoozed reminders
+	StatePath string
+	// CatchUp is how late a missed reminder is still delivered, e.g. after
+	// the computer was turned off. Older ones are skipped; zero delivers
+	// them all.
+	CatchUp time.Duration
+	// Location is the time zone of the reminder times, which tasks store
+	// as wall clock time. Nil means time.Local.
+	Location *time.Location
+}
+
+// NewScheduler returns a scheduler keeping its state in the task directory
+// root
+func NewScheduler(notifier notify.Notifier, workflow *config.Workflow, root string) *Scheduler {
+	return &Scheduler{
+		Notifier:  notifier,
+		Workflow:  workflow,
+		StatePath: StatePath(root),
+		CatchUp:   constants.ReminderCatchUp,
+	}
+}
+
+// Run delivers the reminders of tasks that are due at now and not yet
+// delivered, including ones missed while no scheduler was running, and
+// records them in the state file. The state stays locked during the run.
+func (s *Scheduler) Run(tasks []*task.Task, now time.Time) (*Result, error) {
+	release, err := LockState(s.StatePath)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	state, err := LoadState(s.StatePath)
+	if err != nil {
+		return nil, err
+	}
+	changed := state.prune(s.keys(tasks))
+
+	result := &Result{}
+	for _, r := range s.Pending(tasks, state) {
+		if r.At.After(now) {
+			result.wakeAt(r.At)
+			continue
+		}
+
+		if !r.Snoozed && s.CatchUp > 0 && now.Sub(r.At) > s.CatchUp {
+			result.Skipped = append(result.Skipped, r)
+		} else if err := s.Notifier.Notify(notification(r, now)); err != nil {
+			result.Failed = append(result.Failed, Failure{Reminder: r, Err: err})
+			result.wakeAt(now.Add(constants.ReminderRetryInterval))
+			continue
+		} else {
+			result.Delivered = append(result.Delivered, r)
+		}
+		state.Delivered[r.Task.ID] = r.key
+		delete(state.Snoozed, r.Task.ID)
+		changed = true
+	}
+
+	if changed {
+		if err := state.Save(); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// Pending returns the reminders of tasks that are not delivered yet, in the
+// order they fire. Done tasks have no pending reminders.
+func (s *Scheduler) Pending(tasks []*task.Task, state *State) []Reminder {
+	var pending []Reminder
+	for _, t := range tasks {
+		at, key, ok := s.reminderOf(t)
+		if !ok || s.Workflow.IsDone(t.GetStatus()) {
+			continue
+		}
+		if snooze, ok := state.Snoozed[t.ID]; ok && snooze.Reminder == key {
+			pending = append(pending, Reminder{Task: t, At: snooze.Until.In(s.location()), Snoozed: true, key: key})
+			continue
+		}
+		if state.Delivered[t.ID] == key {
+			continue
+		}
+		pending = append(pending, Reminder{Task: t, At: at, key: key})
+	}
+
+	sort.SliceStable(pending, func(i, j int) bool {
+		if !pending[i].At.Equal(pending[j].At) {
+			return pending[i].At.Before(pending[j].At)
+		}
+		return pending[i].Task.ID < pending[j].Task.ID
+	})
+	return pending
+}
+
+// Snooze postpones the reminder of a task until the given time, whether or
+// not it was delivered already
+func (s *Scheduler) Snooze(t *task.Task, until time.Time) error {
+	_, key, ok := s.reminderOf(t)
+	if !ok {
+		return errors.ValidationError("reminder", fmt.Sprintf("task %s has no reminder", t.ID))
+	}
+	if s.Workflow.IsDone(t.GetStatus()) {
+		return errors.ValidationError("reminder", fmt.Sprintf("task %s is already %s", t.ID, t.GetStatus()))
+	}
+
+	release, err := LockState(s.StatePath)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	state, err := LoadState(s.StatePath)
+	if err != nil {
+		return err
+	}
+	state.Snoozed[t.ID] = Snooze{Reminder: key, Until: until}
+	return state.Save()
+}
+
+// reminderOf returns the reminder time of a task in the scheduler's
+// location and the key it is recorded under
+func (s *Scheduler) reminderOf(t *task.Task) (time.Time, string, bool) {
+	r := t.GetReminder()
+	if r == nil {
+		return time.Time{}, "", false
+	}
+	at := time.Date(r.Year(), r.Month(), r.Day(), r.Hour(), r.Minute(), 0, 0, s.location())
+	return at, r.Format(keyFormat), true
+}
+
+// keys maps the IDs of tasks with a reminder to its key
+func (s *Scheduler) keys(tasks []*task.Task) map[string]string {
+	keys := make(map[string]string)
+	for _, t := range tasks {
+		if _, key, ok := s.reminderOf(t); ok {
+			keys[t.ID] = key
+		}
+	}
+	return keys
+}
+
+func (s *Scheduler) location() *time.Location {
+	if s.Location != nil {
+		return s.Location
+	}
+	return time.Local
+}
+
+// notification builds the message of a reminder. Reminders delivered late
+// say when they were due.
+func notification(r Reminder, now time.Time) notify.Notification {
+	n := notify.Notification{Title: r.Task.Title, Body: r.Task.Description}
+	if !r.Snoozed && now.Sub(r.At) >= time.Minute {
+		missed := fmt.Sprintf("Missed reminder of %s", r.At.Format(constants.DateTimeFormat))
+		if n.Body == "" {
+			n.Body = missed
+		} else {
+			n.Body = missed + "\n" + n.Body
+		}
+	}
+	return n
+}
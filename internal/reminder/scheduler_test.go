@@ -0,0 +1,302 @@
+package reminder
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/constants"
+	"github.com/tkancf/mdtask/internal/notify"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// fakeNotifier records notifications and fails while err is set
+type fakeNotifier struct {
+	sent []notify.Notification
+	err  error
+}
+
+func (f *fakeNotifier) Name() string { return "fake" }
+
+func (f *fakeNotifier) Notify(n notify.Notification) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.sent = append(f.sent, n)
+	return nil
+}
+
+func (f *fakeNotifier) titles() string {
+	var titles []string
+	for _, n := range f.sent {
+		titles = append(titles, n.Title)
+	}
+	f.sent = nil
+	return strings.Join(titles, ",")
+}
+
+var jst = time.FixedZone("JST", 9*60*60)
+
+func newScheduler(t *testing.T) (*Scheduler, *fakeNotifier) {
+	t.Helper()
+	notifier := &fakeNotifier{}
+	s := NewScheduler(notifier, config.DefaultWorkflow(), t.TempDir())
+	s.Location = jst
+	return s, notifier
+}
+
+// newTask returns a task with a reminder at the wall clock time of at
+func newTask(id, title string, at time.Time) *task.Task {
+	tk := &task.Task{ID: id, Title: title, Description: "details", Tags: []string{"mdtask"}}
+	tk.SetStatus(task.StatusTODO)
+	tk.SetReminder(time.Date(at.Year(), at.Month(), at.Day(), at.Hour(), at.Minute(), 0, 0, time.UTC))
+	return tk
+}
+
+func run(t *testing.T, s *Scheduler, tasks []*task.Task, now time.Time) *Result {
+	t.Helper()
+	result, err := s.Run(tasks, now)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	return result
+}
+
+func TestRun_DeliversOnce(t *testing.T) {
+	s, notifier := newScheduler(t)
+	now := time.Date(2025, 6, 20, 9, 30, 0, 0, jst)
+	tasks := []*task.Task{
+		newTask("task/1", "Due now", now),
+		newTask("task/2", "Due later", now.Add(time.Hour)),
+	}
+
+	result := run(t, s, tasks, now.Add(20*time.Second))
+	if got := notifier.titles(); got != "Due now" {
+		t.Errorf("delivered %q, want the reminder of 'Due now'", got)
+	}
+	if !result.Next.Equal(now.Add(time.Hour)) {
+		t.Errorf("Next = %v, want %v", result.Next, now.Add(time.Hour))
+	}
+
+	// A second run, even from another scheduler, does not repeat it
+	again := NewScheduler(notifier, s.Workflow, "")
+	again.StatePath, again.Location = s.StatePath, jst
+	run(t, again, tasks, now.Add(time.Minute))
+	if got := notifier.titles(); got != "" {
+		t.Errorf("delivered %q again", got)
+	}
+
+	result = run(t, s, tasks, now.Add(time.Hour))
+	if got := notifier.titles(); got != "Due later" {
+		t.Errorf("delivered %q, want the reminder of 'Due later'", got)
+	}
+	if !result.Next.IsZero() {
+		t.Errorf("Next = %v with no pending reminders", result.Next)
+	}
+}
+
+func TestRun_CatchUp(t *testing.T) {
+	s, notifier := newScheduler(t)
+	now := time.Date(2025, 6, 20, 9, 30, 0, 0, jst)
+	tasks := []*task.Task{
+		newTask("task/1", "Missed", now.Add(-2*time.Hour)),
+		newTask("task/2", "Long ago", now.Add(-72*time.Hour)),
+	}
+
+	result := run(t, s, tasks, now)
+	if len(notifier.sent) != 1 || notifier.sent[0].Title != "Missed" {
+		t.Fatalf("delivered %+v, want only the missed reminder", notifier.sent)
+	}
+	if body := notifier.sent[0].Body; body != "Missed reminder of 2025-06-20 07:30\ndetails" {
+		t.Errorf("body = %q", body)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0].Task.ID != "task/2" {
+		t.Errorf("skipped %+v, want the reminder older than the catch-up window", result.Skipped)
+	}
+
+	// Skipped reminders count as delivered
+	notifier.sent = nil
+	s.CatchUp = 0
+	run(t, s, tasks, now)
+	if got := notifier.titles(); got != "" {
+		t.Errorf("delivered %q after catching up", got)
+	}
+}
+
+func TestRun_ChangedReminder(t *testing.T) {
+	s, notifier := newScheduler(t)
+	now := time.Date(2025, 6, 20, 9, 30, 0, 0, jst)
+	tk := newTask("task/1", "Call", now)
+
+	run(t, s, []*task.Task{tk}, now)
+	tk.SetReminder(time.Date(2025, 6, 20, 10, 0, 0, 0, time.UTC))
+	result := run(t, s, []*task.Task{tk}, now.Add(time.Minute))
+	if !result.Next.Equal(now.Add(30 * time.Minute)) {
+		t.Errorf("Next = %v, want the new reminder", result.Next)
+	}
+	run(t, s, []*task.Task{tk}, now.Add(30*time.Minute))
+	if got := notifier.titles(); got != "Call,Call" {
+		t.Errorf("delivered %q, want the old and the new reminder", got)
+	}
+}
+
+func TestRun_Snooze(t *testing.T) {
+	s, notifier := newScheduler(t)
+	now := time.Date(2025, 6, 20, 9, 30, 0, 0, jst)
+	tk := newTask("task/1", "Call", now)
+	tasks := []*task.Task{tk}
+
+	run(t, s, tasks, now)
+	if err := s.Snooze(tk, now.Add(30*time.Minute)); err != nil {
+		t.Fatalf("Snooze() error = %v", err)
+	}
+
+	result := run(t, s, tasks, now.Add(time.Minute))
+	if !result.Next.Equal(now.Add(30 * time.Minute)) {
+		t.Errorf("Next = %v, want the end of the snooze", result.Next)
+	}
+	state, err := LoadState(s.StatePath)
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	if pending := s.Pending(tasks, state); len(pending) != 1 || !pending[0].Snoozed {
+		t.Errorf("Pending() = %+v, want the snoozed reminder", pending)
+	}
+
+	run(t, s, tasks, now.Add(45*time.Minute))
+	if len(notifier.sent) != 2 || notifier.sent[1].Body != "details" {
+		t.Errorf("delivered %+v, want the reminder again without a missed note", notifier.sent)
+	}
+	notifier.sent = nil
+	run(t, s, tasks, now.Add(2*time.Hour))
+	if len(notifier.sent) != 0 {
+		t.Errorf("snoozed reminder delivered twice: %+v", notifier.sent)
+	}
+}
+
+// hookNotifier calls notify for every notification
+type hookNotifier func(n notify.Notification)
+
+func (h hookNotifier) Name() string { return "hook" }
+
+func (h hookNotifier) Notify(n notify.Notification) error {
+	h(n)
+	return nil
+}
+
+func TestRun_ConcurrentSnooze(t *testing.T) {
+	s, _ := newScheduler(t)
+	now := time.Date(2025, 6, 20, 9, 30, 0, 0, jst)
+	due := newTask("task/1", "Call", now)
+	later := newTask("task/2", "Write", now.Add(time.Hour))
+
+	// A snooze arriving while the run delivers must wait for it rather
+	// than be overwritten when the run saves
+	snoozed := make(chan error, 1)
+	s.Notifier = hookNotifier(func(notify.Notification) {
+		go func() { snoozed <- s.Snooze(later, now.Add(2*time.Hour)) }()
+		select {
+		case err := <-snoozed:
+			snoozed <- err
+		case <-time.After(200 * time.Millisecond):
+		}
+	})
+	run(t, s, []*task.Task{due, later}, now)
+	if err := <-snoozed; err != nil {
+		t.Fatalf("Snooze() error = %v", err)
+	}
+
+	state, err := LoadState(s.StatePath)
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	if _, ok := state.Delivered["task/1"]; !ok {
+		t.Errorf("Delivered = %v, want task/1", state.Delivered)
+	}
+	if _, ok := state.Snoozed["task/2"]; !ok {
+		t.Errorf("Snoozed = %v, want the snooze of task/2", state.Snoozed)
+	}
+}
+
+func TestSnooze_Invalid(t *testing.T) {
+	s, _ := newScheduler(t)
+	now := time.Date(2025, 6, 20, 9, 30, 0, 0, jst)
+
+	none := &task.Task{ID: "task/1", Tags: []string{"mdtask"}}
+	done := newTask("task/2", "Done", now)
+	done.SetStatus(task.StatusDONE)
+
+	for _, tk := range []*task.Task{none, done} {
+		if err := s.Snooze(tk, now.Add(time.Hour)); err == nil {
+			t.Errorf("Snooze(%s) succeeded", tk.ID)
+		}
+	}
+}
+
+func TestRun_DoneAndFailed(t *testing.T) {
+	s, notifier := newScheduler(t)
+	now := time.Date(2025, 6, 20, 9, 30, 0, 0, jst)
+	done := newTask("task/1", "Done", now)
+	done.SetStatus(task.StatusDONE)
+	open := newTask("task/2", "Open", now)
+	tasks := []*task.Task{done, open}
+
+	notifier.err = fmt.Errorf("no display")
+	result := run(t, s, tasks, now)
+	if len(result.Failed) != 1 || result.Failed[0].Task.ID != "task/2" {
+		t.Fatalf("failed %+v, want the open task", result.Failed)
+	}
+	if want := now.Add(constants.ReminderRetryInterval); !result.Next.Equal(want) {
+		t.Errorf("Next = %v, want a retry at %v", result.Next, want)
+	}
+
+	notifier.err = nil
+	run(t, s, tasks, now.Add(time.Minute))
+	if got := notifier.titles(); got != "Open" {
+		t.Errorf("delivered %q, want the retried reminder only", got)
+	}
+}
+
+func TestLoadState(t *testing.T) {
+	path := StatePath(t.TempDir())
+
+	state, err := LoadState(path)
+	if err != nil || len(state.Delivered) != 0 {
+		t.Fatalf("LoadState() of a missing file = %+v, %v", state, err)
+	}
+
+	state.Delivered["task/1"] = "2025-06-20T09:30"
+	state.Snoozed["task/1"] = Snooze{Reminder: "2025-06-20T09:30", Until: time.Date(2025, 6, 20, 1, 0, 0, 0, time.UTC)}
+	if err := state.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := LoadState(path)
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	if loaded.Delivered["task/1"] != "2025-06-20T09:30" || !loaded.Snoozed["task/1"].Until.Equal(state.Snoozed["task/1"].Until) {
+		t.Errorf("LoadState() = %+v, want %+v", loaded, state)
+	}
+}
+
+func TestState_RenameTask(t *testing.T) {
+	state, _ := LoadState(StatePath(t.TempDir()))
+	state.Delivered["task/1"] = "2025-06-20T09:30"
+	state.Snoozed["task/1"] = Snooze{Reminder: "2025-06-20T09:30"}
+
+	if !state.RenameTask("task/1", "task/2") {
+		t.Error("RenameTask() = false for a task with reminders")
+	}
+	if state.RenameTask("task/3", "task/4") {
+		t.Error("RenameTask() = true for a task without reminders")
+	}
+	if _, ok := state.Delivered["task/1"]; ok || state.Delivered["task/2"] != "2025-06-20T09:30" {
+		t.Errorf("Delivered after RenameTask() = %v", state.Delivered)
+	}
+	if _, ok := state.Snoozed["task/1"]; ok || state.Snoozed["task/2"].Reminder != "2025-06-20T09:30" {
+		t.Errorf("Snoozed after RenameTask() = %v", state.Snoozed)
+	}
+}
@@ -0,0 +1,147 @@
+package reminder
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/constants"
+	"github.com/tkancf/mdtask/internal/filelock"
+)
+
+// keyFormat identifies a reminder by its time, so that changing the
+// reminder of a task arms it again
+const keyFormat = "2006-01-02T15:04"
+
+// Snooze postpones the reminder of a task
+type Snooze struct {
+	// Reminder is the reminder that was snoozed; the snooze is dropped when
+	// the task gets another reminder
+	Reminder string    `json:"reminder"`
+	Until    time.Time `json:"until"`
+}
+
+// State records which reminders were delivered and which are snoozed. It is
+// stored in <root>/.mdtask/reminders.json of the first task directory.
+type State struct {
+	// Delivered maps task IDs to the reminder last delivered
+	Delivered map[string]string `json:"delivered"`
+	Snoozed   map[string]Snooze `json:"snoozed"`
+
+	file string
+}
+
+// StatePath returns the path of the state file for a task directory
+func StatePath(root string) string {
+	return filepath.Join(root, constants.StateDirName, constants.ReminderStateFilename)
+}
+
+// LockState takes the lock guarding the state file at path, so that other
+// mdtask processes, e.g. `remind snooze` while the daemon runs, do not
+// overwrite each other's changes. It returns the function releasing it.
+func LockState(path string) (func(), error) {
+	lock, err := filelock.Acquire(filepath.Join(filepath.Dir(path), constants.ReminderLockFilename), constants.LockTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock reminder state: %w", err)
+	}
+	return lock.Release, nil
+}
+
+// LoadState reads the state file at path. A missing file yields an empty
+// state.
+func LoadState(path string) (*State, error) {
+	state := &State{
+		Delivered: make(map[string]string),
+		Snoozed:   make(map[string]Snooze),
+		file:      path,
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, fmt.Errorf("failed to read reminder state: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("invalid reminder state %s: %w", path, err)
+	}
+	if state.Delivered == nil {
+		state.Delivered = make(map[string]string)
+	}
+	if state.Snoozed == nil {
+		state.Snoozed = make(map[string]Snooze)
+	}
+	return state, nil
+}
+
+// Save writes the state back to its file. The file is replaced by a rename,
+// so a crash never leaves a half written state behind.
+func (s *State) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(s.file)
+	if err := os.MkdirAll(dir, constants.DirPermission); err != nil {
+		return fmt.Errorf("failed to save reminder state: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, constants.ReminderStateFilename+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save reminder state: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to save reminder state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to save reminder state: %w", err)
+	}
+	if err := os.Rename(tmpName, s.file); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to save reminder state: %w", err)
+	}
+	return nil
+}
+
+// RenameTask moves the delivered and snoozed reminders of a task to its
+// new ID. It reports whether the task had any.
+func (s *State) RenameTask(oldID, newID string) bool {
+	key, delivered := s.Delivered[oldID]
+	if delivered {
+		delete(s.Delivered, oldID)
+		s.Delivered[newID] = key
+	}
+	snooze, snoozed := s.Snoozed[oldID]
+	if snoozed {
+		delete(s.Snoozed, oldID)
+		s.Snoozed[newID] = snooze
+	}
+	return delivered || snoozed
+}
+
+// prune forgets tasks that are gone or whose reminder changed. It reports
+// whether anything was removed.
+func (s *State) prune(keys map[string]string) bool {
+	changed := false
+	for id, key := range s.Delivered {
+		if keys[id] != key {
+			delete(s.Delivered, id)
+			changed = true
+		}
+	}
+	for id, snooze := range s.Snoozed {
+		if keys[id] != snooze.Reminder {
+			delete(s.Snoozed, id)
+			changed = true
+		}
+	}
+	return changed
+}
@@ -0,0 +1,266 @@
+// Package report computes daily series of task activity for burndown,
+// cumulative flow and throughput charts, and renders them as text or SVG.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/service"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// Kind is a type of report
+type Kind string
+
+// Report kinds
+const (
+	// Burndown shows the open tasks left each day against an ideal line
+	Burndown Kind = "burndown"
+	// CFD is a cumulative flow diagram: the tasks in each status per day
+	CFD Kind = "cfd"
+	// Throughput shows the tasks completed each day
+	Throughput Kind = "throughput"
+)
+
+// Kinds lists the report kinds in display order
+var Kinds = []Kind{Burndown, CFD, Throughput}
+
+// ParseKind parses a report kind
+func ParseKind(name string) (Kind, error) {
+	for _, k := range Kinds {
+		if strings.EqualFold(name, string(k)) {
+			return k, nil
+		}
+	}
+	names := make([]string, len(Kinds))
+	for i, k := range Kinds {
+		names[i] = string(k)
+	}
+	return "", fmt.Errorf("unknown report %q (valid: %s)", name, strings.Join(names, ", "))
+}
+
+// Title returns the display name of the kind
+func (k Kind) Title() string {
+	switch k {
+	case Burndown:
+		return "Burndown"
+	case CFD:
+		return "Cumulative Flow"
+	case Throughput:
+		return "Throughput"
+	}
+	return string(k)
+}
+
+// Series is a named line, band or set of bars with one value per day
+type Series struct {
+	Name   string
+	Color  string
+	Values []float64
+}
+
+// Report is a chart's data: series of values over consecutive days
+type Report struct {
+	Kind Kind
+	// Days are the start of each day, from the first to the last
+	Days   []time.Time
+	Series []Series
+}
+
+// Burndown series names
+const (
+	SeriesRemaining = "Remaining"
+	SeriesIdeal     = "Ideal"
+	SeriesScope     = "Scope"
+	SeriesCompleted = "Completed"
+)
+
+// Chart colours of the series that are not workflow statuses
+var seriesColors = map[string]string{
+	SeriesRemaining: "#EF4444",
+	SeriesIdeal:     "#9CA3AF",
+	SeriesScope:     "#3B82F6",
+	SeriesCompleted: "#10B981",
+}
+
+// Build computes a report over the days from the day of from to the day of
+// to, both included. Days after the day of now are left out.
+// Archived tasks count only if they are done, since when they were
+// archived is not recorded.
+func Build(kind Kind, tasks []*task.Task, workflow *config.Workflow, from, to, now time.Time) (*Report, error) {
+	// Task times carry their wall clock in the location they were parsed
+	// in, so days are compared by date
+	first := startOfDay(from, from.Location())
+	last := startOfDay(to, from.Location())
+	if today := startOfDay(now, from.Location()); last.After(today) {
+		last = today
+	}
+	if last.Before(first) {
+		return nil, fmt.Errorf("the report range %s to %s is empty", first.Format("2006-01-02"), to.Format("2006-01-02"))
+	}
+
+	r := &Report{Kind: kind}
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		r.Days = append(r.Days, day)
+	}
+
+	var included []*task.Task
+	for _, t := range tasks {
+		if !t.IsArchived() || workflow.IsDone(t.GetStatus()) {
+			included = append(included, t)
+		}
+	}
+
+	switch kind {
+	case Burndown:
+		r.buildBurndown(included, workflow)
+	case CFD:
+		r.buildCFD(included, workflow)
+	case Throughput:
+		r.buildThroughput(included, workflow)
+	default:
+		return nil, fmt.Errorf("unknown report %q", kind)
+	}
+	return r, nil
+}
+
+// newSeries adds a series and returns its values
+func (r *Report) newSeries(name, color string) []float64 {
+	if color == "" {
+		color = seriesColors[name]
+	}
+	values := make([]float64, len(r.Days))
+	r.Series = append(r.Series, Series{Name: name, Color: color, Values: values})
+	return values
+}
+
+// buildBurndown counts, at the end of each day, the tasks that existed and
+// those of them that were not done
+func (r *Report) buildBurndown(tasks []*task.Task, workflow *config.Workflow) {
+	remaining := r.newSeries(SeriesRemaining, "")
+	ideal := r.newSeries(SeriesIdeal, "")
+	scope := r.newSeries(SeriesScope, "")
+
+	for i, day := range r.Days {
+		end := day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		for _, t := range tasks {
+			status, ok := service.StatusAt(t, workflow, end)
+			if !ok {
+				continue
+			}
+			scope[i]++
+			if !workflow.IsDone(status) {
+				remaining[i]++
+			}
+		}
+	}
+
+	// The ideal line burns the first day's open tasks down to zero
+	start := remaining[0]
+	for i := range r.Days {
+		if len(r.Days) == 1 {
+			ideal[i] = start
+			continue
+		}
+		ideal[i] = math.Round(start*float64(len(r.Days)-1-i)/float64(len(r.Days)-1)*100) / 100
+	}
+}
+
+// buildCFD counts the tasks in each status at the end of each day. The
+// series are in reverse workflow order, so done statuses come first and
+// form the bottom band.
+func (r *Report) buildCFD(tasks []*task.Task, workflow *config.Workflow) {
+	names := workflow.Names()
+	index := make(map[task.Status]int, len(names))
+	for i := len(names) - 1; i >= 0; i-- {
+		index[names[i]] = len(r.Series)
+		r.newSeries(string(names[i]), workflow.Color(names[i]))
+	}
+
+	for i, day := range r.Days {
+		end := day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		for _, t := range tasks {
+			status, ok := service.StatusAt(t, workflow, end)
+			if !ok {
+				continue
+			}
+			if s, known := index[status]; known {
+				r.Series[s].Values[i]++
+			}
+		}
+	}
+}
+
+// buildThroughput counts the tasks completed on each day
+func (r *Report) buildThroughput(tasks []*task.Task, workflow *config.Workflow) {
+	completed := r.newSeries(SeriesCompleted, "")
+	for _, t := range tasks {
+		done := service.CompletedAt(t, workflow)
+		if done == nil {
+			continue
+		}
+		day := startOfDay(*done, r.Days[0].Location())
+		for i, d := range r.Days {
+			if d.Equal(day) {
+				completed[i]++
+				break
+			}
+		}
+	}
+}
+
+// Max returns the largest value of the report, stacking the series of a
+// cumulative flow diagram
+func (r *Report) Max() float64 {
+	max := 0.0
+	for i := range r.Days {
+		total := 0.0
+		for _, s := range r.Series {
+			if r.Kind == CFD {
+				total += s.Values[i]
+			} else if s.Values[i] > total {
+				total = s.Values[i]
+			}
+		}
+		if total > max {
+			max = total
+		}
+	}
+	return max
+}
+
+// MarshalJSON encodes the report with dates as YYYY-MM-DD
+func (r *Report) MarshalJSON() ([]byte, error) {
+	type seriesJSON struct {
+		Name   string    `json:"name"`
+		Values []float64 `json:"values"`
+	}
+	out := struct {
+		Report string       `json:"report"`
+		From   string       `json:"from"`
+		To     string       `json:"to"`
+		Days   []string     `json:"days"`
+		Series []seriesJSON `json:"series"`
+	}{Report: string(r.Kind), Days: []string{}, Series: []seriesJSON{}}
+
+	for _, day := range r.Days {
+		out.Days = append(out.Days, day.Format("2006-01-02"))
+	}
+	if len(out.Days) > 0 {
+		out.From, out.To = out.Days[0], out.Days[len(out.Days)-1]
+	}
+	for _, s := range r.Series {
+		out.Series = append(out.Series, seriesJSON{Name: s.Name, Values: s.Values})
+	}
+	return json.Marshal(out)
+}
+
+// startOfDay returns midnight of the date of t in loc
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
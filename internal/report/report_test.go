@@ -0,0 +1,174 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+func day(d, h int) time.Time {
+	return time.Date(2025, 6, d, h, 0, 0, 0, time.UTC)
+}
+
+// sprintTasks returns three tasks created on June 2nd: one done on the
+// 3rd, one started on the 3rd and done on the 4th and one still open
+func sprintTasks() []*task.Task {
+	newTask := func(id string) *task.Task {
+		t := &task.Task{ID: id, Tags: []string{"mdtask"}, Created: day(2, 9), Updated: day(2, 9)}
+		t.SetStatus(task.StatusTODO)
+		return t
+	}
+	quick := newTask("task/1")
+	quick.ChangeStatus(task.StatusDONE, day(3, 10))
+	quick.Updated = day(3, 10)
+
+	slow := newTask("task/2")
+	slow.ChangeStatus(task.StatusWIP, day(3, 11))
+	slow.ChangeStatus(task.StatusDONE, day(4, 15))
+	slow.Updated = day(4, 15)
+
+	open := newTask("task/3")
+
+	// Archived without being done: left out
+	dropped := newTask("task/4")
+	dropped.Archive()
+
+	return []*task.Task{quick, slow, open, dropped}
+}
+
+func TestBuild(t *testing.T) {
+	workflow := config.DefaultWorkflow()
+
+	tests := []struct {
+		kind Kind
+		want map[string][]float64
+	}{
+		{Burndown, map[string][]float64{
+			SeriesRemaining: {0, 3, 2, 1},
+			SeriesIdeal:     {0, 0, 0, 0},
+			SeriesScope:     {0, 3, 3, 3},
+		}},
+		{CFD, map[string][]float64{
+			"DONE": {0, 0, 1, 2},
+			"SCHE": {0, 0, 0, 0},
+			"WAIT": {0, 0, 0, 0},
+			"WIP":  {0, 0, 1, 0},
+			"TODO": {0, 3, 1, 1},
+		}},
+		{Throughput, map[string][]float64{
+			SeriesCompleted: {0, 0, 1, 1},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.kind), func(t *testing.T) {
+			r, err := Build(tt.kind, sprintTasks(), workflow, day(1, 0), day(4, 0), day(20, 0))
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			if len(r.Days) != 4 || !r.Days[0].Equal(day(1, 0)) || !r.Days[3].Equal(day(4, 0)) {
+				t.Fatalf("Days = %v", r.Days)
+			}
+			got := make(map[string][]float64)
+			for _, s := range r.Series {
+				got[s.Name] = s.Values
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("series = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuild_Range(t *testing.T) {
+	workflow := config.DefaultWorkflow()
+
+	// The ideal line starts from the first day's open tasks
+	r, err := Build(Burndown, sprintTasks(), workflow, day(2, 0), day(10, 0), day(4, 12))
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if len(r.Days) != 3 {
+		t.Errorf("days after now should be left out, got %d days", len(r.Days))
+	}
+	if want := []float64{3, 1.5, 0}; !reflect.DeepEqual(r.Series[1].Values, want) {
+		t.Errorf("ideal = %v, want %v", r.Series[1].Values, want)
+	}
+
+	if _, err := Build(Burndown, nil, workflow, day(5, 0), day(4, 0), day(20, 0)); err == nil {
+		t.Error("expected an empty range to be rejected")
+	}
+}
+
+func TestParseKind(t *testing.T) {
+	if k, err := ParseKind("CFD"); err != nil || k != CFD {
+		t.Errorf("ParseKind(CFD) = %v, %v", k, err)
+	}
+	if _, err := ParseKind("gantt"); err == nil {
+		t.Error("expected an unknown report to be rejected")
+	}
+}
+
+func TestReportOutput(t *testing.T) {
+	workflow := config.DefaultWorkflow()
+	r, err := Build(Throughput, sprintTasks(), workflow, day(3, 0), day(4, 0), day(20, 0))
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	want := `{"report":"throughput","from":"2025-06-03","to":"2025-06-04","days":["2025-06-03","2025-06-04"],"series":[{"name":"Completed","values":[1,1]}]}`
+	if string(data) != want {
+		t.Errorf("JSON = %s, want %s", data, want)
+	}
+
+	var buf bytes.Buffer
+	if err := r.WriteText(&buf, 10); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "06-03 Tue ██████████ 1\n") || !strings.Contains(buf.String(), "Total: 2 completed, 1.0 per day") {
+		t.Errorf("WriteText() =\n%s", buf.String())
+	}
+}
+
+func TestSVG(t *testing.T) {
+	workflow := config.NewWorkflow(config.WorkflowConfig{
+		Statuses: []config.StatusConfig{{Name: "TODO"}, {Name: "<b>"}, {Name: "DONE"}},
+	})
+	for _, kind := range Kinds {
+		r, err := Build(kind, sprintTasks(), workflow, day(1, 0), day(4, 0), day(20, 0))
+		if err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+		svg := r.SVG(600, 300)
+		if !strings.HasPrefix(svg, "<svg ") || !strings.HasSuffix(svg, "</svg>\n") {
+			t.Errorf("%s: not an SVG document:\n%s", kind, svg)
+		}
+		if strings.Contains(svg, "<b>") {
+			t.Errorf("%s: status names should be escaped", kind)
+		}
+	}
+}
+
+func TestGridStep(t *testing.T) {
+	tests := []struct {
+		max  float64
+		want float64
+	}{
+		{0, 1}, {3, 1}, {7, 2}, {18, 5}, {40, 10}, {90, 50},
+	}
+	for _, tt := range tests {
+		if got := gridStep(tt.max); got != tt.want {
+			t.Errorf("gridStep(%v) = %v, want %v", tt.max, got, tt.want)
+		}
+	}
+}
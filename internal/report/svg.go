@@ -0,0 +1,175 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+)
+
+// Margins of the plot area inside an SVG chart
+const (
+	svgMarginLeft   = 40
+	svgMarginRight  = 16
+	svgMarginTop    = 16
+	svgMarginBottom = 48
+)
+
+// SVG renders the report as an SVG chart of the given size: lines for a
+// burndown, stacked bands for a cumulative flow diagram and bars for
+// throughput, with a legend below the axis
+func (r *Report) SVG(width, height int) string {
+	step := gridStep(r.Max())
+	c := &svgChart{
+		report: r,
+		left:   svgMarginLeft,
+		top:    svgMarginTop,
+		width:  float64(width - svgMarginLeft - svgMarginRight),
+		height: float64(height - svgMarginTop - svgMarginBottom),
+		step:   step,
+		max:    step * math.Max(1, math.Ceil(r.Max()/step)),
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="100%%" role="img" aria-label="%s" font-family="sans-serif" font-size="11">`,
+		width, height, html.EscapeString(r.Kind.Title()))
+	b.WriteString("\n")
+	c.writeGrid(&b)
+	switch r.Kind {
+	case Burndown:
+		c.writeLines(&b)
+	case CFD:
+		c.writeBands(&b)
+	case Throughput:
+		c.writeBars(&b)
+	}
+	c.writeLegend(&b, height)
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+type svgChart struct {
+	report        *Report
+	left, top     float64
+	width, height float64
+	// step is the distance of the grid lines, max the top of the axis
+	step, max float64
+}
+
+// x returns the horizontal centre of day i
+func (c *svgChart) x(i int) float64 {
+	return c.left + c.slot()*(float64(i)+0.5)
+}
+
+// slot is the horizontal space of one day
+func (c *svgChart) slot() float64 {
+	return c.width / float64(len(c.report.Days))
+}
+
+func (c *svgChart) y(value float64) float64 {
+	return c.top + c.height - value/c.max*c.height
+}
+
+// writeGrid draws horizontal grid lines with their values and the day
+// labels, thinned out so they do not overlap
+func (c *svgChart) writeGrid(b *strings.Builder) {
+	for value := 0.0; value <= c.max+c.step/2; value += c.step {
+		y := c.y(value)
+		fmt.Fprintf(b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#E5E7EB"/>`+"\n", c.left, y, c.left+c.width, y)
+		fmt.Fprintf(b, `<text x="%.1f" y="%.1f" text-anchor="end" fill="#6B7280">%s</text>`+"\n", c.left-6, y+4, formatValue(value))
+	}
+
+	step := int(math.Ceil(float64(len(c.report.Days)) / 10))
+	for i, day := range c.report.Days {
+		if i%step != 0 {
+			continue
+		}
+		fmt.Fprintf(b, `<text x="%.1f" y="%.1f" text-anchor="middle" fill="#6B7280">%s</text>`+"\n",
+			c.x(i), c.top+c.height+16, day.Format("01-02"))
+	}
+}
+
+// writeLines draws each series as a line; the ideal line is dashed
+func (c *svgChart) writeLines(b *strings.Builder) {
+	for _, s := range c.report.Series {
+		points := make([]string, len(s.Values))
+		for i, v := range s.Values {
+			points[i] = fmt.Sprintf("%.1f,%.1f", c.x(i), c.y(v))
+		}
+		dash := ""
+		if s.Name == SeriesIdeal {
+			dash = ` stroke-dasharray="4 4"`
+		}
+		fmt.Fprintf(b, `<polyline fill="none" stroke="%s" stroke-width="2"%s points="%s"><title>%s</title></polyline>`+"\n",
+			html.EscapeString(s.Color), dash, strings.Join(points, " "), html.EscapeString(s.Name))
+	}
+}
+
+// writeBands stacks the series from the bottom up
+func (c *svgChart) writeBands(b *strings.Builder) {
+	base := make([]float64, len(c.report.Days))
+	for _, s := range c.report.Series {
+		top := make([]float64, len(base))
+		for i := range base {
+			top[i] = base[i] + s.Values[i]
+		}
+
+		var points []string
+		for i := range top {
+			points = append(points, fmt.Sprintf("%.1f,%.1f", c.x(i), c.y(top[i])))
+		}
+		for i := len(base) - 1; i >= 0; i-- {
+			points = append(points, fmt.Sprintf("%.1f,%.1f", c.x(i), c.y(base[i])))
+		}
+		fmt.Fprintf(b, `<polygon fill="%s" fill-opacity="0.8" stroke="%s" points="%s"><title>%s</title></polygon>`+"\n",
+			html.EscapeString(s.Color), html.EscapeString(s.Color), strings.Join(points, " "), html.EscapeString(s.Name))
+		base = top
+	}
+}
+
+// writeBars draws a bar per day for the first series
+func (c *svgChart) writeBars(b *strings.Builder) {
+	s := c.report.Series[0]
+	barWidth := c.slot() * 0.7
+	for i, v := range s.Values {
+		if v == 0 {
+			continue
+		}
+		y := c.y(v)
+		fmt.Fprintf(b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%s: %s</title></rect>`+"\n",
+			c.x(i)-barWidth/2, y, barWidth, c.top+c.height-y, html.EscapeString(s.Color),
+			c.report.Days[i].Format("2006-01-02"), formatValue(v))
+	}
+}
+
+func (c *svgChart) writeLegend(b *strings.Builder, height int) {
+	x := c.left
+	y := float64(height) - 12
+	for _, s := range c.report.Series {
+		fmt.Fprintf(b, `<rect x="%.1f" y="%.1f" width="10" height="10" fill="%s"/>`+"\n", x, y-9, html.EscapeString(s.Color))
+		fmt.Fprintf(b, `<text x="%.1f" y="%.1f" fill="#374151">%s</text>`+"\n", x+14, y, html.EscapeString(s.Name))
+		x += 14 + 7*float64(len(s.Name)) + 16
+	}
+}
+
+// gridStep picks the distance of the grid lines for values up to max: 1, 2
+// or 5 times a power of ten, giving about four lines
+func gridStep(max float64) float64 {
+	if max <= 4 {
+		return 1
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(max/4)))
+	for _, f := range []float64{1, 2, 5} {
+		if f*magnitude >= max/4 {
+			return f * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+func formatValue(v float64) string {
+	if v == math.Trunc(v) {
+		return fmt.Sprintf("%.0f", v)
+	}
+	return fmt.Sprintf("%.1f", v)
+}
@@ -0,0 +1,116 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// DefaultTextWidth is the width of the bars of text charts in characters
+const DefaultTextWidth = 50
+
+// cfdRunes are the fill characters of the bands of a cumulative flow
+// diagram, so the bands stay apart without colours
+var cfdRunes = []rune{'█', '▓', '▒', '░', '#', '=', '+', ':'}
+
+// WriteText renders the report as a text chart with one row per day and
+// bars up to width characters long
+func (r *Report) WriteText(w io.Writer, width int) error {
+	if width <= 0 {
+		width = DefaultTextWidth
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s to %s\n", r.Kind.Title(), r.Days[0].Format("2006-01-02"), r.Days[len(r.Days)-1].Format("2006-01-02"))
+	switch r.Kind {
+	case Burndown:
+		r.writeBurndown(&b, width)
+	case CFD:
+		r.writeCFD(&b, width)
+	case Throughput:
+		r.writeThroughput(&b, width)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// scale returns the number of characters a value takes up
+func scale(value, max float64, width int) int {
+	if max <= 0 {
+		return 0
+	}
+	return int(math.Round(value / max * float64(width)))
+}
+
+func dayLabel(r *Report, i int) string {
+	return r.Days[i].Format("01-02 Mon")
+}
+
+// writeBurndown draws the remaining tasks as a solid bar within the scope,
+// with the ideal remaining tasks marked by a line
+func (r *Report) writeBurndown(b *strings.Builder, width int) {
+	remaining, ideal, scope := r.Series[0].Values, r.Series[1].Values, r.Series[2].Values
+	max := r.Max()
+	fmt.Fprintf(b, "█ remaining  ░ scope  │ ideal\n\n")
+	for i := range r.Days {
+		bar := []rune(strings.Repeat(" ", width))
+		for c := 0; c < scale(scope[i], max, width); c++ {
+			bar[c] = '░'
+		}
+		for c := 0; c < scale(remaining[i], max, width); c++ {
+			bar[c] = '█'
+		}
+		if c := scale(ideal[i], max, width); c > 0 {
+			bar[c-1] = '│'
+		}
+		fmt.Fprintf(b, "%s %s %3.0f of %-3.0f ideal %.1f\n", dayLabel(r, i), string(bar), remaining[i], scope[i], ideal[i])
+	}
+}
+
+// writeCFD draws a stacked bar per day, done statuses first
+func (r *Report) writeCFD(b *strings.Builder, width int) {
+	max := r.Max()
+	styles := make([]lipgloss.Style, len(r.Series))
+	var legend []string
+	for s, series := range r.Series {
+		styles[s] = lipgloss.NewStyle().Foreground(lipgloss.Color(series.Color))
+		legend = append(legend, styles[s].Render(string(cfdRunes[s%len(cfdRunes)]))+" "+series.Name)
+	}
+	fmt.Fprintf(b, "%s\n\n", strings.Join(legend, "  "))
+
+	for i := range r.Days {
+		var bar strings.Builder
+		total, drawn := 0.0, 0
+		var counts []string
+		for s, series := range r.Series {
+			// Scale the running total so rounding does not add up
+			total += series.Values[i]
+			end := scale(total, max, width)
+			if end > drawn {
+				bar.WriteString(styles[s].Render(strings.Repeat(string(cfdRunes[s%len(cfdRunes)]), end-drawn)))
+				drawn = end
+			}
+			counts = append(counts, fmt.Sprintf("%.0f", series.Values[i]))
+		}
+		bar.WriteString(strings.Repeat(" ", width-drawn))
+		fmt.Fprintf(b, "%s %s %s\n", dayLabel(r, i), bar.String(), strings.Join(counts, "/"))
+	}
+}
+
+// writeThroughput draws a bar per day and the total and daily average
+func (r *Report) writeThroughput(b *strings.Builder, width int) {
+	completed := r.Series[0].Values
+	max := r.Max()
+	total := 0.0
+	b.WriteString("\n")
+	for i := range r.Days {
+		total += completed[i]
+		n := scale(completed[i], max, width)
+		bar := strings.Repeat("█", n) + strings.Repeat(" ", width-n)
+		fmt.Fprintf(b, "%s %s %.0f\n", dayLabel(r, i), bar, completed[i])
+	}
+	fmt.Fprintf(b, "\nTotal: %.0f completed, %.1f per day\n", total, total/float64(len(r.Days)))
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/tkancf/mdtask/internal/constants"
+)
+
+// writeFileAtomic replaces path with data without ever leaving a partially
+// written file behind. The data is written to a temporary file in the same
+// directory, synced to disk and renamed over the original, so readers and
+// crashes only ever observe the old or the new content. An existing file
+// keeps its permission bits; new files are created with
+// constants.FilePermission. A symlink is kept and its target replaced.
+func writeFileAtomic(path string, data []byte) error {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+
+	tmpName, err := stageFile(path, data)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// stageFile writes data to a temporary file in the directory of path and
+// returns its name; renaming it to path replaces the file atomically. The
+// temporary file has the permission bits of path if it exists and is
+// removed on failure.
+func stageFile(path string, data []byte) (string, error) {
+	perm := os.FileMode(constants.FilePermission)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	tmpName := tmp.Name()
+
+	// Remove the temporary file on any failure below
+	success := false
+	defer func() {
+		if !success {
+			tmp.Close()
+			os.Remove(tmpName)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return "", err
+	}
+	if err := tmp.Sync(); err != nil {
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		return "", err
+	}
+	success = true
+	return tmpName, nil
+}
+
+// syncDir flushes a directory entry change such as a rename to disk.
+// Not all platforms support syncing directories, so errors are ignored.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
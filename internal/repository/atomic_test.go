@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "note.md")
+
+	// New files get the default permission
+	if err := writeFileAtomic(path, []byte("first")); err != nil {
+		t.Fatalf("writeFileAtomic() error = %v", err)
+	}
+
+	// Existing files keep their mode
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(path, []byte("second")); err != nil {
+		t.Fatalf("writeFileAtomic() error = %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "second" {
+		t.Errorf("expected content %q, got %q", "second", content)
+	}
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("expected mode 0600 to be preserved, got %o", info.Mode().Perm())
+		}
+	}
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("expected only the target file, got %v", names)
+	}
+}
+
+func TestWriteFileAtomic_FailureKeepsOriginal(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Getuid() == 0 {
+		t.Skip("requires a non-root user on a Unix-like system")
+	}
+
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "note.md")
+	if err := os.WriteFile(path, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The temp file cannot be created in a read-only directory
+	if err := os.Chmod(tempDir, 0555); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(tempDir, 0755)
+
+	if err := writeFileAtomic(path, []byte("replacement")); err == nil {
+		t.Fatal("expected error writing into a read-only directory")
+	}
+
+	content, _ := os.ReadFile(path)
+	if string(content) != "original" {
+		t.Errorf("original file was modified: %q", content)
+	}
+}
+
+func TestTaskRepository_LockFile(t *testing.T) {
+	tempDir := t.TempDir()
+
+	repo := NewTaskRepository([]string{tempDir})
+	repo.useLockFile = true
+
+	newTask := &task.Task{
+		Title:   "Locked write",
+		Created: time.Now(),
+		Updated: time.Now(),
+	}
+	if _, err := repo.Create(newTask); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	newTask.Title = "Locked update"
+	if err := repo.Update(newTask); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	// While another holder has the lock, acquiring it again times out
+	held, err := acquireFileLock(tempDir, time.Second)
+	if err != nil {
+		t.Fatalf("acquireFileLock() error = %v", err)
+	}
+	if _, err := acquireFileLock(tempDir, 100*time.Millisecond); err == nil {
+		t.Error("expected second lock attempt to time out")
+	}
+	held.Release()
+
+	// Once released the lock can be taken again
+	again, err := acquireFileLock(tempDir, time.Second)
+	if err != nil {
+		t.Fatalf("acquireFileLock() after release error = %v", err)
+	}
+	again.Release()
+}
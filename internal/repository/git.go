@@ -0,0 +1,325 @@
+package repository
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/errors"
+	"github.com/tkancf/mdtask/internal/task"
+	"github.com/tkancf/mdtask/pkg/markdown"
+)
+
+// Revision is a commit that changed the file of a task
+type Revision struct {
+	Hash    string
+	Author  string
+	Time    time.Time
+	Subject string
+	// Path is the file of the task in that commit, relative to the top of
+	// the work tree
+	Path string
+}
+
+// Short returns the abbreviated commit hash
+func (rev Revision) Short() string {
+	if len(rev.Hash) > 7 {
+		return rev.Hash[:7]
+	}
+	return rev.Hash
+}
+
+// gitRepo runs git in the work tree containing a task directory
+type gitRepo struct {
+	top string
+	// identity is passed to commands that commit when the user has not
+	// configured a name and email, so auto-commit works out of the box
+	identity []string
+}
+
+// openGitRepo returns the work tree containing dir, or nil when dir is not
+// in one or git is not installed
+func openGitRepo(dir string) *gitRepo {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return nil
+	}
+	g := &gitRepo{top: strings.TrimSpace(string(out))}
+	if _, err := g.run("var", "GIT_COMMITTER_IDENT"); err != nil {
+		g.identity = []string{"-c", "user.name=mdtask", "-c", "user.email=mdtask@localhost"}
+	}
+	return g
+}
+
+func (g *gitRepo) run(args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", g.top}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// test runs a git command that answers yes or no through its exit status
+func (g *gitRepo) test(args ...string) (bool, error) {
+	err := exec.Command("git", append([]string{"-C", g.top}, args...)...).Run()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return true, nil
+}
+
+// rel returns path relative to the top of the work tree in git's spelling
+func (g *gitRepo) rel(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	// git reports the top with symlinks resolved
+	dir, err := filepath.EvalSymlinks(filepath.Dir(abs))
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(g.top, filepath.Join(dir, filepath.Base(abs)))
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// commit records the current state of paths, which may have been removed,
+// doing nothing when they are unchanged. Other staged changes are left out
+// of the commit.
+func (g *gitRepo) commit(message string, paths ...string) error {
+	var rels []string
+	for _, path := range paths {
+		rel, err := g.rel(path)
+		if err != nil {
+			return err
+		}
+		stage := []string{"add", "--", rel}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			stage = []string{"rm", "--cached", "--quiet", "--ignore-unmatch", "--", rel}
+		}
+		if _, err := g.run(stage...); err != nil {
+			return err
+		}
+		rels = append(rels, rel)
+	}
+
+	unchanged, err := g.test(append([]string{"diff", "--cached", "--quiet", "--"}, rels...)...)
+	if err != nil || unchanged {
+		return err
+	}
+	args := append(append([]string{}, g.identity...), "commit", "--quiet", "-m", message, "--")
+	_, err = g.run(append(args, rels...)...)
+	return err
+}
+
+// log returns the commits that changed rel, newest first, following renames
+func (g *gitRepo) log(rel string) ([]Revision, error) {
+	out, err := g.run("log", "--follow", "--name-status", "--format=%x1e%H%x1f%an%x1f%aI%x1f%s", "--", rel)
+	if err != nil {
+		return nil, err
+	}
+
+	var revisions []Revision
+	for _, record := range strings.Split(string(out), "\x1e") {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+		header := strings.Split(lines[0], "\x1f")
+		if len(header) != 4 {
+			continue
+		}
+		rev := Revision{Hash: header[0], Author: header[1], Subject: header[3], Path: rel}
+		rev.Time, _ = time.Parse(time.RFC3339, header[2])
+		for _, line := range lines[1:] {
+			// A, M and D lines name the file; R and C lines name the
+			// old and the new file
+			fields := strings.Split(line, "\t")
+			if len(fields) >= 2 {
+				rev.Path = fields[len(fields)-1]
+			}
+		}
+		revisions = append(revisions, rev)
+	}
+	return revisions, nil
+}
+
+// show returns the content of rel in a commit
+func (g *gitRepo) show(hash, rel string) ([]byte, error) {
+	return g.run("show", hash+":"+rel)
+}
+
+// gitFor returns the work tree containing root, or nil. The lookup is
+// cached per root.
+func (r *TaskRepository) gitFor(root string) *gitRepo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if g, ok := r.gitRepos[root]; ok {
+		return g
+	}
+	g := openGitRepo(root)
+	r.gitRepos[root] = g
+	return g
+}
+
+// commitFiles commits task files after a write when auto-commit is on;
+// the caller must hold the write lock. Files in different work trees are
+// committed separately.
+func (r *TaskRepository) commitFiles(message string, paths ...string) error {
+	if !r.autoCommit {
+		return nil
+	}
+
+	var repos []*gitRepo
+	files := make(map[string][]string)
+	for _, path := range paths {
+		g := r.gitFor(r.rootFor(path))
+		if g == nil {
+			continue
+		}
+		if _, ok := files[g.top]; !ok {
+			repos = append(repos, g)
+		}
+		files[g.top] = append(files[g.top], path)
+	}
+
+	for _, g := range repos {
+		if err := g.commit(message, files[g.top]...); err != nil {
+			return errors.InternalError("task saved but the git commit failed", err)
+		}
+	}
+	return nil
+}
+
+// commitMessage describes the change of a task from old to t. Old is nil
+// for a new task.
+func commitMessage(old, t *task.Task) string {
+	verb := "Update"
+	switch {
+	case old == nil:
+		verb = "Create"
+	case !old.IsArchived() && t.IsArchived():
+		verb = "Archive"
+	case old.IsArchived() && !t.IsArchived():
+		verb = "Unarchive"
+	}
+	message := fmt.Sprintf("%s %s: %s", verb, t.ID, t.Title)
+
+	var body []string
+	for _, c := range task.Diff(old, t) {
+		if c.Field == "content" || c.Field == "description" {
+			body = append(body, c.Field+" changed")
+			continue
+		}
+		body = append(body, fmt.Sprintf("%s: %q -> %q", c.Field, c.Old, c.New))
+	}
+	if old != nil && len(body) > 0 {
+		message += "\n\n" + strings.Join(body, "\n")
+	}
+	return message
+}
+
+// taskGit returns the work tree and the current file of a task
+func (r *TaskRepository) taskGit(id string) (*gitRepo, string, error) {
+	_, path, err := r.FindByIDWithPath(id)
+	if err != nil {
+		return nil, "", err
+	}
+	g := r.gitFor(r.rootFor(path))
+	if g == nil {
+		return nil, "", errors.ValidationError("git", fmt.Sprintf("%s is not in a git repository", filepath.Dir(path)))
+	}
+	rel, err := g.rel(path)
+	if err != nil {
+		return nil, "", errors.InternalError("failed to resolve task file", err)
+	}
+	return g, rel, nil
+}
+
+// History returns the commits that changed the file of a task, newest first
+func (r *TaskRepository) History(id string) ([]Revision, error) {
+	g, rel, err := r.taskGit(id)
+	if err != nil {
+		return nil, err
+	}
+	revisions, err := g.log(rel)
+	if err != nil {
+		return nil, errors.InternalError("failed to read git history", err)
+	}
+	return revisions, nil
+}
+
+// TaskAt returns a task as it was committed at rev, which can be any git
+// revision such as a hash or HEAD~2. When rev did not change the task, the
+// latest earlier version is returned along with the commit that made it.
+func (r *TaskRepository) TaskAt(id, rev string) (*task.Task, *Revision, error) {
+	g, rel, err := r.taskGit(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, revision, err := g.contentAt(rel, id, rev)
+	if err != nil {
+		return nil, nil, err
+	}
+	t, err := markdown.ParseTaskFile(content)
+	if err != nil {
+		return nil, nil, errors.InternalError(fmt.Sprintf("failed to parse %s at %s", id, revision.Short()), err)
+	}
+	t.Version = contentVersion(content)
+	return t, revision, nil
+}
+
+// contentAt returns the file of a task at rev and the commit that wrote it
+func (g *gitRepo) contentAt(rel, id, rev string) ([]byte, *Revision, error) {
+	out, err := g.run("rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return nil, nil, errors.NotFound("revision", rev)
+	}
+	hash := strings.TrimSpace(string(out))
+
+	revisions, err := g.log(rel)
+	if err != nil {
+		return nil, nil, errors.InternalError("failed to read git history", err)
+	}
+	for i := range revisions {
+		revision := &revisions[i]
+		if revision.Hash != hash {
+			ancestor, err := g.test("merge-base", "--is-ancestor", revision.Hash, hash)
+			if err != nil {
+				return nil, nil, errors.InternalError("failed to read git history", err)
+			}
+			if !ancestor {
+				continue
+			}
+		}
+		content, err := g.show(revision.Hash, revision.Path)
+		if err != nil {
+			// The commit deleted the file
+			break
+		}
+		return content, revision, nil
+	}
+	return nil, nil, errors.NotFound("task "+id+" at revision", rev)
+}
+
+// Revert writes t, a task restored from its version at revision, like
+// Update and, when auto-commit is on, commits it as a revert to revision
+func (r *TaskRepository) Revert(t *task.Task, revision *Revision) error {
+	return r.update(t, func(*task.Task) string {
+		return fmt.Sprintf("Revert %s to %s\n\n%s", t.ID, revision.Short(), revision.Subject)
+	})
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// newGitRepository returns a repository with auto-commit on, storing tasks
+// in a subdirectory of a fresh git work tree
+func newGitRepository(t *testing.T) (*TaskRepository, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "Tester")
+	t.Setenv("GIT_AUTHOR_EMAIL", "tester@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Tester")
+	t.Setenv("GIT_COMMITTER_EMAIL", "tester@example.com")
+
+	top := t.TempDir()
+	if out, err := exec.Command("git", "-C", top, "init", "--quiet").CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	dir := filepath.Join(top, "tasks")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Git.AutoCommit = true
+	return NewTaskRepositoryWithConfig([]string{dir}, cfg), top
+}
+
+func gitLog(t *testing.T, top string) []string {
+	t.Helper()
+	out, err := exec.Command("git", "-C", top, "log", "--format=%s").Output()
+	if err != nil {
+		t.Fatalf("git log: %v", err)
+	}
+	return strings.Split(strings.TrimSpace(string(out)), "\n")
+}
+
+func TestAutoCommit(t *testing.T) {
+	repo, top := newGitRepository(t)
+
+	tk := &task.Task{ID: "task/20250620090000", Title: "Write report", Created: time.Now(), Updated: time.Now()}
+	if _, err := repo.Create(tk); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	tk.SetStatus(task.StatusDONE)
+	if err := repo.Update(tk); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	tk.Archive()
+	if err := repo.Update(tk); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	want := []string{
+		"Archive task/20250620090000: Write report",
+		"Update task/20250620090000: Write report",
+		"Create task/20250620090000: Write report",
+	}
+	if got := gitLog(t, top); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("commits = %q, want %q", got, want)
+	}
+
+	out, err := exec.Command("git", "-C", top, "log", "-1", "--skip=1", "--format=%b").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), `status: "TODO" -> "DONE"`) {
+		t.Errorf("update commit body = %q, want the status change", out)
+	}
+}
+
+func TestAutoCommit_OutsideWorkTree(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Git.AutoCommit = true
+	repo := NewTaskRepositoryWithConfig([]string{t.TempDir()}, cfg)
+
+	tk := &task.Task{Title: "No git here", Created: time.Now(), Updated: time.Now()}
+	if _, err := repo.Create(tk); err != nil {
+		t.Errorf("Create() outside a work tree error = %v", err)
+	}
+	if _, err := repo.History(tk.ID); err == nil {
+		t.Error("History() outside a work tree should fail")
+	}
+}
+
+func TestHistoryAndRevert(t *testing.T) {
+	repo, top := newGitRepository(t)
+
+	tk := &task.Task{ID: "task/20250620090000", Title: "Draft", Created: time.Now(), Updated: time.Now()}
+	if _, err := repo.Create(tk); err != nil {
+		t.Fatal(err)
+	}
+	for _, title := range []string{"Second", "Third"} {
+		tk.Title = title
+		if err := repo.Update(tk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	revisions, err := repo.History(tk.ID)
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	if len(revisions) != 3 {
+		t.Fatalf("History() returned %d revisions, want 3", len(revisions))
+	}
+	if revisions[0].Author != "Tester" || !strings.HasPrefix(revisions[2].Subject, "Create") {
+		t.Errorf("History() = %+v", revisions)
+	}
+
+	// A revision that did not touch the task resolves to the version before it
+	other := filepath.Join(top, "README.md")
+	if err := os.WriteFile(other, []byte("notes\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	exec.Command("git", "-C", top, "add", "README.md").Run()
+	if out, err := exec.Command("git", "-C", top, "commit", "--quiet", "-m", "Add notes").CombinedOutput(); err != nil {
+		t.Fatalf("git commit: %v\n%s", err, out)
+	}
+
+	old, rev, err := repo.TaskAt(tk.ID, "HEAD~2")
+	if err != nil {
+		t.Fatalf("TaskAt() error = %v", err)
+	}
+	if old.Title != "Second" || rev.Hash != revisions[1].Hash {
+		t.Errorf("TaskAt(HEAD~2) = %q at %s, want Second at %s", old.Title, rev.Short(), revisions[1].Short())
+	}
+	if current, _, err := repo.TaskAt(tk.ID, "HEAD"); err != nil || current.Title != "Third" {
+		t.Errorf("TaskAt(HEAD) = %v, %v", current, err)
+	}
+	if _, _, err := repo.TaskAt(tk.ID, "no-such-rev"); err == nil {
+		t.Error("TaskAt() with an unknown revision should fail")
+	}
+
+	reverted, rev, err := repo.TaskAt(tk.ID, revisions[2].Hash)
+	if err != nil {
+		t.Fatalf("TaskAt() error = %v", err)
+	}
+	if reverted.Title != "Draft" {
+		t.Errorf("TaskAt() title = %q", reverted.Title)
+	}
+	current, err := repo.FindByID(tk.ID)
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	reverted.Version = current.Version
+	if err := repo.Revert(reverted, rev); err != nil {
+		t.Fatalf("Revert() error = %v", err)
+	}
+	found, err := repo.FindByID(tk.ID)
+	if err != nil || found.Title != "Draft" {
+		t.Errorf("FindByID() after revert = %v, %v", found, err)
+	}
+	if got := gitLog(t, top)[0]; got != "Revert task/20250620090000 to "+revisions[2].Short() {
+		t.Errorf("latest commit = %q", got)
+	}
+}
@@ -0,0 +1,308 @@
+package repository
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tkancf/mdtask/internal/constants"
+	"github.com/tkancf/mdtask/internal/errors"
+	"github.com/tkancf/mdtask/internal/filelock"
+	"github.com/tkancf/mdtask/internal/task"
+	"github.com/tkancf/mdtask/internal/taskid"
+	"github.com/tkancf/mdtask/pkg/markdown"
+)
+
+// idTaken reports whether a task in any root has the given ID. The roots
+// are scanned before the first check; after that the indexes, which also
+// hold the tasks created since, answer without reading the directories.
+func (r *TaskRepository) idTaken(id string) bool {
+	r.scanIDs.Do(func() {
+		for _, root := range r.rootPaths {
+			r.scanRoot(root)
+		}
+	})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, root := range r.rootPaths {
+		for rel, e := range r.indexFor(root).Entries {
+			if e.Task == nil || e.Task.ID != id {
+				continue
+			}
+			// The file may have been removed since it was indexed
+			if _, err := os.Stat(filepath.Join(root, rel)); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// updateIndex records that a write moved task t from the file from to
+// path, so new IDs are checked against it without a scan. From is empty
+// for a new file and path is empty for a removed one.
+func (r *TaskRepository) updateIndex(from, path string, t *task.Task) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.changes++
+
+	if from != "" {
+		root := r.rootFor(from)
+		if rel, err := relPath(root, from); err == nil {
+			idx := r.indexFor(root)
+			if _, ok := idx.Entries[rel]; ok {
+				delete(idx.Entries, rel)
+				idx.dirty = true
+			}
+		}
+	}
+
+	if path == "" {
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	root := r.rootFor(path)
+	if rel, err := relPath(root, path); err == nil {
+		r.indexFor(root).store(rel, info, t)
+	}
+}
+
+// relPath returns path relative to root, also when only one of them is
+// absolute
+func relPath(root, path string) (string, error) {
+	rootAbs, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Rel(rootAbs, abs)
+}
+
+// IDChange is a task given a new ID by MigrateIDs
+type IDChange struct {
+	OldID   string `json:"old_id"`
+	NewID   string `json:"new_id"`
+	OldPath string `json:"old_path"`
+	Path    string `json:"path"`
+}
+
+// renameFile moves the files written by MigrateIDs into place
+var renameFile = os.Rename
+
+// referencePrefixes are the tags holding the ID of another task
+var referencePrefixes = []string{
+	constants.ParentTagPrefix,
+	constants.BlockedByTagPrefix,
+	constants.PreviousTagPrefix,
+}
+
+// MigrateIDs gives every task whose ID is not in format a new ID in that
+// format, made from its title and creation time, and renames its file to
+// match. Parent, blocked-by and previous-occurrence tags referring to the
+// old IDs are rewritten. With dryRun nothing is written. The changes are
+// returned oldest task first.
+//
+// The files are only touched once all of them could be written. If one
+// of them can still not be moved into place, the changes applied up to
+// then are returned with the error.
+func (r *TaskRepository) MigrateIDs(format string, dryRun bool) ([]IDChange, error) {
+	found, err := r.scanAll()
+	if err != nil {
+		return nil, err
+	}
+	var files []taskFile
+	for _, f := range found {
+		if f.task.IsManagedTask() {
+			files = append(files, f)
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].task.Created.Before(files[j].task.Created)
+	})
+
+	taken := make(map[string]bool)
+	for _, f := range files {
+		taken[f.task.ID] = true
+	}
+
+	var changes []IDChange
+	renamed := make(map[string]string)
+	for _, f := range files {
+		if taskid.Matches(format, f.task.ID) {
+			continue
+		}
+		base := taskid.New(format).At(f.task.Title, f.task.Created)
+		id := base
+		for i := 1; taken[id]; i++ {
+			id = fmt.Sprintf("%s_%d", base, i)
+		}
+		taken[id] = true
+		renamed[f.task.ID] = id
+
+		path := filepath.Join(filepath.Dir(f.path), strings.TrimPrefix(id, constants.TaskIDPrefix)+constants.MarkdownExtension)
+		if _, err := os.Stat(path); err == nil {
+			return nil, errors.ConflictError("task "+f.task.ID, fmt.Sprintf("%s already exists", path))
+		}
+		changes = append(changes, IDChange{OldID: f.task.ID, NewID: id, OldPath: f.path, Path: path})
+	}
+	if dryRun || len(changes) == 0 {
+		return changes, nil
+	}
+
+	unlock, err := r.lockAllWrites()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// Every file is written to a temporary file first, so a failure to
+	// write one leaves all tasks as they were
+	type staged struct {
+		file    taskFile
+		tmp     string
+		path    string
+		version string
+	}
+	var stage []staged
+	discard := func() {
+		for _, st := range stage {
+			os.Remove(st.tmp)
+		}
+	}
+	for _, f := range files {
+		t := f.task
+		changed := false
+		if id, ok := renamed[t.ID]; ok {
+			t.ID = id
+			changed = true
+		}
+		for i, tag := range t.Tags {
+			for _, prefix := range referencePrefixes {
+				if id, ok := renamed[strings.TrimPrefix(tag, prefix)]; ok && strings.HasPrefix(tag, prefix) {
+					t.Tags[i] = prefix + id
+					changed = true
+				}
+			}
+		}
+		if !changed {
+			continue
+		}
+
+		original, err := os.ReadFile(f.path)
+		if err != nil {
+			discard()
+			return nil, errors.InternalError(fmt.Sprintf("failed to read file %s", f.path), err)
+		}
+		content, err := markdown.PatchTaskFile(original, t)
+		if err != nil {
+			discard()
+			return nil, errors.InternalError("failed to write task file", err)
+		}
+		tmp, err := stageFile(f.path, content)
+		if err != nil {
+			discard()
+			return nil, errors.InternalError(fmt.Sprintf("failed to save file %s", f.path), err)
+		}
+		path := filepath.Join(filepath.Dir(f.path), strings.TrimPrefix(t.ID, constants.TaskIDPrefix)+constants.MarkdownExtension)
+		stage = append(stage, staged{file: f, tmp: tmp, path: path, version: contentVersion(content)})
+	}
+
+	// Then the files are moved into place. Should a rename still fail, the
+	// changes made up to then are committed and returned with the error.
+	byNewID := make(map[string]IDChange)
+	for _, c := range changes {
+		byNewID[c.NewID] = c
+	}
+	var applied []IDChange
+	var written []string
+	var failed error
+	for i, st := range stage {
+		if err := renameFile(st.tmp, st.path); err != nil {
+			failed = errors.InternalError(fmt.Sprintf("failed to save file %s", st.path), err)
+			stage = stage[i:]
+			discard()
+			break
+		}
+		written = append(written, st.path)
+		st.file.task.Version = st.version
+		if st.path != st.file.path {
+			if err := os.Remove(st.file.path); err != nil {
+				failed = errors.InternalError(fmt.Sprintf("failed to remove %s", st.file.path), err)
+			}
+			written = append(written, st.file.path)
+		}
+		r.updateIndex(st.file.path, st.path, st.file.task)
+		if c, ok := byNewID[st.file.task.ID]; ok {
+			applied = append(applied, c)
+		}
+		if failed != nil {
+			stage = stage[i+1:]
+			discard()
+			break
+		}
+	}
+	for _, dir := range uniqueDirs(written) {
+		syncDir(dir)
+	}
+
+	if len(written) > 0 {
+		message := fmt.Sprintf("Migrate task IDs to %s", format)
+		var body []string
+		for _, c := range applied {
+			body = append(body, fmt.Sprintf("%s -> %s", c.OldID, c.NewID))
+		}
+		if err := r.commitFiles(message+"\n\n"+strings.Join(body, "\n"), written...); err != nil && failed == nil {
+			failed = err
+		}
+	}
+	return applied, failed
+}
+
+// uniqueDirs returns the directories of paths, each once
+func uniqueDirs(paths []string) []string {
+	var dirs []string
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		if dir := filepath.Dir(path); !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// lockAllWrites serializes writes to every root and returns the function
+// releasing the locks
+func (r *TaskRepository) lockAllWrites() (func(), error) {
+	r.writeMu.Lock()
+	if !r.useLockFile {
+		return r.writeMu.Unlock, nil
+	}
+
+	var locks []*filelock.Lock
+	release := func() {
+		for _, lock := range locks {
+			lock.Release()
+		}
+		r.writeMu.Unlock()
+	}
+	for _, root := range r.rootPaths {
+		lock, err := acquireFileLock(root, constants.LockTimeout)
+		if err != nil {
+			release()
+			return nil, errors.InternalError("failed to acquire write lock", err)
+		}
+		locks = append(locks, lock)
+	}
+	return release, nil
+}
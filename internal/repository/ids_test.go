@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/task"
+	"github.com/tkancf/mdtask/internal/taskid"
+)
+
+func TestCreateIDFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		check  func(id string) bool
+	}{
+		{"", func(id string) bool { return taskid.Matches(taskid.FormatTimestamp, id) }},
+		{taskid.FormatULID, func(id string) bool { return taskid.Matches(taskid.FormatULID, id) }},
+		{taskid.FormatSlug, func(id string) bool {
+			return strings.HasSuffix(id, "-fix-login") || strings.HasSuffix(id, "-fix-login_1") || strings.HasSuffix(id, "-fix-login_2")
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			root := t.TempDir()
+			cfg := config.DefaultConfig()
+			cfg.Storage.IDFormat = tt.format
+			repo := NewTaskRepositoryWithConfig([]string{root}, cfg)
+
+			start := time.Now()
+			seen := make(map[string]bool)
+			for i := 0; i < 3; i++ {
+				tk := &task.Task{Title: "Fix login", Created: time.Now(), Updated: time.Now()}
+				path, err := repo.Create(tk)
+				if err != nil {
+					t.Fatalf("Create() error = %v", err)
+				}
+				if !tt.check(tk.ID) {
+					t.Errorf("Create() ID = %s", tk.ID)
+				}
+				if seen[tk.ID] {
+					t.Errorf("Create() reused ID %s", tk.ID)
+				}
+				seen[tk.ID] = true
+				if filepath.Base(path) != strings.TrimPrefix(tk.ID, "task/")+".md" {
+					t.Errorf("Create() wrote %s for %s", path, tk.ID)
+				}
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("creating 3 tasks took %v", elapsed)
+			}
+		})
+	}
+}
+
+func TestMigrateIDs(t *testing.T) {
+	root := t.TempDir()
+	repo := NewTaskRepository([]string{root})
+
+	created := time.Date(2025, 6, 29, 10, 30, 0, 0, time.Local)
+	parent := &task.Task{ID: "task/20250629103000", Title: "Fix login", Created: created, Updated: created}
+	child := &task.Task{ID: "task/20250629103001", Title: "Write test", Created: created, Updated: created}
+	child.SetParentID(parent.ID)
+	child.AddBlocker(parent.ID)
+	for _, tk := range []*task.Task{parent, child} {
+		if _, err := repo.Create(tk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dryRun, err := repo.MigrateIDs(taskid.FormatSlug, true)
+	if err != nil {
+		t.Fatalf("MigrateIDs() dry run error = %v", err)
+	}
+	if len(dryRun) != 2 || dryRun[0].NewID != "task/20250629-fix-login" {
+		t.Fatalf("MigrateIDs() dry run = %+v", dryRun)
+	}
+	if _, err := os.Stat(filepath.Join(root, "20250629103000.md")); err != nil {
+		t.Error("dry run renamed a file")
+	}
+
+	changes, err := repo.MigrateIDs(taskid.FormatSlug, false)
+	if err != nil {
+		t.Fatalf("MigrateIDs() error = %v", err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("MigrateIDs() = %+v", changes)
+	}
+	if _, err := os.Stat(filepath.Join(root, "20250629103000.md")); !os.IsNotExist(err) {
+		t.Error("old file still exists")
+	}
+
+	migrated, path, err := repo.FindByIDWithPath("task/20250629-write-test")
+	if err != nil {
+		t.Fatalf("FindByIDWithPath() after migration error = %v", err)
+	}
+	if filepath.Base(path) != "20250629-write-test.md" {
+		t.Errorf("migrated file = %s", path)
+	}
+	if migrated.GetParentID() != "task/20250629-fix-login" || !migrated.IsBlockedBy("task/20250629-fix-login") {
+		t.Errorf("references after migration = %v", migrated.Tags)
+	}
+
+	if again, err := repo.MigrateIDs(taskid.FormatSlug, false); err != nil || len(again) != 0 {
+		t.Errorf("second MigrateIDs() = %+v, %v", again, err)
+	}
+}
+
+func TestMigrateIDsCollision(t *testing.T) {
+	root := t.TempDir()
+	repo := NewTaskRepository([]string{root})
+
+	created := time.Date(2025, 6, 29, 10, 30, 0, 0, time.Local)
+	for _, id := range []string{"task/20250629103000", "task/20250629103005"} {
+		if _, err := repo.Create(&task.Task{ID: id, Title: "Same title", Created: created, Updated: created}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	changes, err := repo.MigrateIDs(taskid.FormatSlug, false)
+	if err != nil {
+		t.Fatalf("MigrateIDs() error = %v", err)
+	}
+	if len(changes) != 2 || changes[0].NewID == changes[1].NewID || changes[1].NewID != "task/20250629-same-title_1" {
+		t.Errorf("MigrateIDs() = %+v, want distinct IDs", changes)
+	}
+}
+
+func TestMigrateIDsPartialFailure(t *testing.T) {
+	root := t.TempDir()
+	repo := NewTaskRepository([]string{root})
+
+	created := time.Date(2025, 6, 29, 10, 30, 0, 0, time.Local)
+	for i, title := range []string{"First", "Second", "Third"} {
+		at := created.Add(time.Duration(i) * time.Minute)
+		if _, err := repo.Create(&task.Task{Title: title, Created: at, Updated: at}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The second file cannot be moved into place
+	calls := 0
+	renameFile = func(from, to string) error {
+		if calls++; calls == 2 {
+			return os.ErrPermission
+		}
+		return os.Rename(from, to)
+	}
+	defer func() { renameFile = os.Rename }()
+
+	applied, err := repo.MigrateIDs(taskid.FormatSlug, false)
+	if err == nil {
+		t.Fatal("MigrateIDs() should fail")
+	}
+	if len(applied) != 1 || applied[0].NewID != "task/20250629-first" {
+		t.Fatalf("MigrateIDs() applied = %+v, want the first task only", applied)
+	}
+
+	// The other tasks keep their files and no temporary file is left
+	entries, _ := os.ReadDir(root)
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	if len(names) != 3 || names[0] != "20250629-first.md" || strings.Contains(strings.Join(names, " "), ".tmp") {
+		t.Errorf("files = %v", names)
+	}
+	if _, err := repo.FindByID("task/20250629-first"); err != nil {
+		t.Errorf("FindByID() of the migrated task error = %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/constants"
+	"github.com/tkancf/mdtask/internal/task"
+	"github.com/tkancf/mdtask/pkg/markdown"
+)
+
+// indexFormatVersion is bumped whenever the cached layout changes so that
+// indexes written by older versions are discarded instead of misread.
+const indexFormatVersion = 5
+
+// indexRacyWindow guards against writes that land in the same timestamp tick
+// as the moment a file was indexed. Such entries cannot be told apart from a
+// later same-size write, so they are re-read until they age past the window.
+const indexRacyWindow = 2 * time.Second
+
+// indexEntry caches the parsed front matter of a single Markdown file. The
+// body is not cached, so the index stays small; it is read from the file
+// when the task is loaded. Task is nil for files that are not valid task
+// files.
+type indexEntry struct {
+	ModTime   time.Time  `json:"mod_time"`
+	Size      int64      `json:"size"`
+	IndexedAt time.Time  `json:"indexed_at"`
+	Task      *task.Task `json:"task,omitempty"`
+}
+
+// taskIndex is the on-disk cache for one root, stored in <root>/.mdtask/index.json.
+// Entries are keyed by the file path relative to the root.
+type taskIndex struct {
+	Version int                    `json:"version"`
+	Entries map[string]*indexEntry `json:"entries"`
+
+	file  string
+	dirty bool
+}
+
+func newTaskIndex(root string) *taskIndex {
+	return &taskIndex{
+		Version: indexFormatVersion,
+		Entries: make(map[string]*indexEntry),
+		file:    filepath.Join(root, constants.StateDirName, constants.IndexFilename),
+	}
+}
+
+// loadTaskIndex reads the index for root. A missing, corrupt or outdated
+// index yields an empty one; the cache is rebuilt on the next scan.
+func loadTaskIndex(root string) *taskIndex {
+	idx := newTaskIndex(root)
+
+	data, err := os.ReadFile(idx.file)
+	if err != nil {
+		return idx
+	}
+
+	var stored taskIndex
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Version != indexFormatVersion {
+		return idx
+	}
+	if stored.Entries != nil {
+		idx.Entries = stored.Entries
+	}
+
+	return idx
+}
+
+// lookup returns the cached task for rel if the file is unchanged since it
+// was indexed. The task has no Content; see withContent.
+func (idx *taskIndex) lookup(rel string, info fs.FileInfo) (*task.Task, bool) {
+	e, ok := idx.Entries[rel]
+	if !ok {
+		return nil, false
+	}
+	if e.Size != info.Size() || !e.ModTime.Equal(info.ModTime()) {
+		return nil, false
+	}
+	if e.IndexedAt.Sub(e.ModTime) < indexRacyWindow {
+		return nil, false
+	}
+	return e.Task, true
+}
+
+// store records the parsed task for rel, without its content
+func (idx *taskIndex) store(rel string, info fs.FileInfo, t *task.Task) {
+	if t != nil {
+		t = t.Clone()
+		t.Content = ""
+	}
+	idx.Entries[rel] = &indexEntry{
+		ModTime:   info.ModTime(),
+		Size:      info.Size(),
+		IndexedAt: time.Now(),
+		Task:      t,
+	}
+	idx.dirty = true
+}
+
+// prune drops entries for files that no longer exist and reports whether
+// there were any
+func (idx *taskIndex) prune(seen map[string]bool) bool {
+	pruned := false
+	for rel := range idx.Entries {
+		if !seen[rel] {
+			delete(idx.Entries, rel)
+			idx.dirty = true
+			pruned = true
+		}
+	}
+	return pruned
+}
+
+// withContent returns a copy of the cached task t with the body of the
+// file at path. It reports false if the file no longer has the version t
+// was cached from, e.g. because it changed after it was looked up.
+func withContent(path string, t *task.Task) (*task.Task, bool) {
+	content, err := os.ReadFile(path)
+	if err != nil || contentVersion(content) != t.Version {
+		return nil, false
+	}
+	body, err := markdown.ParseBody(content)
+	if err != nil {
+		return nil, false
+	}
+	t = t.Clone()
+	t.Content = body
+	return t, true
+}
+
+// save persists the index if it changed. The index is only a cache, so
+// failures (e.g. a read-only root) are ignored.
+func (idx *taskIndex) save() {
+	if !idx.dirty {
+		return
+	}
+
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return
+	}
+
+	dir := filepath.Dir(idx.file)
+	if err := os.MkdirAll(dir, constants.DirPermission); err != nil {
+		return
+	}
+
+	tmp, err := os.CreateTemp(dir, constants.IndexFilename+".*.tmp")
+	if err != nil {
+		return
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return
+	}
+	if err := os.Rename(tmpName, idx.file); err != nil {
+		os.Remove(tmpName)
+		return
+	}
+
+	idx.dirty = false
+}
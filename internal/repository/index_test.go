@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/constants"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+const indexTestTask = `---
+id: %s
+title: %s
+tags:
+    - mdtask
+    - mdtask/status/TODO
+created: 2024-01-01 12:00
+updated: 2024-01-01 12:00
+---
+
+Content
+`
+
+// writeAgedFile writes a task file with a modification time old enough to be trusted by the index
+func writeAgedFile(t *testing.T, path, id, title string) {
+	t.Helper()
+	content := []byte(fmt.Sprintf(indexTestTask, id, title))
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatalf("failed to set mtime: %v", err)
+	}
+}
+
+func taskTitles(t *testing.T, repo *TaskRepository) []string {
+	t.Helper()
+	tasks, err := repo.FindAll()
+	if err != nil {
+		t.Fatalf("FindAll() error = %v", err)
+	}
+	var titles []string
+	for _, tk := range tasks {
+		titles = append(titles, tk.ID+":"+tk.Title)
+	}
+	sort.Strings(titles)
+	return titles
+}
+
+func TestTaskIndex_PersistsAndMatchesColdWalk(t *testing.T) {
+	tempDir := t.TempDir()
+
+	writeAgedFile(t, filepath.Join(tempDir, "a.md"), "task/20240101120000", "Task A")
+	writeAgedFile(t, filepath.Join(tempDir, "b.md"), "task/20240101120001", "Task B")
+	if err := os.WriteFile(filepath.Join(tempDir, "note.md"), []byte("# plain note\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	warm := NewTaskRepository([]string{tempDir})
+	first := taskTitles(t, warm)
+
+	if _, err := os.Stat(filepath.Join(tempDir, constants.StateDirName, constants.IndexFilename)); err != nil {
+		t.Fatalf("expected index file to be written: %v", err)
+	}
+
+	// A fresh repository loads the persisted index and must agree with the first scan
+	second := taskTitles(t, NewTaskRepository([]string{tempDir}))
+	if len(first) != 2 || len(second) != 2 {
+		t.Fatalf("expected 2 tasks, got %v and %v", first, second)
+	}
+	for i := range first {
+		if first[i] != second[i] {
+			t.Errorf("cached result %q differs from cold walk %q", second[i], first[i])
+		}
+	}
+}
+
+func TestTaskIndex_Invalidation(t *testing.T) {
+	tempDir := t.TempDir()
+	pathA := filepath.Join(tempDir, "a.md")
+	pathB := filepath.Join(tempDir, "b.md")
+
+	writeAgedFile(t, pathA, "task/20240101120000", "Task A")
+	writeAgedFile(t, pathB, "task/20240101120001", "Task B")
+
+	repo := NewTaskRepository([]string{tempDir})
+	taskTitles(t, repo)
+
+	// Modify one file outside of the repository
+	writeAgedFile(t, pathA, "task/20240101120000", "Task A edited")
+	newTime := time.Now().Add(-30 * time.Minute)
+	os.Chtimes(pathA, newTime, newTime)
+
+	got, err := repo.FindByID("task/20240101120000")
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if got.Title != "Task A edited" {
+		t.Errorf("expected updated title, got %q", got.Title)
+	}
+
+	// Remove the other file
+	if err := os.Remove(pathB); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.FindByID("task/20240101120001"); err == nil {
+		t.Error("expected deleted task not to be found")
+	}
+
+	titles := taskTitles(t, repo)
+	if len(titles) != 1 || titles[0] != "task/20240101120000:Task A edited" {
+		t.Errorf("unexpected tasks after invalidation: %v", titles)
+	}
+}
+
+func TestTaskIndex_ReturnsCopies(t *testing.T) {
+	tempDir := t.TempDir()
+	writeAgedFile(t, filepath.Join(tempDir, "a.md"), "task/20240101120000", "Task A")
+
+	repo := NewTaskRepository([]string{tempDir})
+	taskTitles(t, repo)
+
+	first, err := repo.FindByID("task/20240101120000")
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	first.Title = "mutated"
+	first.Tags = append(first.Tags, "extra")
+
+	second, err := repo.FindByID("task/20240101120000")
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if second.Title != "Task A" || len(second.Tags) != 2 {
+		t.Errorf("cached task was mutated through a returned copy: %+v", second)
+	}
+}
+
+func TestTaskIndex_SkipsStateDirectory(t *testing.T) {
+	tempDir := t.TempDir()
+	writeAgedFile(t, filepath.Join(tempDir, "a.md"), "task/20240101120000", "Task A")
+
+	stateDir := filepath.Join(tempDir, constants.StateDirName)
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeAgedFile(t, filepath.Join(stateDir, "hidden.md"), "task/20240101120009", "Hidden")
+
+	titles := taskTitles(t, NewTaskRepository([]string{tempDir}))
+	if len(titles) != 1 {
+		t.Errorf("expected files under %s to be ignored, got %v", constants.StateDirName, titles)
+	}
+}
+
+func TestTaskIndex_DiscardsCorruptIndex(t *testing.T) {
+	tempDir := t.TempDir()
+	writeAgedFile(t, filepath.Join(tempDir, "a.md"), "task/20240101120000", "Task A")
+
+	stateDir := filepath.Join(tempDir, constants.StateDirName)
+	os.MkdirAll(stateDir, 0755)
+	if err := os.WriteFile(filepath.Join(stateDir, constants.IndexFilename), []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	titles := taskTitles(t, NewTaskRepository([]string{tempDir}))
+	if len(titles) != 1 || titles[0] != "task/20240101120000:Task A" {
+		t.Errorf("unexpected tasks with corrupt index: %v", titles)
+	}
+}
+
+func TestTaskIndex_StoresFrontMatterOnly(t *testing.T) {
+	tempDir := t.TempDir()
+	writeAgedFile(t, filepath.Join(tempDir, "a.md"), "task/20240101120000", "Task A")
+
+	taskTitles(t, NewTaskRepository([]string{tempDir}))
+
+	data, err := os.ReadFile(filepath.Join(tempDir, constants.StateDirName, constants.IndexFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "Content\\n") {
+		t.Errorf("index holds the task body: %s", data)
+	}
+
+	// A fresh repository serves the front matter from the index and the
+	// body from the file
+	repo := NewTaskRepository([]string{tempDir})
+	for _, find := range []func() (*task.Task, error){
+		func() (*task.Task, error) { return repo.FindByID("task/20240101120000") },
+		func() (*task.Task, error) {
+			tasks, err := repo.FindAll()
+			if err != nil || len(tasks) != 1 {
+				return nil, fmt.Errorf("FindAll() = %v, %v", tasks, err)
+			}
+			return tasks[0], nil
+		},
+	} {
+		got, err := find()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Title != "Task A" || got.Content != "Content\n" {
+			t.Errorf("got title %q, content %q", got.Title, got.Content)
+		}
+	}
+}
+
+func TestTaskIndex_DuplicateIDsResolveInWalkOrder(t *testing.T) {
+	tempDir := t.TempDir()
+	names := []string{"b.md", "a/z.md", "a-c.md", "c/d.md"}
+	for i, name := range names {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		writeAgedFile(t, path, "task/20240101120000", fmt.Sprintf("Copy %d", i))
+	}
+	taskTitles(t, NewTaskRepository([]string{tempDir}))
+
+	for i := 0; i < 10; i++ {
+		_, path, err := NewTaskRepository([]string{tempDir}).FindByIDWithPath("task/20240101120000")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := filepath.Join(tempDir, "a", "z.md"); path != want {
+			t.Fatalf("FindByIDWithPath() path = %s, want %s", path, want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"github.com/tkancf/mdtask/internal/search"
 	"github.com/tkancf/mdtask/internal/task"
 )
 
@@ -13,10 +14,22 @@ type Repository interface {
 	Create(t *task.Task) (string, error)
 	Update(t *task.Task) error
 	Save(t *task.Task, filePath string) error
+	Move(id, dir string) (string, error)
+
+	// History operations
+	TaskAt(id, rev string) (*task.Task, *Revision, error)
+	Revert(t *task.Task, revision *Revision) error
+
+	// Trash operations
+	Delete(id string) (*TrashEntry, error)
+	Restore(id string) (*task.Task, error)
+	Trash() ([]TrashEntry, error)
+	EmptyTrash() (int, error)
 
 	// Query operations
 	FindByStatus(status task.Status) ([]*task.Task, error)
 	FindActive() ([]*task.Task, error)
 	Search(query string) ([]*task.Task, error)
+	SearchIndex() (*search.Index, error)
 	SearchByTags(includeTags, excludeTags []string, orMode bool) ([]*task.Task, error)
 }
\ No newline at end of file
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/constants"
+	"github.com/tkancf/mdtask/internal/filelock"
+)
+
+// acquireFileLock blocks until the advisory lock on <root>/.mdtask/write.lock
+// is held or timeout expires. It keeps separate mdtask processes from
+// interleaving writes to the same task directory.
+func acquireFileLock(root string, timeout time.Duration) (*filelock.Lock, error) {
+	return filelock.Acquire(filepath.Join(root, constants.StateDirName, constants.LockFilename), timeout)
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/constants"
+	"github.com/tkancf/mdtask/internal/errors"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// rootContaining returns the configured root that path is in. Of nested
+// roots the innermost one is returned, as it is the one that scans path.
+func (r *TaskRepository) rootContaining(path string) (string, bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	found, depth := "", -1
+	for _, root := range r.rootPaths {
+		rootAbs, err := filepath.Abs(root)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(rootAbs, abs)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && len(rootAbs) > depth {
+			found, depth = root, len(rootAbs)
+		}
+	}
+	return found, depth >= 0
+}
+
+// newTaskDir returns the directory a new task is written to: the directory
+// of the first route matching its tags, or else the first root, followed
+// by the dated subfolder of the storage settings
+func (r *TaskRepository) newTaskDir(t *task.Task) (string, error) {
+	dir := r.rootPaths[0]
+	for _, route := range r.routes {
+		if !route.Matches(t.Tags) {
+			continue
+		}
+		dir = route.Directory()
+		if _, ok := r.rootContaining(dir); !ok {
+			return "", errors.ValidationError("routes", fmt.Sprintf("%s for tag %s is not inside a task directory (%s)", dir, route.Tag, strings.Join(r.rootPaths, ", ")))
+		}
+		break
+	}
+
+	created := t.Created
+	if created.IsZero() {
+		created = time.Now()
+	}
+	return filepath.Join(dir, r.storage.Subfolder(created)), nil
+}
+
+// Move relocates the file of a task to dir, which must be one of the roots
+// or inside one. The file name and the task ID are kept. The new path is
+// returned.
+func (r *TaskRepository) Move(id, dir string) (string, error) {
+	t, path, err := r.FindByIDWithPath(id)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := r.rootContaining(dir); !ok {
+		return "", errors.ValidationError("dir", fmt.Sprintf("%s is not inside a task directory (%s)", dir, strings.Join(r.rootPaths, ", ")))
+	}
+
+	target := filepath.Join(dir, filepath.Base(path))
+	if same, _ := samePath(path, target); same {
+		return path, nil
+	}
+
+	unlock, err := r.lockWrites(r.rootFor(path))
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	if _, err := os.Stat(target); err == nil {
+		return "", errors.ConflictError("task "+id, fmt.Sprintf("%s already exists", target))
+	}
+	if err := os.MkdirAll(dir, constants.DirPermission); err != nil {
+		return "", errors.InternalError(fmt.Sprintf("failed to create directory %s", dir), err)
+	}
+	if err := moveFile(path, target); err != nil {
+		return "", errors.InternalError(fmt.Sprintf("failed to move %s to %s", path, dir), err)
+	}
+	r.updateIndex(path, target, t)
+
+	if err := r.commitFiles(fmt.Sprintf("Move %s to %s: %s", id, dir, t.Title), path, target); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// samePath reports whether a and b name the same file location
+func samePath(a, b string) (bool, error) {
+	absA, err := filepath.Abs(a)
+	if err != nil {
+		return false, err
+	}
+	absB, err := filepath.Abs(b)
+	if err != nil {
+		return false, err
+	}
+	return absA == absB, nil
+}
+
+// moveFile renames src to dst, copying it when they are on different file
+// systems
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(dst, content); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/errors"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+func TestCreateRouted(t *testing.T) {
+	root := t.TempDir()
+	other := t.TempDir()
+	cfg := config.DefaultConfig()
+	cfg.Routes = []config.RouteConfig{
+		{Tag: "work", Dir: filepath.Join(root, "work")},
+		{Tag: "home", Dir: other},
+	}
+	cfg.Storage.Subfolders = "YYYY/MM"
+	repo := NewTaskRepositoryWithConfig([]string{root, other}, cfg)
+
+	created := time.Date(2025, 6, 29, 10, 0, 0, 0, time.Local)
+	tests := []struct {
+		name string
+		tags []string
+		want string
+	}{
+		{"first root", []string{"mdtask"}, filepath.Join(root, "2025", "06")},
+		{"routed", []string{"mdtask", "work/api"}, filepath.Join(root, "work", "2025", "06")},
+		{"other root", []string{"mdtask", "home"}, filepath.Join(other, "2025", "06")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := repo.Create(&task.Task{Title: tt.name, Tags: tt.tags, Created: created, Updated: created})
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if filepath.Dir(path) != tt.want {
+				t.Errorf("Create() wrote %s, want it in %s", path, tt.want)
+			}
+		})
+	}
+
+	cfg.Routes = []config.RouteConfig{{Tag: "work", Dir: t.TempDir()}}
+	repo = NewTaskRepositoryWithConfig([]string{root}, cfg)
+	if _, err := repo.Create(&task.Task{Title: "Outside", Tags: []string{"work"}}); !errors.IsValidation(err) {
+		t.Errorf("Create() with a route outside the roots error = %v, want validation error", err)
+	}
+}
+
+func TestMove(t *testing.T) {
+	root := t.TempDir()
+	other := t.TempDir()
+	repo := NewTaskRepository([]string{root, other})
+
+	tk := &task.Task{ID: "task/20250629100000", Title: "Move me", Created: time.Now(), Updated: time.Now()}
+	path, err := repo.Create(tk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target := filepath.Join(other, "done")
+	moved, err := repo.Move(tk.ID, target)
+	if err != nil {
+		t.Fatalf("Move() error = %v", err)
+	}
+	if moved != filepath.Join(target, filepath.Base(path)) {
+		t.Errorf("Move() = %s", moved)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("old file still exists after Move()")
+	}
+	found, foundPath, err := repo.FindByIDWithPath(tk.ID)
+	if err != nil || foundPath != moved || found.Title != "Move me" {
+		t.Errorf("FindByIDWithPath() after Move() = %v, %s, %v", found, foundPath, err)
+	}
+
+	// Moving to the current directory does nothing
+	if again, err := repo.Move(tk.ID, target); err != nil || again != moved {
+		t.Errorf("Move() to the same directory = %s, %v", again, err)
+	}
+
+	if _, err := repo.Move(tk.ID, t.TempDir()); !errors.IsValidation(err) {
+		t.Errorf("Move() outside the roots error = %v, want validation error", err)
+	}
+
+	if err := os.WriteFile(path, []byte("taken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Move(tk.ID, root); !errors.IsConflict(err) {
+		t.Errorf("Move() onto an existing file error = %v, want conflict", err)
+	}
+	if _, err := repo.Move("task/missing", root); !errors.IsNotFound(err) {
+		t.Errorf("Move() of a missing task error = %v, want not found", err)
+	}
+}
+
+func TestCreateRoutedUniqueID(t *testing.T) {
+	root := t.TempDir()
+	cfg := config.DefaultConfig()
+	cfg.Routes = []config.RouteConfig{{Tag: "work", Dir: filepath.Join(root, "work")}}
+	repo := NewTaskRepositoryWithConfig([]string{root}, cfg)
+
+	first := &task.Task{ID: "task/20250629100000", Title: "First", Tags: []string{"work"}}
+	if _, err := repo.Create(first); err != nil {
+		t.Fatal(err)
+	}
+	second := &task.Task{ID: "task/20250629100000", Title: "Second"}
+	path, err := repo.Create(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.ID != "task/20250629100000_1" || filepath.Base(path) != "20250629100000_1.md" {
+		t.Errorf("Create() in another directory = %s at %s, want a numbered ID", second.ID, path)
+	}
+}
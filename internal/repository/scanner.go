@@ -0,0 +1,299 @@
+package repository
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/tkancf/mdtask/internal/constants"
+	"github.com/tkancf/mdtask/internal/errors"
+	"github.com/tkancf/mdtask/internal/ignore"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// scanWorkers is the number of files parsed at the same time by a scan
+var scanWorkers = runtime.GOMAXPROCS(0)
+
+// scanEntry is a Markdown file found under a root
+type scanEntry struct {
+	// path is where the file is read and written; for a symlinked file it
+	// is the target, so writes do not replace the link
+	path string
+	// real is the path with all symlinks resolved
+	real string
+	// rel is the index key, the path relative to the root
+	rel  string
+	info fs.FileInfo
+
+	task   *task.Task
+	cached bool
+	err    error
+}
+
+// walker lists the Markdown files under a root
+type walker struct {
+	matcher *ignore.Matcher
+	// skip holds the real paths of the other roots, which are scanned on
+	// their own, and visited the real paths of the directories seen, so
+	// symlink loops end
+	skip    map[string]bool
+	visited map[string]bool
+	entries []*scanEntry
+}
+
+// walkRoot returns the Markdown files under root in lexical order. Ignored
+// files, the directories of ignore.Skipped and other roots nested in root
+// are left out. Symlinks are followed and every real directory is read
+// once.
+func (r *TaskRepository) walkRoot(root string) ([]*scanEntry, error) {
+	resolved, err := resolvePath(root)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &walker{
+		matcher: ignore.New(r.ignore),
+		skip:    make(map[string]bool),
+		visited: make(map[string]bool),
+	}
+	for _, other := range r.rootPaths {
+		if otherResolved, err := resolvePath(other); err == nil && otherResolved != resolved {
+			w.skip[otherResolved] = true
+		}
+	}
+
+	if err := w.dir(root, resolved, "."); err != nil {
+		return nil, err
+	}
+	return w.entries, nil
+}
+
+// dir reads the directory at path, whose real path is resolved and whose
+// path relative to the root is rel
+func (w *walker) dir(path, resolved, rel string) error {
+	if w.visited[resolved] {
+		return nil
+	}
+	w.visited[resolved] = true
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		if rel == "." {
+			return err
+		}
+		// An unreadable subdirectory does not fail the whole scan
+		return nil
+	}
+	w.matcher.AddFiles(path, rel)
+
+	for _, e := range entries {
+		name := e.Name()
+		childPath := filepath.Join(path, name)
+		childReal := filepath.Join(resolved, name)
+		childRel := filepath.Join(rel, name)
+
+		isDir := e.IsDir()
+		symlink := e.Type()&fs.ModeSymlink != 0
+		if symlink {
+			target, err := resolvePath(childPath)
+			if err != nil {
+				continue
+			}
+			info, err := os.Stat(target)
+			if err != nil {
+				continue
+			}
+			childReal = target
+			isDir = info.IsDir()
+		}
+
+		if isDir {
+			if ignore.Skipped(name) || w.skip[childReal] || w.matcher.Ignored(childRel, true) {
+				continue
+			}
+			if err := w.dir(childPath, childReal, childRel); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !strings.HasSuffix(name, constants.MarkdownExtension) || w.matcher.Ignored(childRel, false) {
+			continue
+		}
+		entry := &scanEntry{path: childPath, real: childReal, rel: childRel}
+		if symlink {
+			entry.path = childReal
+		}
+		if entry.info, err = os.Stat(entry.path); err != nil {
+			continue
+		}
+		w.entries = append(w.entries, entry)
+	}
+	return nil
+}
+
+// parseEntries loads the tasks of entries with up to scanWorkers files
+// read at a time. Cached entries only have their body read; they are
+// parsed again if the file changed since the lookup.
+func (r *TaskRepository) parseEntries(entries []*scanEntry) {
+	jobs := make(chan *scanEntry)
+	var wg sync.WaitGroup
+	for i := 0; i < max(1, min(scanWorkers, len(entries))); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for e := range jobs {
+				if e.cached {
+					if t, ok := withContent(e.path, e.task); ok {
+						e.task = t
+						continue
+					}
+					e.cached = false
+				}
+				e.task, e.err = r.loadTask(e.path)
+			}
+		}()
+	}
+	for _, e := range entries {
+		jobs <- e
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// scanRoot walks root and returns every parsed task file in walk order.
+// Files whose size and modification time match the index take their front
+// matter from the cache; everything else is parsed in parallel and the
+// index updated.
+func (r *TaskRepository) scanRoot(root string) ([]taskFile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries, err := r.walkRoot(root)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := r.indexFor(root)
+	var load []*scanEntry
+	for _, e := range entries {
+		if e.task, e.cached = idx.lookup(e.rel, e.info); !e.cached || e.task != nil {
+			load = append(load, e)
+		}
+	}
+	r.parseEntries(load)
+
+	seen := make(map[string]bool)
+	var files []taskFile
+	changed := false
+	for _, e := range entries {
+		if e.err != nil {
+			continue
+		}
+		if !e.cached {
+			idx.store(e.rel, e.info, e.task)
+			changed = true
+		}
+		seen[e.rel] = true
+		if e.task != nil {
+			files = append(files, taskFile{path: e.path, real: e.real, task: e.task})
+		}
+	}
+
+	if idx.prune(seen) {
+		changed = true
+	}
+	if changed {
+		r.changes++
+	}
+	idx.save()
+
+	return files, nil
+}
+
+// scanAll scans every root and returns each file once, also when it is
+// reached through symlinks from several roots
+func (r *TaskRepository) scanAll() ([]taskFile, error) {
+	var files []taskFile
+	seen := make(map[string]bool)
+	for _, root := range r.rootPaths {
+		found, err := r.scanRoot(root)
+		if err != nil {
+			return nil, errors.InternalError(fmt.Sprintf("failed to walk directory %s", root), err)
+		}
+		for _, f := range found {
+			if !seen[f.real] {
+				seen[f.real] = true
+				files = append(files, f)
+			}
+		}
+	}
+	return files, nil
+}
+
+// MarkdownFiles returns the Markdown files of all roots with their file
+// info, keyed by the path they are read from. Files are found like a scan
+// finds them: ignored files are left out, symlinks are followed and a file
+// reached from several roots is listed once. Files are not parsed.
+func (r *TaskRepository) MarkdownFiles() (map[string]fs.FileInfo, error) {
+	files := make(map[string]fs.FileInfo)
+	seen := make(map[string]bool)
+	for _, root := range r.rootPaths {
+		entries, err := r.walkRoot(root)
+		if err != nil {
+			return nil, errors.InternalError(fmt.Sprintf("failed to walk directory %s", root), err)
+		}
+		for _, e := range entries {
+			if !seen[e.real] {
+				seen[e.real] = true
+				files[e.path] = e.info
+			}
+		}
+	}
+	return files, nil
+}
+
+// walkLess reports whether the file at rel path a is reached before b by a
+// walk, which reads every directory in lexical order
+func walkLess(a, b string) bool {
+	as := strings.Split(filepath.ToSlash(a), "/")
+	bs := strings.Split(filepath.ToSlash(b), "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] != bs[i] {
+			return as[i] < bs[i]
+		}
+	}
+	return len(as) < len(bs)
+}
+
+// uniqueRoots drops the roots that resolve to the same directory as an
+// earlier one, e.g. "." and "./"
+func uniqueRoots(roots []string) []string {
+	var unique []string
+	seen := make(map[string]bool)
+	for _, root := range roots {
+		resolved, err := resolvePath(root)
+		if err != nil {
+			// A root that does not exist yet is kept as given
+			resolved = filepath.Clean(root)
+		}
+		if !seen[resolved] {
+			seen[resolved] = true
+			unique = append(unique, root)
+		}
+	}
+	return unique
+}
+
+// resolvePath returns the absolute path of path with all symlinks resolved
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
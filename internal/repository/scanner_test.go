@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/tkancf/mdtask/internal/config"
+)
+
+// writeTree writes aged task files at the given paths under root, titled
+// after their path
+func writeTree(t *testing.T, root string, paths ...string) {
+	t.Helper()
+	for i, p := range paths {
+		path := filepath.Join(root, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeAgedFile(t, path, fmt.Sprintf("task/202401011200%02d", i), p)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// scannedTitles returns the titles of all tasks in walk order
+func scannedTitles(t *testing.T, repo *TaskRepository) []string {
+	t.Helper()
+	tasks, err := repo.FindAll()
+	if err != nil {
+		t.Fatalf("FindAll() error = %v", err)
+	}
+	var titles []string
+	for _, tk := range tasks {
+		titles = append(titles, tk.Title)
+	}
+	return titles
+}
+
+func TestScan_Ignores(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root,
+		"a.md",
+		".git/b.md",
+		".obsidian/c.md",
+		"node_modules/pkg/d.md",
+		"drafts/e.md",
+		"notes/f.md",
+		"notes/g.md",
+		"notes/old/h.md",
+		"build/i.md",
+		"j.tmp.md",
+	)
+	writeFile(t, filepath.Join(root, ".mdtaskignore"), "drafts/\n*.tmp.md\n")
+	writeFile(t, filepath.Join(root, ".gitignore"), "build\n")
+	writeFile(t, filepath.Join(root, "notes", ".gitignore"), "*.md\n!g.md\n")
+
+	cfg := config.DefaultConfig()
+	cfg.Ignore = []string{"notes/old/"}
+	got := scannedTitles(t, NewTaskRepositoryWithConfig([]string{root}, cfg))
+
+	want := []string{"a.md", "notes/g.md"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindAll() = %v, want %v", got, want)
+	}
+}
+
+func TestScan_OverlappingRoots(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, "a.md", "tasks/b.md", "tasks/sub/c.md")
+
+	tests := []struct {
+		name  string
+		roots []string
+		want  []string
+	}{
+		{"nested root", []string{root, filepath.Join(root, "tasks")}, []string{"a.md", "tasks/b.md", "tasks/sub/c.md"}},
+		{"nested root first", []string{filepath.Join(root, "tasks"), root}, []string{"tasks/b.md", "tasks/sub/c.md", "a.md"}},
+		{"same root twice", []string{root, root + string(filepath.Separator)}, []string{"a.md", "tasks/b.md", "tasks/sub/c.md"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := scannedTitles(t, NewTaskRepository(tt.roots))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindAll() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScan_Symlinks(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	writeTree(t, root, "a.md")
+	writeTree(t, outside, "linked/b.md", "c.md")
+
+	links := []struct{ target, link string }{
+		{filepath.Join(outside, "linked"), filepath.Join(root, "linked")},
+		{filepath.Join(outside, "linked"), filepath.Join(root, "again")},
+		{filepath.Join(outside, "c.md"), filepath.Join(root, "c.md")},
+		{root, filepath.Join(root, "loop")},
+		{filepath.Join(root, "missing.md"), filepath.Join(root, "broken.md")},
+	}
+	for _, l := range links {
+		if err := os.Symlink(l.target, l.link); err != nil {
+			t.Skipf("symlinks not supported: %v", err)
+		}
+	}
+
+	repo := NewTaskRepository([]string{root})
+	got := scannedTitles(t, repo)
+	want := []string{"a.md", "linked/b.md", "c.md"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindAll() = %v, want %v", got, want)
+	}
+
+	// Writes go to the target and keep the link
+	tk, path, err := repo.FindByIDWithPath("task/20240101120001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk.Title = "Changed"
+	if err := repo.Save(tk, path); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Lstat(filepath.Join(root, "c.md")); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("c.md is no longer a symlink: %v", err)
+	}
+	if got, _ := repo.FindByID("task/20240101120001"); got == nil || got.Title != "Changed" {
+		t.Errorf("FindByID() after Save() = %v", got)
+	}
+}
+
+func TestScan_RootSymlink(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "real/a.md")
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(filepath.Join(dir, "real"), link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	got := scannedTitles(t, NewTaskRepository([]string{link, filepath.Join(dir, "real")}))
+	if want := []string{"real/a.md"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FindAll() = %v, want %v", got, want)
+	}
+}
+
+// benchmarkVault writes n task files spread over directories
+func benchmarkVault(b *testing.B, n int) string {
+	b.Helper()
+	root := b.TempDir()
+	for i := 0; i < n; i++ {
+		dir := filepath.Join(root, fmt.Sprintf("dir%02d", i%20))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			b.Fatal(err)
+		}
+		content := fmt.Sprintf(indexTestTask, fmt.Sprintf("task/%014d", i), fmt.Sprintf("Task %d", i))
+		content += "\n## Notes\n\n" + fmt.Sprintf("%s\n", "Lorem ipsum dolor sit amet, consectetur adipiscing elit.")
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.md", i)), []byte(content), 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return root
+}
+
+// BenchmarkScan compares scans without an index, parsing every file, on
+// pools of different sizes with a scan served from the index
+func BenchmarkScan(b *testing.B) {
+	root := benchmarkVault(b, 2000)
+	workers := scanWorkers
+	defer func() { scanWorkers = workers }()
+
+	cold := func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			repo := NewTaskRepository([]string{root})
+			repo.indexes[root] = newTaskIndex(b.TempDir())
+			if _, err := repo.scanRoot(root); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	for _, n := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("cold/workers=%d", n), func(b *testing.B) {
+			scanWorkers = n
+			cold(b)
+		})
+	}
+	b.Run("warm", func(b *testing.B) {
+		scanWorkers = workers
+		repo := NewTaskRepository([]string{root})
+		if _, err := repo.scanRoot(root); err != nil {
+			b.Fatal(err)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := repo.scanRoot(root); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -1,56 +1,179 @@
 package repository
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
-	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/tkancf/mdtask/internal/config"
 	"github.com/tkancf/mdtask/internal/constants"
 	"github.com/tkancf/mdtask/internal/errors"
+	"github.com/tkancf/mdtask/internal/search"
 	"github.com/tkancf/mdtask/internal/task"
+	"github.com/tkancf/mdtask/internal/taskid"
 	"github.com/tkancf/mdtask/pkg/markdown"
 )
 
 type TaskRepository struct {
 	rootPaths []string
+
+	mu      sync.Mutex
+	indexes map[string]*taskIndex
+
+	// writeMu serializes writes (including the version check in Update)
+	// within this process; useLockFile extends that across processes
+	writeMu     sync.Mutex
+	useLockFile bool
+
+	// autoCommit commits every write to task directories inside a git
+	// work tree; gitRepos caches the work tree of each root
+	autoCommit bool
+	gitRepos   map[string]*gitRepo
+
+	// routes and storage decide where new tasks are written
+	routes  []config.RouteConfig
+	storage config.StorageConfig
+
+	// ids generates the IDs of new tasks; scanIDs loads every root into
+	// the indexes once before the first ID is checked
+	ids     *taskid.Generator
+	scanIDs sync.Once
+
+	// ignore holds the patterns of files and directories left out of scans
+	ignore []string
+
+	// changes counts the changes to task files noticed by scans and writes.
+	// searchIndex is the full-text index of all tasks, built when changes
+	// was searchChanges.
+	changes       uint64
+	searchIndex   *search.Index
+	searchChanges uint64
 }
 
 func NewTaskRepository(rootPaths []string) *TaskRepository {
 	return &TaskRepository{
-		rootPaths: rootPaths,
+		rootPaths: uniqueRoots(rootPaths),
+		indexes:   make(map[string]*taskIndex),
+		gitRepos:  make(map[string]*gitRepo),
+		ids:       taskid.New(""),
 	}
 }
 
-func (r *TaskRepository) FindAll() ([]*task.Task, error) {
-	var tasks []*task.Task
+// NewTaskRepositoryWithConfig creates a repository honouring the storage settings in cfg
+func NewTaskRepositoryWithConfig(rootPaths []string, cfg *config.Config) *TaskRepository {
+	r := NewTaskRepository(rootPaths)
+	if cfg != nil {
+		r.useLockFile = cfg.Storage.LockFile
+		r.autoCommit = cfg.Git.AutoCommit
+		r.routes = cfg.Routes
+		r.storage = cfg.Storage
+		r.ids = taskid.New(cfg.Storage.IDFormat)
+		r.ignore = cfg.Ignore
+	}
+	return r
+}
 
-	for _, root := range r.rootPaths {
-		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
-			if err != nil {
-				return err
-			}
+// lockWrites serializes writes to root and returns the function releasing the lock
+func (r *TaskRepository) lockWrites(root string) (func(), error) {
+	r.writeMu.Lock()
+	if !r.useLockFile {
+		return r.writeMu.Unlock, nil
+	}
+
+	lock, err := acquireFileLock(root, constants.LockTimeout)
+	if err != nil {
+		r.writeMu.Unlock()
+		return nil, errors.InternalError("failed to acquire write lock", err)
+	}
+
+	return func() {
+		lock.Release()
+		r.writeMu.Unlock()
+	}, nil
+}
+
+// rootFor returns the configured root that contains path
+func (r *TaskRepository) rootFor(path string) string {
+	if root, ok := r.rootContaining(path); ok {
+		return root
+	}
+	return filepath.Dir(path)
+}
+
+// taskFile is a parsed task together with the file it was loaded from
+type taskFile struct {
+	path string
+	// real is the path with symlinks resolved, to tell the same file
+	// reached through different roots apart
+	real string
+	task *task.Task
+}
+
+// indexFor returns the in-memory index for root, loading it from disk on first use.
+// The caller must hold r.mu.
+func (r *TaskRepository) indexFor(root string) *taskIndex {
+	idx, ok := r.indexes[root]
+	if !ok {
+		idx = loadTaskIndex(root)
+		r.indexes[root] = idx
+	}
+	return idx
+}
+
+// lookupIndexed finds a task by ID using only the index, verifying that the
+// file on disk is unchanged since it was cached.
+func (r *TaskRepository) lookupIndexed(id string) (*task.Task, string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-			if d.IsDir() || !strings.HasSuffix(path, constants.MarkdownExtension) {
-				return nil
+	// Roots and paths are searched in walk order, so a duplicate ID always
+	// resolves to the file a scan finds first
+	for _, root := range r.rootPaths {
+		idx := r.indexFor(root)
+		rels := make([]string, 0, len(idx.Entries))
+		for rel, e := range idx.Entries {
+			if e.Task != nil && e.Task.ID == id {
+				rels = append(rels, rel)
 			}
+		}
+		sort.Slice(rels, func(i, j int) bool { return walkLess(rels[i], rels[j]) })
 
-			t, err := r.loadTask(path)
+		for _, rel := range rels {
+			path := filepath.Join(root, rel)
+			info, err := os.Stat(path)
 			if err != nil {
-				return nil
+				continue
 			}
-
-			if t != nil && t.IsManagedTask() {
-				tasks = append(tasks, t)
+			t, ok := idx.lookup(rel, info)
+			if !ok || t == nil {
+				continue
+			}
+			if t, ok := withContent(path, t); ok {
+				return t, path, true
 			}
+		}
+	}
 
-			return nil
-		})
+	return nil, "", false
+}
 
-		if err != nil {
-			return nil, errors.InternalError(fmt.Sprintf("failed to walk directory %s", root), err)
+func (r *TaskRepository) FindAll() ([]*task.Task, error) {
+	var tasks []*task.Task
+
+	files, err := r.scanAll()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range files {
+		if f.task.IsManagedTask() {
+			tasks = append(tasks, f.task)
 		}
 	}
 
@@ -58,6 +181,10 @@ func (r *TaskRepository) FindAll() ([]*task.Task, error) {
 }
 
 func (r *TaskRepository) FindByID(id string) (*task.Task, error) {
+	if t, _, ok := r.lookupIndexed(id); ok && t.IsManagedTask() {
+		return t, nil
+	}
+
 	tasks, err := r.FindAll()
 	if err != nil {
 		return nil, err
@@ -74,65 +201,63 @@ func (r *TaskRepository) FindByID(id string) (*task.Task, error) {
 
 // FindByIDWithPath finds a task by ID and returns the task and its file path
 func (r *TaskRepository) FindByIDWithPath(id string) (*task.Task, string, error) {
-	for _, root := range r.rootPaths {
-		var foundTask *task.Task
-		var foundPath string
-		
-		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
-			if err != nil {
-				return err
-			}
+	if t, path, ok := r.lookupIndexed(id); ok {
+		return t, path, nil
+	}
 
-			if d.IsDir() || !strings.HasSuffix(path, constants.MarkdownExtension) {
-				return nil
-			}
+	for _, root := range r.rootPaths {
+		files, err := r.scanRoot(root)
+		if err != nil {
+			continue
+		}
 
-			t, err := r.loadTask(path)
-			if err != nil {
-				return nil
+		for _, f := range files {
+			if f.task.ID == id {
+				return f.task, f.path, nil
 			}
+		}
+	}
 
-			if t != nil && t.ID == id {
-				foundTask = t
-				foundPath = path
-				return filepath.SkipDir // Stop walking
-			}
+	return nil, "", errors.NotFound("task", id)
+}
 
-			return nil
-		})
+// Save writes t to filePath. The file is replaced atomically, so an
+// interrupted write never leaves a truncated file behind.
+func (r *TaskRepository) Save(t *task.Task, filePath string) error {
+	unlock, err := r.lockWrites(r.rootFor(filePath))
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-		if err != nil && err != filepath.SkipDir {
-			continue
-		}
-		
-		if foundTask != nil {
-			return foundTask, foundPath, nil
+	var old *task.Task
+	if r.autoCommit {
+		if content, err := os.ReadFile(filePath); err == nil {
+			old, _ = markdown.ParseTaskFile(content)
 		}
 	}
 
-	// Try to find by filename pattern
-	timestamp := strings.TrimPrefix(id, constants.TaskIDPrefix)
-	for _, root := range r.rootPaths {
-		// Try exact match
-		path := filepath.Join(root, timestamp+constants.MarkdownExtension)
-		if t, err := r.loadTask(path); err == nil && t != nil && t.ID == id {
-			return t, path, nil
-		}
-		
-		// Try with suffix
-		for i := 1; i < 10; i++ {
-			path = filepath.Join(root, fmt.Sprintf("%s_%d%s", timestamp, i, constants.MarkdownExtension))
-			if t, err := r.loadTask(path); err == nil && t != nil && t.ID == id {
-				return t, path, nil
-			}
-		}
+	if err := r.save(t, filePath); err != nil {
+		return err
 	}
 
-	return nil, "", errors.NotFound("task", id)
+	return r.commitFiles(commitMessage(old, t), filePath)
 }
 
-func (r *TaskRepository) Save(t *task.Task, filePath string) error {
-	content, err := markdown.WriteTaskFile(t)
+// save writes t to filePath; the caller must hold the write lock.
+// An existing file is patched rather than regenerated, so front matter keys
+// mdtask does not manage, their order and comments are preserved.
+func (r *TaskRepository) save(t *task.Task, filePath string) error {
+	var content []byte
+	original, err := os.ReadFile(filePath)
+	switch {
+	case err == nil:
+		content, err = markdown.PatchTaskFile(original, t)
+	case os.IsNotExist(err):
+		content, err = markdown.WriteTaskFile(t)
+	default:
+		return errors.InternalError(fmt.Sprintf("failed to read file %s", filePath), err)
+	}
 	if err != nil {
 		return errors.InternalError("failed to write task file", err)
 	}
@@ -142,16 +267,18 @@ func (r *TaskRepository) Save(t *task.Task, filePath string) error {
 		return errors.InternalError(fmt.Sprintf("failed to create directory %s", dir), err)
 	}
 
-	if err := os.WriteFile(filePath, content, constants.FilePermission); err != nil {
+	if err := writeFileAtomic(filePath, content); err != nil {
 		return errors.InternalError(fmt.Sprintf("failed to save file %s", filePath), err)
 	}
 
+	t.Version = contentVersion(content)
+
 	return nil
 }
 
 func (r *TaskRepository) Create(t *task.Task) (string, error) {
 	if t.ID == "" {
-		t.ID = markdown.GenerateTaskID()
+		t.ID = r.ids.Next(t.Title)
 	}
 
 	if !t.IsManagedTask() {
@@ -162,9 +289,19 @@ func (r *TaskRepository) Create(t *task.Task) (string, error) {
 		t.SetStatus(task.StatusTODO)
 	}
 
-	// Extract timestamp from ID (task/YYYYMMDDHHMMSS -> YYYYMMDDHHMMSS.md)
-	timestamp := strings.TrimPrefix(t.ID, constants.TaskIDPrefix)
-	baseFileName := timestamp
+	dir, err := r.newTaskDir(t)
+	if err != nil {
+		return "", err
+	}
+
+	unlock, err := r.lockWrites(r.rootFor(dir))
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	// The file is named after the ID (task/YYYYMMDDHHMMSS -> YYYYMMDDHHMMSS.md)
+	baseFileName := strings.TrimPrefix(t.ID, constants.TaskIDPrefix)
 	
 	// Check if file already exists and add suffix if needed
 	var filePath string
@@ -175,35 +312,72 @@ func (r *TaskRepository) Create(t *task.Task) (string, error) {
 		} else {
 			fileName = fmt.Sprintf("%s_%d%s", baseFileName, i, constants.MarkdownExtension)
 			// Update task ID to match filename
-			t.ID = fmt.Sprintf("%s%s_%d", constants.TaskIDPrefix, timestamp, i)
+			t.ID = fmt.Sprintf("%s%s_%d", constants.TaskIDPrefix, baseFileName, i)
 		}
-		filePath = filepath.Join(r.rootPaths[0], fileName)
+		filePath = filepath.Join(dir, fileName)
 		
-		if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		// Routed tasks land in different directories, so the ID must
+		// also be free in the others
+		if _, err := os.Stat(filePath); os.IsNotExist(err) && !r.idTaken(t.ID) {
 			break
 		}
 	}
 
-	if err := r.Save(t, filePath); err != nil {
+	if err := r.save(t, filePath); err != nil {
+		return "", err
+	}
+	r.updateIndex("", filePath, t)
+
+	if err := r.commitFiles(commitMessage(nil, t), filePath); err != nil {
 		return "", err
 	}
 
 	return filePath, nil
 }
 
+// Update writes t back to its file. If t.Version is set, the update is
+// rejected with a conflict error when the file has been modified since the
+// task was read.
 func (r *TaskRepository) Update(t *task.Task) error {
+	return r.update(t, func(old *task.Task) string { return commitMessage(old, t) })
+}
+
+// update writes t over its file and, when auto-commit is on, commits it
+// with the message made from the task as it was before
+func (r *TaskRepository) update(t *task.Task, message func(old *task.Task) string) error {
 	_, filePath, err := r.FindByIDWithPath(t.ID)
 	if err != nil {
 		return err // Already returns proper error type
 	}
 
+	unlock, err := r.lockWrites(r.rootFor(filePath))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, err := os.ReadFile(filePath)
+	if err != nil {
+		return errors.InternalError(fmt.Sprintf("failed to read file %s", filePath), err)
+	}
+	if t.Version != "" && contentVersion(current) != t.Version {
+		return errors.ConflictError("task "+t.ID, "file was modified since it was read")
+	}
+
 	// Update the updated timestamp
 	t.Updated = time.Now()
 
-	if err := r.Save(t, filePath); err != nil {
+	if err := r.save(t, filePath); err != nil {
 		return err // Already returns proper error type
 	}
 
+	if r.autoCommit {
+		old, _ := markdown.ParseTaskFile(current)
+		if err := r.commitFiles(message(old), filePath); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -217,10 +391,17 @@ func (r *TaskRepository) loadTask(path string) (*task.Task, error) {
 	if err != nil {
 		return nil, nil
 	}
+	t.Version = contentVersion(content)
 
 	return t, nil
 }
 
+// contentVersion returns the version token for the given file content
+func contentVersion(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:8])
+}
+
 func (r *TaskRepository) FindByStatus(status task.Status) ([]*task.Task, error) {
 	allTasks, err := r.FindAll()
 	if err != nil {
@@ -253,34 +434,52 @@ func (r *TaskRepository) FindActive() ([]*task.Task, error) {
 	return active, nil
 }
 
+// Search returns the tasks matching a full-text query, best matches first.
+// See the search package for the query syntax.
 func (r *TaskRepository) Search(query string) ([]*task.Task, error) {
-	allTasks, err := r.FindAll()
+	idx, err := r.SearchIndex()
 	if err != nil {
 		return nil, err
 	}
 
-	query = strings.ToLower(query)
-	var matched []*task.Task
-	
-	for _, t := range allTasks {
-		if strings.Contains(strings.ToLower(t.Title), query) ||
-			strings.Contains(strings.ToLower(t.Description), query) ||
-			strings.Contains(strings.ToLower(t.Content), query) {
-			matched = append(matched, t)
-			continue
-		}
-
-		for _, tag := range t.Tags {
-			if strings.Contains(strings.ToLower(tag), query) {
-				matched = append(matched, t)
-				break
-			}
-		}
+	results := idx.Search(query)
+	matched := make([]*task.Task, len(results))
+	for i, result := range results {
+		// The index is shared; callers get tasks of their own
+		matched[i] = result.Task.Clone()
 	}
 
 	return matched, nil
 }
 
+// SearchIndex returns the full-text index of all tasks. The index is kept
+// and only rebuilt when a scan or write changed task files since it was
+// built. It is shared by all callers, so its tasks must not be modified.
+func (r *TaskRepository) SearchIndex() (*search.Index, error) {
+	r.mu.Lock()
+	before := r.changes
+	r.mu.Unlock()
+
+	tasks, err := r.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.searchIndex != nil && r.searchChanges == r.changes {
+		return r.searchIndex, nil
+	}
+	idx := search.NewIndex(tasks)
+	// Tasks changed during the scan may be missing, so the index is only
+	// kept when nothing changed; the next call then keeps its own
+	if r.changes == before {
+		r.searchIndex = idx
+		r.searchChanges = r.changes
+	}
+	return idx, nil
+}
+
 // SearchByTags searches tasks by tag combinations with AND/OR logic
 func (r *TaskRepository) SearchByTags(includeTags, excludeTags []string, orMode bool) ([]*task.Task, error) {
 	allTasks, err := r.FindAll()
@@ -3,9 +3,13 @@ package repository
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/tkancf/mdtask/internal/errors"
 	"github.com/tkancf/mdtask/internal/task"
 )
 
@@ -184,6 +188,177 @@ func TestTaskRepository_Update(t *testing.T) {
 	}
 }
 
+func TestTaskRepository_UpdateConflict(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "repo-test-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	repo := NewTaskRepository([]string{tempDir})
+
+	created := &task.Task{
+		Title:   "Original Title",
+		Created: time.Now(),
+		Updated: time.Now(),
+		Tags:    []string{},
+	}
+	filePath, err := repo.Create(created)
+	if err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+	if created.Version == "" {
+		t.Fatal("expected Create to set a version")
+	}
+
+	// Two clients read the same task
+	first, err := repo.FindByID(created.ID)
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	second, err := repo.FindByID(created.ID)
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if first.Version != created.Version {
+		t.Errorf("expected loaded version %q to match saved version %q", first.Version, created.Version)
+	}
+
+	// The first write wins and yields a new version
+	first.Title = "First"
+	if err := repo.Update(first); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if first.Version == created.Version {
+		t.Error("expected version to change after update")
+	}
+
+	// The second write is based on stale data and must be rejected
+	second.Title = "Second"
+	if err := repo.Update(second); !errors.IsConflict(err) {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+
+	// Edits made outside the repository are detected as well
+	content, _ := os.ReadFile(filePath)
+	if err := os.WriteFile(filePath, append(content, []byte("\nedited in editor\n")...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	first.Title = "Stale"
+	if err := repo.Update(first); !errors.IsConflict(err) {
+		t.Fatalf("expected conflict after external edit, got %v", err)
+	}
+
+	// Without a version the update is unconditional
+	second.Version = ""
+	if err := repo.Update(second); err != nil {
+		t.Fatalf("unconditional Update() error = %v", err)
+	}
+
+	got, err := repo.FindByID(created.ID)
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if got.Title != "Second" || got.Version != second.Version {
+		t.Errorf("unexpected task after updates: title %q, version %q (want %q)", got.Title, got.Version, second.Version)
+	}
+}
+
+func TestTaskRepository_UpdatePreservesFrontMatter(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "repo-test-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	filePath := filepath.Join(tempDir, "note.md")
+	original := `---
+id: task/20240101120000
+cssclass: wide # layout
+title: Note
+tags:
+    - mdtask
+    - mdtask/status/TODO
+created: 2024-01-01 12:00
+updated: 2024-01-01 12:00
+---
+
+Body
+`
+	if err := os.WriteFile(filePath, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := NewTaskRepository([]string{tempDir})
+	tk, err := repo.FindByID("task/20240101120000")
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	tk.SetStatus(task.StatusDONE)
+	if err := repo.Update(tk); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"cssclass: wide # layout\ntitle: Note\n", "- mdtask/status/DONE", "---\n\nBody\n"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("expected file to contain %q, got:\n%s", want, content)
+		}
+	}
+}
+
+func TestTaskRepository_UpdateFieldsFromIndex(t *testing.T) {
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "note.md")
+	original := `---
+id: task/20240101120000
+title: Note
+tags:
+    - mdtask
+points: 5 # story points
+owner: alice
+created: 2024-01-01 12:00
+updated: 2024-01-01 12:00
+---
+`
+	if err := os.WriteFile(filePath, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filePath, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	// Populate the index, then read the task back from it with a new repository
+	if _, err := NewTaskRepository([]string{tempDir}).FindAll(); err != nil {
+		t.Fatalf("FindAll() error = %v", err)
+	}
+	repo := NewTaskRepository([]string{tempDir})
+	tk, err := repo.FindByID("task/20240101120000")
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if v, _ := tk.GetField("points"); task.FieldString(v) != "5" {
+		t.Errorf("points = %#v, want 5", v)
+	}
+
+	tk.SetField("owner", "bob")
+	if err := repo.Update(tk); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "points: 5 # story points\nowner: bob\n") {
+		t.Errorf("expected only the changed field to be rewritten, got:\n%s", content)
+	}
+}
+
 func TestTaskRepository_FindAll(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "repo-test-*")
 	if err != nil {
@@ -546,4 +721,89 @@ func TestTaskRepository_FilenameSuffix(t *testing.T) {
 	if task2.ID != "task/20240101120000_1" {
 		t.Errorf("expected ID with suffix, got %q", task2.ID)
 	}
-}
\ No newline at end of file
+}
+func TestTaskRepository_SearchIndexCache(t *testing.T) {
+	root := t.TempDir()
+	repo := NewTaskRepository([]string{root})
+
+	past := time.Now().Add(-time.Hour)
+	apple := &task.Task{Title: "Apple pie", Tags: []string{"mdtask"}, Created: past, Updated: past}
+	path, err := repo.Create(apple)
+	if err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+	// Files written in the last moments are always read again
+	if err := os.Chtimes(path, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	search := func(query string) int {
+		t.Helper()
+		idx, err := repo.SearchIndex()
+		if err != nil {
+			t.Fatalf("SearchIndex() error = %v", err)
+		}
+		return len(idx.Search(query))
+	}
+
+	repo.SearchIndex()
+	first, _ := repo.SearchIndex()
+	if again, _ := repo.SearchIndex(); again != first {
+		t.Error("SearchIndex() was rebuilt without changes")
+	}
+
+	// Writes through the repository
+	apple.Title = "Apple crumble"
+	if err := repo.Update(apple); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if search("crumble") != 1 || search("pie") != 0 {
+		t.Error("SearchIndex() does not reflect an update")
+	}
+
+	// Files changed by other programs
+	other := filepath.Join(root, "other.md")
+	content := "---\nid: task/20240101000000\ntitle: Banana bread\ntags:\n    - mdtask\n---\n"
+	if err := os.WriteFile(other, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(other, past, past)
+	if search("banana") != 1 {
+		t.Error("SearchIndex() does not reflect a new file")
+	}
+	os.Remove(other)
+	if search("banana") != 0 {
+		t.Error("SearchIndex() does not reflect a removed file")
+	}
+}
+
+func TestTaskRepository_ConcurrentSearch(t *testing.T) {
+	root := t.TempDir()
+	repo := NewTaskRepository([]string{root})
+
+	past := time.Now().Add(-time.Hour)
+	for _, title := range []string{"Connect database", "Connection pool", "Write docs"} {
+		tk := &task.Task{Title: title, Tags: []string{"mdtask"}, Created: past, Updated: past}
+		path, err := repo.Create(tk)
+		if err != nil {
+			t.Fatalf("failed to create task: %v", err)
+		}
+		os.Chtimes(path, past, past)
+	}
+	// Searches share the cached index
+	repo.SearchIndex()
+	repo.SearchIndex()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results, err := repo.Search("conn*")
+			if err != nil || len(results) != 2 {
+				t.Errorf("Search() = %d results, %v", len(results), err)
+			}
+		}()
+	}
+	wg.Wait()
+}
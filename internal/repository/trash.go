@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/constants"
+	"github.com/tkancf/mdtask/internal/errors"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// TrashEntry is a deleted task kept in the trash of its root. The file is
+// moved unchanged to <root>/.mdtask/trash next to a JSON file holding the
+// entry.
+type TrashEntry struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	ParentID string `json:"parent_id,omitempty"`
+	// Path is the file the task was deleted from
+	Path      string    `json:"path"`
+	DeletedAt time.Time `json:"deleted_at"`
+
+	// file is the task file in the trash
+	file string
+}
+
+// trashMeta is the JSON stored for an entry; the path is kept relative to
+// the root so the directory can be moved
+type trashMeta struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	ParentID  string    `json:"parent_id,omitempty"`
+	Path      string    `json:"path"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+func trashDir(root string) string {
+	return filepath.Join(root, constants.StateDirName, constants.TrashDirName)
+}
+
+// Delete moves the file of a task to the trash of its root. Subtasks are
+// left alone; see TaskService.DeleteTask.
+func (r *TaskRepository) Delete(id string) (*TrashEntry, error) {
+	t, path, err := r.FindByIDWithPath(id)
+	if err != nil {
+		return nil, err
+	}
+	root := r.rootFor(path)
+
+	unlock, err := r.lockWrites(root)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	dir := trashDir(root)
+	if err := os.MkdirAll(dir, constants.DirPermission); err != nil {
+		return nil, errors.InternalError(fmt.Sprintf("failed to create directory %s", dir), err)
+	}
+
+	entry := &TrashEntry{ID: t.ID, Title: t.Title, ParentID: t.GetParentID(), Path: path, DeletedAt: time.Now()}
+	name := entry.DeletedAt.Format("20060102-150405") + "-" + strings.TrimSuffix(filepath.Base(path), constants.MarkdownExtension)
+	entry.file = filepath.Join(dir, name+constants.MarkdownExtension)
+	for i := 1; ; i++ {
+		if _, err := os.Stat(entry.file); os.IsNotExist(err) {
+			break
+		}
+		entry.file = filepath.Join(dir, fmt.Sprintf("%s_%d%s", name, i, constants.MarkdownExtension))
+	}
+
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return nil, errors.InternalError("failed to resolve task file", err)
+	}
+	meta, err := json.MarshalIndent(trashMeta{
+		ID:        entry.ID,
+		Title:     entry.Title,
+		ParentID:  entry.ParentID,
+		Path:      filepath.ToSlash(rel),
+		DeletedAt: entry.DeletedAt,
+	}, "", "  ")
+	if err != nil {
+		return nil, errors.InternalError("failed to encode trash entry", err)
+	}
+	if err := writeFileAtomic(metaPath(entry.file), meta); err != nil {
+		return nil, errors.InternalError("failed to write trash entry", err)
+	}
+	if err := os.Rename(path, entry.file); err != nil {
+		os.Remove(metaPath(entry.file))
+		return nil, errors.InternalError(fmt.Sprintf("failed to move %s to the trash", path), err)
+	}
+	r.updateIndex(path, "", nil)
+
+	if err := r.commitFiles(fmt.Sprintf("Delete %s: %s", t.ID, t.Title), path); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// metaPath returns the JSON file of the trashed task file
+func metaPath(file string) string {
+	return strings.TrimSuffix(file, constants.MarkdownExtension) + ".json"
+}
+
+// Trash returns the deleted tasks of all roots, most recently deleted first
+func (r *TaskRepository) Trash() ([]TrashEntry, error) {
+	var entries []TrashEntry
+	for _, root := range r.rootPaths {
+		metas, err := filepath.Glob(filepath.Join(trashDir(root), "*.json"))
+		if err != nil {
+			return nil, errors.InternalError("failed to read trash", err)
+		}
+		for _, file := range metas {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, errors.InternalError("failed to read trash", err)
+			}
+			var meta trashMeta
+			if err := json.Unmarshal(data, &meta); err != nil {
+				return nil, errors.InternalError(fmt.Sprintf("failed to read trash entry %s", file), err)
+			}
+			entries = append(entries, TrashEntry{
+				ID:        meta.ID,
+				Title:     meta.Title,
+				ParentID:  meta.ParentID,
+				Path:      filepath.Join(root, filepath.FromSlash(meta.Path)),
+				DeletedAt: meta.DeletedAt,
+				file:      strings.TrimSuffix(file, ".json") + constants.MarkdownExtension,
+			})
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].DeletedAt.After(entries[j].DeletedAt)
+	})
+	return entries, nil
+}
+
+// Restore moves the most recently deleted task with the given ID back to
+// the file it was deleted from
+func (r *TaskRepository) Restore(id string) (*task.Task, error) {
+	entries, err := r.Trash()
+	if err != nil {
+		return nil, err
+	}
+	var entry *TrashEntry
+	for i := range entries {
+		if entries[i].ID == id {
+			entry = &entries[i]
+			break
+		}
+	}
+	if entry == nil {
+		return nil, errors.NotFound("task in trash", id)
+	}
+
+	if _, err := r.FindByID(id); err == nil {
+		return nil, errors.ConflictError("task "+id, "a task with this ID already exists")
+	}
+
+	unlock, err := r.lockWrites(r.rootFor(entry.Path))
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if _, err := os.Stat(entry.Path); err == nil {
+		return nil, errors.ConflictError("task "+id, fmt.Sprintf("%s already exists", entry.Path))
+	}
+	dir := filepath.Dir(entry.Path)
+	if err := os.MkdirAll(dir, constants.DirPermission); err != nil {
+		return nil, errors.InternalError(fmt.Sprintf("failed to create directory %s", dir), err)
+	}
+	if err := os.Rename(entry.file, entry.Path); err != nil {
+		return nil, errors.InternalError(fmt.Sprintf("failed to restore %s", entry.Path), err)
+	}
+	os.Remove(metaPath(entry.file))
+
+	t, err := r.loadTask(entry.Path)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, errors.InternalError(fmt.Sprintf("restored file %s is not a task", entry.Path), nil)
+	}
+	r.updateIndex("", entry.Path, t)
+
+	if err := r.commitFiles(fmt.Sprintf("Restore %s: %s", t.ID, t.Title), entry.Path); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// EmptyTrash permanently removes the deleted tasks of all roots and returns
+// how many were removed
+func (r *TaskRepository) EmptyTrash() (int, error) {
+	unlock, err := r.lockAllWrites()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	entries, err := r.Trash()
+	if err != nil {
+		return 0, err
+	}
+
+	for i, entry := range entries {
+		for _, file := range []string{entry.file, metaPath(entry.file)} {
+			if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+				return i, errors.InternalError(fmt.Sprintf("failed to remove %s", file), err)
+			}
+		}
+	}
+	return len(entries), nil
+}
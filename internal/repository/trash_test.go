@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/errors"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+func TestTrash(t *testing.T) {
+	root := t.TempDir()
+	repo := NewTaskRepository([]string{root})
+
+	tk := &task.Task{ID: "task/20250620090000", Title: "Delete me", Created: time.Now(), Updated: time.Now()}
+	path, err := repo.Create(tk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := repo.Delete(tk.ID)
+	if err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if entry.ID != tk.ID || entry.Path != path || entry.Title != "Delete me" {
+		t.Errorf("Delete() = %+v", entry)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("task file still exists after Delete()")
+	}
+	if _, err := repo.FindByID(tk.ID); !errors.IsNotFound(err) {
+		t.Errorf("FindByID() after Delete() error = %v, want not found", err)
+	}
+	if tasks, _ := repo.FindAll(); len(tasks) != 0 {
+		t.Errorf("FindAll() returned %d tasks from the trash", len(tasks))
+	}
+
+	entries, err := repo.Trash()
+	if err != nil || len(entries) != 1 || entries[0].ID != tk.ID || entries[0].Path != path {
+		t.Fatalf("Trash() = %+v, %v", entries, err)
+	}
+
+	restored, err := repo.Restore(tk.ID)
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if restored.Title != "Delete me" {
+		t.Errorf("Restore() title = %q", restored.Title)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("task file not restored: %v", err)
+	}
+	if entries, _ := repo.Trash(); len(entries) != 0 {
+		t.Errorf("Trash() after Restore() = %+v", entries)
+	}
+	if _, err := repo.Restore(tk.ID); !errors.IsNotFound(err) {
+		t.Errorf("Restore() of a task not in the trash error = %v", err)
+	}
+}
+
+func TestTrash_RestoreConflict(t *testing.T) {
+	root := t.TempDir()
+	repo := NewTaskRepository([]string{root})
+
+	tk := &task.Task{ID: "task/20250620090000", Title: "First", Created: time.Now(), Updated: time.Now()}
+	if _, err := repo.Create(tk); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Delete(tk.ID); err != nil {
+		t.Fatal(err)
+	}
+	again := &task.Task{ID: tk.ID, Title: "Second", Created: time.Now(), Updated: time.Now()}
+	if _, err := repo.Create(again); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repo.Restore(tk.ID); !errors.IsConflict(err) {
+		t.Errorf("Restore() over an existing task error = %v, want conflict", err)
+	}
+}
+
+func TestEmptyTrash(t *testing.T) {
+	root := t.TempDir()
+	repo := NewTaskRepository([]string{root})
+
+	for _, id := range []string{"task/20250620090000", "task/20250620090001"} {
+		if _, err := repo.Create(&task.Task{ID: id, Title: id, Created: time.Now(), Updated: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repo.Delete(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Emptying waits for writes in progress
+	unlock, err := repo.lockAllWrites()
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	var n int
+	go func() {
+		defer close(done)
+		n, err = repo.EmptyTrash()
+	}()
+	select {
+	case <-done:
+		t.Fatal("EmptyTrash() did not wait for the write lock")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	<-done
+	if err != nil || n != 2 {
+		t.Fatalf("EmptyTrash() = %d, %v", n, err)
+	}
+	files, _ := os.ReadDir(filepath.Join(root, ".mdtask", "trash"))
+	if len(files) != 0 {
+		t.Errorf("trash still holds %d files", len(files))
+	}
+}
+
+func TestTrash_AutoCommit(t *testing.T) {
+	repo, top := newGitRepository(t)
+
+	tk := &task.Task{ID: "task/20250620090000", Title: "Tracked", Created: time.Now(), Updated: time.Now()}
+	if _, err := repo.Create(tk); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Delete(tk.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := repo.Restore(tk.ID); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	want := "Restore task/20250620090000: Tracked\nDelete task/20250620090000: Tracked\nCreate task/20250620090000: Tracked"
+	if got := strings.Join(gitLog(t, top), "\n"); got != want {
+		t.Errorf("commits =\n%s\nwant\n%s", got, want)
+	}
+}
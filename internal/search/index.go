@@ -0,0 +1,377 @@
+// Package search implements ranked full-text search over tasks. Tasks are
+// tokenized into an in-memory inverted index and scored with BM25F, which
+// weighs matches in the title and description above matches in the content.
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// Field is a part of a task that is indexed
+type Field int
+
+// Indexed fields
+const (
+	FieldTitle Field = iota
+	FieldDescription
+	FieldContent
+	FieldTags
+	numFields
+)
+
+func (f Field) String() string {
+	switch f {
+	case FieldTitle:
+		return "title"
+	case FieldDescription:
+		return "description"
+	case FieldContent:
+		return "content"
+	case FieldTags:
+		return "tags"
+	}
+	return "unknown"
+}
+
+// DefaultBoosts are the weights of matches in each field
+var DefaultBoosts = map[Field]float64{
+	FieldTitle:       3,
+	FieldDescription: 2,
+	FieldContent:     1,
+	FieldTags:        1.5,
+}
+
+// BM25 parameters: k1 controls how quickly repeated terms stop adding to
+// the score, b how much long fields are penalized
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// posting lists the positions of a term in one field of a document
+type posting struct {
+	doc       int
+	field     Field
+	positions []int
+}
+
+type document struct {
+	task   *task.Task
+	length [numFields]int
+}
+
+// Index is an in-memory inverted index over tasks. Searches may run
+// concurrently, but not while tasks are added.
+type Index struct {
+	// Boosts weighs matches per field; missing fields use DefaultBoosts
+	Boosts map[Field]float64
+
+	docs     []*document
+	postings map[string][]posting
+	totalLen [numFields]int
+	// terms is the sorted dictionary, built on demand for prefix queries;
+	// mu guards it, as concurrent searches build it
+	mu    sync.Mutex
+	terms []string
+}
+
+// Result is a task matching a search
+type Result struct {
+	Task    *task.Task
+	Score   float64
+	Snippet Snippet
+}
+
+// NewIndex indexes tasks
+func NewIndex(tasks []*task.Task) *Index {
+	idx := &Index{postings: make(map[string][]posting)}
+	for _, t := range tasks {
+		idx.Add(t)
+	}
+	return idx
+}
+
+// Add indexes a task
+func (idx *Index) Add(t *task.Task) {
+	doc := &document{task: t}
+	id := len(idx.docs)
+	idx.docs = append(idx.docs, doc)
+
+	for f := Field(0); f < numFields; f++ {
+		tokens := Tokenize(fieldText(t, f))
+		doc.length[f] = len(tokens)
+		idx.totalLen[f] += len(tokens)
+
+		positions := make(map[string][]int)
+		var order []string
+		for _, tok := range tokens {
+			if _, seen := positions[tok.Term]; !seen {
+				order = append(order, tok.Term)
+			}
+			positions[tok.Term] = append(positions[tok.Term], tok.Pos)
+		}
+		for _, term := range order {
+			idx.postings[term] = append(idx.postings[term], posting{doc: id, field: f, positions: positions[term]})
+		}
+	}
+	idx.mu.Lock()
+	idx.terms = nil
+	idx.mu.Unlock()
+}
+
+// Len returns the number of indexed tasks
+func (idx *Index) Len() int {
+	return len(idx.docs)
+}
+
+// fieldText returns the text of a field; system tags are not searchable
+func fieldText(t *task.Task, f Field) string {
+	switch f {
+	case FieldTitle:
+		return t.Title
+	case FieldDescription:
+		return t.Description
+	case FieldContent:
+		return t.Content
+	case FieldTags:
+		return strings.Join(t.UserTags(), " ")
+	}
+	return ""
+}
+
+// Search returns the tasks matching every clause of the query, best
+// matches first
+func (idx *Index) Search(q string) []Result {
+	clauses := ParseQuery(q)
+	if len(clauses) == 0 || len(idx.docs) == 0 {
+		return nil
+	}
+
+	// Dictionary terms that matched, for highlighting
+	matched := make(map[string]bool)
+	hits := make([]map[int]*[numFields]float64, len(clauses))
+	for i, clause := range clauses {
+		hits[i] = idx.matchClause(clause, matched)
+		if len(hits[i]) == 0 {
+			return nil
+		}
+	}
+
+	var avgLen [numFields]float64
+	for f := range avgLen {
+		avgLen[f] = float64(idx.totalLen[f]) / float64(len(idx.docs))
+	}
+
+	var results []Result
+	for doc := range hits[0] {
+		score := 0.0
+		var fields [numFields]bool
+		for i := range clauses {
+			freq, ok := hits[i][doc]
+			if !ok {
+				score = -1
+				break
+			}
+			score += idx.idf(len(hits[i])) * idx.saturate(freq, &idx.docs[doc].length, &avgLen)
+			for f, n := range freq {
+				fields[f] = fields[f] || n > 0
+			}
+		}
+		if score < 0 {
+			continue
+		}
+		t := idx.docs[doc].task
+		results = append(results, Result{
+			Task:    t,
+			Score:   score,
+			Snippet: makeTaskSnippet(t, fields, func(term string) bool { return matched[term] }),
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if !a.Task.Updated.Equal(b.Task.Updated) {
+			return a.Task.Updated.After(b.Task.Updated)
+		}
+		return a.Task.ID < b.Task.ID
+	})
+	return results
+}
+
+// idf is the inverse document frequency of a clause matching df documents
+func (idx *Index) idf(df int) float64 {
+	n := float64(len(idx.docs))
+	return math.Log(1 + (n-float64(df)+0.5)/(float64(df)+0.5))
+}
+
+// saturate combines the boosted, length-normalized frequencies of a clause
+// in each field into a single BM25 term weight
+func (idx *Index) saturate(freq *[numFields]float64, length *[numFields]int, avgLen *[numFields]float64) float64 {
+	tf := 0.0
+	for f := Field(0); f < numFields; f++ {
+		if freq[f] == 0 {
+			continue
+		}
+		norm := 1.0
+		if avgLen[f] > 0 {
+			norm = 1 - bm25B + bm25B*float64(length[f])/avgLen[f]
+		}
+		tf += idx.boost(f) * freq[f] / norm
+	}
+	return tf * (bm25K1 + 1) / (tf + bm25K1)
+}
+
+func (idx *Index) boost(f Field) float64 {
+	if b, ok := idx.Boosts[f]; ok {
+		return b
+	}
+	return DefaultBoosts[f]
+}
+
+// matchClause returns, per document, how often the clause occurs in each
+// field. Matching dictionary terms are added to matched.
+func (idx *Index) matchClause(c Clause, matched map[string]bool) map[int]*[numFields]float64 {
+	hits := make(map[int]*[numFields]float64)
+	add := func(doc int, f Field, n int) {
+		if hits[doc] == nil {
+			hits[doc] = new([numFields]float64)
+		}
+		hits[doc][f] += float64(n)
+	}
+
+	// Every term of the clause may stand for several dictionary terms
+	last := len(c.Terms) - 1
+	expanded := make([][]string, len(c.Terms))
+	for i, term := range c.Terms {
+		expanded[i] = idx.lookup(term, c.Prefix && i == last)
+		if len(expanded[i]) == 0 {
+			return nil
+		}
+	}
+
+	if !c.Phrase {
+		for _, term := range expanded[0] {
+			for _, p := range idx.postings[term] {
+				add(p.doc, p.field, len(p.positions))
+			}
+			matched[term] = true
+		}
+		return hits
+	}
+
+	// Phrases: the positions of each term per document field
+	type key struct {
+		doc   int
+		field Field
+	}
+	positions := make([]map[key]map[int]bool, len(c.Terms))
+	for i, terms := range expanded {
+		positions[i] = make(map[key]map[int]bool)
+		for _, term := range terms {
+			for _, p := range idx.postings[term] {
+				k := key{p.doc, p.field}
+				if positions[i][k] == nil {
+					positions[i][k] = make(map[int]bool)
+				}
+				for _, pos := range p.positions {
+					positions[i][k][pos] = true
+				}
+			}
+		}
+	}
+
+	for k, starts := range positions[0] {
+		n := 0
+		for start := range starts {
+			found := true
+			for i := 1; i < len(positions) && found; i++ {
+				found = positions[i][k][start+i]
+			}
+			if found {
+				n++
+			}
+		}
+		if n > 0 {
+			add(k.doc, k.field, n)
+		}
+	}
+	if len(hits) > 0 {
+		for _, terms := range expanded {
+			for _, term := range terms {
+				matched[term] = true
+			}
+		}
+	}
+	return hits
+}
+
+// lookup returns the dictionary terms a query term stands for: itself, the
+// terms it is a prefix of, or for a single CJK character the bigrams
+// containing it
+func (idx *Index) lookup(term string, prefix bool) []string {
+	if !prefix && !isSingleCJK(term) {
+		if _, ok := idx.postings[term]; ok {
+			return []string{term}
+		}
+		return nil
+	}
+
+	dictionary := idx.dictionary()
+	var terms []string
+	if isSingleCJK(term) {
+		for _, t := range dictionary {
+			if strings.Contains(t, term) && utf8.RuneCountInString(t) <= 2 {
+				terms = append(terms, t)
+			}
+		}
+		return terms
+	}
+
+	// A stemmed dictionary term may be shorter than the typed prefix, as
+	// in "connection*" and "connect"; match on the stem of the prefix too
+	stem := Stem(term)
+	for _, t := range []string{term, stem} {
+		for i := sort.SearchStrings(dictionary, t); i < len(dictionary) && strings.HasPrefix(dictionary[i], t); i++ {
+			terms = append(terms, dictionary[i])
+		}
+		if stem == term {
+			break
+		}
+	}
+	return dedupe(terms)
+}
+
+// dictionary returns the indexed terms in sorted order
+func (idx *Index) dictionary() []string {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if idx.terms == nil {
+		idx.terms = make([]string, 0, len(idx.postings))
+		for t := range idx.postings {
+			idx.terms = append(idx.terms, t)
+		}
+		sort.Strings(idx.terms)
+	}
+	return idx.terms
+}
+
+func dedupe(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	result := terms[:0]
+	for _, t := range terms {
+		if !seen[t] {
+			seen[t] = true
+			result = append(result, t)
+		}
+	}
+	return result
+}
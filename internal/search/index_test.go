@@ -0,0 +1,189 @@
+package search
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+func newTestIndex() *Index {
+	return NewIndex([]*task.Task{
+		{
+			ID:          "task/1",
+			Title:       "Fix login redirect",
+			Description: "Users land on a blank page after logging in",
+			Content:     "The session cookie is dropped.\n\n- [ ] Check the login page\n- [ ] Add a test",
+			Tags:        []string{"mdtask", "mdtask/status/WIP", "type/bug"},
+		},
+		{
+			ID:      "task/2",
+			Title:   "Write docs",
+			Content: "Document the login flow and the page layout for new users",
+			Tags:    []string{"mdtask", "type/docs"},
+		},
+		{
+			ID:          "task/3",
+			Title:       "検索機能の改善",
+			Description: "全文検索をもっと速くする",
+			Content:     "インデックスを使った検索。Search should rank results.",
+			Tags:        []string{"mdtask", "area/search"},
+		},
+		{
+			ID:      "task/4",
+			Title:   "Database connections",
+			Content: "Connection pooling for the reporting service",
+			Tags:    []string{"mdtask"},
+		},
+	})
+}
+
+func resultIDs(results []Result) []string {
+	var ids []string
+	for _, r := range results {
+		ids = append(ids, r.Task.ID)
+	}
+	return ids
+}
+
+func TestIndexSearch(t *testing.T) {
+	idx := newTestIndex()
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "login", want: []string{"task/1", "task/2"}},
+		{query: "LOGIN page", want: []string{"task/1", "task/2"}},
+		{query: `"login page"`, want: []string{"task/1"}},
+		{query: `"page login"`, want: nil},
+		{query: "logged", want: []string{"task/1"}},
+		{query: "connecting", want: []string{"task/4"}},
+		{query: "connection*", want: []string{"task/4"}},
+		{query: "doc*", want: []string{"task/2"}},
+		{query: "type/bug", want: []string{"task/1"}},
+		{query: "bug", want: []string{"task/1"}},
+		{query: "mdtask", want: nil},
+		{query: "status", want: nil},
+		{query: "検索", want: []string{"task/3"}},
+		{query: "検索機能", want: []string{"task/3"}},
+		{query: "機能検索", want: nil},
+		{query: "速", want: []string{"task/3"}},
+		{query: "検索 search", want: []string{"task/3"}},
+		{query: "login nonexistent", want: nil},
+		{query: "  ", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got := resultIDs(idx.Search(tt.query))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexSearchRanking(t *testing.T) {
+	idx := NewIndex([]*task.Task{
+		{ID: "task/content", Title: "Weekly review", Content: "Go through the deploy checklist"},
+		{ID: "task/title", Title: "Deploy the release", Content: "Tag and publish"},
+		{ID: "task/description", Title: "Release notes", Description: "Written before each deploy"},
+	})
+
+	got := resultIDs(idx.Search("deploy"))
+	want := []string{"task/title", "task/description", "task/content"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Search() ranked %v, want %v", got, want)
+	}
+
+	// Custom boosts change the order
+	idx.Boosts = map[Field]float64{FieldContent: 10}
+	if got := resultIDs(idx.Search("deploy")); got[0] != "task/content" {
+		t.Errorf("with a content boost the content match should rank first, got %v", got)
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []Clause
+	}{
+		{input: "login pages", want: []Clause{{Terms: []string{"login"}}, {Terms: []string{"page"}}}},
+		{input: `"login pages" fix`, want: []Clause{{Terms: []string{"login", "page"}, Phrase: true}, {Terms: []string{"fix"}}}},
+		{input: "connection*", want: []Clause{{Terms: []string{"connection"}, Prefix: true}}},
+		{input: "検索機能", want: []Clause{{Terms: []string{"検索", "索機", "機能"}, Phrase: true}}},
+		{input: `"unterminated phrase`, want: []Clause{{Terms: []string{"untermin", "phrase"}, Phrase: true}}},
+		{input: `"" * -`, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := ParseQuery(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseQuery(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	idx := newTestIndex()
+
+	tests := []struct {
+		query string
+		field Field
+		want  string
+	}{
+		{query: "cookie", field: FieldContent, want: "The session [cookie] is dropped. - [ ] Check the login page - [ ] Add a test"},
+		{query: "blank", field: FieldDescription, want: "Users land on a [blank] page after logging in"},
+		{query: "redirect", field: FieldTitle, want: "Fix login [redirect]"},
+		{query: "全文検索", field: FieldDescription, want: "[全文検索]をもっと速くする"},
+		{query: "type/bug", field: FieldContent, want: "The session cookie is dropped. - [ ] Check the login page - [ ] Add a test"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			results := idx.Search(tt.query)
+			if len(results) == 0 {
+				t.Fatalf("Search(%q) found nothing", tt.query)
+			}
+			s := results[0].Snippet
+			if s.Field != tt.field {
+				t.Errorf("snippet field = %s, want %s", s.Field, tt.field)
+			}
+			if got := s.Highlight("[", "]"); got != tt.want {
+				t.Errorf("snippet = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnippetWindow(t *testing.T) {
+	text := strings.Repeat("lorem ipsum ", 40) + "needle " + strings.Repeat("dolor sit ", 40)
+	idx := NewIndex([]*task.Task{{ID: "task/1", Content: text}})
+
+	results := idx.Search("needle")
+	if len(results) != 1 {
+		t.Fatalf("got %d results", len(results))
+	}
+	s := results[0].Snippet
+	if !strings.HasPrefix(s.Text, ellipsis) || !strings.HasSuffix(s.Text, ellipsis) {
+		t.Errorf("cut snippet should have ellipses: %q", s.Text)
+	}
+	if len(s.Matches) != 1 || s.Text[s.Matches[0].Start:s.Matches[0].End] != "needle" {
+		t.Errorf("snippet matches = %v in %q", s.Matches, s.Text)
+	}
+	if n := len([]rune(s.Text)); n > SnippetWidth+2 {
+		t.Errorf("snippet has %d characters", n)
+	}
+
+	segments := s.Segments()
+	var joined strings.Builder
+	for _, seg := range segments {
+		joined.WriteString(seg.Text)
+	}
+	if joined.String() != s.Text {
+		t.Errorf("segments do not add up to the snippet text")
+	}
+}
@@ -0,0 +1,79 @@
+package search
+
+import (
+	"strings"
+)
+
+// Clause is one part of a search query. A task must match every clause.
+type Clause struct {
+	// Terms are the normalized terms of the clause, in order
+	Terms []string
+	// Phrase requires the terms to appear next to each other
+	Phrase bool
+	// Prefix matches any term starting with the last term
+	Prefix bool
+}
+
+// ParseQuery splits a search query into clauses:
+//
+//	login page       tasks containing both words
+//	"login page"     the exact phrase
+//	log*             words starting with "log"
+//	検索機能          the bigrams of a CJK word, as a phrase
+//
+// Words that tokenize into several terms, like "type/bug" or CJK text, are
+// matched as phrases.
+func ParseQuery(input string) []Clause {
+	var clauses []Clause
+	for len(input) > 0 {
+		input = strings.TrimLeft(input, " \t\r\n")
+		if input == "" {
+			break
+		}
+
+		var part string
+		quoted := input[0] == '"'
+		if quoted {
+			// An unterminated quote runs to the end of the query
+			end := strings.IndexByte(input[1:], '"')
+			if end < 0 {
+				part, input = input[1:], ""
+			} else {
+				part, input = input[1:end+1], input[end+2:]
+			}
+		} else {
+			end := strings.IndexAny(input, " \t\r\n\"")
+			if end < 0 {
+				end = len(input)
+			}
+			part, input = input[:end], input[end:]
+		}
+
+		if clause, ok := parseClause(part, quoted); ok {
+			clauses = append(clauses, clause)
+		}
+	}
+	return clauses
+}
+
+func parseClause(part string, quoted bool) (Clause, bool) {
+	var clause Clause
+	if !quoted {
+		part, clause.Prefix = strings.CutSuffix(part, "*")
+	}
+
+	// The prefix term is kept as typed, the other terms are stemmed
+	tokens := tokenize(part, false)
+	if len(tokens) == 0 {
+		return clause, false
+	}
+	for i, tok := range tokens {
+		term := tok.Term
+		if !clause.Prefix || i < len(tokens)-1 {
+			term = Stem(term)
+		}
+		clause.Terms = append(clause.Terms, term)
+	}
+	clause.Phrase = len(clause.Terms) > 1
+	return clause, true
+}
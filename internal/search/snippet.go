@@ -0,0 +1,165 @@
+package search
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// SnippetWidth is the length of snippets in characters
+const SnippetWidth = 160
+
+const ellipsis = "…"
+
+// Snippet is an excerpt of a task around the search matches
+type Snippet struct {
+	// Field is the field the excerpt is taken from
+	Field Field
+	Text  string
+	// Matches are the highlighted parts of Text, in order
+	Matches []Span
+}
+
+// Span is a range of byte offsets
+type Span struct {
+	Start, End int
+}
+
+// Segment is a part of a snippet that is either highlighted or not
+type Segment struct {
+	Text  string
+	Match bool
+}
+
+// Segments splits the snippet into plain and highlighted parts
+func (s Snippet) Segments() []Segment {
+	var segments []Segment
+	pos := 0
+	for _, m := range s.Matches {
+		if m.Start > pos {
+			segments = append(segments, Segment{Text: s.Text[pos:m.Start]})
+		}
+		segments = append(segments, Segment{Text: s.Text[m.Start:m.End], Match: true})
+		pos = m.End
+	}
+	if pos < len(s.Text) {
+		segments = append(segments, Segment{Text: s.Text[pos:]})
+	}
+	return segments
+}
+
+// Highlight returns the snippet text with the matches wrapped in open and
+// close, e.g. "**" and "**"
+func (s Snippet) Highlight(open, close string) string {
+	var b strings.Builder
+	for _, seg := range s.Segments() {
+		if seg.Match {
+			b.WriteString(open + seg.Text + close)
+		} else {
+			b.WriteString(seg.Text)
+		}
+	}
+	return b.String()
+}
+
+// makeTaskSnippet excerpts the first of content, description and title
+// in which the query matched. If only tags match, the start of the content
+// or description is used without highlights.
+func makeTaskSnippet(t *task.Task, matched [numFields]bool, match func(term string) bool) Snippet {
+	for _, f := range []Field{FieldContent, FieldDescription, FieldTitle} {
+		if !matched[f] {
+			continue
+		}
+		if s, ok := makeSnippet(f, fieldText(t, f), match); ok {
+			return s
+		}
+	}
+	for _, f := range []Field{FieldContent, FieldDescription} {
+		if text := collapseSpace(fieldText(t, f)); text != "" {
+			start, end := snippetWindow(text, 0, 0)
+			return Snippet{Field: f, Text: trimWindow(text, start, end)}
+		}
+	}
+	return Snippet{}
+}
+
+func makeSnippet(f Field, text string, match func(term string) bool) (Snippet, bool) {
+	text = collapseSpace(text)
+
+	var spans []Span
+	for _, tok := range Tokenize(text) {
+		if !match(tok.Term) {
+			continue
+		}
+		// Overlapping CJK bigrams are merged into one span
+		if n := len(spans); n > 0 && tok.Start <= spans[n-1].End {
+			if tok.End > spans[n-1].End {
+				spans[n-1].End = tok.End
+			}
+			continue
+		}
+		spans = append(spans, Span{tok.Start, tok.End})
+	}
+	if len(spans) == 0 {
+		return Snippet{}, false
+	}
+
+	start, end := snippetWindow(text, spans[0].Start, spans[0].End)
+	offset := -start
+	if start > 0 {
+		offset += len(ellipsis)
+	}
+
+	s := Snippet{Field: f, Text: trimWindow(text, start, end)}
+	for _, span := range spans {
+		if span.Start >= start && span.End <= end {
+			s.Matches = append(s.Matches, Span{span.Start + offset, span.End + offset})
+		}
+	}
+	return s, true
+}
+
+// snippetWindow picks the byte range of a snippet of text that shows the
+// match at [matchStart, matchEnd) with some context before it, cutting at
+// spaces where possible
+func snippetWindow(text string, matchStart, matchEnd int) (int, int) {
+	start := matchStart
+	for n := 0; start > 0 && n < SnippetWidth/4; n++ {
+		_, size := utf8.DecodeLastRuneInString(text[:start])
+		start -= size
+	}
+	if start > 0 {
+		if i := strings.IndexByte(text[start:matchStart], ' '); i >= 0 {
+			start += i + 1
+		}
+	}
+
+	end := start
+	for n := 0; end < len(text) && n < SnippetWidth; n++ {
+		_, size := utf8.DecodeRuneInString(text[end:])
+		end += size
+	}
+	if end < len(text) {
+		if i := strings.LastIndexByte(text[start:end], ' '); i >= 0 && start+i >= matchEnd {
+			end = start + i
+		}
+	}
+	return start, end
+}
+
+// trimWindow returns text[start:end] with ellipses where text was cut
+func trimWindow(text string, start, end int) string {
+	s := text[start:end]
+	if start > 0 {
+		s = ellipsis + s
+	}
+	if end < len(text) {
+		s += ellipsis
+	}
+	return s
+}
+
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
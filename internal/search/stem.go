@@ -0,0 +1,219 @@
+package search
+
+// Stem reduces an English word to its stem with the Porter algorithm, so
+// that "connections", "connected" and "connecting" all index as "connect".
+// Words that are not lowercase ASCII letters are returned unchanged.
+func Stem(word string) string {
+	if len(word) <= 2 || !isLowerASCII(word) {
+		return word
+	}
+
+	b := []byte(word)
+	b = stemStep1a(b)
+	b = stemStep1b(b)
+	b = stemStep1c(b)
+	b = replaceSuffix(b, step2Rules, 0)
+	b = replaceSuffix(b, step3Rules, 0)
+	b = stemStep4(b)
+	b = stemStep5(b)
+	return string(b)
+}
+
+func isLowerASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 'a' || s[i] > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+type suffixRule struct {
+	suffix, replacement string
+}
+
+var step2Rules = []suffixRule{
+	{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+	{"izer", "ize"}, {"bli", "ble"}, {"alli", "al"}, {"entli", "ent"},
+	{"eli", "e"}, {"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"},
+	{"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"},
+	{"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+	{"logi", "log"},
+}
+
+var step3Rules = []suffixRule{
+	{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+	{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+}
+
+var step4Suffixes = []string{
+	"al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement", "ment",
+	"ent", "ion", "ou", "ism", "ate", "iti", "ous", "ive", "ize",
+}
+
+// isConsonant reports whether b[i] is a consonant; y is a consonant unless
+// it follows one
+func isConsonant(b []byte, i int) bool {
+	switch b[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !isConsonant(b, i-1)
+	}
+	return true
+}
+
+// measure counts the vowel-consonant sequences in b
+func measure(b []byte) int {
+	n, i := 0, 0
+	for i < len(b) && isConsonant(b, i) {
+		i++
+	}
+	for i < len(b) {
+		for i < len(b) && !isConsonant(b, i) {
+			i++
+		}
+		if i >= len(b) {
+			break
+		}
+		for i < len(b) && isConsonant(b, i) {
+			i++
+		}
+		n++
+	}
+	return n
+}
+
+func hasVowel(b []byte) bool {
+	for i := range b {
+		if !isConsonant(b, i) {
+			return true
+		}
+	}
+	return false
+}
+
+func endsDoubleConsonant(b []byte) bool {
+	n := len(b)
+	return n >= 2 && b[n-1] == b[n-2] && isConsonant(b, n-1)
+}
+
+// endsCVC reports whether b ends consonant-vowel-consonant where the last
+// consonant is not w, x or y, e.g. "hop"
+func endsCVC(b []byte) bool {
+	n := len(b)
+	if n < 3 || !isConsonant(b, n-3) || isConsonant(b, n-2) || !isConsonant(b, n-1) {
+		return false
+	}
+	switch b[n-1] {
+	case 'w', 'x', 'y':
+		return false
+	}
+	return true
+}
+
+func hasSuffix(b []byte, suffix string) bool {
+	return len(b) >= len(suffix) && string(b[len(b)-len(suffix):]) == suffix
+}
+
+// replaceSuffix applies the first rule whose suffix b ends with, provided
+// the remaining stem has a measure above minMeasure
+func replaceSuffix(b []byte, rules []suffixRule, minMeasure int) []byte {
+	for _, rule := range rules {
+		if !hasSuffix(b, rule.suffix) {
+			continue
+		}
+		stem := b[:len(b)-len(rule.suffix)]
+		if measure(stem) > minMeasure {
+			return append(stem, rule.replacement...)
+		}
+		return b
+	}
+	return b
+}
+
+func stemStep1a(b []byte) []byte {
+	switch {
+	case hasSuffix(b, "sses"), hasSuffix(b, "ies"):
+		return b[:len(b)-2]
+	case hasSuffix(b, "ss"):
+		return b
+	case hasSuffix(b, "s"):
+		return b[:len(b)-1]
+	}
+	return b
+}
+
+func stemStep1b(b []byte) []byte {
+	if hasSuffix(b, "eed") {
+		if measure(b[:len(b)-3]) > 0 {
+			return b[:len(b)-1]
+		}
+		return b
+	}
+
+	var stem []byte
+	switch {
+	case hasSuffix(b, "ed") && hasVowel(b[:len(b)-2]):
+		stem = b[:len(b)-2]
+	case hasSuffix(b, "ing") && hasVowel(b[:len(b)-3]):
+		stem = b[:len(b)-3]
+	default:
+		return b
+	}
+
+	switch {
+	case hasSuffix(stem, "at"), hasSuffix(stem, "bl"), hasSuffix(stem, "iz"):
+		return append(stem, 'e')
+	case endsDoubleConsonant(stem):
+		switch stem[len(stem)-1] {
+		case 'l', 's', 'z':
+			return stem
+		}
+		return stem[:len(stem)-1]
+	case measure(stem) == 1 && endsCVC(stem):
+		return append(stem, 'e')
+	}
+	return stem
+}
+
+func stemStep1c(b []byte) []byte {
+	if hasSuffix(b, "y") && hasVowel(b[:len(b)-1]) {
+		b[len(b)-1] = 'i'
+	}
+	return b
+}
+
+func stemStep4(b []byte) []byte {
+	// The longest matching suffix wins
+	best := ""
+	for _, suffix := range step4Suffixes {
+		if len(suffix) > len(best) && hasSuffix(b, suffix) {
+			best = suffix
+		}
+	}
+	if best == "" {
+		return b
+	}
+	stem := b[:len(b)-len(best)]
+	if measure(stem) <= 1 {
+		return b
+	}
+	if best == "ion" && !hasSuffix(stem, "s") && !hasSuffix(stem, "t") {
+		return b
+	}
+	return stem
+}
+
+func stemStep5(b []byte) []byte {
+	if hasSuffix(b, "e") {
+		stem := b[:len(b)-1]
+		if m := measure(stem); m > 1 || (m == 1 && !endsCVC(stem)) {
+			b = stem
+		}
+	}
+	if hasSuffix(b, "ll") && measure(b) > 1 {
+		b = b[:len(b)-1]
+	}
+	return b
+}
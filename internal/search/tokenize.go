@@ -0,0 +1,112 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Token is a term found in a text
+type Token struct {
+	// Term is the normalized form that is indexed
+	Term string
+	// Start and End are the byte offsets of the token in the text
+	Start, End int
+	// Pos is the position of the token, used for phrase matching
+	Pos int
+}
+
+// Tokenize splits text into lowercase terms. Words are stemmed; runs of
+// Chinese, Japanese or Korean characters, which are not separated by
+// spaces, are split into overlapping bigrams ("検索機能" becomes "検索",
+// "索機" and "機能").
+func Tokenize(text string) []Token {
+	return tokenize(text, true)
+}
+
+func tokenize(text string, stem bool) []Token {
+	var tokens []Token
+
+	var word strings.Builder
+	wordStart := -1
+	flushWord := func(end int) {
+		if wordStart < 0 {
+			return
+		}
+		term := word.String()
+		if stem {
+			term = Stem(term)
+		}
+		tokens = append(tokens, Token{Term: term, Start: wordStart, End: end, Pos: len(tokens)})
+		word.Reset()
+		wordStart = -1
+	}
+
+	// Start offsets of the runes in the current CJK run
+	var run []rune
+	var runStarts []int
+	flushRun := func(end int) {
+		switch len(run) {
+		case 0:
+			return
+		case 1:
+			tokens = append(tokens, Token{Term: string(run), Start: runStarts[0], End: end, Pos: len(tokens)})
+		default:
+			for i := 0; i+1 < len(run); i++ {
+				bigramEnd := end
+				if i+2 < len(run) {
+					bigramEnd = runStarts[i+2]
+				}
+				tokens = append(tokens, Token{Term: string(run[i : i+2]), Start: runStarts[i], End: bigramEnd, Pos: len(tokens)})
+			}
+		}
+		run = run[:0]
+		runStarts = runStarts[:0]
+	}
+
+	for i, r := range text {
+		r = normalizeRune(r)
+		switch {
+		case isCJK(r):
+			flushWord(i)
+			run = append(run, r)
+			runStarts = append(runStarts, i)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushRun(i)
+			if wordStart < 0 {
+				wordStart = i
+			}
+			word.WriteRune(unicode.ToLower(r))
+		default:
+			flushWord(i)
+			flushRun(i)
+		}
+	}
+	flushWord(len(text))
+	flushRun(len(text))
+
+	return tokens
+}
+
+// normalizeRune folds full-width ASCII such as "ＡＢＣ" to its ASCII form
+func normalizeRune(r rune) rune {
+	if r >= 0xFF01 && r <= 0xFF5E {
+		return r - 0xFEE0
+	}
+	return r
+}
+
+func isCJK(r rune) bool {
+	switch r {
+	case 'ー', '々', '〆':
+		return true
+	}
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// isSingleCJK reports whether term is a single CJK character, which only
+// occurs inside the indexed bigrams
+func isSingleCJK(term string) bool {
+	r, size := utf8.DecodeRuneInString(term)
+	return size == len(term) && isCJK(r)
+}
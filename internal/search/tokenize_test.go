@@ -0,0 +1,53 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "words", text: "Fix the Login-Page", want: []string{"fix", "the", "login", "page"}},
+		{name: "stemming", text: "connections failing", want: []string{"connect", "fail"}},
+		{name: "tags", text: "type/bug area/auth", want: []string{"type", "bug", "area", "auth"}},
+		{name: "japanese", text: "検索機能", want: []string{"検索", "索機", "機能"}},
+		{name: "mixed", text: "API連携を追加", want: []string{"api", "連携", "携を", "を追", "追加"}},
+		{name: "single CJK character", text: "件 v2", want: []string{"件", "v2"}},
+		{name: "full width", text: "ＡＰＩ", want: []string{"api"}},
+		{name: "korean", text: "검색", want: []string{"검색"}},
+		{name: "empty", text: " - [ ] ", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, tok := range Tokenize(tt.text) {
+				got = append(got, tok.Term)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenizeOffsets(t *testing.T) {
+	text := "a 検索機能"
+	tokens := Tokenize(text)
+	want := []string{"a", "検索", "索機", "機能"}
+	if len(tokens) != len(want) {
+		t.Fatalf("got %d tokens, want %d", len(tokens), len(want))
+	}
+	for i, tok := range tokens {
+		if got := text[tok.Start:tok.End]; got != want[i] {
+			t.Errorf("token %d spans %q, want %q", i, got, want[i])
+		}
+		if tok.Pos != i {
+			t.Errorf("token %d has position %d", i, tok.Pos)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/tkancf/mdtask/internal/errors"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// Dependency is an edge of the dependency graph: Task is blocked by BlockedBy
+type Dependency struct {
+	Task      string `json:"task"`
+	BlockedBy string `json:"blocked_by"`
+}
+
+// DependencyGraph holds a task together with everything it transitively
+// depends on and everything that transitively depends on it
+type DependencyGraph struct {
+	Root  string
+	Tasks map[string]*task.Task
+	Edges []Dependency
+	// Missing lists blocker IDs that do not match any task
+	Missing []string
+}
+
+// Blockers returns the IDs of the tasks the given task is blocked by
+func (g *DependencyGraph) Blockers(id string) []string {
+	var ids []string
+	for _, e := range g.Edges {
+		if e.Task == id {
+			ids = append(ids, e.BlockedBy)
+		}
+	}
+	return ids
+}
+
+// Dependents returns the IDs of the tasks blocked by the given task
+func (g *DependencyGraph) Dependents(id string) []string {
+	var ids []string
+	for _, e := range g.Edges {
+		if e.BlockedBy == id {
+			ids = append(ids, e.Task)
+		}
+	}
+	return ids
+}
+
+// ValidateDependencies checks that every task t is blocked by exists and
+// that the dependencies do not form a cycle
+func (s *TaskService) ValidateDependencies(t *task.Task) error {
+	return s.validateBlockers(t, t.GetBlockedBy())
+}
+
+// validateBlockers checks the given blockers of t, which must already be
+// applied to t
+func (s *TaskService) validateBlockers(t *task.Task, blockers []string) error {
+	if len(blockers) == 0 {
+		return nil
+	}
+
+	all, err := s.repo.FindAll()
+	if err != nil {
+		return err
+	}
+	graph := make(map[string][]string, len(all))
+	for _, other := range all {
+		graph[other.ID] = other.GetBlockedBy()
+	}
+
+	for _, id := range blockers {
+		if id == t.ID {
+			return errors.ValidationError("blockedby", "a task cannot be blocked by itself")
+		}
+		if _, ok := graph[id]; !ok {
+			return errors.NotFound("blocking task", id)
+		}
+	}
+
+	// A task that has not been created yet cannot be part of a cycle
+	if t.ID == "" {
+		return nil
+	}
+	graph[t.ID] = t.GetBlockedBy()
+	if cycle := findCycle(graph, t.ID); cycle != nil {
+		return errors.ValidationError("blockedby", "dependency cycle: "+strings.Join(cycle, " -> "))
+	}
+	return nil
+}
+
+// findCycle returns a path of blocked-by edges leading from start back to
+// itself, or nil if there is none
+func findCycle(graph map[string][]string, start string) []string {
+	visited := make(map[string]bool)
+	var path []string
+
+	var visit func(id string) bool
+	visit = func(id string) bool {
+		path = append(path, id)
+		for _, next := range graph[id] {
+			if next == start {
+				path = append(path, start)
+				return true
+			}
+			if !visited[next] {
+				visited[next] = true
+				if visit(next) {
+					return true
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		return false
+	}
+
+	if visit(start) {
+		return path
+	}
+	return nil
+}
+
+// ReadyTasks returns the active tasks that are not done and whose blockers
+// are all done. Blockers that no longer exist do not block.
+func (s *TaskService) ReadyTasks() ([]*task.Task, error) {
+	all, err := s.repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	workflow := s.config.GetWorkflow()
+
+	byID := make(map[string]*task.Task, len(all))
+	for _, t := range all {
+		byID[t.ID] = t
+	}
+
+	var ready []*task.Task
+	for _, t := range all {
+		if t.IsArchived() || workflow.IsDone(t.GetStatus()) {
+			continue
+		}
+		blocked := false
+		for _, id := range t.GetBlockedBy() {
+			if blocker, ok := byID[id]; ok && !workflow.IsDone(blocker.GetStatus()) {
+				blocked = true
+				break
+			}
+		}
+		if !blocked {
+			ready = append(ready, t)
+		}
+	}
+	return ready, nil
+}
+
+// OpenBlockers returns the tasks blocking t that are not done yet
+func (s *TaskService) OpenBlockers(t *task.Task) ([]*task.Task, error) {
+	var open []*task.Task
+	for _, id := range t.GetBlockedBy() {
+		blocker, err := s.repo.FindByID(id)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		if !s.config.GetWorkflow().IsDone(blocker.GetStatus()) {
+			open = append(open, blocker)
+		}
+	}
+	return open, nil
+}
+
+// DependencyGraph collects the tasks taskID depends on and the tasks that
+// depend on it, following the relations in both directions
+func (s *TaskService) DependencyGraph(taskID string) (*DependencyGraph, error) {
+	root, err := s.repo.FindByID(taskID)
+	if err != nil {
+		return nil, err
+	}
+	all, err := s.repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*task.Task, len(all))
+	dependents := make(map[string][]string)
+	for _, t := range all {
+		byID[t.ID] = t
+		for _, id := range t.GetBlockedBy() {
+			dependents[id] = append(dependents[id], t.ID)
+		}
+	}
+	byID[root.ID] = root
+
+	g := &DependencyGraph{Root: root.ID, Tasks: map[string]*task.Task{root.ID: root}}
+	edges := make(map[Dependency]bool)
+	missing := make(map[string]bool)
+
+	// Walk up to the blockers
+	queue := []string{root.ID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, blockerID := range byID[id].GetBlockedBy() {
+			edges[Dependency{Task: id, BlockedBy: blockerID}] = true
+			blocker, ok := byID[blockerID]
+			if !ok {
+				missing[blockerID] = true
+				continue
+			}
+			if _, seen := g.Tasks[blockerID]; !seen {
+				g.Tasks[blockerID] = blocker
+				queue = append(queue, blockerID)
+			}
+		}
+	}
+
+	// Walk down to the dependents
+	visited := map[string]bool{root.ID: true}
+	queue = []string{root.ID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, dependentID := range dependents[id] {
+			edges[Dependency{Task: dependentID, BlockedBy: id}] = true
+			g.Tasks[dependentID] = byID[dependentID]
+			if !visited[dependentID] {
+				visited[dependentID] = true
+				queue = append(queue, dependentID)
+			}
+		}
+	}
+
+	for e := range edges {
+		g.Edges = append(g.Edges, e)
+	}
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].Task != g.Edges[j].Task {
+			return g.Edges[i].Task < g.Edges[j].Task
+		}
+		return g.Edges[i].BlockedBy < g.Edges[j].BlockedBy
+	})
+	for id := range missing {
+		g.Missing = append(g.Missing, id)
+	}
+	sort.Strings(g.Missing)
+
+	return g, nil
+}
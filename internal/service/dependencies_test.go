@@ -0,0 +1,132 @@
+package service
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/errors"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// newDependencyFixture creates the chain task/1 <- task/2 <- task/3, where
+// each task is blocked by the one before it, and an unrelated task/4
+func newDependencyFixture() (*MockTaskRepository, *TaskService) {
+	repo := NewMockTaskRepository()
+	for _, spec := range []struct{ id, status, blocker string }{
+		{"task/1", "DONE", ""},
+		{"task/2", "TODO", "task/1"},
+		{"task/3", "TODO", "task/2"},
+		{"task/4", "WIP", ""},
+	} {
+		t := &task.Task{ID: spec.id, Title: spec.id, Tags: []string{"mdtask"}}
+		t.SetStatus(task.Status(spec.status))
+		t.AddBlocker(spec.blocker)
+		repo.tasks[t.ID] = t
+	}
+	return repo, NewTaskService(repo, &config.Config{})
+}
+
+func TestUpdateTask_Dependencies(t *testing.T) {
+	tests := []struct {
+		name    string
+		taskID  string
+		blocker string
+		check   func(error) bool
+	}{
+		{name: "valid", taskID: "task/4", blocker: "task/3"},
+		{name: "missing target", taskID: "task/4", blocker: "task/99", check: errors.IsNotFound},
+		{name: "self", taskID: "task/2", blocker: "task/2", check: errors.IsValidation},
+		{name: "direct cycle", taskID: "task/2", blocker: "task/3", check: errors.IsValidation},
+		{name: "indirect cycle", taskID: "task/1", blocker: "task/3", check: errors.IsValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, service := newDependencyFixture()
+			_, err := service.UpdateTask(tt.taskID, UpdateTaskParams{AddBlockedBy: []string{tt.blocker}})
+			if tt.check == nil {
+				if err != nil {
+					t.Fatalf("UpdateTask() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !tt.check(err) {
+				t.Errorf("UpdateTask() error = %v", err)
+			}
+		})
+	}
+
+	_, service := newDependencyFixture()
+	_, err := service.UpdateTask("task/1", UpdateTaskParams{AddBlockedBy: []string{"task/3"}})
+	if err == nil || !strings.Contains(err.Error(), "task/1 -> task/3 -> task/2 -> task/1") {
+		t.Errorf("cycle error should show the path, got %v", err)
+	}
+}
+
+func TestCreateTask_BlockedBy(t *testing.T) {
+	repo, service := newDependencyFixture()
+
+	created, _, err := service.CreateTask(CreateTaskParams{Title: "Announce", BlockedBy: []string{"task/3"}})
+	if err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	if got := repo.tasks[created.ID].GetBlockedBy(); len(got) != 1 || got[0] != "task/3" {
+		t.Errorf("GetBlockedBy() = %v", got)
+	}
+
+	if _, _, err := service.CreateTask(CreateTaskParams{Title: "Orphan", BlockedBy: []string{"task/99"}}); !errors.IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
+
+func TestReadyTasks(t *testing.T) {
+	repo, service := newDependencyFixture()
+
+	ids := func() []string {
+		ready, err := service.ReadyTasks()
+		if err != nil {
+			t.Fatalf("ReadyTasks() error = %v", err)
+		}
+		var ids []string
+		for _, t := range ready {
+			ids = append(ids, t.ID)
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	// task/1 is done, task/3 waits for task/2
+	if got := strings.Join(ids(), ","); got != "task/2,task/4" {
+		t.Errorf("ReadyTasks() = %s", got)
+	}
+
+	repo.tasks["task/2"].SetStatus(task.StatusDONE)
+	if got := strings.Join(ids(), ","); got != "task/3,task/4" {
+		t.Errorf("ReadyTasks() after completing task/2 = %s", got)
+	}
+}
+
+func TestDependencyGraph(t *testing.T) {
+	repo, service := newDependencyFixture()
+	repo.tasks["task/4"].AddBlocker("task/2")
+	repo.tasks["task/2"].AddBlocker("task/gone")
+
+	g, err := service.DependencyGraph("task/2")
+	if err != nil {
+		t.Fatalf("DependencyGraph() error = %v", err)
+	}
+	if len(g.Tasks) != 4 {
+		t.Errorf("graph has %d tasks, want 4", len(g.Tasks))
+	}
+	if got := strings.Join(g.Blockers("task/2"), ","); got != "task/1,task/gone" {
+		t.Errorf("Blockers(task/2) = %s", got)
+	}
+	if got := strings.Join(g.Dependents("task/2"), ","); got != "task/3,task/4" {
+		t.Errorf("Dependents(task/2) = %s", got)
+	}
+	if len(g.Missing) != 1 || g.Missing[0] != "task/gone" {
+		t.Errorf("Missing = %v", g.Missing)
+	}
+}
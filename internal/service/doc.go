@@ -10,7 +10,10 @@
 //   - Task updates with field-level control
 //   - Hierarchical task management (parent/subtask relationships)
 //   - Task archiving with cascade operations
+//   - Deleting to and restoring from the trash, including subtasks
+//   - Moving tasks between directories, optionally with their subtasks
 //   - Configuration-aware operations (templates, defaults)
+//   - Hooks run on every task the service creates or changes
 //
 // Architecture:
 //
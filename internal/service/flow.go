@@ -0,0 +1,144 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// DurationStats summarizes a set of durations
+type DurationStats struct {
+	Count   int
+	Average time.Duration
+	Median  time.Duration
+}
+
+// FlowMetrics describes how tasks moved through the workflow in [From, To)
+type FlowMetrics struct {
+	From, To time.Time
+	// Completed are the tasks that moved into a done status in the period,
+	// in order of completion
+	Completed []*task.Task
+	// CycleTime runs from the start of work to completion, LeadTime from
+	// creation to completion, both over the completed tasks
+	CycleTime DurationStats
+	LeadTime  DurationStats
+}
+
+// Throughput returns the number of completed tasks per day
+func (m *FlowMetrics) Throughput() float64 {
+	days := m.To.Sub(m.From).Hours() / 24
+	if days <= 0 {
+		return 0
+	}
+	return float64(len(m.Completed)) / days
+}
+
+// WeeklyThroughput returns the number of completed tasks per week
+func (m *FlowMetrics) WeeklyThroughput() float64 {
+	return m.Throughput() * 7
+}
+
+// CompletedAt returns when a task was completed according to its status
+// history. Tasks completed before the history was recorded fall back to
+// their last update. It returns nil for tasks that are not done.
+func CompletedAt(t *task.Task, workflow *config.Workflow) *time.Time {
+	if !workflow.IsDone(t.GetStatus()) {
+		return nil
+	}
+	if at := t.CompletedAt(workflow.IsDone); at != nil {
+		return at
+	}
+	updated := t.Updated
+	return &updated
+}
+
+// StartedAt returns when work on a task started: the first move to the
+// workflow's active status, or without one the first move to a status that
+// is not done. It returns nil if the history does not record a start.
+func StartedAt(t *task.Task, workflow *config.Workflow) *time.Time {
+	if active, ok := workflow.ActiveStatus(); ok {
+		return t.StartedAt(func(s task.Status) bool { return s == active })
+	}
+	return t.StartedAt(func(s task.Status) bool { return !workflow.IsDone(s) })
+}
+
+// StatusAt reconstructs the status of a task at a point in time from its
+// status history. ok is false if the task did not exist yet. Tasks without
+// history are taken to have been in their current status since creation,
+// except that done ones count as done from their last update.
+func StatusAt(t *task.Task, workflow *config.Workflow, at time.Time) (status task.Status, ok bool) {
+	if t.Created.After(at) {
+		return "", false
+	}
+	status = t.GetStatus()
+	if len(t.StatusHistory) > 0 {
+		status = t.StatusHistory[0].From
+		for _, change := range t.StatusHistory {
+			if change.At.After(at) {
+				break
+			}
+			status = change.To
+		}
+	} else if workflow.IsDone(status) {
+		status = workflow.Names()[0]
+	}
+	// Changes made outside mdtask show up by the last update
+	if !at.Before(t.Updated) {
+		status = t.GetStatus()
+	}
+	return status, true
+}
+
+// CalculateFlowMetrics computes throughput, cycle time and lead time from
+// the status history of tasks, including archived ones
+func CalculateFlowMetrics(tasks []*task.Task, workflow *config.Workflow, from, to time.Time) *FlowMetrics {
+	metrics := &FlowMetrics{From: from, To: to}
+	completedAt := make(map[*task.Task]time.Time)
+	var cycle, lead []time.Duration
+	for _, t := range tasks {
+		done := CompletedAt(t, workflow)
+		if done == nil || done.Before(from) || !done.Before(to) {
+			continue
+		}
+		metrics.Completed = append(metrics.Completed, t)
+		completedAt[t] = *done
+
+		if d := done.Sub(t.Created); d >= 0 {
+			lead = append(lead, d)
+		}
+		if started := StartedAt(t, workflow); started != nil && !started.After(*done) {
+			cycle = append(cycle, done.Sub(*started))
+		}
+	}
+
+	sort.SliceStable(metrics.Completed, func(i, j int) bool {
+		return completedAt[metrics.Completed[i]].Before(completedAt[metrics.Completed[j]])
+	})
+	metrics.CycleTime = summarize(cycle)
+	metrics.LeadTime = summarize(lead)
+	return metrics
+}
+
+func summarize(durations []time.Duration) DurationStats {
+	stats := DurationStats{Count: len(durations)}
+	if len(durations) == 0 {
+		return stats
+	}
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	stats.Average = total / time.Duration(len(durations))
+
+	mid := len(durations) / 2
+	stats.Median = durations[mid]
+	if len(durations)%2 == 0 {
+		stats.Median = (durations[mid-1] + durations[mid]) / 2
+	}
+	return stats
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+func TestCalculateFlowMetrics(t *testing.T) {
+	workflow := config.DefaultWorkflow()
+	day := func(d, h int) time.Time { return time.Date(2025, 6, d, h, 0, 0, 0, time.UTC) }
+	newTask := func(id string, created time.Time) *task.Task {
+		t := &task.Task{ID: id, Tags: []string{"mdtask"}, Created: created, Updated: created}
+		t.SetStatus(task.StatusTODO)
+		return t
+	}
+
+	// Started on the 3rd, done on the 5th, then edited much later
+	edited := newTask("task/1", day(1, 9))
+	edited.ChangeStatus(task.StatusWIP, day(3, 9))
+	edited.ChangeStatus(task.StatusDONE, day(5, 9))
+	edited.Updated = day(20, 9)
+
+	// Done without being started, and archived
+	direct := newTask("task/2", day(4, 9))
+	direct.ChangeStatus(task.StatusDONE, day(6, 9))
+	direct.Archive()
+
+	// Done before the history was recorded: falls back to Updated
+	legacy := newTask("task/3", day(2, 9))
+	legacy.SetStatus(task.StatusDONE)
+	legacy.Updated = day(7, 9)
+
+	// Completed outside the range or not at all
+	early := newTask("task/4", day(1, 9))
+	early.ChangeStatus(task.StatusDONE, day(1, 12))
+	open := newTask("task/5", day(2, 9))
+	open.ChangeStatus(task.StatusWIP, day(3, 9))
+
+	tasks := []*task.Task{open, legacy, direct, early, edited}
+	m := CalculateFlowMetrics(tasks, workflow, day(2, 0), day(9, 0))
+
+	var ids []string
+	for _, t := range m.Completed {
+		ids = append(ids, t.ID)
+	}
+	if want := []string{"task/1", "task/2", "task/3"}; len(ids) != len(want) || ids[0] != want[0] || ids[1] != want[1] || ids[2] != want[2] {
+		t.Errorf("Completed = %v, want %v in order of completion", ids, want)
+	}
+	if got := m.Throughput(); got != 3.0/7 {
+		t.Errorf("Throughput() = %v, want %v", got, 3.0/7)
+	}
+	if got := m.WeeklyThroughput(); got < 2.99 || got > 3.01 {
+		t.Errorf("WeeklyThroughput() = %v, want 3", got)
+	}
+
+	if m.CycleTime.Count != 1 || m.CycleTime.Average != 48*time.Hour {
+		t.Errorf("CycleTime = %+v, want one task of 48h", m.CycleTime)
+	}
+	// Lead times: 4d, 2d and 5d
+	if m.LeadTime.Count != 3 || m.LeadTime.Median != 4*24*time.Hour || m.LeadTime.Average != 11*24*time.Hour/3 {
+		t.Errorf("LeadTime = %+v", m.LeadTime)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name      string
+		durations []time.Duration
+		want      DurationStats
+	}{
+		{"none", nil, DurationStats{}},
+		{"odd", []time.Duration{3 * time.Hour, time.Hour, 2 * time.Hour}, DurationStats{Count: 3, Average: 2 * time.Hour, Median: 2 * time.Hour}},
+		{"even", []time.Duration{time.Hour, 4 * time.Hour, 2 * time.Hour, 9 * time.Hour}, DurationStats{Count: 4, Average: 4 * time.Hour, Median: 3 * time.Hour}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := summarize(tt.durations); got != tt.want {
+				t.Errorf("summarize() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusAt(t *testing.T) {
+	workflow := config.DefaultWorkflow()
+	day := func(d int) time.Time { return time.Date(2025, 6, d, 12, 0, 0, 0, time.UTC) }
+
+	tracked := &task.Task{Tags: []string{"mdtask"}, Created: day(2), Updated: day(6)}
+	tracked.SetStatus(task.StatusTODO)
+	tracked.ChangeStatus(task.StatusWIP, day(4))
+	tracked.ChangeStatus(task.StatusDONE, day(6))
+
+	legacy := &task.Task{Tags: []string{"mdtask"}, Created: day(2), Updated: day(5)}
+	legacy.SetStatus(task.StatusDONE)
+
+	tests := []struct {
+		name   string
+		task   *task.Task
+		at     time.Time
+		want   task.Status
+		exists bool
+	}{
+		{"before creation", tracked, day(1), "", false},
+		{"initial status", tracked, day(3), task.StatusTODO, true},
+		{"after a change", tracked, day(5), task.StatusWIP, true},
+		{"current status", tracked, day(7), task.StatusDONE, true},
+		{"legacy before update", legacy, day(4), task.StatusTODO, true},
+		{"legacy after update", legacy, day(5), task.StatusDONE, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := StatusAt(tt.task, workflow, tt.at)
+			if got != tt.want || ok != tt.exists {
+				t.Errorf("StatusAt() = %q, %v, want %q, %v", got, ok, tt.want, tt.exists)
+			}
+		})
+	}
+}
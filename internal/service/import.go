@@ -0,0 +1,212 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/errors"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// SourceField is the custom front matter field holding the ID an imported
+// task had in the tool it was imported from, e.g. "todoist:2995104339"
+const SourceField = "source"
+
+// ImportState is the state of an imported task in its source, mapped onto
+// the workflow when no status is given
+type ImportState int
+
+// Import states
+const (
+	// ImportOpen tasks get the default status
+	ImportOpen ImportState = iota
+	// ImportActive tasks get the workflow's active status
+	ImportActive
+	// ImportWaiting tasks get the WAIT status if the workflow has one
+	ImportWaiting
+	// ImportDone tasks get the first done status
+	ImportDone
+)
+
+// ImportRecord is a task read from another tool
+type ImportRecord struct {
+	// Source identifies the task in its tool; records with the same source
+	// as an existing task are skipped
+	Source      string
+	Title       string
+	Description string
+	// Notes become the content of the task
+	Notes string
+	Due   *time.Time
+	Tags  []string
+	// Status is a workflow status; if empty, State is mapped instead
+	Status string
+	State  ImportState
+	// Parent is the source of the parent task
+	Parent string
+}
+
+// ImportResult is the outcome of importing a record
+type ImportResult struct {
+	Record ImportRecord
+	// Task is the created task, or the existing one for duplicates. It is
+	// nil in a dry run.
+	Task *task.Task
+	Path string
+	// Duplicate is set if a task with the same source already existed
+	Duplicate bool
+	// Params are the parameters the task is created with
+	Params CreateTaskParams
+}
+
+// ImportTasks creates a task for each record whose source has not been
+// imported before. Parents are created before their subtasks; parents that
+// are neither imported nor exist already are left out. All records
+// are validated before any task is created; a dry run stops there.
+func (s *TaskService) ImportTasks(records []ImportRecord, dryRun bool) ([]ImportResult, error) {
+	existing, err := s.repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	bySource := make(map[string]*task.Task)
+	for _, t := range existing {
+		if value, ok := t.GetField(SourceField); ok {
+			bySource[task.FieldString(value)] = t
+		}
+	}
+
+	ordered, err := orderImport(records)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ImportResult, len(ordered))
+	for i, rec := range ordered {
+		result := ImportResult{Record: rec}
+		if t, ok := bySource[rec.Source]; ok {
+			result.Task, result.Duplicate = t, true
+		} else if result.Params, err = s.importParams(rec); err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	if dryRun {
+		return results, nil
+	}
+
+	for i := range results {
+		result := &results[i]
+		if result.Duplicate {
+			continue
+		}
+		if parent := result.Record.Parent; parent != "" {
+			if t, ok := bySource[parent]; ok {
+				result.Params.ParentID = t.ID
+			}
+		}
+		t, path, err := s.CreateTask(result.Params)
+		if err != nil {
+			return results[:i], fmt.Errorf("failed to import %s: %w", result.Record.Source, err)
+		}
+		result.Task, result.Path = t, path
+		bySource[result.Record.Source] = t
+	}
+	return results, nil
+}
+
+// importParams maps a record onto the parameters of a new task. The parent
+// is filled in once it has been created.
+func (s *TaskService) importParams(rec ImportRecord) (CreateTaskParams, error) {
+	if err := task.ValidateTitle(rec.Title); err != nil {
+		return CreateTaskParams{}, errors.ValidationError("title", fmt.Sprintf("%s: %v", rec.Source, err))
+	}
+	// Descriptions are a single line
+	description := strings.Join(strings.Fields(rec.Description), " ")
+
+	status, err := s.importStatus(rec)
+	if err != nil {
+		return CreateTaskParams{}, err
+	}
+	return CreateTaskParams{
+		Title:       rec.Title,
+		Description: description,
+		Content:     rec.Notes,
+		Tags:        rec.Tags,
+		Status:      string(status),
+		Deadline:    rec.Due,
+		Fields:      map[string]interface{}{SourceField: rec.Source},
+	}, nil
+}
+
+// importStatus returns the status of an imported task. It is always set, so
+// subtasks do not take over the status of their parent.
+func (s *TaskService) importStatus(rec ImportRecord) (task.Status, error) {
+	workflow := s.config.GetWorkflow()
+	if rec.Status != "" {
+		status, err := workflow.Parse(rec.Status)
+		if err != nil {
+			return "", errors.ValidationError("status", fmt.Sprintf("%s: %v", rec.Source, err))
+		}
+		return status, nil
+	}
+
+	switch rec.State {
+	case ImportDone:
+		if done := workflow.DoneStatuses(); len(done) > 0 {
+			return done[0], nil
+		}
+	case ImportActive:
+		if active, ok := workflow.ActiveStatus(); ok {
+			return active, nil
+		}
+	case ImportWaiting:
+		if workflow.IsValid(task.StatusWAIT) {
+			return task.StatusWAIT, nil
+		}
+	}
+	return s.DefaultStatus(), nil
+}
+
+// orderImport puts parents before their subtasks, keeping the order of the
+// records otherwise. Sources must be unique.
+func orderImport(records []ImportRecord) ([]ImportRecord, error) {
+	bySource := make(map[string]int, len(records))
+	for i, rec := range records {
+		if rec.Source == "" {
+			return nil, errors.ValidationError("source", fmt.Sprintf("record %d has no source ID", i+1))
+		}
+		if _, ok := bySource[rec.Source]; ok {
+			return nil, errors.ValidationError("source", fmt.Sprintf("%s appears more than once", rec.Source))
+		}
+		bySource[rec.Source] = i
+	}
+
+	ordered := make([]ImportRecord, 0, len(records))
+	// 0: not visited, 1: in progress, 2: added
+	state := make([]int, len(records))
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case 1:
+			return errors.ValidationError("parent", fmt.Sprintf("%s is its own ancestor", records[i].Source))
+		case 2:
+			return nil
+		}
+		state[i] = 1
+		if parent, ok := bySource[records[i].Parent]; ok {
+			if err := visit(parent); err != nil {
+				return err
+			}
+		}
+		state[i] = 2
+		ordered = append(ordered, records[i])
+		return nil
+	}
+	for i := range records {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
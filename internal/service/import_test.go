@@ -0,0 +1,116 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+func TestImportTasks(t *testing.T) {
+	repo := NewMockTaskRepository()
+	existing := &task.Task{
+		ID:     "task/20240101000000",
+		Title:  "Imported before",
+		Tags:   []string{"mdtask"},
+		Fields: map[string]interface{}{SourceField: "todoist:1"},
+	}
+	existing.SetStatus(task.StatusDONE)
+	repo.tasks[existing.ID] = existing
+	service := NewTaskService(repo, &config.Config{})
+
+	due := time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+	records := []ImportRecord{
+		// The subtask comes first and must be created after its parent
+		{Source: "todoist:3", Title: "Subtask", Parent: "todoist:2"},
+		{Source: "todoist:2", Title: "Parent", Due: &due, Tags: []string{"work"}, State: ImportActive},
+		{Source: "todoist:1", Title: "Imported before"},
+		{Source: "todoist:4", Title: "Child of existing", Parent: "todoist:1"},
+		{Source: "todoist:5", Title: "Waiting", Status: "wait"},
+		{Source: "todoist:6", Title: "Finished", State: ImportDone},
+	}
+
+	preview, err := service.ImportTasks(records, true)
+	if err != nil {
+		t.Fatalf("ImportTasks(dry run) error = %v", err)
+	}
+	if len(repo.tasks) != 1 {
+		t.Fatalf("dry run created %d tasks", len(repo.tasks)-1)
+	}
+	if preview[0].Record.Source != "todoist:2" || preview[1].Record.Source != "todoist:3" {
+		t.Errorf("parent is not ordered before its subtask: %s, %s", preview[0].Record.Source, preview[1].Record.Source)
+	}
+
+	results, err := service.ImportTasks(records, false)
+	if err != nil {
+		t.Fatalf("ImportTasks() error = %v", err)
+	}
+	bySource := make(map[string]ImportResult)
+	for _, r := range results {
+		bySource[r.Record.Source] = r
+	}
+
+	if r := bySource["todoist:1"]; !r.Duplicate || r.Task != existing {
+		t.Errorf("existing task was not skipped: %+v", r)
+	}
+	parent := bySource["todoist:2"].Task
+	if parent.GetStatus() != task.StatusWIP || parent.GetDeadline() == nil || !parent.GetDeadline().Equal(due) {
+		t.Errorf("parent has status %s and deadline %v", parent.GetStatus(), parent.GetDeadline())
+	}
+	if source, _ := parent.GetField(SourceField); source != "todoist:2" {
+		t.Errorf("source field = %v", source)
+	}
+	if sub := bySource["todoist:3"].Task; sub.GetParentID() != parent.ID || sub.GetStatus() != task.StatusTODO {
+		t.Errorf("subtask has parent %q and status %s", sub.GetParentID(), sub.GetStatus())
+	}
+	if child := bySource["todoist:4"].Task; child.GetParentID() != existing.ID || child.GetStatus() != task.StatusTODO {
+		t.Errorf("child of existing task has parent %q and status %s", child.GetParentID(), child.GetStatus())
+	}
+	if got := bySource["todoist:5"].Task.GetStatus(); got != task.StatusWAIT {
+		t.Errorf("status = %s, want WAIT", got)
+	}
+	if got := bySource["todoist:6"].Task.GetStatus(); got != task.StatusDONE {
+		t.Errorf("status = %s, want DONE", got)
+	}
+
+	// Importing again creates nothing
+	count := len(repo.tasks)
+	again, err := service.ImportTasks(records, false)
+	if err != nil {
+		t.Fatalf("ImportTasks(again) error = %v", err)
+	}
+	for _, r := range again {
+		if !r.Duplicate {
+			t.Errorf("%s was imported twice", r.Record.Source)
+		}
+	}
+	if len(repo.tasks) != count {
+		t.Errorf("second import created %d tasks", len(repo.tasks)-count)
+	}
+}
+
+func TestImportTasks_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []ImportRecord
+	}{
+		{"missing source", []ImportRecord{{Title: "A"}}},
+		{"duplicate source", []ImportRecord{{Source: "csv:1", Title: "A"}, {Source: "csv:1", Title: "B"}}},
+		{"empty title", []ImportRecord{{Source: "csv:1", Title: "A"}, {Source: "csv:2"}}},
+		{"unknown status", []ImportRecord{{Source: "csv:1", Title: "A", Status: "LATER"}}},
+		{"parent cycle", []ImportRecord{{Source: "csv:1", Title: "A", Parent: "csv:2"}, {Source: "csv:2", Title: "B", Parent: "csv:1"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMockTaskRepository()
+			service := NewTaskService(repo, &config.Config{})
+			if _, err := service.ImportTasks(tt.records, false); err == nil {
+				t.Error("expected an error")
+			}
+			if len(repo.tasks) != 0 {
+				t.Errorf("created %d tasks before failing", len(repo.tasks))
+			}
+		})
+	}
+}
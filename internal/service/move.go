@@ -0,0 +1,43 @@
+package service
+
+import (
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// MovedTask is a task moved by MoveTask and its new file
+type MovedTask struct {
+	Task *task.Task `json:"task"`
+	Path string     `json:"path"`
+}
+
+// MoveTask moves the file of a task to dir, keeping its ID. With
+// withSubtasks its subtasks, and theirs, are moved to the same directory.
+// The moved tasks are returned parent first.
+func (s *TaskService) MoveTask(taskID, dir string, withSubtasks bool) ([]MovedTask, error) {
+	t, err := s.repo.FindByID(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := s.repo.Move(taskID, dir)
+	if err != nil {
+		return nil, err
+	}
+	moved := []MovedTask{{Task: t, Path: path}}
+	if !withSubtasks {
+		return moved, nil
+	}
+
+	subtasks, err := s.findSubtasks(taskID)
+	if err != nil {
+		return moved, err
+	}
+	for _, subtask := range subtasks {
+		more, err := s.MoveTask(subtask.ID, dir, true)
+		moved = append(moved, more...)
+		if err != nil {
+			return moved, err
+		}
+	}
+	return moved, nil
+}
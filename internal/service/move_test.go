@@ -0,0 +1,58 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/tkancf/mdtask/internal/config"
+)
+
+func TestTaskService_MoveTask(t *testing.T) {
+	tests := []struct {
+		name         string
+		withSubtasks bool
+		want         []string
+	}{
+		{
+			name: "task only",
+			want: []string{"task/parent"},
+		},
+		{
+			name:         "with subtasks",
+			withSubtasks: true,
+			want:         []string{"task/parent", "task/child", "task/grandchild"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMockTaskRepository()
+			newFamily(repo)
+			s := NewTaskService(repo, config.DefaultConfig())
+
+			moved, err := s.MoveTask("task/parent", "archive", tt.withSubtasks)
+			if err != nil {
+				t.Fatalf("MoveTask() error = %v", err)
+			}
+			if len(moved) != len(tt.want) {
+				t.Fatalf("MoveTask() moved %d tasks, want %d", len(moved), len(tt.want))
+			}
+			for i, m := range moved {
+				if m.Task.ID != tt.want[i] {
+					t.Errorf("moved[%d] = %s, want %s", i, m.Task.ID, tt.want[i])
+				}
+				if filepath.Dir(m.Path) != "archive" || repo.moved[m.Task.ID] != m.Path {
+					t.Errorf("moved[%d].Path = %s", i, m.Path)
+				}
+			}
+			if len(repo.moved) != len(tt.want) {
+				t.Errorf("repository moved %v", repo.moved)
+			}
+		})
+	}
+
+	repo := NewMockTaskRepository()
+	if _, err := NewTaskService(repo, nil).MoveTask("task/missing", "archive", false); err == nil {
+		t.Error("MoveTask() of a missing task should fail")
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/constants"
+	"github.com/tkancf/mdtask/internal/errors"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// checkedItem matches a ticked markdown checklist item
+var checkedItem = regexp.MustCompile(`(?m)^(\s*[-*+] )\[[xX]\]`)
+
+// occurrenceTagPrefixes are the tags that belong to a single occurrence and
+// are not copied to the next one
+var occurrenceTagPrefixes = []string{
+	constants.StatusTagPrefix,
+	constants.DeadlineTagPrefix,
+	constants.ReminderTagPrefix,
+	constants.WaitForTagPrefix,
+	constants.PreviousTagPrefix,
+}
+
+// CreateNextOccurrence creates the next instance of a recurring task, usually
+// right after it was completed. The deadline and reminder are moved to the
+// next date of the rule that is not in the past, and the new task links back
+// to t; its checklist is unticked and its time log left out. It returns nil
+// if t does not repeat or its next instance exists.
+func (s *TaskService) CreateNextOccurrence(t *task.Task) (*task.Task, error) {
+	rule, err := t.GetRecurrence()
+	if err != nil {
+		return nil, errors.ValidationError("recur", err.Error())
+	}
+	if rule == nil {
+		return nil, nil
+	}
+
+	existing, err := s.FindNextOccurrence(t.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, nil
+	}
+
+	next := &task.Task{
+		Title:       t.Title,
+		Description: t.Description,
+		Aliases:     []string{},
+		Content:     task.RemoveTimeLog(checkedItem.ReplaceAllString(t.Content, "$1[ ]")),
+		Fields:      t.Clone().Fields,
+		Created:     time.Now(),
+		Updated:     time.Now(),
+	}
+	for _, tag := range t.Tags {
+		if !hasAnyPrefix(tag, occurrenceTagPrefixes) && tag != constants.ArchivedTag {
+			next.Tags = append(next.Tags, tag)
+		}
+	}
+	next.SetStatus(s.DefaultStatus())
+	next.SetPreviousID(t.ID)
+
+	deadline, reminder := NextOccurrenceDates(rule, t.GetDeadline(), t.GetReminder(), time.Now())
+	next.SetDeadline(deadline)
+	if reminder != nil {
+		next.SetReminder(*reminder)
+	}
+
+	if _, err := s.create(next); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// FindNextOccurrence returns the task created as the next instance of the
+// given one, or nil if there is none
+func (s *TaskService) FindNextOccurrence(taskID string) (*task.Task, error) {
+	tasks, err := s.repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tasks {
+		if t.GetPreviousID() == taskID {
+			return t, nil
+		}
+	}
+	return nil, nil
+}
+
+// NextOccurrenceDates computes the deadline and reminder of the next
+// instance. The rule is applied to the current deadline, or the reminder
+// date, or today, and repeated until the date is no longer in the past.
+// The reminder keeps its distance to the deadline.
+func NextOccurrenceDates(rule *task.Recurrence, deadline, reminder *time.Time, now time.Time) (time.Time, *time.Time) {
+	// Tag dates are parsed as UTC, so compare against today in UTC too
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	anchor := today
+	if deadline != nil {
+		anchor = *deadline
+	} else if reminder != nil {
+		anchor = time.Date(reminder.Year(), reminder.Month(), reminder.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	next := rule.Next(anchor)
+	for next.Before(today) {
+		next = rule.Next(next)
+	}
+
+	if reminder == nil {
+		return next, nil
+	}
+	shifted := reminder.Add(next.Sub(anchor))
+	return next, &shifted
+}
+
+// DefaultStatus returns the status of new tasks: task.default_status, or
+// the first status of the workflow
+func (s *TaskService) DefaultStatus() task.Status {
+	if s.config != nil && s.config.Task.DefaultStatus != "" {
+		return task.Status(s.config.Task.DefaultStatus)
+	}
+	return s.config.GetWorkflow().Names()[0]
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+func TestUpdateTask_Recurring(t *testing.T) {
+	repo := NewMockTaskRepository()
+	service := NewTaskService(repo, &config.Config{})
+
+	deadline := time.Now().AddDate(0, 0, 1).UTC().Truncate(24 * time.Hour)
+	reminder := deadline.Add(-15 * time.Hour)
+	chore := &task.Task{
+		ID:      "task/20240101000000",
+		Title:   "Water plants",
+		Tags:    []string{"mdtask", "home", "mdtask/recur/every:1w"},
+		Content: "- [x] kitchen\n- [ ] balcony",
+		Fields:  map[string]interface{}{"room": "all"},
+	}
+	chore.SetStatus(task.StatusWIP)
+	chore.SetDeadline(deadline)
+	chore.SetReminder(reminder)
+	repo.tasks[chore.ID] = chore
+
+	done := task.StatusDONE
+	if _, err := service.UpdateTask(chore.ID, UpdateTaskParams{Status: &done}); err != nil {
+		t.Fatalf("UpdateTask() error = %v", err)
+	}
+
+	next, err := service.FindNextOccurrence(chore.ID)
+	if err != nil || next == nil {
+		t.Fatalf("FindNextOccurrence() = %v, %v", next, err)
+	}
+	if next.Title != chore.Title || next.GetStatus() != task.StatusTODO {
+		t.Errorf("unexpected next task %q with status %s", next.Title, next.GetStatus())
+	}
+	if got, want := next.GetDeadline(), deadline.AddDate(0, 0, 7); got == nil || !got.Equal(want) {
+		t.Errorf("next deadline = %v, want %v", got, want)
+	}
+	if got, want := next.GetReminder(), reminder.AddDate(0, 0, 7); got == nil || !got.Equal(want) {
+		t.Errorf("next reminder = %v, want %v", got, want)
+	}
+	if r, _ := next.GetRecurrence(); r == nil || r.String() != "every:1w" {
+		t.Errorf("next task should keep the recurrence, got %v", r)
+	}
+	if next.Content != "- [ ] kitchen\n- [ ] balcony" {
+		t.Errorf("checklist should be reset, got %q", next.Content)
+	}
+	if room, _ := next.GetField("room"); room != "all" {
+		t.Errorf("custom fields should be copied, got %v", next.Fields)
+	}
+
+	// The next instance is only created once
+	again, err := service.CreateNextOccurrence(chore)
+	if err != nil || again != nil {
+		t.Errorf("CreateNextOccurrence() = %v, %v; want nil", again, err)
+	}
+}
+
+func TestSaveTask_Recurring(t *testing.T) {
+	repo := NewMockTaskRepository()
+	service := NewTaskService(repo, &config.Config{})
+
+	chore := &task.Task{ID: "task/20240101000000", Title: "Water plants", Tags: []string{"mdtask", "mdtask/recur/every:1d"}}
+	chore.SetStatus(task.StatusWIP)
+	repo.tasks[chore.ID] = chore
+
+	// Callers such as the web UI and MCP edit a copy and save it
+	edited := chore.Clone()
+	edited.SetStatus(task.StatusDONE)
+	if err := service.SaveTask(edited); err != nil {
+		t.Fatalf("SaveTask() error = %v", err)
+	}
+	next, err := service.FindNextOccurrence(chore.ID)
+	if err != nil || next == nil {
+		t.Fatalf("FindNextOccurrence() = %v, %v", next, err)
+	}
+
+	// Saving the completed task again does not schedule another one
+	if err := service.SaveTask(edited.Clone()); err != nil {
+		t.Fatalf("SaveTask() error = %v", err)
+	}
+	count := 0
+	for _, candidate := range repo.tasks {
+		if candidate.GetPreviousID() == chore.ID {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("found %d next occurrences, want 1", count)
+	}
+}
+
+func TestDefaultStatus(t *testing.T) {
+	kanban := config.WorkflowConfig{Statuses: []config.StatusConfig{{Name: "BACKLOG"}, {Name: "DOING"}, {Name: "DONE"}}}
+	tests := []struct {
+		name string
+		cfg  *config.Config
+		want task.Status
+	}{
+		{"no config", nil, task.StatusTODO},
+		{"default workflow", &config.Config{}, task.StatusTODO},
+		{"workflow without TODO", &config.Config{Workflow: kanban}, "BACKLOG"},
+		{"default_status", &config.Config{Workflow: kanban, Task: config.TaskConfig{DefaultStatus: "DOING"}}, "DOING"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMockTaskRepository()
+			service := NewTaskService(repo, tt.cfg)
+			if got := service.DefaultStatus(); got != tt.want {
+				t.Errorf("DefaultStatus() = %s, want %s", got, tt.want)
+			}
+			if tt.cfg == nil {
+				return
+			}
+
+			created, _, err := service.CreateTask(CreateTaskParams{Title: "New"})
+			if err != nil {
+				t.Fatalf("CreateTask() error = %v", err)
+			}
+			if created.GetStatus() != tt.want {
+				t.Errorf("CreateTask() status = %s, want %s", created.GetStatus(), tt.want)
+			}
+
+			done := &task.Task{ID: "task/20240101000000", Title: "Chore", Tags: []string{"mdtask", "mdtask/recur/every:1d"}}
+			done.SetStatus(task.StatusDONE)
+			next, err := service.CreateNextOccurrence(done)
+			if err != nil || next == nil {
+				t.Fatalf("CreateNextOccurrence() = %v, %v", next, err)
+			}
+			if next.GetStatus() != tt.want {
+				t.Errorf("CreateNextOccurrence() status = %s, want %s", next.GetStatus(), tt.want)
+			}
+		})
+	}
+}
+
+func TestNextOccurrenceDates(t *testing.T) {
+	date := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	weekly, _ := task.ParseRecurrence("weekly:mon")
+	now := date("2024-03-06") // a Wednesday
+
+	// Overdue deadlines skip to the first occurrence that is not in the past
+	deadline := date("2024-02-19")
+	got, reminder := NextOccurrenceDates(weekly, &deadline, nil, now)
+	if got.Format("2006-01-02") != "2024-03-11" || reminder != nil {
+		t.Errorf("NextOccurrenceDates() = %s, %v", got, reminder)
+	}
+
+	// Without a deadline the reminder date is used
+	remind, _ := time.Parse("2006-01-02T15:04", "2024-03-04T09:30")
+	got, reminder = NextOccurrenceDates(weekly, nil, &remind, now)
+	if got.Format("2006-01-02") != "2024-03-11" || reminder == nil || reminder.Format("2006-01-02T15:04") != "2024-03-11T09:30" {
+		t.Errorf("NextOccurrenceDates() = %s, %v", got, reminder)
+	}
+
+	// Without either, the next occurrence after today
+	got, _ = NextOccurrenceDates(weekly, nil, nil, now)
+	if got.Format("2006-01-02") != "2024-03-11" {
+		t.Errorf("NextOccurrenceDates() = %s", got)
+	}
+}
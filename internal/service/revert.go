@@ -0,0 +1,43 @@
+package service
+
+import (
+	"time"
+
+	"github.com/tkancf/mdtask/internal/repository"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// RevertTask restores a task to its version committed at rev, which can be
+// any git revision such as a hash or HEAD~2. The revert is an edit like any
+// other: a status change must be allowed by the workflow and is added to
+// the status history, and the hooks run. It returns the task before and
+// after the revert and the commit the restored version comes from.
+func (s *TaskService) RevertTask(taskID, rev string) (before, after *task.Task, revision *repository.Revision, err error) {
+	current, err := s.repo.FindByID(taskID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	old, revision, err := s.repo.TaskAt(taskID, rev)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Everything is taken from the old version except the status history,
+	// which only grows, and the version the write is checked against
+	t := old.Clone()
+	t.ID = current.ID
+	t.Version = current.Version
+	t.StatusHistory = current.Clone().StatusHistory
+	t.SetStatus(current.GetStatus())
+	if err := s.ChangeStatus(t, old.GetStatus(), time.Now()); err != nil {
+		return nil, nil, nil, err
+	}
+
+	err = s.apply(current, t, func(t *task.Task) error {
+		return s.repo.Revert(t, revision)
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return current, t, revision, nil
+}
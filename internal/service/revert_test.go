@@ -0,0 +1,76 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/errors"
+	"github.com/tkancf/mdtask/internal/hooks"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+func TestRevertTask(t *testing.T) {
+	tests := []struct {
+		name     string
+		workflow config.WorkflowConfig
+		wantErr  bool
+	}{
+		{"default workflow", config.WorkflowConfig{}, false},
+		{"transition not allowed", config.WorkflowConfig{Transitions: map[string][]string{"DONE": {"WIP"}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := filepath.Join(t.TempDir(), "events")
+			command := `echo "$MDTASK_EVENT $MDTASK_TASK_ID" >> "` + log + `"`
+			cfg := &config.Config{Workflow: tt.workflow, Hooks: []config.HookConfig{{On: "status", Command: command}}}
+
+			repo := NewMockTaskRepository()
+			draft := &task.Task{ID: "task/20240101120000", Title: "Draft", Tags: []string{"mdtask"}}
+			draft.SetStatus(task.StatusTODO)
+			finished := draft.Clone()
+			finished.Title = "Final"
+			finished.ChangeStatus(task.StatusDONE, time.Now().Add(-time.Hour))
+			repo.tasks[finished.ID] = finished
+			repo.history = map[string]*task.Task{finished.ID + "@abc123": draft}
+			service := NewTaskService(repo, cfg)
+
+			before, after, revision, err := service.RevertTask(finished.ID, "abc123")
+			hooks.Wait(10 * time.Second)
+			if tt.wantErr {
+				if !errors.IsValidation(err) {
+					t.Errorf("RevertTask() error = %v, want a validation error", err)
+				}
+				if stored := repo.tasks[finished.ID]; stored.Title != "Final" || stored.GetStatus() != task.StatusDONE {
+					t.Errorf("rejected revert changed the task: %q, %s", stored.Title, stored.GetStatus())
+				}
+				if _, err := os.Stat(log); err == nil {
+					t.Error("rejected revert should not run hooks")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RevertTask() error = %v", err)
+			}
+
+			if before.Title != "Final" || after.Title != "Draft" || revision.Hash != "abc123" {
+				t.Errorf("RevertTask() = %q, %q, %v", before.Title, after.Title, revision)
+			}
+			stored := repo.tasks[finished.ID]
+			if stored.Title != "Draft" || stored.GetStatus() != task.StatusTODO {
+				t.Errorf("stored task = %q, %s", stored.Title, stored.GetStatus())
+			}
+			// The history keeps the completion and records the revert
+			if n := len(stored.StatusHistory); n != 2 || stored.StatusHistory[1].From != task.StatusDONE || stored.StatusHistory[1].To != task.StatusTODO {
+				t.Errorf("status history = %+v", stored.StatusHistory)
+			}
+			data, err := os.ReadFile(log)
+			if err != nil || string(data) != "status:TODO "+finished.ID+"\n" {
+				t.Errorf("hook events = %q, %v", data, err)
+			}
+		})
+	}
+}
@@ -7,6 +7,8 @@ import (
 
 	"github.com/tkancf/mdtask/internal/config"
 	"github.com/tkancf/mdtask/internal/errors"
+	"github.com/tkancf/mdtask/internal/hooks"
+	"github.com/tkancf/mdtask/internal/repository"
 	"github.com/tkancf/mdtask/internal/task"
 )
 
@@ -16,20 +18,83 @@ type TaskRepository interface {
 	Create(t *task.Task) (string, error)
 	Update(t *task.Task) error
 	FindAll() ([]*task.Task, error)
+	Delete(id string) (*repository.TrashEntry, error)
+	Restore(id string) (*task.Task, error)
+	Trash() ([]repository.TrashEntry, error)
+	Move(id, dir string) (string, error)
+	TaskAt(id, rev string) (*task.Task, *repository.Revision, error)
+	Revert(t *task.Task, revision *repository.Revision) error
 }
 
 // TaskService handles business logic for task operations
 type TaskService struct {
 	repo   TaskRepository
 	config *config.Config
+	hooks  *hooks.Dispatcher
 }
 
 // NewTaskService creates a new task service
 func NewTaskService(repo TaskRepository, cfg *config.Config) *TaskService {
-	return &TaskService{
+	s := &TaskService{
 		repo:   repo,
 		config: cfg,
 	}
+	if cfg != nil {
+		s.hooks = hooks.New(cfg.Hooks)
+	}
+	return s
+}
+
+// AddTask stores a task built by the caller, e.g. from a form, and runs
+// the create hooks
+func (s *TaskService) AddTask(t *task.Task) (string, error) {
+	return s.create(t)
+}
+
+// SaveTask writes back a task changed by the caller and runs the hooks of
+// the change, e.g. archive or status:DONE
+func (s *TaskService) SaveTask(t *task.Task) error {
+	before, err := s.repo.FindByID(t.ID)
+	if err != nil {
+		return err
+	}
+	return s.update(before, t)
+}
+
+// create stores a new task and runs the create hooks. Every task the
+// service creates goes through here.
+func (s *TaskService) create(t *task.Task) (string, error) {
+	filePath, err := s.repo.Create(t)
+	if err != nil {
+		return "", err
+	}
+	s.hooks.Fire(nil, t)
+	return filePath, nil
+}
+
+// update writes a changed task and runs the hooks of the change from
+// before. Every task the service changes goes through here, so completing
+// a recurring task schedules its next occurrence whichever surface
+// completed it.
+func (s *TaskService) update(before, t *task.Task) error {
+	return s.apply(before, t, s.repo.Update)
+}
+
+// apply writes a changed task with write and runs what follows the change
+// from before: the hooks and the next occurrence of a completed task
+func (s *TaskService) apply(before, t *task.Task, write func(*task.Task) error) error {
+	if err := write(t); err != nil {
+		return err
+	}
+	s.hooks.Fire(before, t)
+
+	workflow := s.config.GetWorkflow()
+	if before != nil && !workflow.IsDone(before.GetStatus()) && workflow.IsDone(t.GetStatus()) {
+		if _, err := s.CreateNextOccurrence(t); err != nil {
+			return errors.InternalError("task updated but its next occurrence could not be created", err)
+		}
+	}
+	return nil
 }
 
 // CreateTask creates a new task with the given parameters
@@ -81,10 +146,10 @@ func (s *TaskService) CreateTask(params CreateTaskParams) (*task.Task, string, e
 	// Set status
 	status := params.Status
 	if status == "" {
-		status = s.config.Task.DefaultStatus
+		status = string(s.DefaultStatus())
 	}
-	if status == "" {
-		status = string(task.StatusTODO)
+	if !s.config.GetWorkflow().IsValid(task.Status(status)) {
+		return nil, "", errors.ValidationError("status", fmt.Sprintf("unknown status %s (valid: %s)", status, s.config.GetWorkflow()))
 	}
 	t.SetStatus(task.Status(status))
 
@@ -98,6 +163,24 @@ func (s *TaskService) CreateTask(params CreateTaskParams) (*task.Task, string, e
 		t.SetReminder(*params.Reminder)
 	}
 
+	// Set custom fields
+	if err := s.ApplyFields(t, params.Fields); err != nil {
+		return nil, "", err
+	}
+
+	// Set recurrence
+	if params.Recurrence != nil {
+		t.SetRecurrence(params.Recurrence)
+	}
+
+	// Set dependencies
+	for _, id := range params.BlockedBy {
+		t.AddBlocker(id)
+	}
+	if err := s.validateBlockers(t, params.BlockedBy); err != nil {
+		return nil, "", err
+	}
+
 	// Handle parent task
 	if params.ParentID != "" {
 		parentTask, err := s.repo.FindByID(params.ParentID)
@@ -114,7 +197,7 @@ func (s *TaskService) CreateTask(params CreateTaskParams) (*task.Task, string, e
 	}
 
 	// Create the task
-	filePath, err := s.repo.Create(t)
+	filePath, err := s.create(t)
 	if err != nil {
 		return nil, "", err
 	}
@@ -128,6 +211,7 @@ func (s *TaskService) UpdateTask(taskID string, params UpdateTaskParams) (*task.
 	if err != nil {
 		return nil, err
 	}
+	before := t.Clone()
 
 	// Update title if provided
 	if params.Title != nil {
@@ -145,9 +229,11 @@ func (s *TaskService) UpdateTask(taskID string, params UpdateTaskParams) (*task.
 		t.Description = *params.Description
 	}
 
-	// Update status if provided
+	// Update status if provided and allowed by the workflow
 	if params.Status != nil {
-		t.SetStatus(*params.Status)
+		if err := s.ChangeStatus(t, *params.Status, time.Now()); err != nil {
+			return nil, err
+		}
 	}
 
 	// Update tags if provided
@@ -193,14 +279,84 @@ func (s *TaskService) UpdateTask(taskID string, params UpdateTaskParams) (*task.
 		t.SetReminder(*params.Reminder)
 	}
 
-	// Save the updated task
-	if err := s.repo.Update(t); err != nil {
+	// Update custom fields if provided
+	if err := s.ApplyFields(t, params.Fields); err != nil {
+		return nil, err
+	}
+
+	// Update recurrence if provided
+	if params.ClearRecurrence {
+		t.RemoveRecurrence()
+	} else if params.Recurrence != nil {
+		t.SetRecurrence(params.Recurrence)
+	}
+
+	// Update dependencies if provided
+	for _, id := range params.RemoveBlockedBy {
+		t.RemoveBlocker(id)
+	}
+	for _, id := range params.AddBlockedBy {
+		t.AddBlocker(id)
+	}
+	if err := s.validateBlockers(t, params.AddBlockedBy); err != nil {
+		return nil, err
+	}
+
+	if params.Version != "" {
+		t.Version = params.Version
+	}
+
+	// Save the updated task; completing a recurring task schedules its
+	// next occurrence
+	if err := s.update(before, t); err != nil {
 		return nil, err
 	}
 
 	return t, nil
 }
 
+// ChangeStatus moves a task to status if the workflow allows it and records
+// the transition in the task's status history. The task is not saved.
+func (s *TaskService) ChangeStatus(t *task.Task, status task.Status, at time.Time) error {
+	if t.GetStatus() == status {
+		return nil
+	}
+	if err := s.checkTransition(t.GetStatus(), status); err != nil {
+		return err
+	}
+	t.ChangeStatus(status, at)
+	return nil
+}
+
+// checkTransition validates a status change against the configured workflow
+func (s *TaskService) checkTransition(from, to task.Status) error {
+	if err := s.config.GetWorkflow().CheckTransition(from, to); err != nil {
+		return errors.ValidationError("status", err.Error())
+	}
+	return nil
+}
+
+// ApplyFields validates custom fields against the configured schema and
+// sets them on the task. A nil value removes the field. The task is not
+// saved.
+func (s *TaskService) ApplyFields(t *task.Task, fields map[string]interface{}) error {
+	for name, value := range fields {
+		if err := task.ValidateFieldName(name); err != nil {
+			return errors.ValidationError("fields", err.Error())
+		}
+		if value == nil {
+			t.DeleteField(name)
+			continue
+		}
+		value, err := s.config.ValidateFieldValue(name, value)
+		if err != nil {
+			return errors.ValidationError("fields", err.Error())
+		}
+		t.SetField(name, value)
+	}
+	return nil
+}
+
 // ArchiveTask archives a task
 func (s *TaskService) ArchiveTask(taskID string) (*task.Task, error) {
 	t, err := s.repo.FindByID(taskID)
@@ -217,8 +373,9 @@ func (s *TaskService) ArchiveTask(taskID string) (*task.Task, error) {
 		return nil, err
 	}
 
+	before := t.Clone()
 	t.Archive()
-	if err := s.repo.Update(t); err != nil {
+	if err := s.update(before, t); err != nil {
 		return nil, err
 	}
 
@@ -244,8 +401,9 @@ func (s *TaskService) UnarchiveTask(taskID string) (*task.Task, error) {
 		}
 	}
 
+	before := t.Clone()
 	t.Unarchive()
-	if err := s.repo.Update(t); err != nil {
+	if err := s.update(before, t); err != nil {
 		return nil, err
 	}
 
@@ -298,8 +456,9 @@ func (s *TaskService) archiveSubtasks(parentID string) error {
 				return err
 			}
 			
+			before := subtask.Clone()
 			subtask.Archive()
-			if err := s.repo.Update(subtask); err != nil {
+			if err := s.update(before, subtask); err != nil {
 				return err
 			}
 		}
@@ -318,6 +477,11 @@ type CreateTaskParams struct {
 	Deadline    *time.Time
 	Reminder    *time.Time
 	ParentID    string
+	Fields      map[string]interface{}
+	// Recurrence makes the task repeat when it is completed
+	Recurrence *task.Recurrence
+	// BlockedBy lists the IDs of the tasks the new task depends on
+	BlockedBy []string
 }
 
 // UpdateTaskParams holds parameters for updating a task
@@ -331,4 +495,14 @@ type UpdateTaskParams struct {
 	ClearDeadline bool
 	Reminder      *time.Time
 	ClearReminder bool
+	// Fields sets custom front matter fields; a nil value removes the field
+	Fields map[string]interface{}
+	// Recurrence sets the repeat rule; ClearRecurrence stops the task repeating
+	Recurrence      *task.Recurrence
+	ClearRecurrence bool
+	// AddBlockedBy and RemoveBlockedBy change the tasks this task depends on
+	AddBlockedBy    []string
+	RemoveBlockedBy []string
+	// Version, if set, rejects the update when the task has changed since
+	Version string
 }
\ No newline at end of file
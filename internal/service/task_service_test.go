@@ -1,24 +1,37 @@
 package service
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/tkancf/mdtask/internal/config"
 	"github.com/tkancf/mdtask/internal/errors"
+	"github.com/tkancf/mdtask/internal/hooks"
+	"github.com/tkancf/mdtask/internal/repository"
 	"github.com/tkancf/mdtask/internal/task"
 )
 
 // MockTaskRepository is a mock implementation of repository for testing
 type MockTaskRepository struct {
 	tasks          map[string]*task.Task
+	trash          []repository.TrashEntry
+	trashed        map[string]*task.Task
+	moved          map[string]string
+	history        map[string]*task.Task // keyed by "<id>@<rev>"
 	shouldFailFind bool
 	shouldFailSave bool
 }
 
 func NewMockTaskRepository() *MockTaskRepository {
 	return &MockTaskRepository{
-		tasks: make(map[string]*task.Task),
+		tasks:   make(map[string]*task.Task),
+		trashed: make(map[string]*task.Task),
 	}
 }
 
@@ -41,6 +54,10 @@ func (m *MockTaskRepository) Create(t *task.Task) (string, error) {
 	
 	if t.ID == "" {
 		t.ID = "task/20240101120000"
+		// Number further tasks like the repository does
+		for i := 1; m.tasks[t.ID] != nil; i++ {
+			t.ID = fmt.Sprintf("task/20240101120000_%d", i)
+		}
 	}
 	m.tasks[t.ID] = t
 	return "/path/to/" + t.ID + ".md", nil
@@ -123,6 +140,62 @@ func (m *MockTaskRepository) SearchByTags(include, exclude []string, orMode bool
 	return m.FindAll()
 }
 
+func (m *MockTaskRepository) Delete(id string) (*repository.TrashEntry, error) {
+	t, err := m.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	delete(m.tasks, id)
+	m.trashed[id] = t
+	entry := repository.TrashEntry{ID: id, Title: t.Title, ParentID: t.GetParentID(), DeletedAt: time.Now()}
+	m.trash = append([]repository.TrashEntry{entry}, m.trash...)
+	return &entry, nil
+}
+
+func (m *MockTaskRepository) Restore(id string) (*task.Task, error) {
+	t, ok := m.trashed[id]
+	if !ok {
+		return nil, errors.NotFound("task in trash", id)
+	}
+	delete(m.trashed, id)
+	for i, entry := range m.trash {
+		if entry.ID == id {
+			m.trash = append(m.trash[:i], m.trash[i+1:]...)
+			break
+		}
+	}
+	m.tasks[id] = t
+	return t, nil
+}
+
+func (m *MockTaskRepository) Trash() ([]repository.TrashEntry, error) {
+	return m.trash, nil
+}
+
+func (m *MockTaskRepository) Move(id, dir string) (string, error) {
+	if _, err := m.FindByID(id); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, strings.TrimPrefix(id, "task/")+".md")
+	if m.moved == nil {
+		m.moved = make(map[string]string)
+	}
+	m.moved[id] = path
+	return path, nil
+}
+
+func (m *MockTaskRepository) TaskAt(id, rev string) (*task.Task, *repository.Revision, error) {
+	old, ok := m.history[id+"@"+rev]
+	if !ok {
+		return nil, nil, errors.NotFound("revision", rev)
+	}
+	return old.Clone(), &repository.Revision{Hash: rev, Subject: "Update " + id}, nil
+}
+
+func (m *MockTaskRepository) Revert(t *task.Task, revision *repository.Revision) error {
+	return m.Update(t)
+}
+
 func TestCreateTask(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -316,6 +389,41 @@ func TestUpdateTask(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:   "set and remove custom fields",
+			taskID: "task/20240101120000",
+			params: UpdateTaskParams{
+				Fields: map[string]interface{}{"estimate": "3h", "owner": nil},
+			},
+			setup: func(repo *MockTaskRepository) {
+				t := &task.Task{
+					ID:     "task/20240101120000",
+					Tags:   []string{"mdtask"},
+					Fields: map[string]interface{}{"owner": "alice"},
+				}
+				repo.tasks[t.ID] = t
+			},
+			wantErr: false,
+			check: func(t *testing.T, task *task.Task) {
+				if v, _ := task.GetField("estimate"); v != "3h" {
+					t.Errorf("expected estimate 3h, got %v", v)
+				}
+				if _, ok := task.GetField("owner"); ok {
+					t.Error("expected owner to be removed")
+				}
+			},
+		},
+		{
+			name:   "reserved field name",
+			taskID: "task/20240101120000",
+			params: UpdateTaskParams{
+				Fields: map[string]interface{}{"title": "x"},
+			},
+			setup: func(repo *MockTaskRepository) {
+				repo.tasks["task/20240101120000"] = &task.Task{ID: "task/20240101120000", Tags: []string{"mdtask"}}
+			},
+			wantErr: true,
+		},
 		{
 			name:   "task not found",
 			taskID: "task/nonexistent",
@@ -349,6 +457,70 @@ func TestUpdateTask(t *testing.T) {
 	}
 }
 
+func TestUpdateTask_Workflow(t *testing.T) {
+	cfg := &config.Config{
+		Workflow: config.WorkflowConfig{
+			Statuses: []config.StatusConfig{
+				{Name: "TODO"}, {Name: "REVIEW"}, {Name: "DONE"},
+			},
+			Transitions: map[string][]string{
+				"TODO":   {"REVIEW"},
+				"REVIEW": {"TODO", "DONE"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		from    task.Status
+		to      task.Status
+		wantErr bool
+	}{
+		{"allowed transition", "TODO", "REVIEW", false},
+		{"forbidden transition", "TODO", "DONE", true},
+		{"unknown status", "TODO", "WIP", true},
+		{"same status", "TODO", "TODO", false},
+		{"status without rules", "DONE", "TODO", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMockTaskRepository()
+			existing := &task.Task{ID: "task/20240101120000", Tags: []string{"mdtask"}}
+			existing.SetStatus(tt.from)
+			repo.tasks[existing.ID] = existing
+
+			service := NewTaskService(repo, cfg)
+			updated, err := service.UpdateTask(existing.ID, UpdateTaskParams{Status: statusPtr(tt.to)})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UpdateTask() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.IsValidation(err) {
+					t.Errorf("expected validation error, got %v", err)
+				}
+				if repo.tasks[existing.ID].GetStatus() != tt.from {
+					t.Error("rejected transition should not change the task")
+				}
+				return
+			}
+			if updated.GetStatus() != tt.to {
+				t.Errorf("status = %s, want %s", updated.GetStatus(), tt.to)
+			}
+
+			// Only actual changes are recorded
+			history := updated.StatusHistory
+			if tt.from == tt.to {
+				if len(history) != 0 {
+					t.Errorf("StatusHistory = %v, want none", history)
+				}
+			} else if len(history) != 1 || history[0].From != tt.from || history[0].To != tt.to || history[0].At.IsZero() {
+				t.Errorf("StatusHistory = %v, want %s -> %s", history, tt.from, tt.to)
+			}
+		})
+	}
+}
+
 func TestArchiveTask(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -453,6 +625,84 @@ func TestArchiveTask(t *testing.T) {
 	}
 }
 
+func TestTaskService_Hooks(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "events")
+	command := `echo "$MDTASK_EVENT $MDTASK_TASK_ID" >> "` + log + `"`
+	cfg := &config.Config{}
+	for _, on := range []string{"create", "update", "archive", "status"} {
+		cfg.Hooks = append(cfg.Hooks, config.HookConfig{On: on, Command: command})
+	}
+
+	repo := NewMockTaskRepository()
+	service := NewTaskService(repo, cfg)
+
+	parent, _, err := service.CreateTask(CreateTaskParams{Title: "Parent"})
+	if err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	child, _, err := service.CreateTask(CreateTaskParams{Title: "Child", ParentID: parent.ID})
+	if err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	if _, err := service.UpdateTask(child.ID, UpdateTaskParams{Status: statusPtr(task.StatusDONE)}); err != nil {
+		t.Fatalf("UpdateTask() error = %v", err)
+	}
+
+	// Tasks changed by the caller are compared with the stored version
+	edited := repo.tasks[parent.ID].Clone()
+	edited.SetStatus(task.StatusWIP)
+	if err := service.SaveTask(edited); err != nil {
+		t.Fatalf("SaveTask() error = %v", err)
+	}
+	if _, err := service.ArchiveTask(parent.ID); err != nil {
+		t.Fatalf("ArchiveTask() error = %v", err)
+	}
+	hooks.Wait(10 * time.Second)
+
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatalf("no hook ran: %v", err)
+	}
+	want := strings.Join([]string{
+		"create " + parent.ID,
+		"create " + child.ID,
+		"update " + child.ID,
+		"status:DONE " + child.ID,
+		"update " + parent.ID,
+		"status:WIP " + parent.ID,
+		"archive " + child.ID,
+		"archive " + parent.ID,
+	}, "\n") + "\n"
+	if string(data) != want {
+		t.Errorf("hook events:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestTaskService_SlowHook(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	cfg := &config.Config{Hooks: []config.HookConfig{{On: "update", URL: server.URL, Timeout: "30s"}}}
+	repo := NewMockTaskRepository()
+	existing := &task.Task{ID: "task/20240101120000", Title: "Slow", Tags: []string{"mdtask"}}
+	repo.tasks[existing.ID] = existing
+	service := NewTaskService(repo, cfg)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := service.UpdateTask(existing.ID, UpdateTaskParams{Title: stringPtr(fmt.Sprintf("Slow %d", i))}); err != nil {
+			t.Fatalf("UpdateTask() error = %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("UpdateTask() waited %v for the webhook", elapsed)
+	}
+}
+
 // Helper functions
 func stringPtr(s string) *string {
 	return &s
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/errors"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// TimerEntry is a clock entry together with its task
+type TimerEntry struct {
+	Task  *task.Task
+	Entry task.ClockEntry
+}
+
+// TimesheetRow is the time logged on one task
+type TimesheetRow struct {
+	Task     *task.Task
+	Duration time.Duration
+}
+
+// TagTotal is the time logged on the tasks with a tag
+type TagTotal struct {
+	Tag      string
+	Duration time.Duration
+}
+
+// Timesheet sums up the time logged between From and To. A task counts
+// towards each of its tags, so tag totals may add up to more than Total.
+type Timesheet struct {
+	From, To time.Time
+	Tasks    []TimesheetRow
+	Tags     []TagTotal
+	Total    time.Duration
+}
+
+// StartTimer starts the clock on a task and moves it to the workflow's
+// active status. Only one timer runs at a time: timers running on other
+// tasks are stopped first and returned.
+func (s *TaskService) StartTimer(taskID string, now time.Time) (TimerEntry, []TimerEntry, error) {
+	t, err := s.repo.FindByID(taskID)
+	if err != nil {
+		return TimerEntry{}, nil, err
+	}
+	if t.IsArchived() {
+		return TimerEntry{}, nil, errors.ValidationError("timer", fmt.Sprintf("%s is archived", t.ID))
+	}
+	if t.RunningClock() != nil {
+		return TimerEntry{}, nil, errors.ValidationError("timer", fmt.Sprintf("a timer is already running for %s", t.ID))
+	}
+
+	// Check the status change before touching any task
+	active, hasActive := s.config.GetWorkflow().ActiveStatus()
+	if hasActive && t.GetStatus() != active {
+		if err := s.checkTransition(t.GetStatus(), active); err != nil {
+			return TimerEntry{}, nil, err
+		}
+	}
+
+	running, err := s.RunningTimers()
+	if err != nil {
+		return TimerEntry{}, nil, err
+	}
+	var stopped []TimerEntry
+	for _, r := range running {
+		entry, err := s.stopTimer(r.Task, now)
+		if err != nil {
+			return TimerEntry{}, stopped, err
+		}
+		stopped = append(stopped, entry)
+	}
+
+	before := t.Clone()
+	entry, err := t.ClockIn(now)
+	if err != nil {
+		return TimerEntry{}, stopped, errors.ValidationError("timer", err.Error())
+	}
+	if hasActive {
+		t.ChangeStatus(active, now)
+	}
+	if err := s.update(before, t); err != nil {
+		return TimerEntry{}, stopped, err
+	}
+	return TimerEntry{Task: t, Entry: entry}, stopped, nil
+}
+
+// StopTimer stops the timer of a task, or every running timer if taskID is
+// empty. It returns the stopped entries.
+func (s *TaskService) StopTimer(taskID string, now time.Time) ([]TimerEntry, error) {
+	var tasks []*task.Task
+	if taskID != "" {
+		t, err := s.repo.FindByID(taskID)
+		if err != nil {
+			return nil, err
+		}
+		if t.RunningClock() == nil {
+			return nil, errors.ValidationError("timer", fmt.Sprintf("no timer is running for %s", t.ID))
+		}
+		tasks = append(tasks, t)
+	} else {
+		running, err := s.RunningTimers()
+		if err != nil {
+			return nil, err
+		}
+		if len(running) == 0 {
+			return nil, errors.ValidationError("timer", "no timer is running")
+		}
+		for _, r := range running {
+			tasks = append(tasks, r.Task)
+		}
+	}
+
+	var stopped []TimerEntry
+	for _, t := range tasks {
+		entry, err := s.stopTimer(t, now)
+		if err != nil {
+			return stopped, err
+		}
+		stopped = append(stopped, entry)
+	}
+	return stopped, nil
+}
+
+func (s *TaskService) stopTimer(t *task.Task, now time.Time) (TimerEntry, error) {
+	before := t.Clone()
+	entry, err := t.ClockOut(now)
+	if err != nil {
+		return TimerEntry{}, errors.ValidationError("timer", err.Error())
+	}
+	if err := s.update(before, t); err != nil {
+		return TimerEntry{}, err
+	}
+	return TimerEntry{Task: t, Entry: entry}, nil
+}
+
+// RunningTimers returns the tasks whose clock is running
+func (s *TaskService) RunningTimers() ([]TimerEntry, error) {
+	all, err := s.repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	var running []TimerEntry
+	for _, t := range all {
+		if entry := t.RunningClock(); entry != nil {
+			running = append(running, TimerEntry{Task: t, Entry: *entry})
+		}
+	}
+	return running, nil
+}
+
+// Timesheet sums up the time logged in [from, to), including archived
+// tasks. Entries crossing the bounds count with the part inside them, and
+// running timers count up to now.
+func (s *TaskService) Timesheet(from, to, now time.Time) (*Timesheet, error) {
+	all, err := s.repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	sheet := &Timesheet{From: from, To: to}
+	byTag := make(map[string]time.Duration)
+	for _, t := range all {
+		var spent time.Duration
+		for _, entry := range t.GetClockEntries() {
+			spent += overlap(entry, from, to, now)
+		}
+		if spent == 0 {
+			continue
+		}
+
+		sheet.Tasks = append(sheet.Tasks, TimesheetRow{Task: t, Duration: spent})
+		sheet.Total += spent
+		for _, tag := range t.UserTags() {
+			byTag[tag] += spent
+		}
+	}
+
+	sort.Slice(sheet.Tasks, func(i, j int) bool {
+		if sheet.Tasks[i].Duration != sheet.Tasks[j].Duration {
+			return sheet.Tasks[i].Duration > sheet.Tasks[j].Duration
+		}
+		return sheet.Tasks[i].Task.ID < sheet.Tasks[j].Task.ID
+	})
+	for tag, d := range byTag {
+		sheet.Tags = append(sheet.Tags, TagTotal{Tag: tag, Duration: d})
+	}
+	sort.Slice(sheet.Tags, func(i, j int) bool {
+		if sheet.Tags[i].Duration != sheet.Tags[j].Duration {
+			return sheet.Tags[i].Duration > sheet.Tags[j].Duration
+		}
+		return sheet.Tags[i].Tag < sheet.Tags[j].Tag
+	})
+	return sheet, nil
+}
+
+// overlap returns the part of an entry that falls in [from, to)
+func overlap(entry task.ClockEntry, from, to, now time.Time) time.Duration {
+	start, end := entry.Start, now
+	if entry.End != nil {
+		end = *entry.End
+	}
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// WeekRange returns the Monday-to-Monday range of the week containing t,
+// shifted by offset weeks
+func WeekRange(t time.Time, offset int) (time.Time, time.Time) {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	daysSinceMonday := (int(day.Weekday()) + 6) % 7
+	from := day.AddDate(0, 0, -daysSinceMonday+7*offset)
+	return from, from.AddDate(0, 0, 7)
+}
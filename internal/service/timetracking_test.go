@@ -0,0 +1,116 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/errors"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+func clockTime(s string) time.Time {
+	t, _ := time.ParseInLocation("2006-01-02 15:04", s, time.Local)
+	return t
+}
+
+func TestStartStopTimer(t *testing.T) {
+	repo := NewMockTaskRepository()
+	service := NewTaskService(repo, &config.Config{})
+
+	login := &task.Task{ID: "task/1", Title: "Fix login", Tags: []string{"mdtask", "client/acme"}}
+	login.SetStatus(task.StatusTODO)
+	docs := &task.Task{ID: "task/2", Title: "Write docs", Tags: []string{"mdtask"}}
+	docs.SetStatus(task.StatusTODO)
+	repo.tasks[login.ID] = login
+	repo.tasks[docs.ID] = docs
+
+	started, stopped, err := service.StartTimer(login.ID, clockTime("2025-06-20 09:00"))
+	if err != nil {
+		t.Fatalf("StartTimer() error = %v", err)
+	}
+	if len(stopped) != 0 || !started.Entry.IsRunning() {
+		t.Errorf("StartTimer() = %v, %v", started, stopped)
+	}
+	if login.GetStatus() != task.StatusWIP {
+		t.Errorf("starting a timer should move the task to WIP, got %s", login.GetStatus())
+	}
+	if _, _, err := service.StartTimer(login.ID, clockTime("2025-06-20 09:05")); !errors.IsValidation(err) {
+		t.Errorf("starting a running timer should fail, got %v", err)
+	}
+
+	// Starting another timer stops the running one
+	_, stopped, err = service.StartTimer(docs.ID, clockTime("2025-06-20 10:30"))
+	if err != nil {
+		t.Fatalf("StartTimer() error = %v", err)
+	}
+	if len(stopped) != 1 || stopped[0].Task.ID != login.ID || stopped[0].Entry.Duration(time.Time{}) != 90*time.Minute {
+		t.Errorf("StartTimer() stopped %v", stopped)
+	}
+
+	stopped, err = service.StopTimer("", clockTime("2025-06-20 11:00"))
+	if err != nil || len(stopped) != 1 || stopped[0].Task.ID != docs.ID {
+		t.Fatalf("StopTimer() = %v, %v", stopped, err)
+	}
+	if _, err := service.StopTimer("", clockTime("2025-06-20 11:00")); !errors.IsValidation(err) {
+		t.Errorf("StopTimer() without a running timer should fail, got %v", err)
+	}
+	if _, err := service.StopTimer(login.ID, clockTime("2025-06-20 11:00")); !errors.IsValidation(err) {
+		t.Errorf("StopTimer() on a stopped task should fail, got %v", err)
+	}
+	if running, _ := service.RunningTimers(); len(running) != 0 {
+		t.Errorf("RunningTimers() = %v", running)
+	}
+
+	// Archived tasks and disallowed transitions are rejected
+	docs.Archive()
+	if _, _, err := service.StartTimer(docs.ID, time.Now()); !errors.IsValidation(err) {
+		t.Errorf("starting a timer on an archived task should fail, got %v", err)
+	}
+	strict := NewTaskService(repo, &config.Config{Workflow: config.WorkflowConfig{
+		Transitions: map[string][]string{"WIP": {"DONE"}, "DONE": {"TODO"}},
+	}})
+	login.SetStatus(task.StatusDONE)
+	if _, _, err := strict.StartTimer(login.ID, time.Now()); !errors.IsValidation(err) {
+		t.Errorf("StartTimer() should enforce the workflow, got %v", err)
+	}
+}
+
+func TestTimesheet(t *testing.T) {
+	repo := NewMockTaskRepository()
+	service := NewTaskService(repo, &config.Config{})
+
+	login := &task.Task{ID: "task/1", Title: "Fix login", Tags: []string{"mdtask", "client/acme", "type/bug"}, Content: "## Log\n\n" +
+		"- 2025-06-15 23:00 - 2025-06-16 01:00 (2h)\n" + // half in the week
+		"- 2025-06-17 09:00 - 10:30 (1h30m)\n"}
+	docs := &task.Task{ID: "task/2", Title: "Write docs", Tags: []string{"mdtask", "client/acme"}, Content: "## Log\n\n" +
+		"- 2025-06-18 14:00 - (running)\n"}
+	old := &task.Task{ID: "task/3", Title: "Old", Tags: []string{"mdtask"}, Content: "## Log\n\n" +
+		"- 2025-06-01 09:00 - 17:00 (8h)\n"}
+	for _, tt := range []*task.Task{login, docs, old} {
+		repo.tasks[tt.ID] = tt
+	}
+
+	from, to := WeekRange(clockTime("2025-06-18 12:00"), 0)
+	if from != clockTime("2025-06-16 00:00") || to != clockTime("2025-06-23 00:00") {
+		t.Fatalf("WeekRange() = %v, %v", from, to)
+	}
+
+	sheet, err := service.Timesheet(from, to, clockTime("2025-06-18 17:00"))
+	if err != nil {
+		t.Fatalf("Timesheet() error = %v", err)
+	}
+	if sheet.Total != 5*time.Hour+30*time.Minute {
+		t.Errorf("Total = %v, want 5h30m", sheet.Total)
+	}
+	if len(sheet.Tasks) != 2 || sheet.Tasks[0].Task.ID != docs.ID || sheet.Tasks[0].Duration != 3*time.Hour {
+		t.Errorf("Tasks = %v", sheet.Tasks)
+	}
+	if len(sheet.Tags) != 2 || sheet.Tags[0] != (TagTotal{"client/acme", 5*time.Hour + 30*time.Minute}) || sheet.Tags[1] != (TagTotal{"type/bug", 2*time.Hour + 30*time.Minute}) {
+		t.Errorf("Tags = %v", sheet.Tags)
+	}
+
+	if from, _ := WeekRange(clockTime("2025-06-22 12:00"), -1); from != clockTime("2025-06-09 00:00") {
+		t.Errorf("last week from a Sunday starts %v", from)
+	}
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/errors"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// DeleteTask moves a task to the trash together with its subtasks, which
+// are deleted first. With keepSubtasks the subtasks stay and are detached
+// from the task instead. The deleted tasks are returned in the order they
+// were deleted.
+func (s *TaskService) DeleteTask(taskID string, keepSubtasks bool) ([]*task.Task, error) {
+	t, err := s.repo.FindByID(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted []*task.Task
+	if err := s.deleteSubtasks(taskID, keepSubtasks, &deleted); err != nil {
+		return deleted, err
+	}
+
+	if _, err := s.repo.Delete(taskID); err != nil {
+		return deleted, err
+	}
+	return append(deleted, t), nil
+}
+
+// deleteSubtasks deletes or detaches the subtasks of parentID
+func (s *TaskService) deleteSubtasks(parentID string, keep bool, deleted *[]*task.Task) error {
+	subtasks, err := s.findSubtasks(parentID)
+	if err != nil {
+		return err
+	}
+
+	for _, subtask := range subtasks {
+		if keep {
+			before := subtask.Clone()
+			subtask.RemoveParent()
+			if err := s.update(before, subtask); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := s.deleteSubtasks(subtask.ID, false, deleted); err != nil {
+			return err
+		}
+		if _, err := s.repo.Delete(subtask.ID); err != nil {
+			return err
+		}
+		*deleted = append(*deleted, subtask)
+	}
+	return nil
+}
+
+// RestoreTask moves a task back from the trash, followed by its subtasks
+// that are in the trash. A subtask cannot be restored while its parent is
+// in the trash.
+func (s *TaskService) RestoreTask(taskID string) ([]*task.Task, error) {
+	entries, err := s.repo.Trash()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.ID != taskID || entry.ParentID == "" {
+			continue
+		}
+		if _, err := s.repo.FindByID(entry.ParentID); err != nil {
+			for _, parent := range entries {
+				if parent.ID == entry.ParentID {
+					return nil, errors.ValidationError("task", fmt.Sprintf("parent %s is in the trash; restore it first", entry.ParentID))
+				}
+			}
+		}
+		break
+	}
+
+	t, err := s.repo.Restore(taskID)
+	if err != nil {
+		return nil, err
+	}
+	restored := []*task.Task{t}
+
+	for _, entry := range entries {
+		if entry.ParentID != taskID {
+			continue
+		}
+		if _, err := s.repo.FindByID(entry.ID); err == nil {
+			// Restored already, or a newer task of the same ID
+			continue
+		}
+		subtasks, err := s.RestoreTask(entry.ID)
+		if err != nil {
+			return restored, err
+		}
+		restored = append(restored, subtasks...)
+	}
+	return restored, nil
+}
+
+// PurgeArchived moves the archived tasks not updated since before to the
+// trash. Subtasks that are purged too are deleted before their parent;
+// the others are detached and kept, so a purge never deletes a task that
+// is still in use. With dryRun the tasks are only returned, in the order
+// they would be deleted.
+func (s *TaskService) PurgeArchived(before time.Time, dryRun bool) ([]*task.Task, error) {
+	tasks, err := s.repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	purge, detach := purgePlan(tasks, before)
+	if dryRun {
+		return purge, nil
+	}
+
+	for _, t := range detach {
+		old := t.Clone()
+		t.RemoveParent()
+		if err := s.update(old, t); err != nil {
+			return nil, err
+		}
+	}
+
+	var purged []*task.Task
+	for _, t := range purge {
+		if _, err := s.repo.Delete(t.ID); err != nil {
+			return purged, err
+		}
+		purged = append(purged, t)
+	}
+	return purged, nil
+}
+
+// purgePlan returns the archived tasks not updated since before, every
+// subtask ahead of its parent, and the subtasks of those tasks that do not
+// qualify and are detached instead
+func purgePlan(tasks []*task.Task, before time.Time) (purge, detach []*task.Task) {
+	eligible := make(map[string]bool)
+	children := make(map[string][]*task.Task)
+	for _, t := range tasks {
+		if t.IsArchived() && t.Updated.Before(before) {
+			eligible[t.ID] = true
+		}
+		if parentID := t.GetParentID(); parentID != "" {
+			children[parentID] = append(children[parentID], t)
+		}
+	}
+
+	visited := make(map[string]bool)
+	var visit func(t *task.Task)
+	visit = func(t *task.Task) {
+		if visited[t.ID] {
+			return
+		}
+		visited[t.ID] = true
+		for _, child := range children[t.ID] {
+			if eligible[child.ID] {
+				visit(child)
+			} else {
+				detach = append(detach, child)
+			}
+		}
+		purge = append(purge, t)
+	}
+
+	for _, t := range tasks {
+		if eligible[t.ID] && !eligible[t.GetParentID()] {
+			visit(t)
+		}
+	}
+	// Tasks in a cycle of parent links have no top-level ancestor
+	for _, t := range tasks {
+		if eligible[t.ID] {
+			visit(t)
+		}
+	}
+	return purge, detach
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"reflect"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// newFamily stores a parent with a child and a grandchild
+func newFamily(repo *MockTaskRepository) {
+	parent := &task.Task{ID: "task/parent", Title: "Parent", Tags: []string{"mdtask"}}
+	child := &task.Task{ID: "task/child", Title: "Child", Tags: []string{"mdtask"}}
+	child.SetParentID(parent.ID)
+	grandchild := &task.Task{ID: "task/grandchild", Title: "Grandchild", Tags: []string{"mdtask"}}
+	grandchild.SetParentID(child.ID)
+	for _, t := range []*task.Task{parent, child, grandchild} {
+		repo.tasks[t.ID] = t
+	}
+}
+
+func ids(tasks []*task.Task) []string {
+	var result []string
+	for _, t := range tasks {
+		result = append(result, t.ID)
+	}
+	return result
+}
+
+func TestTaskService_DeleteTask(t *testing.T) {
+	repo := NewMockTaskRepository()
+	newFamily(repo)
+	s := NewTaskService(repo, config.DefaultConfig())
+
+	deleted, err := s.DeleteTask("task/parent", false)
+	if err != nil {
+		t.Fatalf("DeleteTask() error = %v", err)
+	}
+	if got := ids(deleted); len(got) != 3 || got[0] != "task/grandchild" || got[2] != "task/parent" {
+		t.Errorf("DeleteTask() deleted %v, want subtasks first", got)
+	}
+	if len(repo.tasks) != 0 {
+		t.Errorf("tasks left after delete: %v", repo.tasks)
+	}
+
+	if _, err := s.RestoreTask("task/child"); err == nil {
+		t.Error("RestoreTask() of a subtask with a trashed parent should fail")
+	}
+
+	restored, err := s.RestoreTask("task/parent")
+	if err != nil {
+		t.Fatalf("RestoreTask() error = %v", err)
+	}
+	if got := ids(restored); len(got) != 3 || got[0] != "task/parent" {
+		t.Errorf("RestoreTask() restored %v, want the parent and its subtasks", got)
+	}
+	if len(repo.trash) != 0 {
+		t.Errorf("trash after restore = %v", repo.trash)
+	}
+}
+
+func TestTaskService_DeleteTaskKeepSubtasks(t *testing.T) {
+	repo := NewMockTaskRepository()
+	newFamily(repo)
+	s := NewTaskService(repo, config.DefaultConfig())
+
+	deleted, err := s.DeleteTask("task/parent", true)
+	if err != nil {
+		t.Fatalf("DeleteTask() error = %v", err)
+	}
+	if got := ids(deleted); len(got) != 1 || got[0] != "task/parent" {
+		t.Errorf("DeleteTask() deleted %v, want only the parent", got)
+	}
+	if child := repo.tasks["task/child"]; child == nil || child.HasParent() {
+		t.Errorf("child = %+v, want it kept without parent", child)
+	}
+	if grandchild := repo.tasks["task/grandchild"]; grandchild.GetParentID() != "task/child" {
+		t.Errorf("grandchild parent = %q, want it unchanged", grandchild.GetParentID())
+	}
+}
+
+func TestTaskService_PurgeArchived(t *testing.T) {
+	repo := NewMockTaskRepository()
+	now := time.Date(2025, 6, 20, 9, 0, 0, 0, time.UTC)
+	add := func(id string, archived bool, updated time.Time) {
+		tk := &task.Task{ID: id, Title: id, Tags: []string{"mdtask"}, Updated: updated}
+		if archived {
+			tk.Archive()
+		}
+		repo.tasks[id] = tk
+	}
+	add("task/old-archived", true, now.AddDate(0, 0, -100))
+	add("task/new-archived", true, now.AddDate(0, 0, -10))
+	add("task/old-active", false, now.AddDate(0, 0, -100))
+	s := NewTaskService(repo, config.DefaultConfig())
+
+	before := now.AddDate(0, 0, -90)
+	preview, err := s.PurgeArchived(before, true)
+	if err != nil || len(preview) != 1 || len(repo.tasks) != 3 {
+		t.Fatalf("PurgeArchived(dry run) = %v, %v; %d tasks left", ids(preview), err, len(repo.tasks))
+	}
+
+	purged, err := s.PurgeArchived(before, false)
+	if err != nil {
+		t.Fatalf("PurgeArchived() error = %v", err)
+	}
+	if got := ids(purged); len(got) != 1 || got[0] != "task/old-archived" {
+		t.Errorf("PurgeArchived() = %v", got)
+	}
+	if _, ok := repo.trashed["task/old-archived"]; !ok {
+		t.Error("purged task is not in the trash")
+	}
+}
+
+func TestTaskService_PurgeArchivedSubtasks(t *testing.T) {
+	repo := NewMockTaskRepository()
+	now := time.Date(2025, 6, 20, 9, 0, 0, 0, time.UTC)
+	old := now.AddDate(0, 0, -100)
+	add := func(id, parent string, archived bool, updated time.Time) {
+		tk := &task.Task{ID: id, Title: id, Tags: []string{"mdtask"}, Updated: updated}
+		if parent != "" {
+			tk.SetParentID(parent)
+		}
+		if archived {
+			tk.Archive()
+		}
+		repo.tasks[id] = tk
+	}
+	add("task/parent", "", true, old)
+	add("task/old-child", "task/parent", true, old)
+	add("task/active-child", "task/parent", false, old)
+	add("task/recent-child", "task/parent", true, now.AddDate(0, 0, -1))
+	add("task/grandchild", "task/active-child", true, old)
+	s := NewTaskService(repo, config.DefaultConfig())
+
+	before := now.AddDate(0, 0, -90)
+	preview, err := s.PurgeArchived(before, true)
+	if err != nil {
+		t.Fatalf("PurgeArchived(dry run) error = %v", err)
+	}
+	purged, err := s.PurgeArchived(before, false)
+	if err != nil {
+		t.Fatalf("PurgeArchived() error = %v", err)
+	}
+
+	// The preview lists what is deleted, subtasks ahead of their parent
+	want := []string{"task/grandchild", "task/old-child", "task/parent"}
+	for name, list := range map[string][]*task.Task{"dry run": preview, "run": purged} {
+		got := ids(list)
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("PurgeArchived(%s) = %v, want %v", name, got, want)
+		}
+	}
+	if order := ids(purged); slices.Index(order, "task/old-child") > slices.Index(order, "task/parent") {
+		t.Errorf("PurgeArchived() deleted the parent before its subtask: %v", order)
+	}
+	for _, id := range []string{"task/active-child", "task/recent-child"} {
+		kept := repo.tasks[id]
+		if kept == nil || kept.HasParent() {
+			t.Errorf("%s = %+v, want it kept without parent", id, kept)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package task
+
+import (
+	"strings"
+
+	"github.com/tkancf/mdtask/internal/constants"
+)
+
+// Dependency methods. A task may be blocked by several others, each stored
+// as its own mdtask/blockedby/<id> tag.
+
+// GetBlockedBy returns the IDs of the tasks this task is blocked by
+func (t *Task) GetBlockedBy() []string {
+	var ids []string
+	for _, tag := range t.Tags {
+		if id := strings.TrimPrefix(tag, constants.BlockedByTagPrefix); id != tag && id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// IsBlockedBy returns true if the task depends on the given task ID
+func (t *Task) IsBlockedBy(id string) bool {
+	return t.hasTag(constants.BlockedByTagPrefix + id)
+}
+
+// AddBlocker marks the task as blocked by the given task ID
+func (t *Task) AddBlocker(id string) {
+	if id != "" && !t.IsBlockedBy(id) {
+		t.Tags = append(t.Tags, constants.BlockedByTagPrefix+id)
+	}
+}
+
+// RemoveBlocker removes the dependency on the given task ID
+func (t *Task) RemoveBlocker(id string) {
+	var tags []string
+	for _, tag := range t.Tags {
+		if tag != constants.BlockedByTagPrefix+id {
+			tags = append(tags, tag)
+		}
+	}
+	t.Tags = tags
+}
+
+// HasBlockers returns true if the task depends on any other task
+func (t *Task) HasBlockers() bool {
+	return len(t.GetBlockedBy()) > 0
+}
@@ -0,0 +1,32 @@
+package task
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBlockedBy(t *testing.T) {
+	task := &Task{Tags: []string{"mdtask", "mdtask/blockedby/task/1", "project"}}
+
+	if got := task.GetBlockedBy(); len(got) != 1 || got[0] != "task/1" {
+		t.Fatalf("GetBlockedBy() = %v", got)
+	}
+
+	task.AddBlocker("task/2")
+	task.AddBlocker("task/1") // already present
+	if got := strings.Join(task.GetBlockedBy(), ","); got != "task/1,task/2" {
+		t.Errorf("after AddBlocker got %s", got)
+	}
+	if !task.IsBlockedBy("task/2") || task.IsBlockedBy("task/3") {
+		t.Error("IsBlockedBy() returned the wrong result")
+	}
+
+	task.RemoveBlocker("task/1")
+	task.RemoveBlocker("task/2")
+	if task.HasBlockers() {
+		t.Errorf("expected no blockers, got %v", task.GetBlockedBy())
+	}
+	if strings.Join(task.Tags, ",") != "mdtask,project" {
+		t.Errorf("other tags should be kept, got %v", task.Tags)
+	}
+}
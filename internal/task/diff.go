@@ -0,0 +1,93 @@
+package task
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/constants"
+)
+
+// Change is the difference in one field between two versions of a task
+type Change struct {
+	// Field is title, description, status, tags, deadline, reminder,
+	// parent, blocked_by, recur, archived, content, or fields.<name> for a
+	// custom field
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// Diff lists the fields that differ from old to new, in a fixed order. A
+// nil old compares against an empty task. The updated time and the status
+// history are left out, as they follow from the other changes.
+func Diff(old, new *Task) []Change {
+	if old == nil {
+		old = &Task{}
+	}
+
+	var changes []Change
+	add := func(field, a, b string) {
+		if a != b {
+			changes = append(changes, Change{Field: field, Old: a, New: b})
+		}
+	}
+
+	add("title", old.Title, new.Title)
+	add("description", old.Description, new.Description)
+	add("status", string(old.GetStatus()), string(new.GetStatus()))
+	add("tags", strings.Join(old.UserTags(), ", "), strings.Join(new.UserTags(), ", "))
+	add("deadline", formatTime(old.GetDeadline(), constants.DateFormat), formatTime(new.GetDeadline(), constants.DateFormat))
+	add("reminder", formatTime(old.GetReminder(), constants.DateTimeFormat), formatTime(new.GetReminder(), constants.DateTimeFormat))
+	add("parent", old.GetParentID(), new.GetParentID())
+	add("blocked_by", strings.Join(old.GetBlockedBy(), ", "), strings.Join(new.GetBlockedBy(), ", "))
+	add("recur", recurrenceString(old), recurrenceString(new))
+	add("archived", archivedString(old), archivedString(new))
+
+	names := old.FieldNames()
+	for _, name := range new.FieldNames() {
+		if _, ok := old.Fields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	for _, name := range sortedUnique(names) {
+		add("fields."+name, FieldString(old.Fields[name]), FieldString(new.Fields[name]))
+	}
+
+	add("content", old.Content, new.Content)
+	return changes
+}
+
+func formatTime(t *time.Time, layout string) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(layout)
+}
+
+func recurrenceString(t *Task) string {
+	if r, err := t.GetRecurrence(); err == nil && r != nil {
+		return r.String()
+	}
+	return ""
+}
+
+func archivedString(t *Task) string {
+	if t.IsArchived() {
+		return "true"
+	}
+	return ""
+}
+
+func sortedUnique(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	var result []string
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
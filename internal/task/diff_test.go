@@ -0,0 +1,49 @@
+package task
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	deadline := time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+
+	old := &Task{
+		ID:      "task/20250620090000",
+		Title:   "Write report",
+		Tags:    []string{"mdtask", "mdtask/status/TODO", "work"},
+		Fields:  map[string]interface{}{"points": 3},
+		Content: "draft",
+	}
+	new := old.Clone()
+	new.Title = "Write final report"
+	new.SetStatus(StatusDONE)
+	new.Tags = append(new.Tags, "urgent")
+	new.SetDeadline(deadline)
+	new.Fields = map[string]interface{}{"owner": "alice"}
+	new.Content = "done"
+	new.Updated = time.Now()
+
+	want := []Change{
+		{Field: "title", Old: "Write report", New: "Write final report"},
+		{Field: "status", Old: "TODO", New: "DONE"},
+		{Field: "tags", Old: "work", New: "work, urgent"},
+		{Field: "deadline", Old: "", New: "2025-06-30"},
+		{Field: "fields.owner", Old: "", New: "alice"},
+		{Field: "fields.points", Old: "3", New: ""},
+		{Field: "content", Old: "draft", New: "done"},
+	}
+	if got := Diff(old, new); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() =\n%#v\nwant\n%#v", got, want)
+	}
+
+	if got := Diff(old, old.Clone()); got != nil {
+		t.Errorf("Diff() of equal tasks = %v, want none", got)
+	}
+
+	created := Diff(nil, old)
+	if len(created) == 0 || created[0].Field != "title" || created[0].Old != "" {
+		t.Errorf("Diff(nil, task) = %v", created)
+	}
+}
@@ -0,0 +1,166 @@
+package task
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// reservedFields are the front matter keys mdtask manages itself. They can
+// not be used as custom field names.
+var reservedFields = map[string]bool{
+	"id":          true,
+	"aliases":     true,
+	"tags":        true,
+	"created":     true,
+	"description": true,
+	"title":       true,
+	"updated":     true,
+
+	"status_history": true,
+}
+
+// IsReservedField reports whether name is a front matter key managed by mdtask
+func IsReservedField(name string) bool {
+	return reservedFields[name]
+}
+
+// ValidateFieldName checks that name can be used as a custom field
+func ValidateFieldName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("field name cannot be empty")
+	}
+	if strings.ContainsAny(name, "\n\r:") {
+		return fmt.Errorf("field name %q contains invalid characters", name)
+	}
+	if IsReservedField(name) {
+		return fmt.Errorf("field %q is managed by mdtask", name)
+	}
+	return nil
+}
+
+// GetField returns the value of a custom field
+func (t *Task) GetField(name string) (interface{}, bool) {
+	value, ok := t.Fields[name]
+	return value, ok
+}
+
+// SetField sets a custom field
+func (t *Task) SetField(name string, value interface{}) {
+	if t.Fields == nil {
+		t.Fields = make(map[string]interface{})
+	}
+	t.Fields[name] = value
+}
+
+// DeleteField removes a custom field. The map is kept even when it becomes
+// empty so that the removal is written back to the file.
+func (t *Task) DeleteField(name string) {
+	if t.Fields == nil {
+		t.Fields = make(map[string]interface{})
+	}
+	delete(t.Fields, name)
+}
+
+// FieldNames returns the names of the custom fields in sorted order
+func (t *Task) FieldNames() []string {
+	names := make([]string, 0, len(t.Fields))
+	for name := range t.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FieldString formats a custom field value for display and matching
+func FieldString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		// Numbers read back from JSON are always float64
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = FieldString(item)
+		}
+		return strings.Join(parts, ", ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// MatchField reports whether the task matches a field filter of the form
+// "name=value" or "name!=value", or has the field at all for a bare "name".
+// Values are compared case-insensitively; list values match any element.
+func (t *Task) MatchField(filter string) bool {
+	name, value, negate := filter, "", false
+	hasValue := false
+	if i := strings.Index(filter, "!="); i >= 0 {
+		name, value, negate, hasValue = filter[:i], filter[i+2:], true, true
+	} else if i := strings.Index(filter, "="); i >= 0 {
+		name, value, hasValue = filter[:i], filter[i+1:], true
+	}
+	name = strings.TrimSpace(name)
+	value = strings.TrimSpace(value)
+
+	actual, ok := t.Fields[name]
+	if !hasValue {
+		return ok
+	}
+
+	matched := false
+	if ok {
+		if items, isList := actual.([]interface{}); isList {
+			for _, item := range items {
+				if strings.EqualFold(FieldString(item), value) {
+					matched = true
+					break
+				}
+			}
+		} else {
+			matched = strings.EqualFold(FieldString(actual), value)
+		}
+	}
+	return matched != negate
+}
+
+// MatchFields reports whether the task matches all field filters
+func (t *Task) MatchFields(filters []string) bool {
+	for _, filter := range filters {
+		if !t.MatchField(filter) {
+			return false
+		}
+	}
+	return true
+}
+
+func cloneFields(fields map[string]interface{}) map[string]interface{} {
+	if fields == nil {
+		return nil
+	}
+	result := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		result[k] = cloneValue(v)
+	}
+	return result
+}
+
+func cloneValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = cloneValue(item)
+		}
+		return result
+	case map[string]interface{}:
+		return cloneFields(v)
+	default:
+		return v
+	}
+}
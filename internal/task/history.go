@@ -0,0 +1,49 @@
+package task
+
+import "time"
+
+// StatusChange records a status transition of a task
+type StatusChange struct {
+	From Status
+	To   Status
+	At   time.Time
+}
+
+// ChangeStatus moves the task to status and appends the transition to its
+// history. It reports whether the status changed; setting the current status
+// again records nothing.
+func (t *Task) ChangeStatus(status Status, at time.Time) bool {
+	from := t.GetStatus()
+	if from == status {
+		return false
+	}
+	t.SetStatus(status)
+	t.StatusHistory = append(t.StatusHistory, StatusChange{From: from, To: status, At: at})
+	return true
+}
+
+// CompletedAt returns when the task last moved into a done status, or nil
+// if it is not done or its history does not record the change
+func (t *Task) CompletedAt(isDone func(Status) bool) *time.Time {
+	if !isDone(t.GetStatus()) {
+		return nil
+	}
+	for i := len(t.StatusHistory) - 1; i >= 0; i-- {
+		change := t.StatusHistory[i]
+		if isDone(change.To) && !isDone(change.From) {
+			return &change.At
+		}
+	}
+	return nil
+}
+
+// StartedAt returns when the task first moved into a status for which
+// isStarted is true, or nil if it never did
+func (t *Task) StartedAt(isStarted func(Status) bool) *time.Time {
+	for _, change := range t.StatusHistory {
+		if isStarted(change.To) {
+			return &change.At
+		}
+	}
+	return nil
+}
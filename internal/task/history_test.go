@@ -0,0 +1,58 @@
+package task
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChangeStatus(t *testing.T) {
+	tk := &Task{Tags: []string{"mdtask"}}
+	tk.SetStatus(StatusTODO)
+
+	at := time.Date(2025, 6, 20, 9, 0, 0, 0, time.UTC)
+	if !tk.ChangeStatus(StatusWIP, at) {
+		t.Fatal("ChangeStatus() should report a change")
+	}
+	if tk.ChangeStatus(StatusWIP, at.Add(time.Hour)) {
+		t.Error("setting the current status again should not be recorded")
+	}
+	if tk.GetStatus() != StatusWIP {
+		t.Errorf("status = %s, want WIP", tk.GetStatus())
+	}
+
+	want := []StatusChange{{From: StatusTODO, To: StatusWIP, At: at}}
+	if len(tk.StatusHistory) != 1 || tk.StatusHistory[0] != want[0] {
+		t.Errorf("StatusHistory = %v, want %v", tk.StatusHistory, want)
+	}
+}
+
+func TestCompletedAndStartedAt(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 6, d, 9, 0, 0, 0, time.UTC) }
+	isDone := func(s Status) bool { return s == StatusDONE }
+	isWIP := func(s Status) bool { return s == StatusWIP }
+
+	tk := &Task{Tags: []string{"mdtask"}}
+	tk.SetStatus(StatusTODO)
+	tk.ChangeStatus(StatusWIP, day(2))
+	tk.ChangeStatus(StatusDONE, day(3))
+	tk.ChangeStatus(StatusWIP, day(4))
+
+	if got := tk.CompletedAt(isDone); got != nil {
+		t.Errorf("a reopened task should not be completed, got %v", got)
+	}
+
+	tk.ChangeStatus(StatusDONE, day(5))
+	if got := tk.CompletedAt(isDone); got == nil || !got.Equal(day(5)) {
+		t.Errorf("CompletedAt() = %v, want the last completion %v", got, day(5))
+	}
+	if got := tk.StartedAt(isWIP); got == nil || !got.Equal(day(2)) {
+		t.Errorf("StartedAt() = %v, want the first start %v", got, day(2))
+	}
+
+	// Done without a recorded transition
+	legacy := &Task{Tags: []string{"mdtask"}}
+	legacy.SetStatus(StatusDONE)
+	if got := legacy.CompletedAt(isDone); got != nil {
+		t.Errorf("CompletedAt() without history = %v, want nil", got)
+	}
+}
@@ -0,0 +1,228 @@
+package task
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/constants"
+)
+
+// Recurrence kinds
+const (
+	RecurDaily   = "daily"
+	RecurWeekly  = "weekly"
+	RecurMonthly = "monthly"
+	RecurEvery   = "every"
+)
+
+// Recurrence is a parsed mdtask/recur/<rule> tag. Supported rules:
+//
+//	daily
+//	weekly:mon        weekly:mon,thu
+//	monthly:1         monthly:last
+//	every:3d          every:2w, every:6m, every:1y
+type Recurrence struct {
+	Kind string
+
+	// Weekdays for weekly rules
+	Weekdays []time.Weekday
+
+	// Day of the month for monthly rules; -1 means the last day
+	Day int
+
+	// Interval and its unit (d, w, m or y) for every rules
+	Interval int
+	Unit     string
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseRecurrence parses a recurrence rule such as weekly:mon
+func ParseRecurrence(rule string) (*Recurrence, error) {
+	rule = strings.ToLower(strings.TrimSpace(rule))
+	kind, arg, _ := strings.Cut(rule, ":")
+
+	switch kind {
+	case RecurDaily:
+		if arg != "" {
+			return nil, fmt.Errorf("invalid recurrence %q: daily takes no argument", rule)
+		}
+		return &Recurrence{Kind: RecurDaily}, nil
+
+	case RecurWeekly:
+		if arg == "" {
+			return nil, fmt.Errorf("invalid recurrence %q: use weekly:<day>[,<day>...], e.g. weekly:mon", rule)
+		}
+		r := &Recurrence{Kind: RecurWeekly}
+		seen := make(map[time.Weekday]bool)
+		for _, name := range strings.Split(arg, ",") {
+			day, ok := weekdayNames[strings.TrimSpace(name)]
+			if !ok {
+				return nil, fmt.Errorf("invalid recurrence %q: unknown weekday %q", rule, name)
+			}
+			if !seen[day] {
+				seen[day] = true
+				r.Weekdays = append(r.Weekdays, day)
+			}
+		}
+		return r, nil
+
+	case RecurMonthly:
+		if arg == "last" {
+			return &Recurrence{Kind: RecurMonthly, Day: -1}, nil
+		}
+		day, err := strconv.Atoi(arg)
+		if err != nil || day < 1 || day > 31 {
+			return nil, fmt.Errorf("invalid recurrence %q: use monthly:<1-31> or monthly:last", rule)
+		}
+		return &Recurrence{Kind: RecurMonthly, Day: day}, nil
+
+	case RecurEvery:
+		if len(arg) < 2 {
+			return nil, fmt.Errorf("invalid recurrence %q: use every:<n><d|w|m|y>, e.g. every:3d", rule)
+		}
+		n, err := strconv.Atoi(arg[:len(arg)-1])
+		unit := arg[len(arg)-1:]
+		if err != nil || n < 1 || !strings.Contains("dwmy", unit) {
+			return nil, fmt.Errorf("invalid recurrence %q: use every:<n><d|w|m|y>, e.g. every:3d", rule)
+		}
+		return &Recurrence{Kind: RecurEvery, Interval: n, Unit: unit}, nil
+	}
+
+	return nil, fmt.Errorf("invalid recurrence %q: use daily, weekly:<day>, monthly:<day> or every:<n><d|w|m|y>", rule)
+}
+
+// String returns the rule in the form used in the tag
+func (r *Recurrence) String() string {
+	switch r.Kind {
+	case RecurWeekly:
+		names := make([]string, len(r.Weekdays))
+		for i, day := range r.Weekdays {
+			names[i] = strings.ToLower(day.String()[:3])
+		}
+		return RecurWeekly + ":" + strings.Join(names, ",")
+	case RecurMonthly:
+		if r.Day == -1 {
+			return RecurMonthly + ":last"
+		}
+		return fmt.Sprintf("%s:%d", RecurMonthly, r.Day)
+	case RecurEvery:
+		return fmt.Sprintf("%s:%d%s", RecurEvery, r.Interval, r.Unit)
+	}
+	return r.Kind
+}
+
+// Next returns the first occurrence after from. The time of day of from is
+// kept so that reminders move along with their date.
+func (r *Recurrence) Next(from time.Time) time.Time {
+	switch r.Kind {
+	case RecurWeekly:
+		for i := 1; i <= 7; i++ {
+			next := from.AddDate(0, 0, i)
+			for _, day := range r.Weekdays {
+				if next.Weekday() == day {
+					return next
+				}
+			}
+		}
+	case RecurMonthly:
+		// Try this month first, then the following ones
+		for i := 0; i <= 12; i++ {
+			next := r.dayInMonth(from, i)
+			if next.After(from) {
+				return next
+			}
+		}
+	case RecurEvery:
+		switch r.Unit {
+		case "w":
+			return from.AddDate(0, 0, 7*r.Interval)
+		case "m":
+			return addMonths(from, r.Interval)
+		case "y":
+			return addMonths(from, 12*r.Interval)
+		}
+		return from.AddDate(0, 0, r.Interval)
+	}
+	return from.AddDate(0, 0, 1)
+}
+
+// Occurrences returns the next n occurrences after from
+func (r *Recurrence) Occurrences(from time.Time, n int) []time.Time {
+	result := make([]time.Time, 0, n)
+	for i := 0; i < n; i++ {
+		from = r.Next(from)
+		result = append(result, from)
+	}
+	return result
+}
+
+// dayInMonth returns the rule's day in the month offset months after t,
+// clamped to the length of that month
+func (r *Recurrence) dayInMonth(t time.Time, offset int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(offset), 1, t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+	last := daysIn(first)
+	day := r.Day
+	if day == -1 || day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// addMonths adds months to t without overflowing into the next month,
+// e.g. Jan 31 + 1 month is the last day of February
+func addMonths(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+	day := t.Day()
+	if last := daysIn(first); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+func daysIn(firstOfMonth time.Time) int {
+	return firstOfMonth.AddDate(0, 1, -1).Day()
+}
+
+// GetRecurrence returns the parsed recurrence rule, or nil if the task does
+// not repeat
+func (t *Task) GetRecurrence() (*Recurrence, error) {
+	value, ok := t.getTagWithPrefix(constants.RecurTagPrefix)
+	if !ok {
+		return nil, nil
+	}
+	return ParseRecurrence(value)
+}
+
+// SetRecurrence makes the task repeat according to r
+func (t *Task) SetRecurrence(r *Recurrence) {
+	t.setTagWithPrefix(constants.RecurTagPrefix, r.String())
+}
+
+// RemoveRecurrence stops the task from repeating
+func (t *Task) RemoveRecurrence() {
+	t.setTagWithPrefix(constants.RecurTagPrefix, "")
+}
+
+// GetPreviousID returns the ID of the occurrence this task was created from
+func (t *Task) GetPreviousID() string {
+	if value, ok := t.getTagWithPrefix(constants.PreviousTagPrefix); ok {
+		return value
+	}
+	return ""
+}
+
+// SetPreviousID links the task to the occurrence it was created from
+func (t *Task) SetPreviousID(id string) {
+	t.setTagWithPrefix(constants.PreviousTagPrefix, id)
+}
@@ -0,0 +1,109 @@
+package task
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		rule    string
+		want    string
+		wantErr bool
+	}{
+		{rule: "daily", want: "daily"},
+		{rule: "weekly:mon", want: "weekly:mon"},
+		{rule: "Weekly:Mon,thu,mon", want: "weekly:mon,thu"},
+		{rule: "monthly:1", want: "monthly:1"},
+		{rule: "monthly:last", want: "monthly:last"},
+		{rule: "every:3d", want: "every:3d"},
+		{rule: "every:2w", want: "every:2w"},
+		{rule: "every:1y", want: "every:1y"},
+		{rule: "weekly", wantErr: true},
+		{rule: "weekly:someday", wantErr: true},
+		{rule: "monthly:32", wantErr: true},
+		{rule: "every:0d", wantErr: true},
+		{rule: "every:3x", wantErr: true},
+		{rule: "hourly", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			r, err := ParseRecurrence(tt.rule)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseRecurrence(%q) expected error", tt.rule)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRecurrence(%q) error = %v", tt.rule, err)
+			}
+			if got := r.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	date := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+
+	tests := []struct {
+		rule string
+		from string
+		want string
+	}{
+		{"daily", "2024-02-28", "2024-02-29"},
+		{"weekly:mon", "2024-01-01", "2024-01-08"}, // a Monday
+		{"weekly:mon", "2024-01-03", "2024-01-08"},
+		{"weekly:mon,thu", "2024-01-01", "2024-01-04"},
+		{"monthly:15", "2024-01-10", "2024-01-15"},
+		{"monthly:15", "2024-01-15", "2024-02-15"},
+		{"monthly:31", "2024-01-31", "2024-02-29"},
+		{"monthly:last", "2024-02-29", "2024-03-31"},
+		{"every:3d", "2024-01-30", "2024-02-02"},
+		{"every:2w", "2024-01-01", "2024-01-15"},
+		{"every:1m", "2024-01-31", "2024-02-29"},
+		{"every:1y", "2024-02-29", "2025-02-28"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule+" from "+tt.from, func(t *testing.T) {
+			r, err := ParseRecurrence(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := r.Next(date(tt.from)).Format("2006-01-02"); got != tt.want {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecurrenceTags(t *testing.T) {
+	task := &Task{Tags: []string{"mdtask", "mdtask/recur/weekly:fri"}}
+
+	r, err := task.GetRecurrence()
+	if err != nil || r == nil || r.String() != "weekly:fri" {
+		t.Fatalf("GetRecurrence() = %v, %v", r, err)
+	}
+
+	every, _ := ParseRecurrence("every:3d")
+	task.SetRecurrence(every)
+	task.SetPreviousID("task/20240101000000")
+	if r, _ := task.GetRecurrence(); r.String() != "every:3d" {
+		t.Errorf("after SetRecurrence got %s", r)
+	}
+	if got := task.GetPreviousID(); got != "task/20240101000000" {
+		t.Errorf("GetPreviousID() = %q", got)
+	}
+
+	task.RemoveRecurrence()
+	if r, err := task.GetRecurrence(); r != nil || err != nil {
+		t.Errorf("expected no recurrence after RemoveRecurrence, got %v, %v", r, err)
+	}
+}
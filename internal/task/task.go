@@ -26,6 +26,20 @@ type Task struct {
 	Created     time.Time
 	Updated     time.Time
 	Content     string
+
+	// Fields holds custom front matter keys that mdtask does not manage,
+	// e.g. estimate or owner. A nil map leaves unknown keys in the file
+	// untouched; a non-nil map is written back as-is, so removed entries
+	// are deleted from the file.
+	Fields map[string]interface{}
+
+	// StatusHistory lists the status changes of the task, oldest first
+	StatusHistory []StatusChange
+
+	// Version identifies the file content the task was read from. Updates
+	// carrying a version are rejected if the file has changed since.
+	// It is empty for tasks that have not been loaded or saved yet.
+	Version string
 }
 
 // Helper function to filter tags by prefix
@@ -132,6 +146,18 @@ func (t *Task) IsManagedTask() bool {
 	return t.hasTag(constants.TagPrefix)
 }
 
+// UserTags returns the tags that are not mdtask's own, i.e. neither
+// "mdtask" nor under "mdtask/"
+func (t *Task) UserTags() []string {
+	var tags []string
+	for _, tag := range t.Tags {
+		if tag != constants.TagPrefix && !strings.HasPrefix(tag, constants.TagPrefix+"/") {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
 func (t *Task) GetReminder() *time.Time {
 	if value, ok := t.getTagWithPrefix(constants.ReminderTagPrefix); ok {
 		// Try parsing with time first
@@ -182,4 +208,24 @@ func (t *Task) HasParent() bool {
 // IsParentOf returns true if this task is the parent of the given task ID
 func (t *Task) IsParentOf(childID string) bool {
 	return t.ID == childID
-}
\ No newline at end of file
+}
+// Clone returns a deep copy of the task so callers can modify it freely
+func (t *Task) Clone() *Task {
+	c := *t
+	c.Aliases = cloneStrings(t.Aliases)
+	c.Tags = cloneStrings(t.Tags)
+	c.Fields = cloneFields(t.Fields)
+	if t.StatusHistory != nil {
+		c.StatusHistory = append([]StatusChange(nil), t.StatusHistory...)
+	}
+	return &c
+}
+
+func cloneStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	result := make([]string, len(s))
+	copy(result, s)
+	return result
+}
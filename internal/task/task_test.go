@@ -328,6 +328,27 @@ func TestSetWaitReason(t *testing.T) {
 	}
 }
 
+func TestUserTags(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+		want []string
+	}{
+		{"no tags", nil, nil},
+		{"only system tags", []string{"mdtask", "mdtask/status/TODO", "mdtask/parent/task/1"}, nil},
+		{"mixed", []string{"mdtask", "work", "mdtask/status/WIP", "mdtasks", "home/mdtask"}, []string{"work", "mdtasks", "home/mdtask"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &Task{Tags: tt.tags}
+			if got := task.UserTags(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UserTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestIsManagedTask(t *testing.T) {
 	tests := []struct {
 		name string
@@ -470,4 +491,59 @@ func TestRemoveReminder(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestMatchField(t *testing.T) {
+	task := &Task{Fields: map[string]interface{}{
+		"owner":    "Alice",
+		"estimate": float64(3),
+		"labels":   []interface{}{"frontend", "bug"},
+	}}
+
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{"owner=alice", true},
+		{"owner=bob", false},
+		{"owner!=bob", true},
+		{"owner", true},
+		{"missing", false},
+		{"missing!=x", true},
+		{"estimate=3", true},
+		{"labels=bug", true},
+		{"labels=docs", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			if got := task.MatchField(tt.filter); got != tt.want {
+				t.Errorf("MatchField(%q) = %v, want %v", tt.filter, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldsCloneAndDelete(t *testing.T) {
+	original := &Task{Fields: map[string]interface{}{
+		"project": map[string]interface{}{"name": "Website"},
+	}}
+
+	clone := original.Clone()
+	clone.Fields["project"].(map[string]interface{})["name"] = "Changed"
+	clone.SetField("owner", "bob")
+	if original.Fields["project"].(map[string]interface{})["name"] != "Website" {
+		t.Error("Clone() should deep copy nested field values")
+	}
+	if _, ok := original.GetField("owner"); ok {
+		t.Error("Clone() should copy the fields map")
+	}
+
+	empty := &Task{}
+	empty.DeleteField("owner")
+	if empty.Fields == nil {
+		t.Error("DeleteField() should leave a non-nil map so the removal is written")
+	}
+	if !reflect.DeepEqual(clone.FieldNames(), []string{"owner", "project"}) {
+		t.Errorf("FieldNames() = %v", clone.FieldNames())
+	}
+}
@@ -0,0 +1,272 @@
+package task
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TimeLogHeading starts the section of the task content that holds the
+// clock entries, one list item per period of work:
+//
+//	## Log
+//
+//	- 2025-06-20 09:00 - 10:30 (1h30m)
+//	- 2025-06-20 23:00 - 2025-06-21 01:15 (2h15m)
+//	- 2025-06-21 14:00 - (running)
+//
+// Other lines in the section, such as notes, are left alone.
+const TimeLogHeading = "## Log"
+
+const (
+	clockDateTimeFormat = "2006-01-02 15:04"
+	clockTimeFormat     = "15:04"
+)
+
+// clockLine matches a clock entry: start, optional end and optional note
+var clockLine = regexp.MustCompile(`^\s*[-*+] (\d{4}-\d{2}-\d{2} \d{2}:\d{2})\s*-\s*(\d{4}-\d{2}-\d{2} \d{2}:\d{2}|\d{2}:\d{2})?\s*(\(.*\))?\s*$`)
+
+// ClockEntry is a period of work on a task. End is nil while the clock runs.
+type ClockEntry struct {
+	Start time.Time
+	End   *time.Time
+}
+
+// IsRunning reports whether the entry has not been stopped yet
+func (e ClockEntry) IsRunning() bool {
+	return e.End == nil
+}
+
+// Duration returns the length of the entry; running entries count up to now
+func (e ClockEntry) Duration(now time.Time) time.Duration {
+	end := now
+	if e.End != nil {
+		end = *e.End
+	}
+	if end.Before(e.Start) {
+		return 0
+	}
+	return end.Sub(e.Start)
+}
+
+// String formats the entry as a line of the log section
+func (e ClockEntry) String() string {
+	start := e.Start.Format(clockDateTimeFormat)
+	if e.End == nil {
+		return fmt.Sprintf("- %s - (running)", start)
+	}
+	end := e.End.Format(clockTimeFormat)
+	if !sameDay(e.Start, *e.End) {
+		end = e.End.Format(clockDateTimeFormat)
+	}
+	return fmt.Sprintf("- %s - %s (%s)", start, end, FormatDuration(e.Duration(*e.End)))
+}
+
+// FormatDuration formats a duration in hours and minutes, e.g. 1h30m or 45m
+func FormatDuration(d time.Duration) string {
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	if minutes%60 == 0 {
+		return fmt.Sprintf("%dh", minutes/60)
+	}
+	return fmt.Sprintf("%dh%02dm", minutes/60, minutes%60)
+}
+
+// FormatSpan formats a duration of a day or more in days and hours, e.g.
+// 3d or 2d4h, and shorter ones like FormatDuration
+func FormatSpan(d time.Duration) string {
+	if d.Round(time.Minute) < 24*time.Hour {
+		return FormatDuration(d)
+	}
+	d = d.Round(time.Hour)
+	days, hours := int(d/(24*time.Hour)), int(d%(24*time.Hour)/time.Hour)
+	if hours == 0 {
+		return fmt.Sprintf("%dd", days)
+	}
+	return fmt.Sprintf("%dd%dh", days, hours)
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// parseClockLine parses a clock entry in local time
+func parseClockLine(line string) (ClockEntry, bool) {
+	m := clockLine.FindStringSubmatch(line)
+	if m == nil {
+		return ClockEntry{}, false
+	}
+	start, err := time.ParseInLocation(clockDateTimeFormat, m[1], time.Local)
+	if err != nil {
+		return ClockEntry{}, false
+	}
+
+	entry := ClockEntry{Start: start}
+	if m[2] == "" {
+		return entry, true
+	}
+
+	var end time.Time
+	if len(m[2]) == len(clockTimeFormat) {
+		clock, err := time.ParseInLocation(clockTimeFormat, m[2], time.Local)
+		if err != nil {
+			return ClockEntry{}, false
+		}
+		end = time.Date(start.Year(), start.Month(), start.Day(), clock.Hour(), clock.Minute(), 0, 0, time.Local)
+		// An end time without a date before the start is on the next day
+		if end.Before(start) {
+			end = end.AddDate(0, 0, 1)
+		}
+	} else {
+		end, err = time.ParseInLocation(clockDateTimeFormat, m[2], time.Local)
+		if err != nil {
+			return ClockEntry{}, false
+		}
+	}
+	entry.End = &end
+	return entry, true
+}
+
+// timeLogSection returns the line range of the log section: the heading
+// line and the line after the section's last line. ok is false if the
+// content has no log section.
+func timeLogSection(lines []string) (heading, end int, ok bool) {
+	heading = -1
+	for i, line := range lines {
+		if strings.EqualFold(strings.TrimSpace(line), TimeLogHeading) {
+			heading = i
+			break
+		}
+	}
+	if heading < 0 {
+		return 0, 0, false
+	}
+
+	// The section ends at the next heading of the same or a higher level
+	end = len(lines)
+	for i := heading + 1; i < len(lines); i++ {
+		if strings.HasPrefix(lines[i], "# ") || strings.HasPrefix(lines[i], "## ") {
+			end = i
+			break
+		}
+	}
+	return heading, end, true
+}
+
+// GetClockEntries returns the clock entries of the task's log section
+func (t *Task) GetClockEntries() []ClockEntry {
+	lines := strings.Split(t.Content, "\n")
+	heading, end, ok := timeLogSection(lines)
+	if !ok {
+		return nil
+	}
+
+	var entries []ClockEntry
+	for _, line := range lines[heading+1 : end] {
+		if entry, ok := parseClockLine(line); ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// RunningClock returns the entry whose clock is running, or nil
+func (t *Task) RunningClock() *ClockEntry {
+	for _, entry := range t.GetClockEntries() {
+		if entry.IsRunning() {
+			return &entry
+		}
+	}
+	return nil
+}
+
+// TimeSpent returns the total time logged on the task, counting a running
+// clock up to now
+func (t *Task) TimeSpent(now time.Time) time.Duration {
+	var total time.Duration
+	for _, entry := range t.GetClockEntries() {
+		total += entry.Duration(now)
+	}
+	return total
+}
+
+// ClockIn starts a new clock entry at now, adding the log section if the
+// task has none. It fails if a clock is already running.
+func (t *Task) ClockIn(now time.Time) (ClockEntry, error) {
+	if t.RunningClock() != nil {
+		return ClockEntry{}, fmt.Errorf("the clock is already running")
+	}
+	entry := ClockEntry{Start: now.Truncate(time.Minute)}
+
+	lines := strings.Split(t.Content, "\n")
+	heading, end, ok := timeLogSection(lines)
+	if !ok {
+		content := strings.TrimRight(t.Content, "\n")
+		if content != "" {
+			content += "\n\n"
+		}
+		t.Content = content + TimeLogHeading + "\n\n" + entry.String() + "\n"
+		return entry, nil
+	}
+
+	// Append after the last non-blank line of the section
+	insert := end
+	for insert > heading+1 && strings.TrimSpace(lines[insert-1]) == "" {
+		insert--
+	}
+	added := []string{entry.String()}
+	if insert == heading+1 {
+		added = []string{"", entry.String()}
+	}
+	lines = append(lines[:insert], append(added, lines[insert:]...)...)
+	t.Content = strings.Join(lines, "\n")
+	if !strings.HasSuffix(t.Content, "\n") {
+		t.Content += "\n"
+	}
+	return entry, nil
+}
+
+// ClockOut stops the running clock entry at now. It fails if no clock is
+// running.
+func (t *Task) ClockOut(now time.Time) (ClockEntry, error) {
+	lines := strings.Split(t.Content, "\n")
+	heading, end, ok := timeLogSection(lines)
+	if ok {
+		for i := heading + 1; i < end; i++ {
+			entry, ok := parseClockLine(lines[i])
+			if !ok || !entry.IsRunning() {
+				continue
+			}
+			stop := now.Truncate(time.Minute)
+			if stop.Before(entry.Start) {
+				stop = entry.Start
+			}
+			entry.End = &stop
+			lines[i] = entry.String()
+			t.Content = strings.Join(lines, "\n")
+			return entry, nil
+		}
+	}
+	return ClockEntry{}, fmt.Errorf("the clock is not running")
+}
+
+// RemoveTimeLog returns content without its log section, e.g. for a copy of
+// a task that starts without logged time
+func RemoveTimeLog(content string) string {
+	lines := strings.Split(content, "\n")
+	heading, end, ok := timeLogSection(lines)
+	if !ok {
+		return content
+	}
+	lines = append(lines[:heading], lines[end:]...)
+	result := strings.TrimRight(strings.Join(lines, "\n"), "\n")
+	if result == "" {
+		return ""
+	}
+	return result + "\n"
+}
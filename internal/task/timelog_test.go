@@ -0,0 +1,129 @@
+package task
+
+import (
+	"testing"
+	"time"
+)
+
+func at(s string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02 15:04", s, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestClockInOut(t *testing.T) {
+	task := &Task{Content: "# Fix login\n\nSome notes\n"}
+
+	if _, err := task.ClockOut(at("2025-06-20 09:00")); err == nil {
+		t.Error("ClockOut() without a running clock should fail")
+	}
+
+	if _, err := task.ClockIn(at("2025-06-20 09:00")); err != nil {
+		t.Fatalf("ClockIn() error = %v", err)
+	}
+	if _, err := task.ClockIn(at("2025-06-20 09:10")); err == nil {
+		t.Error("ClockIn() with a running clock should fail")
+	}
+	want := "# Fix login\n\nSome notes\n\n## Log\n\n- 2025-06-20 09:00 - (running)\n"
+	if task.Content != want {
+		t.Errorf("content after ClockIn() = %q, want %q", task.Content, want)
+	}
+	if task.RunningClock() == nil {
+		t.Error("RunningClock() should return the running entry")
+	}
+	if got := task.TimeSpent(at("2025-06-20 09:20")); got != 20*time.Minute {
+		t.Errorf("TimeSpent() while running = %v", got)
+	}
+
+	entry, err := task.ClockOut(at("2025-06-20 10:30"))
+	if err != nil {
+		t.Fatalf("ClockOut() error = %v", err)
+	}
+	if entry.Duration(time.Time{}) != 90*time.Minute {
+		t.Errorf("entry duration = %v", entry.Duration(time.Time{}))
+	}
+
+	// Past midnight the end carries its date
+	task.ClockIn(at("2025-06-20 23:00"))
+	task.ClockOut(at("2025-06-21 01:15"))
+
+	want = "# Fix login\n\nSome notes\n\n## Log\n\n" +
+		"- 2025-06-20 09:00 - 10:30 (1h30m)\n" +
+		"- 2025-06-20 23:00 - 2025-06-21 01:15 (2h15m)\n"
+	if task.Content != want {
+		t.Errorf("content = %q, want %q", task.Content, want)
+	}
+	if got := task.TimeSpent(time.Now()); got != 225*time.Minute {
+		t.Errorf("TimeSpent() = %v, want 3h45m", got)
+	}
+	if task.RunningClock() != nil {
+		t.Error("no clock should be running")
+	}
+}
+
+func TestGetClockEntries(t *testing.T) {
+	task := &Task{Content: "# Task\n\n## Log\n\n" +
+		"- 2025-06-20 09:00 - 10:00\n" +
+		"Called the customer\n" +
+		"* 2025-06-20 23:30 - 00:30 (edited by hand)\n" +
+		"- not an entry\n" +
+		"\n## Notes\n\n- 2025-06-22 09:00 - 10:00\n"}
+
+	entries := task.GetClockEntries()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2: %v", len(entries), entries)
+	}
+	if d := entries[1].Duration(time.Time{}); d != time.Hour {
+		t.Errorf("an end time before the start should be on the next day, got %v", d)
+	}
+
+	// New entries go to the end of the log section, not of the content
+	task.ClockIn(at("2025-06-21 08:00"))
+	entries = task.GetClockEntries()
+	if len(entries) != 3 || !entries[2].IsRunning() {
+		t.Errorf("entries after ClockIn() = %v", entries)
+	}
+	if got := RemoveTimeLog(task.Content); got != "# Task\n\n## Notes\n\n- 2025-06-22 09:00 - 10:00\n" {
+		t.Errorf("RemoveTimeLog() = %q", got)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0m"},
+		{45 * time.Minute, "45m"},
+		{time.Hour, "1h"},
+		{95 * time.Minute, "1h35m"},
+		{125*time.Minute + 40*time.Second, "2h06m"},
+		{30 * time.Hour, "30h"},
+	}
+	for _, tt := range tests {
+		if got := FormatDuration(tt.d); got != tt.want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestFormatSpan(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{45 * time.Minute, "45m"},
+		{23*time.Hour + 59*time.Minute, "23h59m"},
+		{24 * time.Hour, "1d"},
+		{52*time.Hour + 20*time.Minute, "2d4h"},
+		{71*time.Hour + 40*time.Minute, "3d"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatSpan(tt.d); got != tt.want {
+			t.Errorf("FormatSpan(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
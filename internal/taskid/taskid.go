@@ -0,0 +1,221 @@
+// Package taskid generates the IDs of new tasks. The ID of a task is
+// "task/" followed by the base name of its file, so the format of the ID
+// also names the file:
+//
+//	timestamp  task/20250629103000, then task/20250629103000_1 within the same second
+//	ulid       task/01JYX3Q5Z8P6M2K4T7W9B1C3D5
+//	slug       task/20250629-fix-login
+package taskid
+
+import (
+	"crypto/rand"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/tkancf/mdtask/internal/constants"
+)
+
+// Formats accepted by New and the storage.id_format config setting
+const (
+	FormatTimestamp = "timestamp"
+	FormatULID      = "ulid"
+	FormatSlug      = "slug"
+)
+
+// Formats returns the names accepted by New
+func Formats() []string {
+	return []string{FormatTimestamp, FormatULID, FormatSlug}
+}
+
+// Validate returns an error if format is not a known format. Empty means
+// timestamp.
+func Validate(format string) error {
+	if format == "" {
+		return nil
+	}
+	for _, f := range Formats() {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown ID format %q (valid: %s)", format, strings.Join(Formats(), ", "))
+}
+
+// maxSlugLength is the number of characters of the title kept in a slug
+const maxSlugLength = 50
+
+const slugDateFormat = "20060102"
+
+// Generator hands out IDs of one format. Timestamp and ULID IDs generated
+// by the same Generator never repeat, without waiting for the clock.
+type Generator struct {
+	format string
+
+	mu sync.Mutex
+	// lastStamp and seq number the IDs of the same second
+	lastStamp string
+	seq       int
+	// lastMillis and entropy make ULIDs of the same millisecond increase
+	lastMillis int64
+	entropy    [10]byte
+}
+
+// New returns a generator for format, which must have passed Validate.
+// Empty means timestamp.
+func New(format string) *Generator {
+	if format == "" {
+		format = FormatTimestamp
+	}
+	return &Generator{format: format}
+}
+
+// Format returns the format of the generated IDs
+func (g *Generator) Format() string {
+	return g.format
+}
+
+// Next returns the ID of a task with the given title created now
+func (g *Generator) Next(title string) string {
+	return g.At(title, time.Now())
+}
+
+// At returns the ID of a task with the given title created at t
+func (g *Generator) At(title string, t time.Time) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	switch g.format {
+	case FormatULID:
+		return constants.TaskIDPrefix + g.ulid(t)
+	case FormatSlug:
+		slug := Slug(title)
+		if slug == "" {
+			slug = t.Format("150405")
+		}
+		return constants.TaskIDPrefix + t.Format(slugDateFormat) + "-" + slug
+	default:
+		stamp := t.Format(constants.IDTimeFormat)
+		if stamp != g.lastStamp {
+			g.lastStamp = stamp
+			g.seq = 0
+			return constants.TaskIDPrefix + stamp
+		}
+		g.seq++
+		return fmt.Sprintf("%s%s_%d", constants.TaskIDPrefix, stamp, g.seq)
+	}
+}
+
+// crockford is the base32 alphabet of ULIDs
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ulid returns a ULID for t: 48 bits of milliseconds followed by 80 random
+// bits, which are incremented instead within the same millisecond
+func (g *Generator) ulid(t time.Time) string {
+	ms := t.UnixMilli()
+	if ms != g.lastMillis || !increment(g.entropy[:]) {
+		if _, err := rand.Read(g.entropy[:]); err != nil {
+			panic(fmt.Sprintf("taskid: no random source: %v", err))
+		}
+		g.lastMillis = ms
+	}
+
+	var id [16]byte
+	for i := 0; i < 6; i++ {
+		id[i] = byte(ms >> (40 - 8*i))
+	}
+	copy(id[6:], g.entropy[:])
+
+	// 26 characters of 5 bits hold the 128 bits behind two zero bits
+	var out [26]byte
+	for i := range out {
+		var v byte
+		for bit := i*5 - 2; bit < i*5+3; bit++ {
+			v <<= 1
+			if bit >= 0 && id[bit/8]&(0x80>>(bit%8)) != 0 {
+				v |= 1
+			}
+		}
+		out[i] = crockford[v]
+	}
+	return string(out[:])
+}
+
+// increment adds one to the big-endian number b and reports false when it
+// overflows
+func increment(b []byte) bool {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Slug returns the title in lower case with runs of anything but letters
+// and digits replaced by a hyphen, cut to 50 characters
+func Slug(title string) string {
+	var b strings.Builder
+	n := 0
+	hyphen := false
+	for _, r := range strings.ToLower(title) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r) {
+			hyphen = true
+			continue
+		}
+		sep := hyphen && n > 0
+		if sep && n+2 > maxSlugLength || n+1 > maxSlugLength {
+			break
+		}
+		if sep {
+			b.WriteByte('-')
+			n++
+		}
+		hyphen = false
+		b.WriteRune(r)
+		n++
+	}
+	return b.String()
+}
+
+var (
+	timestampPattern = regexp.MustCompile(`^(\d{14})(_\d+)?$`)
+	ulidPattern      = regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}(_\d+)?$`)
+	slugPattern      = regexp.MustCompile(`^(\d{8})-[^/\s]+$`)
+)
+
+// Matches reports whether id, with or without the "task/" prefix, is in
+// format
+func Matches(format, id string) bool {
+	base := strings.TrimPrefix(id, constants.TaskIDPrefix)
+	switch format {
+	case FormatULID:
+		return ulidPattern.MatchString(base)
+	case FormatSlug:
+		m := slugPattern.FindStringSubmatch(base)
+		return m != nil && validTime(slugDateFormat, m[1])
+	default:
+		m := timestampPattern.FindStringSubmatch(base)
+		return m != nil && validTime(constants.IDTimeFormat, m[1])
+	}
+}
+
+// Valid reports whether id, with or without the "task/" prefix, is in any
+// of the formats
+func Valid(id string) bool {
+	for _, format := range Formats() {
+		if Matches(format, id) {
+			return true
+		}
+	}
+	return false
+}
+
+func validTime(layout, value string) bool {
+	_, err := time.Parse(layout, value)
+	return err == nil
+}
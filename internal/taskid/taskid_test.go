@@ -0,0 +1,120 @@
+package taskid
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerator_Timestamp(t *testing.T) {
+	g := New("")
+	at := time.Date(2025, 6, 29, 10, 30, 0, 0, time.UTC)
+
+	want := []string{"task/20250629103000", "task/20250629103000_1", "task/20250629103000_2"}
+	for i, w := range want {
+		if got := g.At("", at); got != w {
+			t.Errorf("At() #%d = %s, want %s", i, got, w)
+		}
+	}
+	if got := g.At("", at.Add(time.Second)); got != "task/20250629103001" {
+		t.Errorf("At() in the next second = %s", got)
+	}
+}
+
+func TestGenerator_ULID(t *testing.T) {
+	g := New(FormatULID)
+	at := time.Date(2025, 6, 29, 10, 30, 0, 0, time.UTC)
+
+	previous := ""
+	for i := 0; i < 100; i++ {
+		id := g.At("", at)
+		if !Matches(FormatULID, id) {
+			t.Fatalf("At() = %s, not a ULID", id)
+		}
+		if id <= previous {
+			t.Fatalf("At() = %s, not after %s", id, previous)
+		}
+		previous = id
+	}
+
+	// The first ten characters encode the time
+	if got := strings.TrimPrefix(previous, "task/")[:10]; got != "01JYXKS620" {
+		t.Errorf("time part = %s, want 01JYXKS620", got)
+	}
+	if later := g.At("", at.Add(time.Millisecond)); later <= previous {
+		t.Errorf("ULID of a later millisecond %s is not after %s", later, previous)
+	}
+}
+
+func TestGenerator_Slug(t *testing.T) {
+	g := New(FormatSlug)
+	at := time.Date(2025, 6, 29, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Fix login", "task/20250629-fix-login"},
+		{"  [PROJ-12] Fix: the login!  ", "task/20250629-proj-12-fix-the-login"},
+		{"会議の準備", "task/20250629-会議の準備"},
+		{"!!!", "task/20250629-103000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			if got := g.At(tt.title, at); got != tt.want {
+				t.Errorf("At(%q) = %s, want %s", tt.title, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlug(t *testing.T) {
+	long := strings.Repeat("word ", 20)
+	got := Slug(long)
+	if len([]rune(got)) > maxSlugLength || strings.HasSuffix(got, "-") {
+		t.Errorf("Slug() = %q, want at most %d characters without a trailing hyphen", got, maxSlugLength)
+	}
+	if got := Slug("Café au lait"); got != "café-au-lait" {
+		t.Errorf("Slug() = %q", got)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		format string
+		id     string
+		want   bool
+	}{
+		{FormatTimestamp, "task/20250629103000", true},
+		{FormatTimestamp, "20250629103000_2", true},
+		{FormatTimestamp, "20251329103000", false},
+		{FormatTimestamp, "202506", false},
+		{FormatULID, "task/01JYX5GNE0ABCDEFGHJKMNPQRS", true},
+		{FormatULID, "01JYX5GNE0ABCDEFGHJKMNPQRI", false},
+		{FormatULID, "81JYX5GNE0ABCDEFGHJKMNPQRS", false},
+		{FormatSlug, "task/20250629-fix-login", true},
+		{FormatSlug, "20250629-fix-login_1", true},
+		{FormatSlug, "invalid-id", false},
+		{FormatSlug, "20250629103000", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format+" "+tt.id, func(t *testing.T) {
+			if got := Matches(tt.format, tt.id); got != tt.want {
+				t.Errorf("Matches(%s, %s) = %v, want %v", tt.format, tt.id, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, format := range append(Formats(), "") {
+		if err := Validate(format); err != nil {
+			t.Errorf("Validate(%q) error = %v", format, err)
+		}
+	}
+	if err := Validate("uuid"); err == nil {
+		t.Error("Validate(uuid) should fail")
+	}
+}
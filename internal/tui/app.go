@@ -11,10 +11,13 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/errors"
 	"github.com/tkancf/mdtask/internal/repository"
+	"github.com/tkancf/mdtask/internal/service"
 	"github.com/tkancf/mdtask/internal/task"
 	"github.com/tkancf/mdtask/internal/tui/components"
 	"github.com/tkancf/mdtask/internal/tui/views"
+	"github.com/tkancf/mdtask/internal/watcher"
 )
 
 const (
@@ -41,6 +44,7 @@ type undoAction struct {
 type App struct {
 	repo           repository.Repository
 	config         *config.Config
+	service        *service.TaskService
 	list           list.Model
 	tasks          []*task.Task
 	detail         *views.DetailView
@@ -49,6 +53,7 @@ type App struct {
 	selectedTask   *task.Task
 	selectedTasks  map[string]*task.Task // For multi-select
 	undoHistory    []undoAction          // History for undo
+	events         <-chan watcher.Event  // File changes made outside the TUI
 	viewState      viewState
 	width          int
 	height         int
@@ -71,26 +76,13 @@ func (i taskItem) Title() string {
 	return prefix + i.task.Title
 }
 func (i taskItem) Description() string {
-	status := ""
-	for _, tag := range i.task.Tags {
-		if tag == "mdtask/status/TODO" {
-			status = "TODO"
-			break
-		} else if tag == "mdtask/status/WIP" {
-			status = "WIP"
-			break
-		} else if tag == "mdtask/status/WAIT" {
-			status = "WAIT"
-			break
-		} else if tag == "mdtask/status/SCHE" {
-			status = "SCHE"
-			break
-		} else if tag == "mdtask/status/DONE" {
-			status = "DONE"
-			break
-		}
+	desc := fmt.Sprintf("Status: %s | ID: %s", i.task.GetStatus(), i.task.ID)
+	if i.task.RunningClock() != nil {
+		desc += fmt.Sprintf(" | ⏱ %s running", task.FormatDuration(i.task.TimeSpent(time.Now())))
+	} else if spent := i.task.TimeSpent(time.Now()); spent > 0 {
+		desc += fmt.Sprintf(" | ⏱ %s", task.FormatDuration(spent))
 	}
-	return fmt.Sprintf("Status: %s | ID: %s", status, i.task.ID)
+	return desc
 }
 
 // itemDelegate is a custom delegate for list items
@@ -158,6 +150,10 @@ func NewApp(repo repository.Repository, cfg *config.Config) *App {
 				key.WithKeys("s"),
 				key.WithHelp("s", "change status"),
 			),
+			key.NewBinding(
+				key.WithKeys("t"),
+				key.WithHelp("t", "start/stop timer"),
+			),
 			key.NewBinding(
 				key.WithKeys("u"),
 				key.WithHelp("u", "undo"),
@@ -168,13 +164,22 @@ func NewApp(repo repository.Repository, cfg *config.Config) *App {
 	return &App{
 		repo:          repo,
 		config:        cfg,
+		service:       service.NewTaskService(repo, cfg),
 		list:          l,
 		selectedTasks: make(map[string]*task.Task),
 		undoHistory:   make([]undoAction, 0),
 	}
 }
 
+// SetEvents makes the app reload whenever a task changes on disk
+func (a *App) SetEvents(events <-chan watcher.Event) {
+	a.events = events
+}
+
 func (a *App) Init() tea.Cmd {
+	if a.events != nil {
+		return tea.Batch(a.loadTasks, a.waitForEvent)
+	}
 	return a.loadTasks
 }
 
@@ -227,14 +232,14 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "s":
 				// Open status selector
 				if len(a.selectedTasks) > 0 {
-					// Bulk status change
-					a.statusSelector = components.NewStatusSelector(task.StatusTODO)
+					// Bulk status change; the workflow is checked per task
+					a.statusSelector = components.NewStatusSelector("", a.config.GetWorkflow().Statuses())
 					a.viewState = statusSelectView
 					return a, a.statusSelector.Init()
 				} else if i, ok := a.list.SelectedItem().(taskItem); ok {
 					// Single task status change
 					a.selectedTask = i.task
-					a.statusSelector = components.NewStatusSelector(a.selectedTask.GetStatus())
+					a.statusSelector = components.NewStatusSelector(a.selectedTask.GetStatus(), a.nextStatuses(a.selectedTask))
 					a.viewState = statusSelectView
 					return a, a.statusSelector.Init()
 				}
@@ -260,6 +265,11 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 				}
 				a.list.SetItems(items)
+			case "t":
+				// Start or stop the timer of the task
+				if i, ok := a.list.SelectedItem().(taskItem); ok {
+					return a, a.toggleTimer(i.task)
+				}
 			case "u":
 				// Undo last action
 				if len(a.undoHistory) > 0 {
@@ -301,11 +311,24 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.tasks = msg.tasks
 		items := make([]list.Item, len(a.tasks))
 		for i, t := range a.tasks {
-			items[i] = taskItem{task: t}
+			_, selected := a.selectedTasks[t.ID]
+			items[i] = taskItem{task: t, selected: selected}
+			// Keep an open detail view in sync with the reloaded task
+			if a.detail != nil && a.selectedTask != nil && a.selectedTask.ID == t.ID {
+				a.selectedTask = t
+				a.detail.SetTask(t)
+			}
 		}
 		a.list.SetItems(items)
 		return a, nil
 
+	case taskChangedMsg:
+		if !msg.ok {
+			// Watcher stopped; nothing more to wait for
+			return a, nil
+		}
+		return a, tea.Batch(a.loadTasks, a.waitForEvent)
+
 	case views.GoBackMsg:
 		a.viewState = listView
 		a.detail = nil
@@ -333,9 +356,8 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				newStatus: msg.Status,
 				timestamp: time.Now(),
 			})
-			a.selectedTask.SetStatus(msg.Status)
 			a.viewState = listView
-			return a, a.updateTask(a.selectedTask)
+			return a, a.updateTask(a.selectedTask, msg.Status)
 		}
 		return a, nil
 
@@ -347,6 +369,10 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case taskUpdatedMsg:
 		if msg.err != nil {
 			a.err = msg.err
+			// The task changed on disk; reload so the next change starts from fresh data
+			if errors.IsConflict(msg.err) {
+				return a, a.loadTasks
+			}
 			return a, nil
 		}
 		// Clear selections after update
@@ -479,6 +505,11 @@ type taskCreatedMsg struct {
 	err error
 }
 
+type taskChangedMsg struct {
+	event watcher.Event
+	ok    bool
+}
+
 // Commands
 func (a *App) loadTasks() tea.Msg {
 	tasks, err := a.repo.FindAll()
@@ -488,9 +519,36 @@ func (a *App) loadTasks() tea.Msg {
 	return tasksLoadedMsg{tasks: tasks}
 }
 
-func (a *App) updateTask(t *task.Task) tea.Cmd {
+// waitForEvent blocks until the watcher reports a change
+func (a *App) waitForEvent() tea.Msg {
+	ev, ok := <-a.events
+	return taskChangedMsg{event: ev, ok: ok}
+}
+
+// nextStatuses lists the statuses the workflow allows for t
+func (a *App) nextStatuses(t *task.Task) []config.StatusConfig {
+	workflow := a.config.GetWorkflow()
+	var statuses []config.StatusConfig
+	for _, status := range workflow.NextStatuses(t.GetStatus()) {
+		sc, _ := workflow.Lookup(status)
+		statuses = append(statuses, sc)
+	}
+	return statuses
+}
+
+// setStatus changes the status through the service so that the workflow
+// transitions are enforced
+func (a *App) setStatus(t *task.Task, status task.Status) error {
+	_, err := a.service.UpdateTask(t.ID, service.UpdateTaskParams{
+		Status:  &status,
+		Version: t.Version,
+	})
+	return err
+}
+
+func (a *App) updateTask(t *task.Task, status task.Status) tea.Cmd {
 	return func() tea.Msg {
-		err := a.repo.Update(t)
+		err := a.setStatus(t, status)
 		return taskUpdatedMsg{err: err}
 	}
 }
@@ -498,8 +556,7 @@ func (a *App) updateTask(t *task.Task) tea.Cmd {
 func (a *App) updateTasks(tasks map[string]*task.Task, status task.Status) tea.Cmd {
 	return func() tea.Msg {
 		for _, t := range tasks {
-			t.SetStatus(status)
-			if err := a.repo.Update(t); err != nil {
+			if err := a.setStatus(t, status); err != nil {
 				return taskUpdatedMsg{err: err}
 			}
 		}
@@ -507,6 +564,19 @@ func (a *App) updateTasks(tasks map[string]*task.Task, status task.Status) tea.C
 	}
 }
 
+// toggleTimer stops the timer of t if it is running and starts it otherwise
+func (a *App) toggleTimer(t *task.Task) tea.Cmd {
+	return func() tea.Msg {
+		var err error
+		if t.RunningClock() != nil {
+			_, err = a.service.StopTimer(t.ID, time.Now())
+		} else {
+			_, _, err = a.service.StartTimer(t.ID, time.Now())
+		}
+		return taskUpdatedMsg{err: err}
+	}
+}
+
 func (a *App) undoLastAction() tea.Cmd {
 	return func() tea.Msg {
 		if len(a.undoHistory) == 0 {
@@ -539,8 +609,8 @@ func (a *App) processUndo(actions []undoAction) tea.Cmd {
 			if err != nil {
 				continue
 			}
-			t.SetStatus(action.oldStatus)
-			if err := a.repo.Update(t); err != nil {
+			t.ChangeStatus(action.oldStatus, time.Now())
+			if err := a.service.SaveTask(t); err != nil {
 				return taskUpdatedMsg{err: err}
 			}
 		}
@@ -550,7 +620,7 @@ func (a *App) processUndo(actions []undoAction) tea.Cmd {
 
 func (a *App) createTask(t *task.Task) tea.Cmd {
 	return func() tea.Msg {
-		_, err := a.repo.Create(t)
+		_, err := a.service.AddTask(t)
 		return taskCreatedMsg{err: err}
 	}
 }
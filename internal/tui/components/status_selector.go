@@ -4,6 +4,7 @@ import (
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/tkancf/mdtask/internal/config"
 	"github.com/tkancf/mdtask/internal/task"
 )
 
@@ -44,23 +45,17 @@ var keys = keyMap{
 }
 
 type StatusSelector struct {
-	statuses []task.Status
+	statuses []config.StatusConfig
 	cursor   int
 	selected task.Status
 }
 
-func NewStatusSelector(current task.Status) *StatusSelector {
-	statuses := []task.Status{
-		task.StatusTODO,
-		task.StatusWIP,
-		task.StatusWAIT,
-		task.StatusSCHE,
-		task.StatusDONE,
-	}
-
+// NewStatusSelector offers the given statuses, e.g. the ones the workflow
+// allows after current, with the cursor on current
+func NewStatusSelector(current task.Status, statuses []config.StatusConfig) *StatusSelector {
 	cursor := 0
 	for i, s := range statuses {
-		if s == current {
+		if task.Status(s.Name) == current {
 			cursor = i
 			break
 		}
@@ -90,7 +85,10 @@ func (s *StatusSelector) Update(msg tea.Msg) (*StatusSelector, tea.Cmd) {
 				s.cursor++
 			}
 		case key.Matches(msg, keys.Select):
-			s.selected = s.statuses[s.cursor]
+			if len(s.statuses) == 0 {
+				return s, StatusCancelledCmd
+			}
+			s.selected = task.Status(s.statuses[s.cursor].Name)
 			return s, StatusSelectedCmd(s.selected)
 		case key.Matches(msg, keys.Cancel):
 			return s, StatusCancelledCmd
@@ -102,7 +100,8 @@ func (s *StatusSelector) Update(msg tea.Msg) (*StatusSelector, tea.Cmd) {
 func (s *StatusSelector) View() string {
 	var items string
 	for i, status := range s.statuses {
-		label := string(status)
+		dot := lipgloss.NewStyle().Foreground(lipgloss.Color(status.Color)).Render("●")
+		label := dot + " " + status.Label
 		if i == s.cursor {
 			items += selectedStyle.Render(label) + "\n"
 		} else {
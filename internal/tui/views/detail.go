@@ -3,6 +3,7 @@ package views
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/viewport"
@@ -77,6 +78,14 @@ func NewDetailView(t *task.Task) *DetailView {
 	}
 }
 
+// SetTask replaces the displayed task, e.g. after it was changed on disk
+func (d *DetailView) SetTask(t *task.Task) {
+	d.task = t
+	if d.ready {
+		d.viewport.SetContent(d.renderContent())
+	}
+}
+
 func (d *DetailView) Init() tea.Cmd {
 	return nil
 }
@@ -141,6 +150,14 @@ func (d *DetailView) renderHeader() string {
 	if deadline := d.task.GetDeadline(); deadline != nil {
 		info += "\n" + infoStyle.Render(fmt.Sprintf("Deadline: %s", deadline.Format("2006-01-02")))
 	}
+
+	if spent := d.task.TimeSpent(time.Now()); spent > 0 || d.task.RunningClock() != nil {
+		timeInfo := fmt.Sprintf("Time spent: %s", task.FormatDuration(spent))
+		if d.task.RunningClock() != nil {
+			timeInfo += " (running)"
+		}
+		info += "\n" + infoStyle.Render(timeInfo)
+	}
 	
 	return lipgloss.JoinVertical(lipgloss.Left, title, info, "")
 }
@@ -155,19 +172,8 @@ func (d *DetailView) renderFooter() string {
 }
 
 func (d *DetailView) getTaskStatus() string {
-	for _, tag := range d.task.Tags {
-		switch tag {
-		case "mdtask/status/TODO":
-			return "TODO"
-		case "mdtask/status/WIP":
-			return "WIP"
-		case "mdtask/status/WAIT":
-			return "WAIT"
-		case "mdtask/status/SCHE":
-			return "SCHE"
-		case "mdtask/status/DONE":
-			return "DONE"
-		}
+	if status := d.task.GetStatus(); status != "" {
+		return string(status)
 	}
 	return "Unknown"
 }
@@ -0,0 +1,265 @@
+// Package watcher detects changes to task files on disk and broadcasts them
+// as task events.
+//
+// The watcher polls a Source for the Markdown files of the task directories
+// and compares each file's size and modification time with the previous
+// scan, so it works the same on
+// every platform and on network or synced folders where native file system
+// notifications are unreliable. Changed files are parsed to find the task they
+// contain; files that are not mdtask tasks are ignored.
+//
+// Example Usage:
+//
+//	w := watcher.New(repo, constants.WatchPollInterval)
+//	w.Start()
+//	defer w.Stop()
+//
+//	events, cancel := w.Subscribe()
+//	defer cancel()
+//	for ev := range events {
+//	    fmt.Println(ev.Type, ev.TaskID)
+//	}
+package watcher
+
+import (
+	"io/fs"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/constants"
+	"github.com/tkancf/mdtask/internal/task"
+	"github.com/tkancf/mdtask/pkg/markdown"
+)
+
+// EventType describes what happened to a task
+type EventType string
+
+const (
+	EventCreated EventType = "created"
+	EventUpdated EventType = "updated"
+	EventDeleted EventType = "deleted"
+)
+
+// subscriberBuffer is the number of events queued per subscriber before
+// further events are dropped for that subscriber
+const subscriberBuffer = 64
+
+// Event is emitted whenever a task file is created, changed or removed
+type Event struct {
+	Type   EventType  `json:"type"`
+	TaskID string     `json:"id"`
+	Path   string     `json:"path"`
+	Task   *task.Task `json:"-"` // nil for deleted tasks
+}
+
+// Source lists the Markdown files to watch with their file info, keyed by
+// path. *repository.TaskRepository is the Source of the task directories,
+// so the watcher sees the same files as the repository: ignored files are
+// left out, symlinks are followed and overlapping roots are listed once.
+type Source interface {
+	MarkdownFiles() (map[string]fs.FileInfo, error)
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+	taskID  string
+}
+
+// Watcher polls task directories and notifies subscribers about changes
+type Watcher struct {
+	source   Source
+	interval time.Duration
+
+	mu          sync.Mutex
+	files       map[string]fileState
+	subscribers map[chan Event]struct{}
+	stop        chan struct{}
+	done        chan struct{}
+}
+
+// New creates a watcher for the files of source
+func New(source Source, interval time.Duration) *Watcher {
+	if interval <= 0 {
+		interval = constants.WatchPollInterval
+	}
+	return &Watcher{
+		source:      source,
+		interval:    interval,
+		files:       make(map[string]fileState),
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Start records the current state of all task files and begins polling.
+// Files that already exist when Start is called do not produce events.
+func (w *Watcher) Start() {
+	w.mu.Lock()
+	if w.stop != nil {
+		w.mu.Unlock()
+		return
+	}
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+	w.mu.Unlock()
+
+	w.scan()
+
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+				w.publish(w.scan())
+			}
+		}
+	}()
+}
+
+// Stop ends polling and closes all subscriber channels
+func (w *Watcher) Stop() {
+	w.mu.Lock()
+	if w.stop == nil {
+		w.mu.Unlock()
+		return
+	}
+	close(w.stop)
+	done := w.done
+	w.mu.Unlock()
+
+	<-done
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for ch := range w.subscribers {
+		close(ch)
+		delete(w.subscribers, ch)
+	}
+	w.stop = nil
+}
+
+// Subscribe returns a channel receiving all future events and a function
+// that cancels the subscription
+func (w *Watcher) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	w.mu.Lock()
+	w.subscribers[ch] = struct{}{}
+	w.mu.Unlock()
+
+	cancel := func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if _, ok := w.subscribers[ch]; ok {
+			delete(w.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return ch, cancel
+}
+
+// publish delivers events without blocking; slow subscribers miss events
+// rather than stalling the watcher
+func (w *Watcher) publish(events []Event) {
+	if len(events) == 0 {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for ch := range w.subscribers {
+		for _, ev := range events {
+			select {
+			case ch <- ev:
+			default:
+			}
+		}
+	}
+}
+
+// scan compares the file system with the previous snapshot and returns the
+// resulting events
+func (w *Watcher) scan() []Event {
+	current := make(map[string]fileState)
+	var events []Event
+
+	w.mu.Lock()
+	previous := w.files
+	w.mu.Unlock()
+
+	files, err := w.source.MarkdownFiles()
+	if err != nil {
+		// Keep the previous snapshot rather than report every task as
+		// deleted, e.g. while a network folder is unavailable
+		return nil
+	}
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		info := files[path]
+		prev, known := previous[path]
+		if known && prev.size == info.Size() && prev.modTime.Equal(info.ModTime()) {
+			current[path] = prev
+			continue
+		}
+
+		t := loadTask(path)
+		state := fileState{modTime: info.ModTime(), size: info.Size()}
+		if t != nil {
+			state.taskID = t.ID
+		}
+		current[path] = state
+
+		switch {
+		case t != nil && (!known || prev.taskID == ""):
+			events = append(events, Event{Type: EventCreated, TaskID: t.ID, Path: path, Task: t})
+		case t != nil && prev.taskID != t.ID:
+			events = append(events, Event{Type: EventDeleted, TaskID: prev.taskID, Path: path})
+			events = append(events, Event{Type: EventCreated, TaskID: t.ID, Path: path, Task: t})
+		case t != nil:
+			events = append(events, Event{Type: EventUpdated, TaskID: t.ID, Path: path, Task: t})
+		case known && prev.taskID != "":
+			events = append(events, Event{Type: EventDeleted, TaskID: prev.taskID, Path: path})
+		}
+	}
+
+	for path, prev := range previous {
+		if _, ok := current[path]; !ok && prev.taskID != "" {
+			events = append(events, Event{Type: EventDeleted, TaskID: prev.taskID, Path: path})
+		}
+	}
+
+	w.mu.Lock()
+	w.files = current
+	w.mu.Unlock()
+
+	return events
+}
+
+// loadTask parses a file and returns the task if it is managed by mdtask
+func loadTask(path string) *task.Task {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	t, err := markdown.ParseTaskFile(content)
+	if err != nil || !t.IsManagedTask() {
+		return nil
+	}
+
+	return t
+}
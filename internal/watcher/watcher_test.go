@@ -0,0 +1,209 @@
+package watcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/repository"
+)
+
+const testTask = `---
+id: %s
+title: %s
+tags:
+    - mdtask
+    - mdtask/status/TODO
+created: 2024-01-01 12:00
+updated: 2024-01-01 12:00
+---
+
+Content
+`
+
+// writeFile writes content and bumps the mtime so changes are visible even
+// on file systems with coarse timestamp resolution
+func writeFile(t *testing.T, path, content string, mtime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatalf("failed to set mtime: %v", err)
+	}
+}
+
+func eventSummary(events []Event) []string {
+	var result []string
+	for _, ev := range events {
+		result = append(result, string(ev.Type)+":"+ev.TaskID)
+	}
+	return result
+}
+
+func TestWatcher_Scan(t *testing.T) {
+	tempDir := t.TempDir()
+	base := time.Now().Add(-time.Hour)
+
+	existing := filepath.Join(tempDir, "existing.md")
+	writeFile(t, existing, fmt.Sprintf(testTask, "task/1", "Existing"), base)
+
+	w := New(repository.NewTaskRepository([]string{tempDir}), time.Second)
+	if events := w.scan(); len(events) != 1 {
+		t.Fatalf("initial scan should report existing task, got %v", eventSummary(events))
+	}
+
+	tests := []struct {
+		name   string
+		change func()
+		want   []string
+	}{
+		{
+			name:   "no changes",
+			change: func() {},
+			want:   nil,
+		},
+		{
+			name: "task created",
+			change: func() {
+				writeFile(t, filepath.Join(tempDir, "new.md"), fmt.Sprintf(testTask, "task/2", "New"), base)
+			},
+			want: []string{"created:task/2"},
+		},
+		{
+			name: "task updated",
+			change: func() {
+				writeFile(t, existing, fmt.Sprintf(testTask, "task/1", "Existing edited"), base.Add(time.Minute))
+			},
+			want: []string{"updated:task/1"},
+		},
+		{
+			name: "non-task file ignored",
+			change: func() {
+				writeFile(t, filepath.Join(tempDir, "note.md"), "# Just a note\n", base)
+			},
+			want: nil,
+		},
+		{
+			name: "state directory ignored",
+			change: func() {
+				stateDir := filepath.Join(tempDir, ".mdtask")
+				os.MkdirAll(stateDir, 0755)
+				writeFile(t, filepath.Join(stateDir, "hidden.md"), fmt.Sprintf(testTask, "task/9", "Hidden"), base)
+			},
+			want: nil,
+		},
+		{
+			name: "task deleted",
+			change: func() {
+				os.Remove(filepath.Join(tempDir, "new.md"))
+			},
+			want: []string{"deleted:task/2"},
+		},
+		{
+			name: "task no longer managed",
+			change: func() {
+				writeFile(t, existing, "# Plain note now\n", base.Add(2*time.Minute))
+			},
+			want: []string{"deleted:task/1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.change()
+			got := eventSummary(w.scan())
+			if len(got) != len(tt.want) {
+				t.Fatalf("scan() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("scan()[%d] = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWatcher_RepositoryFiles(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	base := time.Now().Add(-time.Hour)
+
+	for _, dir := range []string{"sub", "drafts", "archive"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "linked")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	writeFile(t, filepath.Join(root, ".mdtaskignore"), "drafts/\n", base)
+
+	// The second root lies inside the first
+	cfg := &config.Config{Ignore: []string{"archive/"}}
+	w := New(repository.NewTaskRepositoryWithConfig([]string{root, filepath.Join(root, "sub")}, cfg), time.Second)
+	w.scan()
+
+	writeFile(t, filepath.Join(root, "sub", "nested.md"), fmt.Sprintf(testTask, "task/1", "Nested"), base)
+	writeFile(t, filepath.Join(outside, "linked.md"), fmt.Sprintf(testTask, "task/2", "Linked"), base)
+	writeFile(t, filepath.Join(root, "drafts", "draft.md"), fmt.Sprintf(testTask, "task/3", "Draft"), base)
+	writeFile(t, filepath.Join(root, "archive", "old.md"), fmt.Sprintf(testTask, "task/4", "Old"), base)
+
+	got := eventSummary(w.scan())
+	want := []string{"created:task/2", "created:task/1"}
+	if len(got) != len(want) {
+		t.Fatalf("scan() = %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("scan()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestWatcher_Subscribe(t *testing.T) {
+	tempDir := t.TempDir()
+
+	w := New(repository.NewTaskRepository([]string{tempDir}), 10*time.Millisecond)
+	events, cancel := w.Subscribe()
+	defer cancel()
+
+	w.Start()
+	defer w.Stop()
+
+	writeFile(t, filepath.Join(tempDir, "a.md"), fmt.Sprintf(testTask, "task/1", "A"), time.Now())
+
+	select {
+	case ev := <-events:
+		if ev.Type != EventCreated || ev.TaskID != "task/1" {
+			t.Errorf("unexpected event %+v", ev)
+		}
+		if ev.Task == nil || ev.Task.Title != "A" {
+			t.Errorf("expected event to carry the parsed task, got %+v", ev.Task)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+}
+
+func TestWatcher_StopClosesSubscribers(t *testing.T) {
+	w := New(repository.NewTaskRepository([]string{t.TempDir()}), 10*time.Millisecond)
+	events, cancel := w.Subscribe()
+	defer cancel()
+
+	w.Start()
+	w.Stop()
+
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("expected channel to be closed after Stop")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel was not closed")
+	}
+}
@@ -9,8 +9,11 @@ import (
 
 	"github.com/tkancf/mdtask/internal/config"
 	"github.com/tkancf/mdtask/internal/errors"
+	"github.com/tkancf/mdtask/internal/query"
+	"github.com/tkancf/mdtask/internal/repository"
+	"github.com/tkancf/mdtask/internal/search"
+	"github.com/tkancf/mdtask/internal/service"
 	"github.com/tkancf/mdtask/internal/task"
-	"github.com/tkancf/mdtask/pkg/markdown"
 )
 
 type PageData struct {
@@ -19,18 +22,26 @@ type PageData struct {
 	Task        *task.Task
 	TotalTasks  int
 	ActiveTasks int
-	TodoCount   int
-	WipCount    int
-	WaitCount   int
-	DoneCount   int
+	// StatusCounts is keyed by status name
+	StatusCounts map[string]int
 	Query       string
 	Status      string
+	// Results are the full-text search results, best matches first
+	Results  []search.Result
+	Archived bool
+	// Charts are the reports with their date range and tag filter
+	Charts   []Chart
+	From, To string
+	Tag      string
+	// Entries are the deleted tasks on the trash page
+	Entries []repository.TrashEntry
 	// Statistics
 	CreatedToday   int
 	CompletedToday int
 	UpdatedToday   int
 	OverdueTasks   int
 	UpcomingTasks  int
+	Flow           *service.FlowMetrics
 }
 
 func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
@@ -52,22 +63,20 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 
 	// Get all tasks for statistics
 	allTasks, _ := s.repo.FindAll()
-	stats := calculateDashboardStats(allTasks)
-	
+	stats := calculateDashboardStats(allTasks, s.workflow())
+
 	data := PageData{
 		Title:          "Dashboard",
 		Tasks:          tasks,
 		TotalTasks:     len(allTasks),
 		ActiveTasks:    len(tasks),
-		TodoCount:      stats.TODO,
-		WipCount:       stats.WIP,
-		WaitCount:      stats.WAIT,
-		DoneCount:      stats.DONE,
+		StatusCounts:   stats.ByStatus,
 		CreatedToday:   stats.CreatedToday,
 		CompletedToday: stats.CompletedToday,
 		UpdatedToday:   stats.UpdatedToday,
 		OverdueTasks:   stats.OverdueTasks,
 		UpcomingTasks:  stats.UpcomingTasks,
+		Flow:           stats.Flow,
 	}
 
 	if err := s.templates.ExecuteTemplate(w, "index.html", data); err != nil {
@@ -76,48 +85,72 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleTasks(w http.ResponseWriter, r *http.Request) {
-	var tasks []*task.Task
-	var err error
-	var title string
-
-	// Check for tag filters
-	includeTags := r.URL.Query()["tags"]
-	excludeTags := r.URL.Query()["exclude"]
-	orMode := r.URL.Query().Get("or") == "true"
-	includeArchived := r.URL.Query().Get("archived") == "true"
-
-	if len(includeTags) > 0 || len(excludeTags) > 0 {
-		// Tag-based search
-		tasks, err = s.repo.SearchByTags(includeTags, excludeTags, orMode)
+	params := r.URL.Query()
+	title := "All Tasks"
+
+	// Tag filters are a shorthand for a tag query
+	includeTags := params["tags"]
+	excludeTags := params["exclude"]
+	orMode := params.Get("or") == "true"
+	includeArchived := params.Get("archived") == "true"
+	tagQuery := query.Tags(includeTags, excludeTags, orMode)
+	if tagQuery != nil {
+		title = "Tasks filtered by tags"
+	}
+
+	var q query.Expr
+	if text := strings.TrimSpace(params.Get("q")); text != "" {
+		parsed, err := query.Parse(text)
 		if err != nil {
-			handleError(w, errors.InternalError("Failed to search tasks", err))
+			handleError(w, errors.ValidationError("q", err.Error()))
 			return
 		}
-		
-		// Include archived if requested
-		if includeArchived {
-			allTasks, _ := s.repo.FindAll()
-			for _, t := range allTasks {
-				if t.IsArchived() && matchesTags(t, includeTags, excludeTags, orMode) {
-					tasks = append(tasks, t)
-				}
+		q = parsed
+		title = "Query results"
+	}
+
+	allTasks, err := s.repo.FindAll()
+	if err != nil {
+		handleError(w, errors.InternalError("Failed to load tasks", err))
+		return
+	}
+
+	var tasks []*task.Task
+	if filter := query.And(tagQuery, q); filter != nil {
+		env := query.NewEnv(allTasks, s.workflow().IsDone)
+		tasks = query.Filter(allTasks, filter, env, includeArchived)
+		title = fmt.Sprintf("%s (%d)", title, len(tasks))
+	} else {
+		for _, t := range allTasks {
+			if !t.IsArchived() {
+				tasks = append(tasks, t)
 			}
 		}
-		
-		title = fmt.Sprintf("Tasks filtered by tags (%d)", len(tasks))
-	} else {
-		// Default: show active tasks
-		tasks, err = s.repo.FindActive()
+	}
+
+	// Filter by status
+	var status task.Status
+	if statusStr := params.Get("status"); statusStr != "" {
+		status, err = s.workflow().Parse(statusStr)
 		if err != nil {
-			handleError(w, errors.InternalError("Failed to load tasks", err))
+			handleError(w, errors.ValidationError("status", err.Error()))
 			return
 		}
-		title = "All Tasks"
+		var filtered []*task.Task
+		for _, t := range tasks {
+			if t.GetStatus() == status {
+				filtered = append(filtered, t)
+			}
+		}
+		tasks = filtered
+		title = fmt.Sprintf("%s Tasks", s.workflow().Label(status))
 	}
 
 	data := PageData{
-		Title: title,
-		Tasks: tasks,
+		Title:  title,
+		Tasks:  tasks,
+		Query:  params.Get("q"),
+		Status: string(status),
 	}
 
 	if err := s.templates.ExecuteTemplate(w, "tasks.html", data); err != nil {
@@ -125,49 +158,6 @@ func (s *Server) handleTasks(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func matchesTags(t *task.Task, includeTags, excludeTags []string, orMode bool) bool {
-	// Check exclude tags first
-	for _, excludeTag := range excludeTags {
-		for _, tag := range t.Tags {
-			if strings.EqualFold(tag, excludeTag) {
-				return false
-			}
-		}
-	}
-	
-	// Check include tags
-	if len(includeTags) == 0 {
-		return true
-	}
-	
-	if orMode {
-		// OR mode: at least one match
-		for _, includeTag := range includeTags {
-			for _, tag := range t.Tags {
-				if strings.EqualFold(tag, includeTag) {
-					return true
-				}
-			}
-		}
-		return false
-	} else {
-		// AND mode: all must match
-		for _, includeTag := range includeTags {
-			found := false
-			for _, tag := range t.Tags {
-				if strings.EqualFold(tag, includeTag) {
-					found = true
-					break
-				}
-			}
-			if !found {
-				return false
-			}
-		}
-		return true
-	}
-}
-
 func (s *Server) handleKanban(w http.ResponseWriter, r *http.Request) {
 	tasks, err := s.repo.FindActive()
 	if err != nil {
@@ -176,31 +166,15 @@ func (s *Server) handleKanban(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Count tasks by status
-	todoCount := 0
-	wipCount := 0
-	waitCount := 0
-	doneCount := 0
-	
+	counts := make(map[string]int)
 	for _, t := range tasks {
-		switch t.GetStatus() {
-		case "TODO":
-			todoCount++
-		case "WIP":
-			wipCount++
-		case "WAIT":
-			waitCount++
-		case "DONE":
-			doneCount++
-		}
+		counts[string(t.GetStatus())]++
 	}
 
 	data := PageData{
-		Title:     "Kanban Board",
-		Tasks:     tasks,
-		TodoCount: todoCount,
-		WipCount:  wipCount,
-		WaitCount: waitCount,
-		DoneCount: doneCount,
+		Title:        "Kanban Board",
+		Tasks:        tasks,
+		StatusCounts: counts,
 	}
 
 	if err := s.templates.ExecuteTemplate(w, "kanban.html", data); err != nil {
@@ -211,17 +185,8 @@ func (s *Server) handleKanban(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleByStatus(w http.ResponseWriter, r *http.Request) {
 	statusStr := strings.TrimPrefix(r.URL.Path, "/status/")
 	
-	var status task.Status
-	switch statusStr {
-	case "todo":
-		status = task.StatusTODO
-	case "wip":
-		status = task.StatusWIP
-	case "wait":
-		status = task.StatusWAIT
-	case "done":
-		status = task.StatusDONE
-	default:
+	status, err := s.workflow().Parse(statusStr)
+	if err != nil {
 		http.NotFound(w, r)
 		return
 	}
@@ -245,33 +210,41 @@ func (s *Server) handleByStatus(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("q")
-	if query == "" {
-		http.Redirect(w, r, "/tasks", http.StatusSeeOther)
-		return
-	}
+	includeArchived := r.URL.Query().Get("archived") == "true"
 
-	tasks, err := s.repo.Search(query)
-	if err != nil {
-		handleError(w, errors.InternalError("Failed to search tasks", err))
-		return
+	data := PageData{
+		Title:    "Search",
+		Query:    query,
+		Archived: includeArchived,
 	}
 
-	data := PageData{
-		Title: fmt.Sprintf("Search Results for '%s'", query),
-		Tasks: tasks,
-		Query: query,
+	if query != "" {
+		idx, err := s.repo.SearchIndex()
+		if err != nil {
+			handleError(w, errors.InternalError("Failed to search tasks", err))
+			return
+		}
+
+		for _, result := range idx.Search(query) {
+			if includeArchived || !result.Task.IsArchived() {
+				data.Results = append(data.Results, result)
+			}
+		}
+		data.Title = fmt.Sprintf("Search Results for '%s'", query)
 	}
 
-	if err := s.templates.ExecuteTemplate(w, "tasks.html", data); err != nil {
+	if err := s.templates.ExecuteTemplate(w, "search.html", data); err != nil {
 		handleError(w, errors.InternalError("Failed to render template", err))
 	}
 }
 
 func (s *Server) handleNew(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "GET" {
+		t := &task.Task{}
+		t.SetStatus(s.service().DefaultStatus())
 		data := PageData{
 			Title: "New Task",
-			Task:  &task.Task{},
+			Task:  t,
 		}
 		if err := s.templates.ExecuteTemplate(w, "edit.html", data); err != nil {
 			handleError(w, errors.InternalError("Failed to render template", err))
@@ -286,13 +259,12 @@ func (s *Server) handleNew(w http.ResponseWriter, r *http.Request) {
 		}
 
 		t := &task.Task{
-			ID:      markdown.GenerateTaskID(),
 			Created: time.Now(),
 			Updated: time.Now(),
 		}
 
 		if err := s.parseTaskForm(r, t); err != nil {
-			handleError(w, errors.ValidationError("form", "Invalid form data"))
+			handleError(w, formError(err))
 			return
 		}
 
@@ -301,11 +273,11 @@ func (s *Server) handleNew(w http.ResponseWriter, r *http.Request) {
 
 		// Set default status if not set
 		if t.GetStatus() == "" {
-			t.SetStatus(task.StatusTODO)
+			t.SetStatus(s.service().DefaultStatus())
 		}
 
 		// Create the task
-		filePath, err := s.repo.Create(t)
+		filePath, err := s.service().AddTask(t)
 		if err != nil {
 			handleError(w, errors.InternalError("Failed to create task", err))
 			return
@@ -368,16 +340,21 @@ func (s *Server) handleEdit(w http.ResponseWriter, r *http.Request) {
 
 		// Parse form
 		if err := s.parseTaskForm(r, t); err != nil {
-			handleError(w, errors.ValidationError("form", "Invalid form data"))
+			handleError(w, formError(err))
 			return
 		}
 
 		// Add back preserved tags
 		t.Tags = append(t.Tags, preservedTags...)
 
+		// Reject the edit if the task changed after the form was rendered
+		if version := r.FormValue("version"); version != "" {
+			t.Version = version
+		}
+
 		// Update task
-		if err := s.repo.Update(t); err != nil {
-			handleError(w, errors.InternalError("Failed to update task", err))
+		if err := s.service().SaveTask(t); err != nil {
+			handleError(w, updateError("Failed to update task", err))
 			return
 		}
 
@@ -400,11 +377,12 @@ func (s *Server) handleArchive(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	t.Archive()
-	
-	if err := s.repo.Update(t); err != nil {
-		handleError(w, errors.InternalError("Failed to archive task", err))
-		return
+	// Archive the task and its subtasks; archiving again changes nothing
+	if !t.IsArchived() {
+		if _, err := s.service().ArchiveTask(id); err != nil {
+			handleError(w, updateError("Failed to archive task", err))
+			return
+		}
 	}
 
 	fmt.Printf("Archived task %s\n", t.ID)
@@ -423,6 +401,8 @@ type APITaskResponse struct {
 	Updated     time.Time  `json:"updated"`
 	Content     string     `json:"content,omitempty"`
 	Deadline    *time.Time `json:"deadline,omitempty"`
+	Version     string     `json:"version,omitempty"`
+	Fields      map[string]interface{} `json:"fields,omitempty"`
 }
 
 func (s *Server) handleAPITasks(w http.ResponseWriter, r *http.Request) {
@@ -437,6 +417,22 @@ func (s *Server) handleAPITasks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Optional query, e.g. /api/tasks?q=status:WIP+and+tag:type/bug
+	if text := strings.TrimSpace(r.URL.Query().Get("q")); text != "" {
+		q, err := query.Parse(text)
+		if err != nil {
+			handleError(w, errors.ValidationError("q", err.Error()))
+			return
+		}
+		allTasks, err := s.repo.FindAll()
+		if err != nil {
+			handleError(w, errors.InternalError("Failed to load tasks", err))
+			return
+		}
+		env := query.NewEnv(allTasks, s.workflow().IsDone)
+		tasks = query.Filter(allTasks, q, env, false)
+	}
+
 	response := make([]APITaskResponse, len(tasks))
 	for i, t := range tasks {
 		response[i] = APITaskResponse{
@@ -448,6 +444,8 @@ func (s *Server) handleAPITasks(w http.ResponseWriter, r *http.Request) {
 			Created:     t.Created,
 			Updated:     t.Updated,
 			Deadline:    t.GetDeadline(),
+			Version:     t.Version,
+			Fields:      t.Fields,
 		}
 	}
 
@@ -486,9 +484,12 @@ func (s *Server) handleAPITask(w http.ResponseWriter, r *http.Request) {
 			Updated:     t.Updated,
 			Content:     t.Content,
 			Deadline:    t.GetDeadline(),
+			Version:     t.Version,
+			Fields:      t.Fields,
 		}
 
 		w.Header().Set("Content-Type", "application/json")
+		setETag(w, t)
 		json.NewEncoder(w).Encode(response)
 		
 	case "PUT":
@@ -504,6 +505,7 @@ func (s *Server) handleAPITask(w http.ResponseWriter, r *http.Request) {
 			Description string   `json:"description"`
 			Tags        []string `json:"tags"`
 			Deadline    *string  `json:"deadline"`
+			Fields      map[string]interface{} `json:"fields"`
 		}
 		
 		if err := json.NewDecoder(r.Body).Decode(&updateRequest); err != nil {
@@ -556,32 +558,52 @@ func (s *Server) handleAPITask(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
+		if err := s.service().ApplyFields(t, updateRequest.Fields); err != nil {
+			handleError(w, err)
+			return
+		}
+
 		// Update task status
 		if updateRequest.Status != "" {
-			switch updateRequest.Status {
-			case "TODO":
-				t.SetStatus(task.StatusTODO)
-			case "WIP":
-				t.SetStatus(task.StatusWIP)
-			case "WAIT":
-				t.SetStatus(task.StatusWAIT)
-			case "DONE":
-				t.SetStatus(task.StatusDONE)
-			default:
-				http.Error(w, "Invalid status", http.StatusBadRequest)
+			if err := s.setStatus(t, updateRequest.Status); err != nil {
+				handleError(w, err)
 				return
 			}
 		}
 
+		// Honour If-Match so clients don't overwrite changes they haven't seen
+		ifMatch := parseIfMatch(r.Header.Get("If-Match"))
+		if ifMatch != "" {
+			t.Version = ifMatch
+		}
+
 		t.Updated = time.Now()
 		
-		if err := s.repo.Update(t); err != nil {
-			handleError(w, errors.InternalError("Failed to update task", err))
+		if err := s.service().SaveTask(t); err != nil {
+			if ifMatch != "" && errors.IsConflict(err) {
+				http.Error(w, err.Error(), http.StatusPreconditionFailed)
+				return
+			}
+			handleError(w, updateError("Failed to update task", err))
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+		setETag(w, t)
+		json.NewEncoder(w).Encode(map[string]string{"status": "success", "version": t.Version})
+
+	case "DELETE":
+		deleted, err := s.service().DeleteTask(id, r.URL.Query().Get("keep_subtasks") == "true")
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+		ids := make([]string, len(deleted))
+		for i, t := range deleted {
+			ids[i] = t.ID
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "success", "deleted": ids})
 		
 	default:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -0,0 +1,43 @@
+package web
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/tkancf/mdtask/internal/errors"
+	"github.com/tkancf/mdtask/internal/ical"
+)
+
+// handleCalendar serves the tasks as an iCalendar feed for calendar apps to
+// subscribe to, filtered by ?tag=...&status=... (both can be repeated) and
+// including archived tasks with ?archived=true
+func (s *Server) handleCalendar(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	workflow := s.workflow()
+
+	selection := ical.Selection{Tags: params["tag"], Archived: params.Get("archived") == "true"}
+	for _, name := range params["status"] {
+		status, err := workflow.Parse(name)
+		if err != nil {
+			handleError(w, errors.ValidationError("status", err.Error()))
+			return
+		}
+		selection.Statuses = append(selection.Statuses, status)
+	}
+
+	tasks, err := s.repo.FindAll()
+	if err != nil {
+		handleError(w, errors.InternalError("Failed to load tasks", err))
+		return
+	}
+
+	// Encode before writing so errors still get an error response
+	var buf bytes.Buffer
+	if err := ical.NewEncoder(workflow).Encode(&buf, selection.Select(tasks, workflow)); err != nil {
+		handleError(w, errors.InternalError("Failed to encode calendar", err))
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="mdtask.ics"`)
+	w.Write(buf.Bytes())
+}
@@ -3,9 +3,11 @@ package web
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/tkancf/mdtask/internal/constants"
 	"github.com/tkancf/mdtask/internal/errors"
+	"github.com/tkancf/mdtask/internal/service"
 	"github.com/tkancf/mdtask/internal/task"
 )
 
@@ -67,18 +69,55 @@ func handleError(w http.ResponseWriter, err error) {
 	}
 }
 
-// getStatusFromForm gets the status from form value
-func getStatusFromForm(formStatus string) task.Status {
-	switch formStatus {
-	case "WIP":
-		return task.StatusWIP
-	case "WAIT":
-		return task.StatusWAIT
-	case "SCHE":
-		return task.StatusSCHE
-	case "DONE":
-		return task.StatusDONE
-	default:
-		return task.StatusTODO
+// updateError wraps a failed update as an internal error, except for
+// conflicts which are passed through so they are reported as such
+func updateError(message string, err error) error {
+	if errors.IsConflict(err) {
+		return err
 	}
-}
\ No newline at end of file
+	return errors.InternalError(message, err)
+}
+
+// formError reports validation errors such as a disallowed status change
+// as they are and anything else as invalid form data
+func formError(err error) error {
+	if errors.IsValidation(err) {
+		return err
+	}
+	return errors.ValidationError("form", "Invalid form data")
+}
+
+// parseIfMatch extracts the task version from an If-Match header.
+// An empty result means the request carries no precondition.
+func parseIfMatch(header string) string {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return ""
+	}
+	header = strings.TrimPrefix(header, "W/")
+	return strings.Trim(header, `"`)
+}
+
+// setETag exposes the task version as an ETag header
+func setETag(w http.ResponseWriter, t *task.Task) {
+	if t.Version != "" {
+		w.Header().Set("ETag", `"`+t.Version+`"`)
+	}
+}
+
+// setStatus moves the task to the named status if the workflow allows it
+// and records the change in its history
+func (s *Server) setStatus(t *task.Task, name string) error {
+	status, err := s.workflow().Parse(name)
+	if err != nil {
+		return errors.ValidationError("status", err.Error())
+	}
+	return s.service().ChangeStatus(t, status, time.Now())
+}
+
+// service returns the task service, which runs the configured hooks on
+// every change
+func (s *Server) service() *service.TaskService {
+	return service.NewTaskService(s.repo, s.config)
+}
+
@@ -0,0 +1,68 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/watcher"
+)
+
+// sseHeartbeatInterval keeps idle event streams from being closed by proxies
+const sseHeartbeatInterval = 30 * time.Second
+
+// EnableLiveUpdates streams task changes detected by w to browsers via /events
+func (s *Server) EnableLiveUpdates(w *watcher.Watcher) {
+	s.watcher = w
+}
+
+// handleEvents streams task change events as Server-Sent Events
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if s.watcher == nil {
+		http.Error(w, "Live updates are not enabled", http.StatusNotFound)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// The stream stays open far longer than the server's write timeout
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	events, cancel := s.watcher.Subscribe()
+	defer cancel()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: task\ndata: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package web
+
+import (
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/errors"
+	"github.com/tkancf/mdtask/internal/query"
+	"github.com/tkancf/mdtask/internal/report"
+)
+
+// Chart is a report rendered for the reports page
+type Chart struct {
+	Title string
+	SVG   template.HTML
+}
+
+// reportDays is the default length of the reports page
+const reportDays = 14
+
+// Size of the report charts
+const (
+	chartWidth  = 960
+	chartHeight = 320
+)
+
+// handleReports shows burndown, cumulative flow and throughput charts for
+// ?from=YYYY-MM-DD&to=YYYY-MM-DD&tag=..., by default over the last 14 days
+func (s *Server) handleReports(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	// Task times are read as UTC wall clock, so the days are too
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	to := today
+	if value := params.Get("to"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			handleError(w, errors.ValidationError("to", "expected a date as YYYY-MM-DD"))
+			return
+		}
+		to = parsed
+	}
+	from := to.AddDate(0, 0, 1-reportDays)
+	if value := params.Get("from"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			handleError(w, errors.ValidationError("from", "expected a date as YYYY-MM-DD"))
+			return
+		}
+		from = parsed
+	}
+
+	tasks, err := s.repo.FindAll()
+	if err != nil {
+		handleError(w, errors.InternalError("Failed to load tasks", err))
+		return
+	}
+	tag := params.Get("tag")
+	if tag != "" {
+		tasks = query.Filter(tasks, query.Tags([]string{tag}, nil, false), query.NewEnv(tasks, s.workflow().IsDone), true)
+	}
+
+	data := PageData{
+		Title: "Reports",
+		From:  from.Format("2006-01-02"),
+		To:    to.Format("2006-01-02"),
+		Tag:   tag,
+	}
+	for _, kind := range report.Kinds {
+		rep, err := report.Build(kind, tasks, s.workflow(), from, to, today)
+		if err != nil {
+			handleError(w, errors.ValidationError("range", err.Error()))
+			return
+		}
+		data.Charts = append(data.Charts, Chart{
+			Title: kind.Title(),
+			// The SVG is generated by report with all text escaped
+			SVG: template.HTML(rep.SVG(chartWidth, chartHeight)),
+		})
+	}
+
+	if err := s.templates.ExecuteTemplate(w, "reports.html", data); err != nil {
+		handleError(w, errors.InternalError("Failed to render template", err))
+	}
+}
@@ -3,32 +3,41 @@ package web
 import (
 	"time"
 
+	"github.com/tkancf/mdtask/internal/config"
 	"github.com/tkancf/mdtask/internal/constants"
+	"github.com/tkancf/mdtask/internal/service"
 	"github.com/tkancf/mdtask/internal/task"
 )
 
 type DashboardStats struct {
 	Total           int
-	TODO            int
-	WIP             int
-	WAIT            int
-	DONE            int
-	SCHE            int
+	ByStatus        map[string]int
 	CreatedToday    int
 	CompletedToday  int
 	UpdatedToday    int
 	OverdueTasks    int
 	UpcomingTasks   int
+	// Flow holds the flow metrics of the last flowWindowDays days
+	Flow *service.FlowMetrics
 }
 
+// flowWindowDays is the period the dashboard computes flow metrics over
+const flowWindowDays = 30
+
 // calculateDashboardStats calculates statistics for the dashboard
-func calculateDashboardStats(tasks []*task.Task) DashboardStats {
-	stats := DashboardStats{}
+func calculateDashboardStats(tasks []*task.Task, workflow *config.Workflow) DashboardStats {
+	stats := DashboardStats{ByStatus: make(map[string]int)}
 	now := time.Now()
 	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 	todayEnd := todayStart.AddDate(0, 0, 1)
+	stats.Flow = service.CalculateFlowMetrics(tasks, workflow, todayStart.AddDate(0, 0, 1-flowWindowDays), todayEnd)
 
 	for _, t := range tasks {
+		// Completions count for archived tasks too
+		if done := service.CompletedAt(t, workflow); done != nil && !done.Before(todayStart) && done.Before(todayEnd) {
+			stats.CompletedToday++
+		}
+
 		if t.IsArchived() {
 			continue
 		}
@@ -36,18 +45,7 @@ func calculateDashboardStats(tasks []*task.Task) DashboardStats {
 		stats.Total++
 
 		// Count by status
-		switch t.GetStatus() {
-		case task.StatusTODO:
-			stats.TODO++
-		case task.StatusWIP:
-			stats.WIP++
-		case task.StatusWAIT:
-			stats.WAIT++
-		case task.StatusSCHE:
-			stats.SCHE++
-		case task.StatusDONE:
-			stats.DONE++
-		}
+		stats.ByStatus[string(t.GetStatus())]++
 
 		// Count today's activities
 		if t.Created.After(todayStart) && t.Created.Before(todayEnd) {
@@ -56,9 +54,6 @@ func calculateDashboardStats(tasks []*task.Task) DashboardStats {
 		if t.Updated.After(todayStart) && t.Updated.Before(todayEnd) {
 			stats.UpdatedToday++
 		}
-		if t.GetStatus() == task.StatusDONE && t.Updated.After(todayStart) && t.Updated.Before(todayEnd) {
-			stats.CompletedToday++
-		}
 
 		// Check deadlines
 		if deadline := t.GetDeadline(); deadline != nil {
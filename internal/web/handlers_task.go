@@ -27,10 +27,15 @@ func (s *Server) parseTaskForm(r *http.Request, t *task.Task) error {
 		return err
 	}
 
-	// Parse tags
+	// Parse tags. The form has no status tag, so the current status is
+	// put back for the workflow check below.
+	from := t.GetStatus()
 	formTags := r.FormValue("tags")
 	additionalTags := r.Form["additional_tags"]
 	t.Tags = parseFormTags(formTags, additionalTags)
+	if from != "" {
+		t.SetStatus(from)
+	}
 
 	// Ensure mdtask tag
 	if !t.IsManagedTask() {
@@ -39,7 +44,9 @@ func (s *Server) parseTaskForm(r *http.Request, t *task.Task) error {
 
 	// Set status
 	if status := r.FormValue("status"); status != "" {
-		t.SetStatus(getStatusFromForm(status))
+		if err := s.setStatus(t, status); err != nil {
+			return err
+		}
 	}
 
 	// Set deadline
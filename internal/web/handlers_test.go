@@ -0,0 +1,146 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/config"
+	"github.com/tkancf/mdtask/internal/repository"
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+// newTestServer returns a server over a task directory in a temporary
+// directory
+func newTestServer(t *testing.T, cfg *config.Config) (*Server, *repository.TaskRepository) {
+	t.Helper()
+	repo := repository.NewTaskRepositoryWithConfig([]string{t.TempDir()}, cfg)
+	s, err := NewServer(repo, cfg, "0")
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	return s, repo
+}
+
+// postEdit submits the edit form of a task
+func postEdit(s *Server, t *task.Task, status task.Status) *httptest.ResponseRecorder {
+	form := url.Values{
+		"title":       {t.Title},
+		"description": {t.Description},
+		"content":     {t.Content},
+		"tags":        {strings.Join(t.UserTags(), ",")},
+		"status":      {string(status)},
+		"version":     {t.Version},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/edit/"+t.ID, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	s.handleEdit(rec, req)
+	return rec
+}
+
+func TestHandleEdit_Workflow(t *testing.T) {
+	// Tasks in progress can only be finished
+	cfg := &config.Config{Workflow: config.WorkflowConfig{
+		Transitions: map[string][]string{"TODO": {"DONE"}, "WIP": {"DONE"}},
+	}}
+
+	tests := []struct {
+		name     string
+		status   task.Status
+		wantCode int
+	}{
+		{"status unchanged", task.StatusWIP, http.StatusSeeOther},
+		{"allowed change", task.StatusDONE, http.StatusSeeOther},
+		{"change not allowed", task.StatusTODO, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo := newTestServer(t, cfg)
+			tk := &task.Task{Title: "Write docs", Tags: []string{"mdtask", "docs"}, Created: time.Now(), Updated: time.Now()}
+			tk.SetStatus(task.StatusWIP)
+			if _, err := repo.Create(tk); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			stored, _ := repo.FindByID(tk.ID)
+
+			rec := postEdit(s, stored, tt.status)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("edit returned %d: %s", rec.Code, rec.Body)
+			}
+
+			saved, _ := repo.FindByID(tk.ID)
+			want := tt.status
+			if tt.wantCode != http.StatusSeeOther {
+				want = task.StatusWIP
+			}
+			if saved.GetStatus() != want {
+				t.Errorf("status = %s, want %s", saved.GetStatus(), want)
+			}
+		})
+	}
+}
+
+func TestHandleEdit_KeepsStatusHistory(t *testing.T) {
+	cfg := &config.Config{}
+	s, repo := newTestServer(t, cfg)
+
+	finished := time.Now().Add(-48 * time.Hour).Truncate(time.Minute)
+	tk := &task.Task{Title: "Ship release", Tags: []string{"mdtask"}, Created: finished, Updated: finished}
+	tk.SetStatus(task.StatusTODO)
+	tk.ChangeStatus(task.StatusDONE, finished)
+	if _, err := repo.Create(tk); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	stored, _ := repo.FindByID(tk.ID)
+
+	// Renaming a done task is not a status change
+	stored.Title = "Ship release 1.0"
+	if rec := postEdit(s, stored, task.StatusDONE); rec.Code != http.StatusSeeOther {
+		t.Fatalf("edit returned %d: %s", rec.Code, rec.Body)
+	}
+
+	saved, _ := repo.FindByID(tk.ID)
+	if saved.Title != "Ship release 1.0" {
+		t.Errorf("title = %q", saved.Title)
+	}
+	if len(saved.StatusHistory) != 1 {
+		t.Errorf("status history = %+v, want the completion only", saved.StatusHistory)
+	}
+	isDone := cfg.GetWorkflow().IsDone
+	if at := saved.CompletedAt(isDone); at == nil || !at.Equal(finished) {
+		t.Errorf("CompletedAt() = %v, want %v", at, finished)
+	}
+}
+
+func TestHandleArchive_Subtasks(t *testing.T) {
+	s, repo := newTestServer(t, &config.Config{})
+
+	parent := &task.Task{Title: "Release", Tags: []string{"mdtask"}, Created: time.Now(), Updated: time.Now()}
+	if _, err := repo.Create(parent); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	child := &task.Task{Title: "Changelog", Tags: []string{"mdtask"}, Created: time.Now(), Updated: time.Now()}
+	child.SetParentID(parent.ID)
+	if _, err := repo.Create(child); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// Archiving again changes nothing
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		s.handleArchive(rec, httptest.NewRequest(http.MethodPost, "/archive/"+parent.ID, nil))
+		if rec.Code != http.StatusSeeOther {
+			t.Fatalf("archive returned %d: %s", rec.Code, rec.Body)
+		}
+	}
+	for _, id := range []string{parent.ID, child.ID} {
+		if saved, _ := repo.FindByID(id); !saved.IsArchived() {
+			t.Errorf("%s was not archived", saved.Title)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tkancf/mdtask/internal/errors"
+	"github.com/tkancf/mdtask/internal/repository"
+)
+
+// handleDelete moves a task and its subtasks to the trash; with the form
+// value keep_subtasks=true the subtasks are detached instead
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/delete/")
+	deleted, err := s.service().DeleteTask(id, r.FormValue("keep_subtasks") == "true")
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	for _, t := range deleted {
+		fmt.Printf("Deleted task %s\n", t.ID)
+	}
+	http.Redirect(w, r, "/tasks", http.StatusSeeOther)
+}
+
+// handleTrash lists the deleted tasks
+func (s *Server) handleTrash(w http.ResponseWriter, r *http.Request) {
+	entries, err := s.repo.Trash()
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	data := PageData{
+		Title:   "Trash",
+		Entries: entries,
+	}
+	if err := s.templates.ExecuteTemplate(w, "trash.html", data); err != nil {
+		handleError(w, errors.InternalError("Failed to render template", err))
+	}
+}
+
+// handleTrashRestore restores a deleted task with its deleted subtasks
+func (s *Server) handleTrashRestore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/trash/restore/")
+	restored, err := s.service().RestoreTask(id)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	for _, t := range restored {
+		fmt.Printf("Restored task %s\n", t.ID)
+	}
+	http.Redirect(w, r, "/task/"+id, http.StatusSeeOther)
+}
+
+// handleTrashEmpty permanently removes the deleted tasks
+func (s *Server) handleTrashEmpty(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	n, err := s.repo.EmptyTrash()
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	fmt.Printf("Removed %d task(s) from the trash\n", n)
+	http.Redirect(w, r, "/trash", http.StatusSeeOther)
+}
+
+// handleAPITrash returns the deleted tasks as JSON
+func (s *Server) handleAPITrash(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	entries, err := s.repo.Trash()
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	if entries == nil {
+		entries = []repository.TrashEntry{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
@@ -13,6 +13,8 @@ import (
 	"github.com/tkancf/mdtask/internal/config"
 	"github.com/tkancf/mdtask/internal/constants"
 	"github.com/tkancf/mdtask/internal/repository"
+	"github.com/tkancf/mdtask/internal/task"
+	"github.com/tkancf/mdtask/internal/watcher"
 )
 
 //go:embed templates/*
@@ -26,9 +28,16 @@ type Server struct {
 	templates *template.Template
 	config    *config.Config
 	port      string
+	watcher   *watcher.Watcher
 }
 
 func NewServer(repo repository.Repository, cfg *config.Config, port string) (*Server, error) {
+	s := &Server{
+		repo:   repo,
+		config: cfg,
+		port:   port,
+	}
+
 	funcMap := template.FuncMap{
 		"now": time.Now,
 		"eq": func(a, b interface{}) bool {
@@ -43,6 +52,61 @@ func NewServer(repo repository.Repository, cfg *config.Config, port string) (*Se
 		"hasPrefix": func(s, prefix string) bool {
 			return strings.HasPrefix(s, prefix)
 		},
+		"fieldString": task.FieldString,
+		// recurrence returns the task's rule as text, or a note if it
+		// cannot be parsed
+		"recurrence": func(t *task.Task) string {
+			rule, err := t.GetRecurrence()
+			if err != nil {
+				return "invalid rule"
+			}
+			if rule == nil {
+				return ""
+			}
+			return rule.String()
+		},
+		// timeSpent sums up the task's clock entries, or returns "" if no
+		// time was logged
+		"span": task.FormatSpan,
+		"timeSpent": func(t *task.Task) string {
+			entries := t.GetClockEntries()
+			if len(entries) == 0 {
+				return ""
+			}
+			spent := fmt.Sprintf("%s in %d entries", task.FormatDuration(t.TimeSpent(time.Now())), len(entries))
+			if len(entries) == 1 {
+				spent = fmt.Sprintf("%s in 1 entry", task.FormatDuration(t.TimeSpent(time.Now())))
+			}
+			if running := t.RunningClock(); running != nil {
+				spent += fmt.Sprintf(", running since %s", running.Start.Format("2006-01-02 15:04"))
+			}
+			return spent
+		},
+		// Status helpers read the workflow on every call so that a
+		// reloaded config is picked up
+		"statuses": func() []config.StatusConfig {
+			return s.workflow().Statuses()
+		},
+		// statusChoices lists the statuses a task can be set to; new tasks
+		// may start in any status
+		"statusChoices": func(t *task.Task) []config.StatusConfig {
+			workflow := s.workflow()
+			if t.ID == "" {
+				return workflow.Statuses()
+			}
+			var choices []config.StatusConfig
+			for _, status := range workflow.NextStatuses(t.GetStatus()) {
+				sc, _ := workflow.Lookup(status)
+				choices = append(choices, sc)
+			}
+			return choices
+		},
+		"statusLabel": func(status interface{}) string {
+			return s.workflow().Label(task.Status(fmt.Sprint(status)))
+		},
+		"statusColor": func(status interface{}) string {
+			return s.workflow().Color(task.Status(fmt.Sprint(status)))
+		},
 	}
 
 	tmpl, err := template.New("").Funcs(funcMap).ParseFS(templateFS, "templates/*.html")
@@ -50,12 +114,14 @@ func NewServer(repo repository.Repository, cfg *config.Config, port string) (*Se
 		return nil, fmt.Errorf("failed to parse templates: %w", err)
 	}
 
-	return &Server{
-		repo:      repo,
-		templates: tmpl,
-		config:    cfg,
-		port:      port,
-	}, nil
+	s.templates = tmpl
+
+	return s, nil
+}
+
+// workflow returns the status workflow of the current config
+func (s *Server) workflow() *config.Workflow {
+	return s.config.GetWorkflow()
 }
 
 func (s *Server) Start() error {
@@ -68,14 +134,22 @@ func (s *Server) Start() error {
 	mux.HandleFunc("/kanban", s.handleKanban)
 	mux.HandleFunc("/status/", s.handleByStatus)
 	mux.HandleFunc("/search", s.handleSearch)
+	mux.HandleFunc("/reports", s.handleReports)
+	mux.HandleFunc("/calendar.ics", s.handleCalendar)
 	mux.HandleFunc("/task/", s.handleTask)
 	mux.HandleFunc("/new", s.handleNew)
 	mux.HandleFunc("/edit/", s.handleEdit)
 	mux.HandleFunc("/archive/", s.handleArchive)
+	mux.HandleFunc("/delete/", s.handleDelete)
+	mux.HandleFunc("/trash", s.handleTrash)
+	mux.HandleFunc("/trash/restore/", s.handleTrashRestore)
+	mux.HandleFunc("/trash/empty", s.handleTrashEmpty)
+	mux.HandleFunc("/events", s.handleEvents)
 	
 	// API routes
 	mux.HandleFunc("/api/tasks", s.handleAPITasks)
 	mux.HandleFunc("/api/task/", s.handleAPITask)
+	mux.HandleFunc("/api/trash", s.handleAPITrash)
 
 	// Static files
 	mux.Handle("/static/", http.FileServer(http.FS(staticFS)))
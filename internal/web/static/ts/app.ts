@@ -2,6 +2,7 @@
 document.addEventListener('DOMContentLoaded', (): void => {
     // Add any interactive features here
     initializeEventListeners();
+    initializeLiveUpdates();
 });
 
 function initializeEventListeners(): void {
@@ -49,4 +50,33 @@ function initializeEventListeners(): void {
             }
         });
     });
-}
\ No newline at end of file
+}
+
+// Reload the page when tasks change on disk, unless the user is editing something
+function initializeLiveUpdates(): void {
+    if (!('EventSource' in window)) {
+        return;
+    }
+
+    let reloadTimer: number | undefined;
+    const source = new EventSource('/events');
+    source.addEventListener('task', () => {
+        window.clearTimeout(reloadTimer);
+        reloadTimer = window.setTimeout(() => {
+            if (!isEditing()) {
+                window.location.reload();
+            }
+        }, 500);
+    });
+}
+
+// isEditing reports whether the page holds input a reload would lose: the
+// edit modal or a form marked with data-editing. Action buttons such as
+// delete or restore are posted from plain forms and do not count.
+function isEditing(): boolean {
+    const modal = document.getElementById('editModal');
+    if (modal && !modal.classList.contains('hidden')) {
+        return true;
+    }
+    return document.querySelector('form[data-editing]') !== null;
+}
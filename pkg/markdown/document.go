@@ -0,0 +1,434 @@
+package markdown
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/tkancf/mdtask/internal/constants"
+	"github.com/tkancf/mdtask/internal/task"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultIndent matches the indentation used by WriteTaskFile
+const defaultIndent = 4
+
+// Document is a parsed task file that keeps the original YAML node tree of
+// its front matter. Writing a task back through a Document only touches the
+// fields mdtask manages, so unknown keys, key order and comments survive and
+// edits show up as minimal diffs.
+type Document struct {
+	node           *yaml.Node // mapping node of the front matter
+	rawFrontMatter string
+	body           string // body as exposed in task.Task.Content
+	rawBody        string // everything after the closing delimiter, verbatim
+}
+
+// ParseDocument parses the front matter and body of a task file
+func ParseDocument(content []byte) (*Document, error) {
+	frontMatter, rawBody, err := splitFrontMatter(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract front matter: %w", err)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(frontMatter), &root); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML front matter: %w", err)
+	}
+
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		node = root.Content[0]
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("failed to parse YAML front matter: expected a mapping")
+	}
+
+	return &Document{
+		node:           node,
+		rawFrontMatter: frontMatter,
+		body:           strings.TrimLeft(rawBody, "\n"),
+		rawBody:        rawBody,
+	}, nil
+}
+
+// PatchTaskFile renders t on top of the original file content. Only the
+// managed front matter fields that differ from the original are rewritten.
+// If original cannot be parsed, the task is written from scratch.
+func PatchTaskFile(original []byte, t *task.Task) ([]byte, error) {
+	doc, err := ParseDocument(original)
+	if err != nil {
+		return WriteTaskFile(t)
+	}
+	return doc.Render(t)
+}
+
+// Render returns the file content for t, reusing the original front matter
+// and body wherever they are unchanged
+func (d *Document) Render(t *task.Task) ([]byte, error) {
+	frontMatter := d.rawFrontMatter
+	if d.apply(t) {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(d.indent())
+		if err := enc.Encode(d.node); err != nil {
+			return nil, fmt.Errorf("failed to marshal front matter: %w", err)
+		}
+		enc.Close()
+		frontMatter = buf.String()
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.WriteString(frontMatter)
+	if frontMatter != "" && !strings.HasSuffix(frontMatter, "\n") {
+		buf.WriteString("\n")
+	}
+	buf.WriteString("---\n")
+
+	if t.Content == d.body {
+		buf.WriteString(d.rawBody)
+		return buf.Bytes(), nil
+	}
+
+	buf.WriteString("\n")
+	content := strings.TrimSpace(t.Content)
+	if content != "" {
+		buf.WriteString(content)
+		buf.WriteString("\n")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// apply patches the managed fields into the node tree and reports whether
+// anything changed. Keys missing from the original are appended in the order
+// WriteTaskFile uses, unless their value is empty.
+func (d *Document) apply(t *task.Task) bool {
+	changed := false
+	changed = d.setString("id", t.ID) || changed
+	changed = d.setStrings("aliases", t.Aliases) || changed
+	changed = d.setStrings("tags", t.Tags) || changed
+	changed = d.setString("created", formatTime(d.lookup("created"), t.Created.Format(constants.DateTimeFormat))) || changed
+	changed = d.setString("description", t.Description) || changed
+	changed = d.setString("title", t.Title) || changed
+	changed = d.setString("updated", formatTime(d.lookup("updated"), t.Updated.Format(constants.DateTimeFormat))) || changed
+	changed = d.setStatusHistory(t.StatusHistory) || changed
+	changed = d.setFields(t.Fields) || changed
+	return changed
+}
+
+// fields returns the custom front matter keys, or nil if there are none
+func (d *Document) fields() map[string]interface{} {
+	var fields map[string]interface{}
+	for i := 0; i+1 < len(d.node.Content); i += 2 {
+		key := d.node.Content[i].Value
+		if task.IsReservedField(key) {
+			continue
+		}
+		if fields == nil {
+			fields = make(map[string]interface{})
+		}
+		fields[key] = nodeValue(d.node.Content[i+1])
+	}
+	return fields
+}
+
+// setFields writes the custom fields back. Unchanged values keep their
+// original node, keys missing from fields are removed and new keys are
+// appended in sorted order. A nil map leaves the custom keys alone.
+func (d *Document) setFields(fields map[string]interface{}) bool {
+	if fields == nil {
+		return false
+	}
+
+	changed := false
+	seen := make(map[string]bool)
+	content := make([]*yaml.Node, 0, len(d.node.Content))
+	for i := 0; i+1 < len(d.node.Content); i += 2 {
+		keyNode, valueNode := d.node.Content[i], d.node.Content[i+1]
+		key := keyNode.Value
+		if task.IsReservedField(key) {
+			content = append(content, keyNode, valueNode)
+			continue
+		}
+
+		value, ok := fields[key]
+		if !ok {
+			changed = true
+			continue
+		}
+		seen[key] = true
+
+		if !equalValues(nodeValue(valueNode), value) {
+			replacement, err := fieldNode(value)
+			if err == nil {
+				replacement.LineComment = valueNode.LineComment
+				replacement.FootComment = valueNode.FootComment
+				valueNode = replacement
+				changed = true
+			}
+		}
+		content = append(content, keyNode, valueNode)
+	}
+	d.node.Content = content
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		if !seen[key] && !task.IsReservedField(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		valueNode, err := fieldNode(fields[key])
+		if err != nil {
+			continue
+		}
+		d.appendKey(key, valueNode)
+		changed = true
+	}
+
+	return changed
+}
+
+// lookup returns the value node for key, or nil if the key is absent
+func (d *Document) lookup(key string) *yaml.Node {
+	for i := 0; i+1 < len(d.node.Content); i += 2 {
+		if d.node.Content[i].Value == key {
+			return d.node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// appendKey adds a new key/value pair at the end of the mapping
+func (d *Document) appendKey(key string, value *yaml.Node) {
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+	d.node.Content = append(d.node.Content, keyNode, value)
+}
+
+func (d *Document) setString(key, value string) bool {
+	node := d.lookup(key)
+	if node == nil {
+		if value == "" {
+			return false
+		}
+		d.appendKey(key, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value})
+		return true
+	}
+
+	var current string
+	if node.Kind == yaml.ScalarNode && node.Decode(&current) == nil && current == value {
+		return false
+	}
+
+	// Keep explicit quoting styles; the encoder quotes plain values when needed
+	style := node.Style & (yaml.DoubleQuotedStyle | yaml.SingleQuotedStyle)
+	if node.Kind != yaml.ScalarNode {
+		style = 0
+	}
+	node.Kind = yaml.ScalarNode
+	node.Tag = "!!str"
+	node.Value = value
+	node.Style = style
+	node.Content = nil
+	return true
+}
+
+func (d *Document) setStrings(key string, values []string) bool {
+	node := d.lookup(key)
+	if node == nil {
+		if len(values) == 0 {
+			return false
+		}
+		seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, v := range values {
+			seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v})
+		}
+		d.appendKey(key, seq)
+		return true
+	}
+
+	var current []string
+	if node.Kind == yaml.SequenceNode && node.Decode(&current) == nil && equalStrings(current, values) {
+		return false
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" && len(values) == 0 {
+		return false
+	}
+
+	// Reuse item nodes for values that are kept so their comments survive
+	var existing []*yaml.Node
+	style := yaml.Style(0)
+	if node.Kind == yaml.SequenceNode {
+		existing = node.Content
+		style = node.Style & yaml.FlowStyle
+	}
+	used := make([]bool, len(existing))
+	content := make([]*yaml.Node, 0, len(values))
+	for _, v := range values {
+		item := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}
+		for i, e := range existing {
+			if !used[i] && e.Kind == yaml.ScalarNode && e.Value == v {
+				used[i] = true
+				item = e
+				break
+			}
+		}
+		content = append(content, item)
+	}
+
+	node.Kind = yaml.SequenceNode
+	node.Tag = "!!seq"
+	node.Value = ""
+	node.Style = style
+	node.Content = content
+	return true
+}
+
+// indent guesses the indentation of the original front matter from its
+// first nested block so that re-encoding keeps the file's layout
+func (d *Document) indent() int {
+	for i := 0; i+1 < len(d.node.Content); i += 2 {
+		key, value := d.node.Content[i], d.node.Content[i+1]
+		if value.Style&yaml.FlowStyle != 0 || len(value.Content) == 0 {
+			continue
+		}
+
+		var n int
+		switch value.Kind {
+		case yaml.SequenceNode:
+			// Items start after the "- " indicator
+			n = value.Content[0].Column - 2 - key.Column
+		case yaml.MappingNode:
+			n = value.Content[0].Column - key.Column
+		default:
+			continue
+		}
+
+		if n < 2 {
+			n = 2
+		}
+		return n
+	}
+	return defaultIndent
+}
+
+// formatTime keeps the original timestamp text when it denotes the same
+// minute as formatted, e.g. a date-only value that was never changed
+func formatTime(node *yaml.Node, formatted string) string {
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return formatted
+	}
+	if parsed, ok := parseTime(node.Value); ok && parsed.Format(constants.DateTimeFormat) == formatted {
+		return node.Value
+	}
+	return formatted
+}
+
+// nodeValue decodes a custom field value. Timestamps are kept as the text
+// that was written so that they survive a JSON round trip unchanged.
+func nodeValue(node *yaml.Node) interface{} {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return nodeValue(node.Alias)
+	case yaml.SequenceNode:
+		values := make([]interface{}, 0, len(node.Content))
+		for _, item := range node.Content {
+			values = append(values, nodeValue(item))
+		}
+		return values
+	case yaml.MappingNode:
+		values := make(map[string]interface{}, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			values[node.Content[i].Value] = nodeValue(node.Content[i+1])
+		}
+		return values
+	}
+
+	if node.ShortTag() == "!!timestamp" {
+		return node.Value
+	}
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return node.Value
+	}
+	return value
+}
+
+// fieldNode encodes a custom field value. Strings that look like dates are
+// written as plain timestamps rather than quoted strings.
+func fieldNode(value interface{}) (*yaml.Node, error) {
+	if s, ok := value.(string); ok {
+		node := &yaml.Node{Kind: yaml.ScalarNode, Value: s}
+		if node.ShortTag() == "!!timestamp" {
+			node.Tag = "!!timestamp"
+			return node, nil
+		}
+	}
+
+	var node yaml.Node
+	if err := node.Encode(value); err != nil {
+		return nil, fmt.Errorf("failed to encode field value: %w", err)
+	}
+	return &node, nil
+}
+
+// equalValues compares decoded field values, treating numbers of different
+// types as equal when they have the same value
+func equalValues(a, b interface{}) bool {
+	return reflect.DeepEqual(normalizeValue(a), normalizeValue(b))
+}
+
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return string(v)
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = normalizeValue(item)
+		}
+		return result
+	case []string:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = item
+		}
+		return result
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			result[k] = normalizeValue(item)
+		}
+		return result
+	}
+	return value
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,263 @@
+package markdown
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const obsidianNote = `---
+# Managed by mdtask and Obsidian
+cssclass: wide
+id: task/20240101120000
+title: Original title
+tags:
+  - mdtask
+  - mdtask/status/TODO # current status
+  - project
+publish: true
+created: 2024-01-01
+updated: 2024-01-01 12:00
+project:
+  name: Website
+  owner: alice
+---
+
+# Original title
+
+Body text.
+`
+
+func TestPatchTaskFile_Unchanged(t *testing.T) {
+	parsed, err := ParseTaskFile([]byte(obsidianNote))
+	if err != nil {
+		t.Fatalf("ParseTaskFile() error = %v", err)
+	}
+
+	got, err := PatchTaskFile([]byte(obsidianNote), parsed)
+	if err != nil {
+		t.Fatalf("PatchTaskFile() error = %v", err)
+	}
+
+	if string(got) != obsidianNote {
+		t.Errorf("unchanged task should produce identical file, got:\n%s", got)
+	}
+}
+
+func TestPatchTaskFile_PreservesUnknownKeys(t *testing.T) {
+	parsed, err := ParseTaskFile([]byte(obsidianNote))
+	if err != nil {
+		t.Fatalf("ParseTaskFile() error = %v", err)
+	}
+
+	parsed.Title = "New title"
+	parsed.SetStatus("WIP")
+	parsed.Updated = time.Date(2024, 2, 3, 4, 5, 0, 0, time.UTC)
+
+	got, err := PatchTaskFile([]byte(obsidianNote), parsed)
+	if err != nil {
+		t.Fatalf("PatchTaskFile() error = %v", err)
+	}
+	out := string(got)
+
+	for _, want := range []string{
+		"# Managed by mdtask and Obsidian",
+		"cssclass: wide",
+		"publish: true",
+		"  name: Website",
+		"  owner: alice",
+		"title: New title",
+		"  - mdtask/status/WIP",
+		"  - project",
+		"created: 2024-01-01\n",
+		"updated: 2024-02-03 04:05",
+		"\n# Original title\n\nBody text.\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	// Key order is kept
+	order := []string{"cssclass:", "id:", "title:", "tags:", "publish:", "created:", "updated:", "project:"}
+	last := -1
+	for _, key := range order {
+		idx := strings.Index(out, "\n"+key)
+		if idx < last {
+			t.Errorf("key %s moved, got:\n%s", key, out)
+		}
+		last = idx
+	}
+
+	// The result parses back to the updated task
+	reparsed, err := ParseTaskFile(got)
+	if err != nil {
+		t.Fatalf("ParseTaskFile() of patched file error = %v", err)
+	}
+	if reparsed.Title != "New title" || reparsed.GetStatus() != "WIP" {
+		t.Errorf("unexpected reparsed task: %+v", reparsed)
+	}
+}
+
+func TestPatchTaskFile_AddsMissingKeys(t *testing.T) {
+	original := "---\ntitle: Note\n---\nBody\n"
+	parsed, err := ParseTaskFile([]byte(original))
+	if err != nil {
+		t.Fatalf("ParseTaskFile() error = %v", err)
+	}
+
+	parsed.ID = "task/20240101120000"
+	parsed.Tags = []string{"mdtask", "mdtask/status/TODO"}
+	parsed.Created = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	parsed.Updated = parsed.Created
+
+	got, err := PatchTaskFile([]byte(original), parsed)
+	if err != nil {
+		t.Fatalf("PatchTaskFile() error = %v", err)
+	}
+	out := string(got)
+
+	if !strings.HasPrefix(out, "---\ntitle: Note\nid: task/20240101120000\n") {
+		t.Errorf("expected new keys after existing ones, got:\n%s", out)
+	}
+	if strings.Contains(out, "aliases") || strings.Contains(out, "description") {
+		t.Errorf("empty fields should not be added, got:\n%s", out)
+	}
+	if !strings.HasSuffix(out, "---\nBody\n") {
+		t.Errorf("body should be kept verbatim, got:\n%s", out)
+	}
+}
+
+func TestPatchTaskFile_ContentChange(t *testing.T) {
+	parsed, err := ParseTaskFile([]byte(obsidianNote))
+	if err != nil {
+		t.Fatalf("ParseTaskFile() error = %v", err)
+	}
+	parsed.Content = "Replaced body"
+
+	got, err := PatchTaskFile([]byte(obsidianNote), parsed)
+	if err != nil {
+		t.Fatalf("PatchTaskFile() error = %v", err)
+	}
+
+	if !strings.HasSuffix(string(got), "updated: 2024-01-01 12:00\nproject:\n  name: Website\n  owner: alice\n---\n\nReplaced body\n") {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+}
+
+func TestPatchTaskFile_InvalidOriginal(t *testing.T) {
+	tk, err := ParseTaskFile([]byte(obsidianNote))
+	if err != nil {
+		t.Fatalf("ParseTaskFile() error = %v", err)
+	}
+
+	got, err := PatchTaskFile([]byte("no front matter"), tk)
+	if err != nil {
+		t.Fatalf("PatchTaskFile() error = %v", err)
+	}
+	want, _ := WriteTaskFile(tk)
+	if string(got) != string(want) {
+		t.Errorf("expected fallback to WriteTaskFile, got:\n%s", got)
+	}
+}
+
+func TestParseTaskFile_Fields(t *testing.T) {
+	parsed, err := ParseTaskFile([]byte(obsidianNote))
+	if err != nil {
+		t.Fatalf("ParseTaskFile() error = %v", err)
+	}
+
+	want := map[string]interface{}{
+		"cssclass": "wide",
+		"publish":  true,
+		"project":  map[string]interface{}{"name": "Website", "owner": "alice"},
+	}
+	if !reflect.DeepEqual(parsed.Fields, want) {
+		t.Errorf("Fields = %#v, want %#v", parsed.Fields, want)
+	}
+
+	managed, err := ParseTaskFile([]byte("---\nid: task/1\ntitle: T\n---\n"))
+	if err != nil {
+		t.Fatalf("ParseTaskFile() error = %v", err)
+	}
+	if managed.Fields != nil {
+		t.Errorf("expected no fields, got %#v", managed.Fields)
+	}
+}
+
+func TestPatchTaskFile_Fields(t *testing.T) {
+	parsed, err := ParseTaskFile([]byte(obsidianNote))
+	if err != nil {
+		t.Fatalf("ParseTaskFile() error = %v", err)
+	}
+
+	parsed.SetField("estimate", "3h")
+	parsed.SetField("due", "2024-03-01")
+	parsed.SetField("points", 5)
+	parsed.DeleteField("publish")
+
+	got, err := PatchTaskFile([]byte(obsidianNote), parsed)
+	if err != nil {
+		t.Fatalf("PatchTaskFile() error = %v", err)
+	}
+	out := string(got)
+
+	if strings.Contains(out, "publish") {
+		t.Errorf("deleted field should be removed, got:\n%s", out)
+	}
+	if !strings.Contains(out, "project:\n  name: Website\n  owner: alice\ndue: 2024-03-01\nestimate: 3h\npoints: 5\n---") {
+		t.Errorf("new fields should be appended in sorted order, got:\n%s", out)
+	}
+	if !strings.Contains(out, "  - mdtask/status/TODO # current status") {
+		t.Errorf("comments should be kept, got:\n%s", out)
+	}
+
+	reparsed, err := ParseTaskFile(got)
+	if err != nil {
+		t.Fatalf("ParseTaskFile() of patched file error = %v", err)
+	}
+	if v, _ := reparsed.GetField("due"); v != "2024-03-01" {
+		t.Errorf("due = %#v, want date text", v)
+	}
+	if v, _ := reparsed.GetField("points"); v != 5 {
+		t.Errorf("points = %#v, want 5", v)
+	}
+}
+
+func TestPatchTaskFile_FieldsNumberTypes(t *testing.T) {
+	original := "---\nid: task/1\ntitle: T\ncreated: 2024-01-01 12:00\nupdated: 2024-01-01 12:00\npoints: 5 # story points\n---\n"
+	parsed, err := ParseTaskFile([]byte(original))
+	if err != nil {
+		t.Fatalf("ParseTaskFile() error = %v", err)
+	}
+
+	// Values decoded from JSON, e.g. the index, are float64
+	parsed.Fields["points"] = float64(5)
+	got, err := PatchTaskFile([]byte(original), parsed)
+	if err != nil {
+		t.Fatalf("PatchTaskFile() error = %v", err)
+	}
+	if string(got) != original {
+		t.Errorf("equal number should not rewrite the file, got:\n%s", got)
+	}
+}
+
+func TestWriteTaskFile_Fields(t *testing.T) {
+	tk, err := ParseTaskFile([]byte(obsidianNote))
+	if err != nil {
+		t.Fatalf("ParseTaskFile() error = %v", err)
+	}
+
+	got, err := WriteTaskFile(tk)
+	if err != nil {
+		t.Fatalf("WriteTaskFile() error = %v", err)
+	}
+	reparsed, err := ParseTaskFile(got)
+	if err != nil {
+		t.Fatalf("ParseTaskFile() error = %v", err)
+	}
+	if !reflect.DeepEqual(reparsed.Fields, tk.Fields) {
+		t.Errorf("Fields = %#v, want %#v", reparsed.Fields, tk.Fields)
+	}
+}
@@ -0,0 +1,137 @@
+package markdown
+
+import (
+	"github.com/tkancf/mdtask/internal/constants"
+	"github.com/tkancf/mdtask/internal/task"
+	"gopkg.in/yaml.v3"
+)
+
+// statusHistoryKey is the front matter key holding the status changes of a
+// task, one flow mapping per change:
+//
+//	status_history:
+//	    - {at: '2025-06-20 09:00', from: TODO, to: WIP}
+//	    - {at: '2025-06-23 17:30', from: WIP, to: DONE}
+const statusHistoryKey = "status_history"
+
+// statusChangeYAML is a status change as written in the front matter
+type statusChangeYAML struct {
+	At   string `yaml:"at"`
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+// statusHistory returns the recorded status changes, skipping entries that
+// cannot be parsed, or nil if the front matter has none
+func (d *Document) statusHistory() []task.StatusChange {
+	node := d.lookup(statusHistoryKey)
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	history := make([]task.StatusChange, 0, len(node.Content))
+	for _, item := range node.Content {
+		if change, ok := decodeStatusChange(item); ok {
+			history = append(history, change)
+		}
+	}
+	return history
+}
+
+func decodeStatusChange(node *yaml.Node) (task.StatusChange, bool) {
+	var raw statusChangeYAML
+	if node.Kind != yaml.MappingNode || node.Decode(&raw) != nil || raw.To == "" {
+		return task.StatusChange{}, false
+	}
+	at, ok := parseTime(raw.At)
+	if !ok {
+		return task.StatusChange{}, false
+	}
+	return task.StatusChange{From: task.Status(raw.From), To: task.Status(raw.To), At: at}, true
+}
+
+// setStatusHistory writes the status changes back, keeping the nodes of
+// entries that did not change and of entries mdtask cannot read. A nil
+// history leaves the key alone.
+func (d *Document) setStatusHistory(history []task.StatusChange) bool {
+	if history == nil {
+		return false
+	}
+
+	node := d.lookup(statusHistoryKey)
+	var existing []*yaml.Node
+	if node != nil && node.Kind == yaml.SequenceNode {
+		existing = node.Content
+	}
+
+	changed := node != nil && node.Kind != yaml.SequenceNode
+	content := make([]*yaml.Node, 0, len(history))
+	next := 0
+	for _, item := range existing {
+		current, ok := decodeStatusChange(item)
+		if !ok {
+			content = append(content, item)
+			continue
+		}
+		if next < len(history) && equalStatusChange(current, history[next]) {
+			content = append(content, item)
+			next++
+			continue
+		}
+		changed = true
+	}
+	for _, change := range history[next:] {
+		content = append(content, statusChangeNode(change))
+		changed = true
+	}
+	if !changed {
+		return false
+	}
+
+	if len(content) == 0 {
+		d.removeKey(statusHistoryKey)
+		return node != nil
+	}
+	if node == nil {
+		node = &yaml.Node{}
+		d.appendKey(statusHistoryKey, node)
+	}
+	node.Kind = yaml.SequenceNode
+	node.Tag = "!!seq"
+	node.Value = ""
+	node.Style = 0
+	node.Content = content
+	return true
+}
+
+// equalStatusChange compares changes at the minute precision of the file
+func equalStatusChange(a, b task.StatusChange) bool {
+	return a.From == b.From && a.To == b.To &&
+		a.At.Format(constants.DateTimeFormat) == b.At.Format(constants.DateTimeFormat)
+}
+
+func statusChangeNode(change task.StatusChange) *yaml.Node {
+	scalar := func(value string) *yaml.Node {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	}
+	return &yaml.Node{
+		Kind:  yaml.MappingNode,
+		Tag:   "!!map",
+		Style: yaml.FlowStyle,
+		Content: []*yaml.Node{
+			scalar("at"), scalar(change.At.Format(constants.DateTimeFormat)),
+			scalar("from"), scalar(string(change.From)),
+			scalar("to"), scalar(string(change.To)),
+		},
+	}
+}
+
+// removeKey deletes a key/value pair from the mapping
+func (d *Document) removeKey(key string) {
+	for i := 0; i+1 < len(d.node.Content); i += 2 {
+		if d.node.Content[i].Value == key {
+			d.node.Content = append(d.node.Content[:i], d.node.Content[i+2:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tkancf/mdtask/internal/task"
+)
+
+const historyNote = `---
+id: task/20250620090000
+title: Ship release
+tags:
+  - mdtask
+  - mdtask/status/WIP
+created: 2025-06-20 09:00
+updated: 2025-06-20 10:00
+status_history:
+  - {at: '2025-06-20 10:00', from: TODO, to: WIP} # picked up
+  - {at: 'not a date', from: WIP, to: DONE}
+---
+
+Body.
+`
+
+func TestParseTaskFile_StatusHistory(t *testing.T) {
+	parsed, err := ParseTaskFile([]byte(historyNote))
+	if err != nil {
+		t.Fatalf("ParseTaskFile() error = %v", err)
+	}
+
+	want := task.StatusChange{From: task.StatusTODO, To: task.StatusWIP, At: time.Date(2025, 6, 20, 10, 0, 0, 0, time.UTC)}
+	if len(parsed.StatusHistory) != 1 || parsed.StatusHistory[0] != want {
+		t.Errorf("StatusHistory = %v, want [%v]", parsed.StatusHistory, want)
+	}
+	if _, ok := parsed.GetField("status_history"); ok {
+		t.Error("status_history should not be a custom field")
+	}
+}
+
+func TestPatchTaskFile_AppendsStatusHistory(t *testing.T) {
+	parsed, err := ParseTaskFile([]byte(historyNote))
+	if err != nil {
+		t.Fatalf("ParseTaskFile() error = %v", err)
+	}
+
+	// Unchanged history leaves the file alone, unparsable entries included
+	got, err := PatchTaskFile([]byte(historyNote), parsed)
+	if err != nil {
+		t.Fatalf("PatchTaskFile() error = %v", err)
+	}
+	if string(got) != historyNote {
+		t.Errorf("unchanged history should produce identical file, got:\n%s", got)
+	}
+
+	parsed.StatusHistory = append(parsed.StatusHistory, task.StatusChange{
+		From: task.StatusWIP,
+		To:   task.StatusDONE,
+		At:   time.Date(2025, 6, 21, 17, 30, 0, 0, time.UTC),
+	})
+	got, err = PatchTaskFile([]byte(historyNote), parsed)
+	if err != nil {
+		t.Fatalf("PatchTaskFile() error = %v", err)
+	}
+	if !strings.Contains(string(got), "{at: '2025-06-20 10:00', from: TODO, to: WIP} # picked up") {
+		t.Errorf("kept entries should keep their comments, got:\n%s", got)
+	}
+	if !strings.Contains(string(got), "{at: '2025-06-21 17:30', from: WIP, to: DONE}") {
+		t.Errorf("new entry missing, got:\n%s", got)
+	}
+
+	reparsed, err := ParseTaskFile(got)
+	if err != nil {
+		t.Fatalf("ParseTaskFile() error = %v", err)
+	}
+	if len(reparsed.StatusHistory) != 2 {
+		t.Errorf("StatusHistory = %v, want 2 entries", reparsed.StatusHistory)
+	}
+}
+
+func TestWriteTaskFile_StatusHistory(t *testing.T) {
+	tk := &task.Task{ID: "task/1", Title: "New", Tags: []string{"mdtask", "mdtask/status/TODO"}}
+	data, err := WriteTaskFile(tk)
+	if err != nil {
+		t.Fatalf("WriteTaskFile() error = %v", err)
+	}
+	if strings.Contains(string(data), "status_history") {
+		t.Errorf("a task without history should not write the key, got:\n%s", data)
+	}
+
+	tk.ChangeStatus(task.StatusWIP, time.Date(2025, 6, 20, 9, 0, 0, 0, time.UTC))
+	data, err = WriteTaskFile(tk)
+	if err != nil {
+		t.Fatalf("WriteTaskFile() error = %v", err)
+	}
+	if !strings.Contains(string(data), "status_history:\n    - {at: '2025-06-20 09:00', from: TODO, to: WIP}\n") {
+		t.Errorf("WriteTaskFile() =\n%s", data)
+	}
+}
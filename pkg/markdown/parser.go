@@ -8,7 +8,6 @@ import (
 
 	"github.com/tkancf/mdtask/internal/constants"
 	"github.com/tkancf/mdtask/internal/task"
-	"gopkg.in/yaml.v3"
 )
 
 type FrontMatter struct {
@@ -22,33 +21,25 @@ type FrontMatter struct {
 }
 
 func ParseTaskFile(content []byte) (*task.Task, error) {
-	frontMatter, body, err := extractFrontMatter(content)
+	doc, err := ParseDocument(content)
 	if err != nil {
-		return nil, fmt.Errorf("failed to extract front matter: %w", err)
+		return nil, err
 	}
 
 	var fm FrontMatter
-	if err := yaml.Unmarshal([]byte(frontMatter), &fm); err != nil {
+	if err := doc.node.Decode(&fm); err != nil {
 		return nil, fmt.Errorf("failed to parse YAML front matter: %w", err)
 	}
 
 	// Parse time strings
-	created, err := time.Parse(constants.DateTimeFormat, fm.Created)
-	if err != nil {
-		// Try without time
-		created, err = time.Parse(constants.DateFormat, fm.Created)
-		if err != nil {
-			created = time.Now()
-		}
+	created, ok := parseTime(fm.Created)
+	if !ok {
+		created = time.Now()
 	}
-	
-	updated, err := time.Parse(constants.DateTimeFormat, fm.Updated)
-	if err != nil {
-		// Try without time
-		updated, err = time.Parse(constants.DateFormat, fm.Updated)
-		if err != nil {
-			updated = time.Now()
-		}
+
+	updated, ok := parseTime(fm.Updated)
+	if !ok {
+		updated = time.Now()
 	}
 
 	t := &task.Task{
@@ -59,13 +50,47 @@ func ParseTaskFile(content []byte) (*task.Task, error) {
 		Tags:        fm.Tags,
 		Created:     created,
 		Updated:     updated,
-		Content:     body,
+		Content:     doc.body,
+		Fields:      doc.fields(),
+
+		StatusHistory: doc.statusHistory(),
 	}
 
 	return t, nil
 }
 
+// ParseBody returns the body of a task file as ParseTaskFile exposes it in
+// task.Task.Content, without decoding the front matter
+func ParseBody(content []byte) (string, error) {
+	_, body, err := splitFrontMatter(content)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimLeft(body, "\n"), nil
+}
+
+// parseTime parses a front matter timestamp, with or without the time part
+func parseTime(value string) (time.Time, bool) {
+	if t, err := time.Parse(constants.DateTimeFormat, value); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse(constants.DateFormat, value); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
 func extractFrontMatter(content []byte) (string, string, error) {
+	frontMatter, body, err := splitFrontMatter(content)
+	if err != nil {
+		return "", "", err
+	}
+	return frontMatter, strings.TrimLeft(body, "\n"), nil
+}
+
+// splitFrontMatter returns the front matter between the "---" delimiters and
+// the untouched remainder of the file after the closing delimiter
+func splitFrontMatter(content []byte) (string, string, error) {
 	lines := bytes.Split(content, []byte("\n"))
 	
 	if len(lines) < 3 || !bytes.Equal(lines[0], []byte("---")) {
@@ -89,7 +114,6 @@ func extractFrontMatter(content []byte) (string, string, error) {
 
 	bodyLines := lines[endIndex+1:]
 	body := strings.Join(bytesToStrings(bodyLines), "\n")
-	body = strings.TrimLeft(body, "\n")
 
 	return frontMatter, body, nil
 }
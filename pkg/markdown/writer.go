@@ -4,11 +4,10 @@ import (
 	"bytes"
 	"fmt"
 	"strings"
-	"sync"
-	"time"
 
 	"github.com/tkancf/mdtask/internal/constants"
 	"github.com/tkancf/mdtask/internal/task"
+	"github.com/tkancf/mdtask/internal/taskid"
 	"gopkg.in/yaml.v3"
 )
 
@@ -23,9 +22,9 @@ func WriteTaskFile(t *task.Task) ([]byte, error) {
 		Updated:     t.Updated.Format(constants.DateTimeFormat),
 	}
 
-	yamlData, err := yaml.Marshal(&fm)
+	yamlData, err := marshalFrontMatter(&fm, t.StatusHistory, t.Fields)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal front matter: %w", err)
+		return nil, err
 	}
 
 	var buf bytes.Buffer
@@ -42,20 +41,31 @@ func WriteTaskFile(t *task.Task) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-var lastGeneratedTime time.Time
-var mu sync.Mutex
+// marshalFrontMatter encodes the managed keys and the status history followed
+// by the custom fields in sorted order
+func marshalFrontMatter(fm *FrontMatter, history []task.StatusChange, fields map[string]interface{}) ([]byte, error) {
+	var node yaml.Node
+	if err := node.Encode(fm); err != nil {
+		return nil, fmt.Errorf("failed to marshal front matter: %w", err)
+	}
 
-func GenerateTaskID() string {
-	mu.Lock()
-	defer mu.Unlock()
-	
-	now := time.Now()
-	// If generating in the same second, wait a bit
-	if now.Format(constants.IDTimeFormat) == lastGeneratedTime.Format(constants.IDTimeFormat) {
-		time.Sleep(constants.GenerateIDSleepDuration)
-		now = time.Now()
+	doc := &Document{node: &node}
+	doc.setStatusHistory(history)
+	doc.setFields(fields)
+
+	data, err := yaml.Marshal(&node)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal front matter: %w", err)
 	}
-	lastGeneratedTime = now
-	
-	return fmt.Sprintf("%s%s", constants.TaskIDPrefix, now.Format(constants.IDTimeFormat))
-}
\ No newline at end of file
+	return data, nil
+}
+
+// idGenerator numbers the IDs of the same second instead of waiting for
+// the next one
+var idGenerator = taskid.New(taskid.FormatTimestamp)
+
+// GenerateTaskID returns a new timestamp ID such as task/20250629103000.
+// Further IDs within the same second get a suffix: task/20250629103000_1.
+func GenerateTaskID() string {
+	return idGenerator.Next("")
+}